@@ -76,6 +76,26 @@ func main() {
 	var testServerConfig testserver.Config
 	testingCmd := cmd.NewTestingCommand(rootCmd.Use, &testServerConfig)
 	cmd.RegisterTestingFlags(testingCmd, &testServerConfig)
+
+	testingExportCmd := cmd.NewTestingExportCommand(rootCmd.Use)
+	if err := cmd.RegisterTestingArchiveFlags(testingExportCmd); err != nil {
+		log.Fatal().Err(err).Msg("failed to register testing export flags")
+	}
+	testingCmd.AddCommand(testingExportCmd)
+
+	testingImportCmd := cmd.NewTestingImportCommand(rootCmd.Use)
+	if err := cmd.RegisterTestingArchiveFlags(testingImportCmd); err != nil {
+		log.Fatal().Err(err).Msg("failed to register testing import flags")
+	}
+	testingCmd.AddCommand(testingImportCmd)
+
+	testingValidateCmd := cmd.NewTestingValidateCommand(rootCmd.Use)
+	testingCmd.AddCommand(testingValidateCmd)
+
+	testingStatsCmd := cmd.NewTestingStatsCommand(rootCmd.Use)
+	cmd.RegisterTestingStatsFlags(testingStatsCmd)
+	testingCmd.AddCommand(testingStatsCmd)
+
 	rootCmd.AddCommand(testingCmd)
 	if err := rootCmd.Execute(); err != nil {
 		if !errors.Is(err, errParsing) {
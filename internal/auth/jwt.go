@@ -0,0 +1,407 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	jose "github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+	grpcauth "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	log "github.com/authzed/spicedb/internal/logging"
+)
+
+const (
+	errInvalidJWT = "invalid bearer token: %s"
+
+	// DefaultTenantClaim is the claim from which the tenant of a request is read, unless
+	// overridden with WithTenantClaim.
+	DefaultTenantClaim = "tenant_id"
+
+	// DefaultJWKSRefreshInterval is the interval at which the keys of the issuer are refetched,
+	// unless overridden with WithJWKSRefreshInterval.
+	DefaultJWKSRefreshInterval = 1 * time.Hour
+
+	// jwksMinRefetchInterval is the minimum interval between fetches of the keys of the issuer
+	// caused by tokens signed with unknown keys, so that such tokens cannot flood the issuer.
+	jwksMinRefetchInterval = 1 * time.Minute
+
+	// jwtClockSkewLeeway is the leeway applied when checking the expiry and not-before times of
+	// tokens, to allow for clock skew between the issuer and the server.
+	jwtClockSkewLeeway = 30 * time.Second
+
+	jwksFetchTimeout = 10 * time.Second
+)
+
+// JWTOption configures a JWTValidator.
+type JWTOption func(*JWTValidator)
+
+// WithIssuer requires the `iss` claim of tokens to equal the given issuer.
+func WithIssuer(issuer string) JWTOption {
+	return func(v *JWTValidator) {
+		v.issuer = issuer
+	}
+}
+
+// WithAudience requires the `aud` claim of tokens to contain the given audience.
+func WithAudience(audience string) JWTOption {
+	return func(v *JWTValidator) {
+		v.audience = audience
+	}
+}
+
+// WithTenantClaim sets the claim from which the tenant of a request is read.
+//
+// default: DefaultTenantClaim
+func WithTenantClaim(claim string) JWTOption {
+	return func(v *JWTValidator) {
+		v.tenantClaim = claim
+	}
+}
+
+// WithJWKSRefreshInterval sets the interval at which the keys of the issuer are refetched.
+//
+// default: DefaultJWKSRefreshInterval
+func WithJWKSRefreshInterval(interval time.Duration) JWTOption {
+	return func(v *JWTValidator) {
+		v.refreshInterval = interval
+	}
+}
+
+// JWTValidator validates JWT bearer tokens against the keys published in the JWKS of an OIDC
+// issuer, and extracts the tenant of the request from a claim of the token. Tokens must be signed
+// with RS256, RS384, RS512, ES256, ES384 or ES512, with the algorithm named by the `alg` of the key
+// if it has one, must not carry critical header parameters, and must carry an `exp` claim.
+type JWTValidator struct {
+	jwksURL         string
+	issuer          string
+	audience        string
+	tenantClaim     string
+	refreshInterval time.Duration
+	httpClient      *http.Client
+	now             func() time.Time
+
+	fetchLock sync.Mutex
+	keysLock  sync.RWMutex
+	keys      map[string]verificationKey
+	fetchedAt time.Time
+}
+
+// NewJWTValidator creates a JWTValidator verifying tokens with the keys found at the given JWKS
+// URL. The keys are fetched lazily, when the first token is validated.
+func NewJWTValidator(jwksURL string, opts ...JWTOption) (*JWTValidator, error) {
+	if jwksURL == "" {
+		return nil, errors.New("a JWKS URL must be provided to validate JWTs")
+	}
+
+	v := &JWTValidator{
+		jwksURL:         jwksURL,
+		tenantClaim:     DefaultTenantClaim,
+		refreshInterval: DefaultJWKSRefreshInterval,
+		httpClient:      &http.Client{Timeout: jwksFetchTimeout},
+		now:             time.Now,
+	}
+	for _, opt := range opts {
+		opt(v)
+	}
+
+	if v.tenantClaim == "" {
+		return nil, errors.New("the tenant claim must not be empty")
+	}
+	return v, nil
+}
+
+type tenantKey struct{}
+
+// TenantFromContext returns the tenant read from the token which authenticated the request by
+// the AuthFunc of a JWTValidator, if any.
+func TenantFromContext(ctx context.Context) (string, bool) {
+	tenant, ok := ctx.Value(tenantKey{}).(string)
+	return tenant, ok
+}
+
+// AuthFunc returns an auth function which requires that gRPC requests have a Bearer Token value
+// that is a valid JWT, and adds the tenant read from the token to the context of the request.
+func (v *JWTValidator) AuthFunc() grpcauth.AuthFunc {
+	return func(ctx context.Context) (context.Context, error) {
+		token, err := grpcauth.AuthFromMD(ctx, "bearer")
+		if err != nil {
+			return nil, status.Errorf(codes.Unauthenticated, errInvalidJWT, err.Error())
+		}
+
+		tenant, err := v.Validate(ctx, token)
+		if err != nil {
+			return nil, status.Errorf(codes.Unauthenticated, errInvalidJWT, err.Error())
+		}
+
+		ctx = context.WithValue(ctx, tenantKey{}, tenant)
+		return log.Ctx(ctx).With().Str("tenant", tenant).Logger().WithContext(ctx), nil
+	}
+}
+
+// Validate verifies the signature and claims of the given token and returns its tenant.
+func (v *JWTValidator) Validate(ctx context.Context, token string) (string, error) {
+	parsed, err := jwt.ParseSigned(token)
+	if err != nil {
+		return "", fmt.Errorf("malformed token: %w", err)
+	}
+
+	if len(parsed.Headers) != 1 {
+		return "", errors.New("malformed token: expected a single signature")
+	}
+
+	header := parsed.Headers[0]
+	if _, ok := header.ExtraHeaders["crit"]; ok {
+		return "", errors.New("token has unsupported critical header parameters")
+	}
+
+	key, err := v.keyForID(ctx, header.KeyID)
+	if err != nil {
+		return "", err
+	}
+
+	if _, ok := key.algorithms[header.Algorithm]; !ok {
+		if _, ok := supportedJWTAlgorithms[header.Algorithm]; !ok {
+			return "", fmt.Errorf("unsupported signing algorithm `%s`", header.Algorithm)
+		}
+		return "", fmt.Errorf("signing algorithm `%s` is not allowed for key `%s`", header.Algorithm, header.KeyID)
+	}
+
+	var registered jwt.Claims
+	var claims map[string]any
+	if err := parsed.Claims(key.publicKey, &registered, &claims); err != nil {
+		if errors.Is(err, jose.ErrCryptoFailure) {
+			return "", errors.New("invalid token signature")
+		}
+		return "", fmt.Errorf("malformed token claims: %w", err)
+	}
+
+	if err := v.validateRegisteredClaims(registered); err != nil {
+		return "", err
+	}
+
+	tenant, _ := claims[v.tenantClaim].(string)
+	if tenant == "" {
+		return "", fmt.Errorf("token has no `%s` claim", v.tenantClaim)
+	}
+	return tenant, nil
+}
+
+func (v *JWTValidator) validateRegisteredClaims(registered jwt.Claims) error {
+	if registered.Expiry == nil {
+		return errors.New("token has no expiry")
+	}
+
+	expected := jwt.Expected{
+		Issuer: v.issuer,
+		Time:   v.now(),
+	}
+	if v.audience != "" {
+		expected.Audience = jwt.Audience{v.audience}
+	}
+
+	err := registered.ValidateWithLeeway(expected, jwtClockSkewLeeway)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, jwt.ErrExpired):
+		return errors.New("token has expired")
+	case errors.Is(err, jwt.ErrNotValidYet), errors.Is(err, jwt.ErrIssuedInTheFuture):
+		return errors.New("token is not yet valid")
+	case errors.Is(err, jwt.ErrInvalidIssuer):
+		return fmt.Errorf("token was not issued by `%s`", v.issuer)
+	case errors.Is(err, jwt.ErrInvalidAudience):
+		return fmt.Errorf("token is not intended for audience `%s`", v.audience)
+	default:
+		return err
+	}
+}
+
+// supportedJWTAlgorithms are the signing algorithms accepted for tokens.
+var supportedJWTAlgorithms = map[string]struct{}{
+	string(jose.RS256): {},
+	string(jose.RS384): {},
+	string(jose.RS512): {},
+	string(jose.ES256): {},
+	string(jose.ES384): {},
+	string(jose.ES512): {},
+}
+
+var ecdsaAlgorithms = map[elliptic.Curve]string{
+	elliptic.P256(): string(jose.ES256),
+	elliptic.P384(): string(jose.ES384),
+	elliptic.P521(): string(jose.ES512),
+}
+
+// verificationKey is a key of the issuer along with the signing algorithms which tokens verified
+// with it may use.
+type verificationKey struct {
+	publicKey  any
+	algorithms map[string]struct{}
+}
+
+// newVerificationKey returns the verification key for the given JWK, pinning its signing
+// algorithms to the `alg` of the key, if any, or otherwise to those matching its type and curve.
+func newVerificationKey(jwk jose.JSONWebKey) (verificationKey, error) {
+	var algorithms []string
+	switch key := jwk.Key.(type) {
+	case *rsa.PublicKey:
+		algorithms = []string{string(jose.RS256), string(jose.RS384), string(jose.RS512)}
+
+	case *ecdsa.PublicKey:
+		algorithm, ok := ecdsaAlgorithms[key.Curve]
+		if !ok {
+			return verificationKey{}, fmt.Errorf("unsupported curve `%s`", key.Curve.Params().Name)
+		}
+		algorithms = []string{algorithm}
+
+	default:
+		return verificationKey{}, fmt.Errorf("unsupported key type %T", jwk.Key)
+	}
+
+	allowed := make(map[string]struct{}, len(algorithms))
+	for _, algorithm := range algorithms {
+		if jwk.Algorithm == "" || jwk.Algorithm == algorithm {
+			allowed[algorithm] = struct{}{}
+		}
+	}
+	if len(allowed) == 0 {
+		return verificationKey{}, fmt.Errorf("unsupported algorithm `%s` for the key", jwk.Algorithm)
+	}
+
+	return verificationKey{publicKey: jwk.Key, algorithms: allowed}, nil
+}
+
+// keyForID returns the key of the issuer with the given ID, fetching the keys if they are stale
+// or if no such key is known. If the token names no key, the issuer must publish a single key.
+func (v *JWTValidator) keyForID(ctx context.Context, keyID string) (verificationKey, error) {
+	v.keysLock.RLock()
+	key, found := lookupJWK(v.keys, keyID)
+	fetchedAt := v.fetchedAt
+	v.keysLock.RUnlock()
+
+	now := v.now()
+	stale := now.Sub(fetchedAt) >= v.refreshInterval
+	if found && !stale {
+		return key, nil
+	}
+
+	if !stale && now.Sub(fetchedAt) < jwksMinRefetchInterval {
+		return verificationKey{}, fmt.Errorf("token is signed with unknown key `%s`", keyID)
+	}
+
+	if err := v.refreshKeys(ctx, fetchedAt); err != nil {
+		if found {
+			// Keep using the previously fetched keys until the issuer is reachable again.
+			log.Ctx(ctx).Warn().Err(err).Str("url", v.jwksURL).Msg("failed to refresh JWKS; using cached keys")
+			return key, nil
+		}
+		return verificationKey{}, err
+	}
+
+	v.keysLock.RLock()
+	defer v.keysLock.RUnlock()
+
+	key, found = lookupJWK(v.keys, keyID)
+	if !found {
+		return verificationKey{}, fmt.Errorf("token is signed with unknown key `%s`", keyID)
+	}
+	return key, nil
+}
+
+func lookupJWK(keys map[string]verificationKey, keyID string) (verificationKey, bool) {
+	if keyID == "" && len(keys) == 1 {
+		for _, key := range keys {
+			return key, true
+		}
+	}
+
+	key, ok := keys[keyID]
+	return key, ok && keyID != ""
+}
+
+// refreshKeys fetches the keys of the issuer, unless they have been fetched by another caller
+// since the given time.
+func (v *JWTValidator) refreshKeys(ctx context.Context, lastFetchedAt time.Time) error {
+	v.fetchLock.Lock()
+	defer v.fetchLock.Unlock()
+
+	v.keysLock.RLock()
+	alreadyFetched := v.fetchedAt.After(lastFetchedAt)
+	v.keysLock.RUnlock()
+	if alreadyFetched {
+		return nil
+	}
+
+	keys, err := v.fetchKeys(ctx)
+
+	v.keysLock.Lock()
+	defer v.keysLock.Unlock()
+
+	// Record failed attempts as well, so that an unreachable issuer is not retried on every request.
+	v.fetchedAt = v.now()
+	if err != nil {
+		return err
+	}
+
+	v.keys = keys
+	log.Ctx(ctx).Debug().Str("url", v.jwksURL).Int("keys", len(keys)).Msg("fetched JWKS")
+	return nil
+}
+
+func (v *JWTValidator) fetchKeys(ctx context.Context) (map[string]verificationKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.jwksURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch JWKS: unexpected status %d", resp.StatusCode)
+	}
+
+	// The keys are decoded one by one, so that keys of unsupported types are skipped rather than
+	// failing the whole set.
+	var jwks struct {
+		Keys []json.RawMessage `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&jwks); err != nil {
+		return nil, fmt.Errorf("failed to decode JWKS: %w", err)
+	}
+
+	keys := make(map[string]verificationKey, len(jwks.Keys))
+	for _, encoded := range jwks.Keys {
+		var jwk jose.JSONWebKey
+		if err := jwk.UnmarshalJSON(encoded); err != nil {
+			log.Ctx(ctx).Warn().Err(err).Msg("skipping invalid key in JWKS")
+			continue
+		}
+
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := newVerificationKey(jwk)
+		if err != nil {
+			log.Ctx(ctx).Warn().Err(err).Str("kid", jwk.KeyID).Msg("skipping unsupported key in JWKS")
+			continue
+		}
+		keys[jwk.KeyID] = key
+	}
+	return keys, nil
+}
@@ -0,0 +1,278 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/authzed/grpcutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+)
+
+type testIssuer struct {
+	rsaKey   *rsa.PrivateKey
+	ecKey    *ecdsa.PrivateKey
+	jwks     atomic.Value
+	fetches  atomic.Int32
+	server   *httptest.Server
+	validity time.Duration
+}
+
+func newTestIssuer(t *testing.T) *testIssuer {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	issuer := &testIssuer{rsaKey: rsaKey, ecKey: ecKey, validity: time.Hour}
+	issuer.publish(map[string]any{
+		"kty": "RSA",
+		"kid": "rsa",
+		"use": "sig",
+		"n":   encodeSegment(rsaKey.N.Bytes()),
+		"e":   encodeSegment(big.NewInt(int64(rsaKey.E)).Bytes()),
+	}, map[string]any{
+		"kty": "EC",
+		"kid": "ec",
+		"crv": "P-256",
+		"x":   encodeSegment(ecKey.X.FillBytes(make([]byte, 32))),
+		"y":   encodeSegment(ecKey.Y.FillBytes(make([]byte, 32))),
+	})
+
+	issuer.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		issuer.fetches.Add(1)
+		_, _ = w.Write(issuer.jwks.Load().([]byte))
+	}))
+	t.Cleanup(issuer.server.Close)
+	return issuer
+}
+
+func (ti *testIssuer) publish(keys ...map[string]any) {
+	encoded, err := json.Marshal(map[string]any{"keys": keys})
+	if err != nil {
+		panic(err)
+	}
+	ti.jwks.Store(encoded)
+}
+
+func encodeSegment(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func (ti *testIssuer) sign(t *testing.T, alg, kid string, claims map[string]any) string {
+	return ti.signWithHeader(t, map[string]any{"alg": alg, "kid": kid, "typ": "JWT"}, claims)
+}
+
+func (ti *testIssuer) signWithHeader(t *testing.T, fields map[string]any, claims map[string]any) string {
+	header, err := json.Marshal(fields)
+	require.NoError(t, err)
+
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+
+	signingInput := encodeSegment(header) + "." + encodeSegment(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+
+	var signature []byte
+	switch fields["alg"] {
+	case "RS256":
+		signature, err = rsa.SignPKCS1v15(rand.Reader, ti.rsaKey, crypto.SHA256, digest[:])
+		require.NoError(t, err)
+	case "ES256":
+		r, s, err := ecdsa.Sign(rand.Reader, ti.ecKey, digest[:])
+		require.NoError(t, err)
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	default:
+		signature = []byte("unsigned")
+	}
+	return signingInput + "." + encodeSegment(signature)
+}
+
+func (ti *testIssuer) claims(overrides map[string]any) map[string]any {
+	claims := map[string]any{
+		"iss":       "https://issuer.example.com",
+		"aud":       []string{"spicedb"},
+		"exp":       time.Now().Add(ti.validity).Unix(),
+		"tenant_id": "sometenant",
+	}
+	for key, value := range overrides {
+		if value == nil {
+			delete(claims, key)
+			continue
+		}
+		claims[key] = value
+	}
+	return claims
+}
+
+func TestJWTValidator(t *testing.T) {
+	issuer := newTestIssuer(t)
+	validator, err := NewJWTValidator(issuer.server.URL,
+		WithIssuer("https://issuer.example.com"),
+		WithAudience("spicedb"),
+	)
+	require.NoError(t, err)
+
+	tcs := []struct {
+		name          string
+		token         func() string
+		expectedError string
+	}{
+		{"valid RS256", func() string {
+			return issuer.sign(t, "RS256", "rsa", issuer.claims(nil))
+		}, ""},
+		{"valid ES256", func() string {
+			return issuer.sign(t, "ES256", "ec", issuer.claims(nil))
+		}, ""},
+		{"audience as a string", func() string {
+			return issuer.sign(t, "RS256", "rsa", issuer.claims(map[string]any{"aud": "spicedb"}))
+		}, ""},
+		{"expired", func() string {
+			return issuer.sign(t, "RS256", "rsa", issuer.claims(map[string]any{"exp": time.Now().Add(-time.Hour).Unix()}))
+		}, "token has expired"},
+		{"without expiry", func() string {
+			return issuer.sign(t, "RS256", "rsa", issuer.claims(map[string]any{"exp": nil}))
+		}, "token has no expiry"},
+		{"not yet valid", func() string {
+			return issuer.sign(t, "RS256", "rsa", issuer.claims(map[string]any{"nbf": time.Now().Add(time.Hour).Unix()}))
+		}, "token is not yet valid"},
+		{"other issuer", func() string {
+			return issuer.sign(t, "RS256", "rsa", issuer.claims(map[string]any{"iss": "https://other.example.com"}))
+		}, "token was not issued by `https://issuer.example.com`"},
+		{"other audience", func() string {
+			return issuer.sign(t, "RS256", "rsa", issuer.claims(map[string]any{"aud": "other"}))
+		}, "token is not intended for audience `spicedb`"},
+		{"without tenant", func() string {
+			return issuer.sign(t, "RS256", "rsa", issuer.claims(map[string]any{"tenant_id": nil}))
+		}, "token has no `tenant_id` claim"},
+		{"unsigned", func() string {
+			return issuer.sign(t, "none", "rsa", issuer.claims(nil))
+		}, "unsupported signing algorithm `none`"},
+		{"algorithm not matching the key", func() string {
+			return issuer.sign(t, "ES256", "rsa", issuer.claims(nil))
+		}, "signing algorithm `ES256` is not allowed for key `rsa`"},
+		{"critical header parameters", func() string {
+			return issuer.signWithHeader(t, map[string]any{
+				"alg":  "RS256",
+				"kid":  "rsa",
+				"crit": []string{"exp"},
+				"exp":  time.Now().Add(time.Hour).Unix(),
+			}, issuer.claims(nil))
+		}, "token has unsupported critical header parameters"},
+		{"tampered claims", func() string {
+			token := issuer.sign(t, "RS256", "rsa", issuer.claims(nil))
+			other := issuer.sign(t, "RS256", "rsa", issuer.claims(map[string]any{"tenant_id": "othertenant"}))
+			return token[:len(token)-10] + other[len(other)-10:]
+		}, "invalid token signature"},
+		{"malformed", func() string {
+			return "notatoken"
+		}, "malformed token"},
+	}
+
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			tenant, err := validator.Validate(context.Background(), tc.token())
+			if tc.expectedError != "" {
+				require.ErrorContains(t, err, tc.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, "sometenant", tenant)
+		})
+	}
+
+	// The keys are fetched once and then cached.
+	require.Equal(t, int32(1), issuer.fetches.Load())
+}
+
+func TestJWTValidatorKeyRotation(t *testing.T) {
+	issuer := newTestIssuer(t)
+	validator, err := NewJWTValidator(issuer.server.URL)
+	require.NoError(t, err)
+
+	now := time.Now()
+	validator.now = func() time.Time { return now }
+
+	_, err = validator.Validate(context.Background(), issuer.sign(t, "RS256", "rsa", issuer.claims(nil)))
+	require.NoError(t, err)
+
+	// Tokens signed with a new key are rejected without refetching the keys too often.
+	issuer.publish(map[string]any{
+		"kty": "EC",
+		"kid": "rotated",
+		"crv": "P-256",
+		"x":   encodeSegment(issuer.ecKey.X.FillBytes(make([]byte, 32))),
+		"y":   encodeSegment(issuer.ecKey.Y.FillBytes(make([]byte, 32))),
+	})
+	_, err = validator.Validate(context.Background(), issuer.sign(t, "ES256", "rotated", issuer.claims(nil)))
+	require.ErrorContains(t, err, "token is signed with unknown key `rotated`")
+	require.Equal(t, int32(1), issuer.fetches.Load())
+
+	// Once the minimum interval has passed, the keys are refetched to find the new key.
+	now = now.Add(jwksMinRefetchInterval)
+	_, err = validator.Validate(context.Background(), issuer.sign(t, "ES256", "rotated", issuer.claims(nil)))
+	require.NoError(t, err)
+	require.Equal(t, int32(2), issuer.fetches.Load())
+
+	// The removed key is no longer accepted once the keys are refreshed.
+	now = now.Add(DefaultJWKSRefreshInterval)
+	_, err = validator.Validate(context.Background(), issuer.sign(t, "RS256", "rsa", issuer.claims(nil)))
+	require.ErrorContains(t, err, "token is signed with unknown key `rsa`")
+}
+
+func TestJWTAuthFunc(t *testing.T) {
+	issuer := newTestIssuer(t)
+	validator, err := NewJWTValidator(issuer.server.URL, WithTenantClaim("org"))
+	require.NoError(t, err)
+
+	authFunc := validator.AuthFunc()
+	ctx, err := authFunc(withTokenMetadata("bearer " + issuer.sign(t, "RS256", "rsa", issuer.claims(map[string]any{"org": "someorg"}))))
+	require.NoError(t, err)
+
+	tenant, ok := TenantFromContext(ctx)
+	require.True(t, ok)
+	require.Equal(t, "someorg", tenant)
+
+	_, err = authFunc(withTokenMetadata("bearer " + issuer.sign(t, "RS256", "rsa", issuer.claims(nil))))
+	grpcutil.RequireStatus(t, codes.Unauthenticated, err)
+
+	_, err = authFunc(withTokenMetadata("bearer sometoken"))
+	grpcutil.RequireStatus(t, codes.Unauthenticated, err)
+
+	_, err = authFunc(context.Background())
+	grpcutil.RequireStatus(t, codes.Unauthenticated, err)
+}
+
+func TestJWTValidatorPinsKeyAlgorithm(t *testing.T) {
+	issuer := newTestIssuer(t)
+	issuer.publish(map[string]any{
+		"kty": "RSA",
+		"kid": "rsa",
+		"alg": "RS512",
+		"n":   encodeSegment(issuer.rsaKey.N.Bytes()),
+		"e":   encodeSegment(big.NewInt(int64(issuer.rsaKey.E)).Bytes()),
+	})
+
+	validator, err := NewJWTValidator(issuer.server.URL)
+	require.NoError(t, err)
+
+	// The key is only usable with the algorithm it names, even if the signature is valid.
+	_, err = validator.Validate(context.Background(), issuer.sign(t, "RS256", "rsa", issuer.claims(nil)))
+	require.ErrorContains(t, err, "signing algorithm `RS256` is not allowed for key `rsa`")
+}
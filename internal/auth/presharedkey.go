@@ -3,10 +3,13 @@ package auth
 import (
 	"context"
 	"crypto/subtle"
+	"strconv"
 
 	grpcauth "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/auth"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+
+	log "github.com/authzed/spicedb/internal/logging"
 )
 
 const (
@@ -16,8 +19,26 @@ const (
 
 var errInvalidToken = "invalid token"
 
+// PresharedKeyID returns the identifier of the preshared key found at the given index of those
+// configured, so that the key which authenticated a request can be audited without logging anything
+// derived from the key itself. Identifiers are 1-based, matching the order of the keys configured.
+func PresharedKeyID(index int) string {
+	return "key-" + strconv.Itoa(index+1)
+}
+
+type presharedKeyIDKey struct{}
+
+// PresharedKeyIDFromContext returns the PresharedKeyID of the preshared key which authenticated
+// the request, if any.
+func PresharedKeyIDFromContext(ctx context.Context) (string, bool) {
+	keyID, ok := ctx.Value(presharedKeyIDKey{}).(string)
+	return keyID, ok
+}
+
 // MustRequirePresharedKey requires that gRPC requests have a Bearer Token value
-// equivalent to one of the provided preshared key(s).
+// equivalent to one of the provided preshared key(s). Multiple keys allow for them to be
+// rotated without downtime. The PresharedKeyID of the key matched is added to the context
+// of the request and to its logger.
 func MustRequirePresharedKey(presharedKeys []string) grpcauth.AuthFunc {
 	if len(presharedKeys) == 0 {
 		panic("RequirePresharedKey was given an empty preshared keys slice")
@@ -39,9 +60,11 @@ func MustRequirePresharedKey(presharedKeys []string) grpcauth.AuthFunc {
 			return nil, status.Errorf(codes.Unauthenticated, errMissingPresharedKey)
 		}
 
-		for _, presharedKey := range presharedKeys {
+		for index, presharedKey := range presharedKeys {
 			if match := subtle.ConstantTimeCompare([]byte(presharedKey), []byte(token)); match == 1 {
-				return ctx, nil
+				keyID := PresharedKeyID(index)
+				ctx = context.WithValue(ctx, presharedKeyIDKey{}, keyID)
+				return log.Ctx(ctx).With().Str("presharedKeyID", keyID).Logger().WithContext(ctx), nil
 			}
 		}
 
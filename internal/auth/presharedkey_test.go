@@ -18,13 +18,14 @@ func TestPresharedKeys(t *testing.T) {
 		withMetadata   bool
 		authzHeader    string
 		expectedStatus codes.Code
+		expectedKeyID  string
 	}{
-		{"valid request with the first key", []string{"one", "two"}, true, "bearer one", codes.OK},
-		{"valid request with the second key", []string{"one", "two"}, true, "bearer two", codes.OK},
-		{"denied due to unknown key", []string{"one", "two"}, true, "bearer three", codes.PermissionDenied},
-		{"unauthenticated due to missing key", []string{"one", "two"}, true, "bearer ", codes.Unauthenticated},
-		{"unauthenticated due to empty header", []string{"one", "two"}, true, "", codes.Unauthenticated},
-		{"unauthenticated due to missing metadata", []string{"one", "two"}, false, "", codes.Unauthenticated},
+		{"valid request with the first key", []string{"one", "two"}, true, "bearer one", codes.OK, "key-1"},
+		{"valid request with the second key", []string{"one", "two"}, true, "bearer two", codes.OK, "key-2"},
+		{"denied due to unknown key", []string{"one", "two"}, true, "bearer three", codes.PermissionDenied, ""},
+		{"unauthenticated due to missing key", []string{"one", "two"}, true, "bearer ", codes.Unauthenticated, ""},
+		{"unauthenticated due to empty header", []string{"one", "two"}, true, "", codes.Unauthenticated, ""},
+		{"unauthenticated due to missing metadata", []string{"one", "two"}, false, "", codes.Unauthenticated, ""},
 	}
 
 	for _, testcase := range testcases {
@@ -35,12 +36,16 @@ func TestPresharedKeys(t *testing.T) {
 			if testcase.withMetadata {
 				ctx = withTokenMetadata(testcase.authzHeader)
 			}
-			_, err := f(ctx)
+			authedCtx, err := f(ctx)
 			if testcase.expectedStatus != codes.OK {
 				require.Error(t, err)
 				grpcutil.RequireStatus(t, testcase.expectedStatus, err)
 			} else {
 				require.NoError(t, err)
+
+				keyID, ok := PresharedKeyIDFromContext(authedCtx)
+				require.True(t, ok)
+				require.Equal(t, testcase.expectedKeyID, keyID)
 			}
 		})
 	}
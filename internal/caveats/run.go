@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 
 	"golang.org/x/exp/maps"
@@ -63,9 +64,11 @@ type ExpressionResult interface {
 }
 
 type syntheticResult struct {
-	value         bool
-	contextValues map[string]any
-	exprString    string
+	value           bool
+	contextValues   map[string]any
+	exprString      string
+	isPartialResult bool
+	missingVarNames []string
 }
 
 func (sr syntheticResult) Value() bool {
@@ -73,11 +76,14 @@ func (sr syntheticResult) Value() bool {
 }
 
 func (sr syntheticResult) IsPartial() bool {
-	return false
+	return sr.isPartialResult
 }
 
 func (sr syntheticResult) MissingVarNames() ([]string, error) {
-	return nil, fmt.Errorf("not a partial value")
+	if !sr.IsPartial() {
+		return nil, fmt.Errorf("not a partial value")
+	}
+	return sr.missingVarNames, nil
 }
 
 func (sr syntheticResult) ContextValues() map[string]any {
@@ -227,6 +233,12 @@ func runExpressionWithCaveats(
 		}
 	}
 
+	// Partially applied children do not short circuit the evaluation, as a later child may
+	// still determine the result of the operation. If no child does, the names of all the
+	// missing parameters are collected and returned as part of a partial result.
+	hasPartialChild := false
+	missingVarNames := mapz.NewSet[string]()
+
 	for _, child := range cop.Children {
 		childResult, err := runExpressionWithCaveats(ctx, env, child, context, loadedCaveats, debugOption)
 		if err != nil {
@@ -234,7 +246,27 @@ func runExpressionWithCaveats(
 		}
 
 		if childResult.IsPartial() {
-			return childResult, nil
+			childMissingVarNames, err := childResult.MissingVarNames()
+			if err != nil {
+				return nil, err
+			}
+
+			hasPartialChild = true
+			missingVarNames.Extend(childMissingVarNames)
+
+			if debugOption == RunCaveatExpressionWithDebugInformation {
+				contextValues = combineMaps(contextValues, childResult.ContextValues())
+				exprString, err := childResult.ExpressionString()
+				if err != nil {
+					return nil, err
+				}
+
+				if cop.Op == core.CaveatOperation_NOT {
+					exprString = "!(" + exprString + ")"
+				}
+				exprStringPieces = append(exprStringPieces, exprString)
+			}
+			continue
 		}
 
 		switch cop.Op {
@@ -257,7 +289,7 @@ func runExpressionWithCaveats(
 					return nil, err
 				}
 
-				return syntheticResult{false, contextValues, built}, nil
+				return syntheticResult{false, contextValues, built, false, nil}, nil
 			}
 
 		case core.CaveatOperation_OR:
@@ -279,7 +311,7 @@ func runExpressionWithCaveats(
 					return nil, err
 				}
 
-				return syntheticResult{true, contextValues, built}, nil
+				return syntheticResult{true, contextValues, built, false, nil}, nil
 			}
 
 		case core.CaveatOperation_NOT:
@@ -298,7 +330,7 @@ func runExpressionWithCaveats(
 				return nil, err
 			}
 
-			return syntheticResult{!childResult.Value(), contextValues, built}, nil
+			return syntheticResult{!childResult.Value(), contextValues, built, false, nil}, nil
 
 		default:
 			return nil, spiceerrors.MustBugf("unknown caveat operation: %v", cop.Op)
@@ -310,7 +342,13 @@ func runExpressionWithCaveats(
 		return nil, err
 	}
 
-	return syntheticResult{boolResult, contextValues, built}, nil
+	if hasPartialChild {
+		sortedMissingVarNames := missingVarNames.AsSlice()
+		sort.Strings(sortedMissingVarNames)
+		return syntheticResult{false, contextValues, built, true, sortedMissingVarNames}, nil
+	}
+
+	return syntheticResult{boolResult, contextValues, built, false, nil}, nil
 }
 
 func combineMaps(first map[string]any, second map[string]any) map[string]any {
@@ -263,3 +263,142 @@ func TestRunCaveatWithEmptyMap(t *testing.T) {
 	req.Error(err)
 	req.True(errors.As(err, &caveats.EvaluationErr{}))
 }
+
+func TestRunCaveatExpressionsWithMissingContext(t *testing.T) {
+	tcs := []struct {
+		name                    string
+		expression              *core.CaveatExpression
+		context                 map[string]any
+		expectedValue           bool
+		expectedMissingVarNames []string
+	}{
+		{
+			"missing single",
+			caveatexpr("firstCaveat"),
+			map[string]any{},
+			false,
+			[]string{"first"},
+		},
+		{
+			"missing both in and",
+			caveatAnd(
+				caveatexpr("secondCaveat"),
+				caveatexpr("firstCaveat"),
+			),
+			map[string]any{},
+			false,
+			[]string{"first", "second"},
+		},
+		{
+			"missing first in and with false second",
+			caveatAnd(
+				caveatexpr("firstCaveat"),
+				caveatexpr("secondCaveat"),
+			),
+			map[string]any{
+				"second": "hi",
+			},
+			false,
+			nil,
+		},
+		{
+			"missing first in or with true second",
+			caveatOr(
+				caveatexpr("firstCaveat"),
+				caveatexpr("secondCaveat"),
+			),
+			map[string]any{
+				"second": "hello",
+			},
+			true,
+			nil,
+		},
+		{
+			"missing first in or with false second",
+			caveatOr(
+				caveatexpr("firstCaveat"),
+				caveatexpr("secondCaveat"),
+			),
+			map[string]any{
+				"second": "hi",
+			},
+			false,
+			[]string{"first"},
+		},
+		{
+			"missing in inversion",
+			caveatInvert(
+				caveatexpr("thirdCaveat"),
+			),
+			map[string]any{},
+			false,
+			[]string{"third"},
+		},
+		{
+			"missing in nested",
+			caveatAnd(
+				caveatOr(
+					caveatexpr("firstCaveat"),
+					caveatexpr("secondCaveat"),
+				),
+				caveatInvert(
+					caveatexpr("thirdCaveat"),
+				),
+			),
+			map[string]any{
+				"first": "12",
+			},
+			false,
+			[]string{"second", "third"},
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			req := require.New(t)
+
+			rawDS, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+			req.NoError(err)
+
+			ds, _ := testfixtures.DatastoreFromSchemaAndTestRelationships(rawDS, `
+				caveat firstCaveat(first int) {
+					first == 42
+				}
+
+				caveat secondCaveat(second string) {
+					second == 'hello'
+				}
+
+				caveat thirdCaveat(third bool) {
+					third
+				}
+				`, nil, req)
+			headRevision, err := ds.HeadRevision(context.Background())
+			req.NoError(err)
+
+			reader := ds.SnapshotReader(headRevision)
+
+			for _, debugOption := range []caveats.RunCaveatExpressionDebugOption{
+				caveats.RunCaveatExpressionNoDebugging,
+				caveats.RunCaveatExpressionWithDebugInformation,
+			} {
+				debugOption := debugOption
+				t.Run(fmt.Sprintf("%v", debugOption), func(t *testing.T) {
+					req := require.New(t)
+
+					result, err := caveats.RunCaveatExpression(context.Background(), tc.expression, tc.context, reader, debugOption)
+					req.NoError(err)
+					req.Equal(tc.expectedValue, result.Value())
+					req.Equal(len(tc.expectedMissingVarNames) > 0, result.IsPartial())
+
+					if result.IsPartial() {
+						missingVarNames, err := result.MissingVarNames()
+						req.NoError(err)
+						req.Equal(tc.expectedMissingVarNames, missingVarNames)
+					}
+				})
+			}
+		})
+	}
+}
@@ -30,6 +30,86 @@ func UpdateTuplesInDatastore(ctx context.Context, ds datastore.Datastore, update
 	})
 }
 
+// DeleteBySubject deletes every relationship whose subject matches the given filter, across all
+// resource types and relations, in a single transaction. Matching relationships are found via the
+// reverse (subject-side) index. Returns the revision of the deletion and the number of
+// relationships deleted.
+func DeleteBySubject(ctx context.Context, ds datastore.Datastore, subjectsFilter datastore.SubjectsFilter) (datastore.Revision, uint64, error) {
+	var deletedCount uint64
+	revision, err := ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		iter, err := rwt.ReverseQueryRelationships(ctx, subjectsFilter)
+		if err != nil {
+			return err
+		}
+
+		var updates []*core.RelationTupleUpdate
+		for tpl := iter.Next(); tpl != nil; tpl = iter.Next() {
+			updates = append(updates, &core.RelationTupleUpdate{
+				Operation: core.RelationTupleUpdate_DELETE,
+				Tuple:     tpl.CloneVT(),
+			})
+		}
+		if err := iter.Err(); err != nil {
+			iter.Close()
+			return err
+		}
+		iter.Close()
+
+		// Set on every attempt, as the transaction may be retried.
+		deletedCount = uint64(len(updates))
+		if len(updates) == 0 {
+			return nil
+		}
+
+		return rwt.WriteRelationships(ctx, updates)
+	})
+	if err != nil {
+		return datastore.NoRevision, 0, err
+	}
+
+	return revision, deletedCount, nil
+}
+
+// SubjectImpact summarizes the relationships whose subject matches a filter, as would be deleted
+// by DeleteBySubject with the same filter.
+type SubjectImpact struct {
+	// Resources maps each resource type to the relations on which a matching subject has
+	// relationships, and each relation to the IDs of those resources, in the order found.
+	Resources map[string]map[string][]string
+
+	// RelationshipCount is the total number of matching relationships.
+	RelationshipCount uint64
+}
+
+// SummarizeSubjectImpact returns a summary of every relationship whose subject matches the given
+// filter, across all resource types and relations, without modifying anything. Matching
+// relationships are found via the reverse (subject-side) index, as in DeleteBySubject, making this
+// a preview of the relationships it would delete at the reader's revision.
+func SummarizeSubjectImpact(ctx context.Context, reader datastore.Reader, subjectsFilter datastore.SubjectsFilter) (SubjectImpact, error) {
+	iter, err := reader.ReverseQueryRelationships(ctx, subjectsFilter)
+	if err != nil {
+		return SubjectImpact{}, err
+	}
+	defer iter.Close()
+
+	impact := SubjectImpact{Resources: make(map[string]map[string][]string)}
+	for tpl := iter.Next(); tpl != nil; tpl = iter.Next() {
+		resource := tpl.ResourceAndRelation
+		relations, ok := impact.Resources[resource.Namespace]
+		if !ok {
+			relations = make(map[string][]string)
+			impact.Resources[resource.Namespace] = relations
+		}
+		relations[resource.Relation] = append(relations[resource.Relation], resource.ObjectId)
+		impact.RelationshipCount++
+	}
+	if err := iter.Err(); err != nil {
+		return SubjectImpact{}, err
+	}
+
+	return impact, nil
+}
+
 // ContextualizedCaveatFrom convenience method that handles creation of a contextualized caveat
 // given the possibility of arguments with zero-values.
 func ContextualizedCaveatFrom(name string, context map[string]any) (*core.ContextualizedCaveat, error) {
@@ -0,0 +1,103 @@
+package common_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/internal/datastore/memdb"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+func TestDeleteBySubject(t *testing.T) {
+	require := require.New(t)
+
+	ds, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(err)
+
+	ctx := context.Background()
+	_, err = common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE,
+		tuple.MustParse("document:first#viewer@user:tom"),
+		tuple.MustParse("document:second#editor@user:tom"),
+		tuple.MustParse("folder:root#owner@user:tom"),
+		tuple.MustParse("team:engineering#member@user:tom"),
+		tuple.MustParse("document:first#viewer@user:fred"),
+		tuple.MustParse("document:first#viewer@team:engineering#member"),
+	)
+	require.NoError(err)
+
+	rev, deleted, err := common.DeleteBySubject(ctx, ds, datastore.SubjectsFilter{
+		SubjectType:        "user",
+		OptionalSubjectIds: []string{"tom"},
+	})
+	require.NoError(err)
+	require.Equal(uint64(4), deleted)
+
+	iter, err := ds.SnapshotReader(rev).ReverseQueryRelationships(ctx, datastore.SubjectsFilter{
+		SubjectType: "user",
+	})
+	require.NoError(err)
+	defer iter.Close()
+
+	var remaining []string
+	for tpl := iter.Next(); tpl != nil; tpl = iter.Next() {
+		remaining = append(remaining, tuple.MustString(tpl))
+	}
+	require.NoError(iter.Err())
+	require.Equal([]string{"document:first#viewer@user:fred"}, remaining)
+
+	_, deleted, err = common.DeleteBySubject(ctx, ds, datastore.SubjectsFilter{
+		SubjectType:        "user",
+		OptionalSubjectIds: []string{"tom"},
+	})
+	require.NoError(err)
+	require.Equal(uint64(0), deleted)
+}
+
+func TestSummarizeSubjectImpact(t *testing.T) {
+	require := require.New(t)
+
+	ds, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(err)
+
+	ctx := context.Background()
+	rev, err := common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE,
+		tuple.MustParse("document:first#viewer@user:tom"),
+		tuple.MustParse("document:second#viewer@user:tom"),
+		tuple.MustParse("document:second#editor@user:tom"),
+		tuple.MustParse("team:engineering#member@user:tom"),
+		tuple.MustParse("document:first#viewer@user:fred"),
+	)
+	require.NoError(err)
+
+	impact, err := common.SummarizeSubjectImpact(ctx, ds.SnapshotReader(rev), datastore.SubjectsFilter{
+		SubjectType:        "user",
+		OptionalSubjectIds: []string{"tom"},
+	})
+	require.NoError(err)
+	require.Equal(uint64(4), impact.RelationshipCount)
+	require.Len(impact.Resources, 2)
+	require.ElementsMatch([]string{"first", "second"}, impact.Resources["document"]["viewer"])
+	require.Equal([]string{"second"}, impact.Resources["document"]["editor"])
+	require.Equal(map[string][]string{"member": {"engineering"}}, impact.Resources["team"])
+
+	// Summarizing does not delete anything.
+	_, deleted, err := common.DeleteBySubject(ctx, ds, datastore.SubjectsFilter{
+		SubjectType:        "user",
+		OptionalSubjectIds: []string{"tom"},
+	})
+	require.NoError(err)
+	require.Equal(impact.RelationshipCount, deleted)
+
+	impact, err = common.SummarizeSubjectImpact(ctx, ds.SnapshotReader(rev), datastore.SubjectsFilter{
+		SubjectType:        "user",
+		OptionalSubjectIds: []string{"nobody"},
+	})
+	require.NoError(err)
+	require.Equal(uint64(0), impact.RelationshipCount)
+	require.Empty(impact.Resources)
+}
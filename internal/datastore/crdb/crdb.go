@@ -487,3 +487,13 @@ func readClusterTTLNanos(ctx context.Context, conn pgxcommon.DBFuncQuerier) (int
 func revisionFromTimestamp(t time.Time) revision.Decimal {
 	return revision.NewFromDecimal(decimal.NewFromInt(t.UnixNano()))
 }
+
+// RevisionTimestamp returns the physical time of the hybrid logical clock timestamp
+// that makes up the revision.
+func (cds *crdbDatastore) RevisionTimestamp(revisionRaw datastore.Revision) (time.Time, error) {
+	r, ok := revisionRaw.(revision.Decimal)
+	if !ok {
+		return time.Time{}, datastore.NewInvalidRevisionErr(revisionRaw, datastore.CouldNotDetermineRevision)
+	}
+	return time.Unix(0, r.IntPart()).UTC(), nil
+}
@@ -0,0 +1,208 @@
+package memdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/authzed/spicedb/pkg/datastore"
+	"github.com/authzed/spicedb/pkg/datastore/options"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+// DumpVersion is the version of the format written by Dump.
+const DumpVersion = 1
+
+// ErrLoadIntoNonEmptyDatastore is returned by Load when the datastore already holds a schema or
+// relationships, which the dump would otherwise be merged with.
+var ErrLoadIntoNonEmptyDatastore = errors.New("cannot load a dump into a datastore that is not empty")
+
+// dump is the JSON document written by Dump. Definitions and relationships are stored as their
+// serialized protos, so that every field, such as caveat context and definition metadata, is
+// preserved, and are sorted so that the same state always produces the same dump.
+type dump struct {
+	Version       int      `json:"version"`
+	Revision      string   `json:"revision"`
+	Caveats       [][]byte `json:"caveats"`
+	Namespaces    [][]byte `json:"namespaces"`
+	Relationships [][]byte `json:"relationships"`
+}
+
+// deterministic serializes protos with map entries, such as those of caveat context, in a stable
+// order.
+var deterministic = proto.MarshalOptions{Deterministic: true}
+
+// Dump serializes the caveats, namespaces and relationships found in the given datastore at its
+// head revision, returning the serialized state and the revision at which it was read.
+func Dump(ctx context.Context, ds datastore.Datastore) ([]byte, datastore.Revision, error) {
+	state, err := datastore.ReadHeadState(ctx, ds)
+	if err != nil {
+		return nil, datastore.NoRevision, err
+	}
+
+	d := dump{
+		Version:  DumpVersion,
+		Revision: state.Revision.String(),
+	}
+
+	for _, caveatDef := range state.Caveats {
+		serialized, err := deterministic.Marshal(caveatDef.Definition)
+		if err != nil {
+			return nil, datastore.NoRevision, fmt.Errorf("failed to serialize caveat `%s`: %w", caveatDef.Definition.Name, err)
+		}
+		d.Caveats = append(d.Caveats, serialized)
+	}
+
+	for _, nsDef := range state.Namespaces {
+		serialized, err := deterministic.Marshal(nsDef.Definition)
+		if err != nil {
+			return nil, datastore.NoRevision, fmt.Errorf("failed to serialize namespace `%s`: %w", nsDef.Definition.Name, err)
+		}
+		d.Namespaces = append(d.Namespaces, serialized)
+	}
+
+	var relationships []*core.RelationTuple
+	if err := state.ForEachRelationship(ctx, func(tpl *core.RelationTuple) error {
+		relationships = append(relationships, tpl.CloneVT())
+		return nil
+	}); err != nil {
+		return nil, datastore.NoRevision, err
+	}
+
+	relationshipStrings := make([]string, 0, len(relationships))
+	for _, tpl := range relationships {
+		relationshipStrings = append(relationshipStrings, tuple.MustString(tpl))
+	}
+	sort.Sort(byString{relationships, relationshipStrings})
+
+	for index, tpl := range relationships {
+		serialized, err := deterministic.Marshal(tpl)
+		if err != nil {
+			return nil, datastore.NoRevision, fmt.Errorf("failed to serialize relationship `%s`: %w", relationshipStrings[index], err)
+		}
+		d.Relationships = append(d.Relationships, serialized)
+	}
+
+	serialized, err := json.Marshal(d)
+	if err != nil {
+		return nil, datastore.NoRevision, err
+	}
+	return serialized, state.Revision, nil
+}
+
+// byString sorts relationships by their string form.
+type byString struct {
+	relationships []*core.RelationTuple
+	strings       []string
+}
+
+func (bs byString) Len() int           { return len(bs.relationships) }
+func (bs byString) Less(i, j int) bool { return bs.strings[i] < bs.strings[j] }
+func (bs byString) Swap(i, j int) {
+	bs.relationships[i], bs.relationships[j] = bs.relationships[j], bs.relationships[i]
+	bs.strings[i], bs.strings[j] = bs.strings[j], bs.strings[i]
+}
+
+// Load writes the caveats, namespaces and relationships serialized by Dump into the given datastore
+// in a single transaction, returning the revision at which they were written. The datastore must
+// be empty, typically one just created by NewMemdbDatastore. Revisions are specific to
+// each datastore, so the state of the dumped revision is found at the returned revision rather
+// than at the dumped one.
+func Load(ctx context.Context, ds datastore.Datastore, serialized []byte) (datastore.Revision, error) {
+	var d dump
+	if err := json.Unmarshal(serialized, &d); err != nil {
+		return datastore.NoRevision, fmt.Errorf("failed to parse dump: %w", err)
+	}
+
+	if d.Version != DumpVersion {
+		return datastore.NoRevision, fmt.Errorf("unsupported dump version %d; expected %d", d.Version, DumpVersion)
+	}
+
+	caveats := make([]*core.CaveatDefinition, 0, len(d.Caveats))
+	for _, serializedCaveat := range d.Caveats {
+		caveatDef := &core.CaveatDefinition{}
+		if err := caveatDef.UnmarshalVT(serializedCaveat); err != nil {
+			return datastore.NoRevision, fmt.Errorf("failed to parse caveat in dump: %w", err)
+		}
+		caveats = append(caveats, caveatDef)
+	}
+
+	namespaces := make([]*core.NamespaceDefinition, 0, len(d.Namespaces))
+	for _, serializedNamespace := range d.Namespaces {
+		nsDef := &core.NamespaceDefinition{}
+		if err := nsDef.UnmarshalVT(serializedNamespace); err != nil {
+			return datastore.NoRevision, fmt.Errorf("failed to parse namespace in dump: %w", err)
+		}
+		namespaces = append(namespaces, nsDef)
+	}
+
+	updates := make([]*core.RelationTupleUpdate, 0, len(d.Relationships))
+	for _, serializedRelationship := range d.Relationships {
+		tpl := &core.RelationTuple{}
+		if err := tpl.UnmarshalVT(serializedRelationship); err != nil {
+			return datastore.NoRevision, fmt.Errorf("failed to parse relationship in dump: %w", err)
+		}
+		updates = append(updates, tuple.Create(tpl))
+	}
+
+	// Relationships are only found by resource type, so the datastore is checked for relationships
+	// of each type defined or related in the dump, which are those it would be merged with.
+	resourceTypes := make(map[string]struct{}, len(namespaces))
+	for _, nsDef := range namespaces {
+		resourceTypes[nsDef.Name] = struct{}{}
+	}
+	for _, update := range updates {
+		resourceTypes[update.Tuple.ResourceAndRelation.Namespace] = struct{}{}
+	}
+
+	return ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		existingCaveats, err := rwt.ListAllCaveats(ctx)
+		if err != nil {
+			return err
+		}
+
+		existingNamespaces, err := rwt.ListAllNamespaces(ctx)
+		if err != nil {
+			return err
+		}
+
+		if len(existingCaveats) > 0 || len(existingNamespaces) > 0 {
+			return ErrLoadIntoNonEmptyDatastore
+		}
+
+		for resourceType := range resourceTypes {
+			iter, err := rwt.QueryRelationships(ctx, datastore.RelationshipsFilter{ResourceType: resourceType}, options.WithLimit(options.LimitOne))
+			if err != nil {
+				return err
+			}
+
+			found := iter.Next() != nil
+			iter.Close()
+			if err := iter.Err(); err != nil {
+				return err
+			}
+			if found {
+				return ErrLoadIntoNonEmptyDatastore
+			}
+		}
+
+		if len(caveats) > 0 {
+			if err := rwt.WriteCaveats(ctx, caveats); err != nil {
+				return err
+			}
+		}
+
+		if len(namespaces) > 0 {
+			if err := rwt.WriteNamespaces(ctx, namespaces...); err != nil {
+				return err
+			}
+		}
+
+		return rwt.WriteRelationships(ctx, updates)
+	})
+}
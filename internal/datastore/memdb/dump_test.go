@@ -0,0 +1,94 @@
+package memdb
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/internal/testfixtures"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+func TestDumpAndLoad(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	rawDS, err := NewMemdbDatastore(0, 0, DisableGC)
+	require.NoError(err)
+
+	original, _ := testfixtures.StandardDatastoreWithCaveatedData(rawDS, require)
+
+	serialized, dumpedRevision, err := Dump(ctx, original)
+	require.NoError(err)
+
+	originalHead, err := original.HeadRevision(ctx)
+	require.NoError(err)
+	require.True(dumpedRevision.Equal(originalHead))
+
+	restored, err := NewMemdbDatastore(0, 0, DisableGC)
+	require.NoError(err)
+
+	loadedRevision, err := Load(ctx, restored, serialized)
+	require.NoError(err)
+
+	reserialized, reloadedRevision, err := Dump(ctx, restored)
+	require.NoError(err)
+	require.True(reloadedRevision.Equal(loadedRevision))
+
+	// Other than the revision, the dump of the restored datastore must match the original.
+	var originalDump, restoredDump dump
+	require.NoError(json.Unmarshal(serialized, &originalDump))
+	require.NoError(json.Unmarshal(reserialized, &restoredDump))
+	require.NotEmpty(originalDump.Namespaces)
+	require.NotEmpty(originalDump.Caveats)
+	require.Len(originalDump.Relationships, len(testfixtures.StandardTuples))
+
+	originalDump.Revision = ""
+	restoredDump.Revision = ""
+	require.Equal(originalDump, restoredDump)
+}
+
+func TestLoadUnsupportedVersion(t *testing.T) {
+	ds, err := NewMemdbDatastore(0, 0, DisableGC)
+	require.NoError(t, err)
+
+	_, err = Load(context.Background(), ds, []byte(`{"version": 2}`))
+	require.ErrorContains(t, err, "unsupported dump version 2")
+}
+
+func TestLoadIntoNonEmptyDatastore(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	rawDS, err := NewMemdbDatastore(0, 0, DisableGC)
+	require.NoError(err)
+	original, _ := testfixtures.StandardDatastoreWithData(rawDS, require)
+
+	serialized, _, err := Dump(ctx, original)
+	require.NoError(err)
+
+	_, err = Load(ctx, original, serialized)
+	require.ErrorIs(err, ErrLoadIntoNonEmptyDatastore)
+
+	schemaOnly, err := NewMemdbDatastore(0, 0, DisableGC)
+	require.NoError(err)
+	schemaOnly, _ = testfixtures.StandardDatastoreWithSchema(schemaOnly, require)
+
+	_, err = Load(ctx, schemaOnly, serialized)
+	require.ErrorIs(err, ErrLoadIntoNonEmptyDatastore)
+
+	// Relationships are found even without the schema defining them.
+	relationshipsOnly, err := NewMemdbDatastore(0, 0, DisableGC)
+	require.NoError(err)
+	_, err = relationshipsOnly.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		return rwt.WriteRelationships(ctx, []*core.RelationTupleUpdate{tuple.Create(tuple.MustParse(testfixtures.StandardTuples[0]))})
+	})
+	require.NoError(err)
+
+	_, err = Load(ctx, relationshipsOnly, serialized)
+	require.ErrorIs(err, ErrLoadIntoNonEmptyDatastore)
+}
@@ -37,6 +37,7 @@ func NewMemdbDatastore(
 	watchBufferLength uint16,
 	revisionQuantization,
 	gcWindow time.Duration,
+	options ...Option,
 ) (datastore.Datastore, error) {
 	if revisionQuantization > gcWindow {
 		return nil, errors.New("gc window must be larger than quantization interval")
@@ -46,7 +47,14 @@ func NewMemdbDatastore(
 		revisionQuantization = 1
 	}
 
-	db, err := memdb.NewMemDB(schema)
+	config := generateConfig(options)
+
+	indexedSubjectTypes := make(map[string]struct{}, len(config.indexedSubjectTypes))
+	for _, subjectType := range config.indexedSubjectTypes {
+		indexedSubjectTypes[subjectType] = struct{}{}
+	}
+
+	db, err := memdb.NewMemDB(newSchema(indexedSubjectTypes))
 	if err != nil {
 		return nil, err
 	}
@@ -59,19 +67,26 @@ func NewMemdbDatastore(
 
 	negativeGCWindow := decimal.NewFromInt(gcWindow.Nanoseconds()).Mul(decimal.NewFromInt(-1))
 
+	initialRevision := revisionFromTimestamp(time.Now().UTC()).Decimal
+
 	return &memdbDatastore{
 		db: db,
 		revisions: []snapshot{
 			{
-				revision: revisionFromTimestamp(time.Now().UTC()).Decimal,
+				revision: initialRevision,
 				db:       db,
 			},
 		},
+		initialRevision:     initialRevision,
+		namespaceWatermarks: make(map[string]decimal.Decimal),
+
+		negativeGCWindow:       negativeGCWindow,
+		revisionRetentionCount: int(config.revisionRetentionCount),
+		quantizationPeriod:     decimal.NewFromInt(revisionQuantization.Nanoseconds()),
+		watchBufferLength:      watchBufferLength,
+		uniqueID:               uniqueID,
 
-		negativeGCWindow:   negativeGCWindow,
-		quantizationPeriod: decimal.NewFromInt(revisionQuantization.Nanoseconds()),
-		watchBufferLength:  watchBufferLength,
-		uniqueID:           uniqueID,
+		indexedSubjectTypes: indexedSubjectTypes,
 	}, nil
 }
 
@@ -83,10 +98,16 @@ type memdbDatastore struct {
 	revisions      []snapshot
 	activeWriteTxn *memdb.Txn
 
-	negativeGCWindow   decimal.Decimal
-	quantizationPeriod decimal.Decimal
-	watchBufferLength  uint16
-	uniqueID           string
+	negativeGCWindow       decimal.Decimal
+	revisionRetentionCount int
+	quantizationPeriod     decimal.Decimal
+	watchBufferLength      uint16
+	uniqueID               string
+
+	indexedSubjectTypes map[string]struct{}
+
+	initialRevision     decimal.Decimal
+	namespaceWatermarks map[string]decimal.Decimal
 }
 
 type snapshot struct {
@@ -101,11 +122,11 @@ func (mdb *memdbDatastore) SnapshotReader(revisionRaw datastore.Revision) datast
 	defer mdb.RUnlock()
 
 	if len(mdb.revisions) == 0 {
-		return &memdbReader{nil, nil, fmt.Errorf("memdb datastore is not ready")}
+		return &memdbReader{nil, nil, fmt.Errorf("memdb datastore is not ready"), nil}
 	}
 
 	if err := mdb.checkRevisionLocalCallerMustLock(dr); err != nil {
-		return &memdbReader{nil, nil, err}
+		return &memdbReader{nil, nil, err, nil}
 	}
 
 	revIndex := sort.Search(len(mdb.revisions), func(i int) bool {
@@ -119,7 +140,7 @@ func (mdb *memdbDatastore) SnapshotReader(revisionRaw datastore.Revision) datast
 
 	rev := mdb.revisions[revIndex]
 	if rev.db == nil {
-		return &memdbReader{nil, nil, fmt.Errorf("memdb datastore is already closed")}
+		return &memdbReader{nil, nil, fmt.Errorf("memdb datastore is already closed"), nil}
 	}
 
 	roTxn := rev.db.Txn(false)
@@ -127,7 +148,7 @@ func (mdb *memdbDatastore) SnapshotReader(revisionRaw datastore.Revision) datast
 		return roTxn, nil
 	}
 
-	return &memdbReader{noopTryLocker{}, txSrc, nil}
+	return &memdbReader{noopTryLocker{}, txSrc, nil, mdb.indexedSubjectTypes}
 }
 
 func (mdb *memdbDatastore) ReadWriteTx(
@@ -169,7 +190,7 @@ func (mdb *memdbDatastore) ReadWriteTx(
 		}
 
 		newRevision := mdb.newRevisionID()
-		rwt := &memdbReadWriteTx{memdbReader{&sync.Mutex{}, txSrc, nil}, newRevision}
+		rwt := &memdbReadWriteTx{memdbReader{&sync.Mutex{}, txSrc, nil, mdb.indexedSubjectTypes}, newRevision}
 		if err := f(rwt); err != nil {
 			mdb.Lock()
 			if tx != nil {
@@ -201,13 +222,25 @@ func (mdb *memdbDatastore) ReadWriteTx(
 			Changes:  nil,
 		}
 		if tx != nil {
+			// The watermarks of the changed namespaces are only advanced once the changes are
+			// committed.
+			changedNamespaces := map[string]struct{}{}
 			for _, change := range tx.Changes() {
+				if change.Table == tableNamespace {
+					changed := change.After
+					if changed == nil {
+						changed = change.Before
+					}
+					changedNamespaces[changed.(*namespace).name] = struct{}{}
+				}
+
 				if change.Table == tableRelationship {
 					if change.After != nil {
 						rt, err := change.After.(*relationship).RelationTuple()
 						if err != nil {
 							return datastore.NoRevision, err
 						}
+						changedNamespaces[rt.ResourceAndRelation.Namespace] = struct{}{}
 						newChanges.Changes = append(newChanges.Changes, &corev1.RelationTupleUpdate{
 							Operation: corev1.RelationTupleUpdate_TOUCH,
 							Tuple:     rt,
@@ -218,6 +251,7 @@ func (mdb *memdbDatastore) ReadWriteTx(
 						if err != nil {
 							return datastore.NoRevision, err
 						}
+						changedNamespaces[rt.ResourceAndRelation.Namespace] = struct{}{}
 						newChanges.Changes = append(newChanges.Changes, &corev1.RelationTupleUpdate{
 							Operation: corev1.RelationTupleUpdate_DELETE,
 							Tuple:     rt,
@@ -235,6 +269,10 @@ func (mdb *memdbDatastore) ReadWriteTx(
 			}
 
 			tx.Commit()
+
+			for name := range changedNamespaces {
+				mdb.namespaceWatermarks[name] = newRevision.Decimal
+			}
 		}
 		mdb.activeWriteTxn = nil
 
@@ -245,6 +283,7 @@ func (mdb *memdbDatastore) ReadWriteTx(
 
 		snap := mdb.db.Snapshot()
 		mdb.revisions = append(mdb.revisions, snapshot{newRevision.Decimal, snap})
+		mdb.pruneStaleRevisionsCallerMustLock()
 		return newRevision, nil
 	}
 
@@ -15,18 +15,151 @@ import (
 	test "github.com/authzed/spicedb/pkg/datastore/test"
 	ns "github.com/authzed/spicedb/pkg/namespace"
 	corev1 "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
 )
 
-type memDBTest struct{}
+type memDBTest struct {
+	options []Option
+}
 
 func (mdbt memDBTest) New(revisionQuantization, _, gcWindow time.Duration, watchBufferLength uint16) (datastore.Datastore, error) {
-	return NewMemdbDatastore(watchBufferLength, revisionQuantization, gcWindow)
+	return NewMemdbDatastore(watchBufferLength, revisionQuantization, gcWindow, mdbt.options...)
 }
 
 func TestMemdbDatastore(t *testing.T) {
 	test.All(t, memDBTest{})
 }
 
+func TestMemdbDatastoreWithIndexedSubjectTypes(t *testing.T) {
+	test.All(t, memDBTest{options: []Option{WithIndexedSubjectTypes("user", "group")}})
+}
+
+func TestIndexedSubjectTypeReverseQuery(t *testing.T) {
+	require := require.New(t)
+
+	ds, err := NewMemdbDatastore(0, 0, DisableGC, WithIndexedSubjectTypes("user"))
+	require.NoError(err)
+
+	ctx := context.Background()
+	rev, err := ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		return rwt.WriteRelationships(ctx, []*corev1.RelationTupleUpdate{
+			tuple.Create(tuple.MustParse("document:first#viewer@user:tom")),
+			tuple.Create(tuple.MustParse("document:second#viewer@user:tom")),
+			tuple.Create(tuple.MustParse("document:third#viewer@user:fred#manager")),
+			tuple.Create(tuple.MustParse("document:first#viewer@team:engineering#member")),
+		})
+	})
+	require.NoError(err)
+
+	reader := ds.SnapshotReader(rev)
+
+	for _, tc := range []struct {
+		name           string
+		filter         datastore.SubjectsFilter
+		expectedTuples []string
+	}{
+		{
+			"ellipsis relation",
+			datastore.SubjectsFilter{
+				SubjectType:    "user",
+				RelationFilter: datastore.SubjectRelationFilter{}.WithEllipsisRelation(),
+			},
+			[]string{"document:first#viewer@user:tom", "document:second#viewer@user:tom"},
+		},
+		{
+			"non-ellipsis relation",
+			datastore.SubjectsFilter{
+				SubjectType:    "user",
+				RelationFilter: datastore.SubjectRelationFilter{}.WithNonEllipsisRelation("manager"),
+			},
+			[]string{"document:third#viewer@user:fred#manager"},
+		},
+		{
+			"any relation",
+			datastore.SubjectsFilter{
+				SubjectType: "user",
+			},
+			[]string{"document:first#viewer@user:tom", "document:second#viewer@user:tom", "document:third#viewer@user:fred#manager"},
+		},
+		{
+			"unindexed subject type",
+			datastore.SubjectsFilter{
+				SubjectType:    "team",
+				RelationFilter: datastore.SubjectRelationFilter{}.WithNonEllipsisRelation("member"),
+			},
+			[]string{"document:first#viewer@team:engineering#member"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			iter, err := reader.ReverseQueryRelationships(ctx, tc.filter)
+			require.NoError(err)
+			defer iter.Close()
+
+			var found []string
+			for tpl := iter.Next(); tpl != nil; tpl = iter.Next() {
+				found = append(found, tuple.MustString(tpl))
+			}
+			require.NoError(iter.Err())
+			require.ElementsMatch(tc.expectedTuples, found)
+		})
+	}
+}
+
+func TestNamespaceWatermark(t *testing.T) {
+	require := require.New(t)
+
+	rawDS, err := NewMemdbDatastore(0, 0, DisableGC)
+	require.NoError(err)
+
+	ds := rawDS.(datastore.NamespaceWatermarker)
+	ctx := context.Background()
+
+	initial, err := ds.NamespaceWatermark(ctx, "document")
+	require.NoError(err)
+
+	nsRev, err := rawDS.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		return rwt.WriteNamespaces(ctx, ns.Namespace("document"), ns.Namespace("user"))
+	})
+	require.NoError(err)
+	require.True(nsRev.GreaterThan(initial))
+
+	watermark, err := ds.NamespaceWatermark(ctx, "document")
+	require.NoError(err)
+	require.True(nsRev.Equal(watermark))
+
+	relRev, err := rawDS.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		return rwt.WriteRelationships(ctx, []*corev1.RelationTupleUpdate{
+			tuple.Create(tuple.MustParse("document:first#viewer@user:tom")),
+		})
+	})
+	require.NoError(err)
+
+	// Only the namespace of the resource has its watermark advanced.
+	watermark, err = ds.NamespaceWatermark(ctx, "document")
+	require.NoError(err)
+	require.True(relRev.Equal(watermark))
+
+	watermark, err = ds.NamespaceWatermark(ctx, "user")
+	require.NoError(err)
+	require.True(nsRev.Equal(watermark))
+
+	deleteRev, err := rawDS.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		return rwt.WriteRelationships(ctx, []*corev1.RelationTupleUpdate{
+			tuple.Delete(tuple.MustParse("document:first#viewer@user:tom")),
+		})
+	})
+	require.NoError(err)
+
+	watermark, err = ds.NamespaceWatermark(ctx, "document")
+	require.NoError(err)
+	require.True(deleteRev.Equal(watermark))
+
+	// Namespaces which have never changed report the initial revision.
+	watermark, err = ds.NamespaceWatermark(ctx, "unknown")
+	require.NoError(err)
+	require.True(initial.Equal(watermark))
+}
+
 func TestConcurrentWritePanic(t *testing.T) {
 	require := require.New(t)
 
@@ -77,3 +210,42 @@ func TestConcurrentWritePanic(t *testing.T) {
 	}, 1*time.Second, 10*time.Millisecond)
 	require.ErrorIs(err, recoverErr)
 }
+
+func TestStatisticsRelationshipCountByObjectType(t *testing.T) {
+	require := require.New(t)
+
+	ds, err := NewMemdbDatastore(0, 0, DisableGC)
+	require.NoError(err)
+
+	ctx := context.Background()
+	_, err = ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		if err := rwt.WriteNamespaces(ctx, ns.Namespace("document"), ns.Namespace("folder"), ns.Namespace("user")); err != nil {
+			return err
+		}
+
+		return rwt.WriteRelationships(ctx, []*corev1.RelationTupleUpdate{
+			tuple.Create(tuple.MustParse("document:first#viewer@user:tom")),
+			tuple.Create(tuple.MustParse("document:second#viewer@user:tom")),
+			tuple.Create(tuple.MustParse("folder:root#viewer@user:fred")),
+		})
+	})
+	require.NoError(err)
+
+	stats, err := ds.Statistics(ctx)
+	require.NoError(err)
+	require.Equal(uint64(3), stats.EstimatedRelationshipCount)
+	require.Len(stats.ObjectTypeStatistics, 3)
+	require.Equal(map[string]uint64{"document": 2, "folder": 1, "user": 0}, stats.RelationshipCountByObjectType)
+
+	_, err = ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		return rwt.WriteRelationships(ctx, []*corev1.RelationTupleUpdate{
+			tuple.Delete(tuple.MustParse("document:first#viewer@user:tom")),
+		})
+	})
+	require.NoError(err)
+
+	stats, err = ds.Statistics(ctx)
+	require.NoError(err)
+	require.Equal(uint64(2), stats.EstimatedRelationshipCount)
+	require.Equal(map[string]uint64{"document": 1, "folder": 1, "user": 0}, stats.RelationshipCountByObjectType)
+}
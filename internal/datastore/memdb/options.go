@@ -0,0 +1,41 @@
+package memdb
+
+type memdbOptions struct {
+	indexedSubjectTypes    []string
+	revisionRetentionCount uint32
+}
+
+// Option configures optional behavior of the memdb datastore.
+type Option func(*memdbOptions)
+
+func generateConfig(options []Option) memdbOptions {
+	computed := memdbOptions{}
+	for _, option := range options {
+		option(&computed)
+	}
+	return computed
+}
+
+// WithIndexedSubjectTypes configures the datastore to maintain an additional
+// index over the subject relation of every relationship whose subject is one
+// of the given object types. The extra index is kept up to date on every write
+// and is used to speed up reverse (subject-side) queries for those types, at
+// the cost of additional work when writing relationships.
+func WithIndexedSubjectTypes(subjectTypes ...string) Option {
+	return func(mo *memdbOptions) {
+		mo.indexedSubjectTypes = append(mo.indexedSubjectTypes, subjectTypes...)
+	}
+}
+
+// WithRevisionRetentionCount configures the datastore to keep the most recent
+// count revisions readable regardless of their age, in addition to those
+// within the GC window: a revision only becomes stale once it is both outside
+// the GC window and older than the most recent count revisions. When set, the
+// snapshots of stale revisions are also discarded as new revisions are
+// written, bounding the memory held for history. Zero, the default, retains
+// revisions by the GC window alone.
+func WithRevisionRetentionCount(count uint32) Option {
+	return func(mo *memdbOptions) {
+		mo.revisionRetentionCount = count
+	}
+}
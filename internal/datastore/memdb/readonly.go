@@ -23,6 +23,8 @@ type memdbReader struct {
 	TryLocker
 	txSource txFactory
 	initErr  error
+
+	indexedSubjectTypes map[string]struct{}
 }
 
 // QueryRelationships reads relationships starting from the resource side.
@@ -106,11 +108,7 @@ func (r *memdbReader) ReverseQueryRelationships(
 
 	queryOpts := options.NewReverseQueryOptionsWithOptions(opts...)
 
-	iterator, err := tx.Get(
-		tableRelationship,
-		indexSubjectNamespace,
-		subjectsFilter.SubjectType,
-	)
+	iterator, err := r.reverseIteratorForFilter(tx, subjectsFilter)
 	if err != nil {
 		return nil, err
 	}
@@ -276,6 +274,38 @@ func iteratorForFilter(txn *memdb.Txn, filter datastore.RelationshipsFilter) (me
 	return iter, err
 }
 
+// reverseIteratorForFilter returns the best iterator for the subjects filter. If the subject type
+// has been configured for additional indexing and the filter matches exactly one subject relation,
+// the subject namespace and relation index is used; otherwise, the subject namespace index is used.
+func (r *memdbReader) reverseIteratorForFilter(txn *memdb.Txn, subjectsFilter datastore.SubjectsFilter) (memdb.ResultIterator, error) {
+	if _, ok := r.indexedSubjectTypes[subjectsFilter.SubjectType]; ok {
+		relationFilter := subjectsFilter.RelationFilter
+
+		subjectRelation := ""
+		switch {
+		case relationFilter.OnlyNonEllipsisRelations:
+		case relationFilter.IncludeEllipsisRelation && relationFilter.NonEllipsisRelation == "":
+			subjectRelation = datastore.Ellipsis
+		case !relationFilter.IncludeEllipsisRelation && relationFilter.NonEllipsisRelation != "":
+			subjectRelation = relationFilter.NonEllipsisRelation
+		}
+
+		if subjectRelation != "" {
+			iter, err := txn.Get(tableRelationship, indexSubjectNamespaceAndRelation, subjectsFilter.SubjectType, subjectRelation)
+			if err != nil {
+				return nil, fmt.Errorf("unable to get iterator for subjects filter: %w", err)
+			}
+			return iter, nil
+		}
+	}
+
+	iter, err := txn.Get(tableRelationship, indexSubjectNamespace, subjectsFilter.SubjectType)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get iterator for subjects filter: %w", err)
+	}
+	return iter, nil
+}
+
 func filterFuncForFilters(
 	optionalResourceType string,
 	optionalResourceIds []string,
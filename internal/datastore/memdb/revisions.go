@@ -14,6 +14,10 @@ func revisionFromTimestamp(t time.Time) revision.Decimal {
 	return revision.NewFromDecimal(decimal.NewFromInt(t.UnixNano()))
 }
 
+func timestampFromRevision(r revision.Decimal) time.Time {
+	return time.Unix(0, r.IntPart()).UTC()
+}
+
 func (mdb *memdbDatastore) newRevisionID() revision.Decimal {
 	mdb.Lock()
 	defer mdb.Unlock()
@@ -103,6 +107,55 @@ func (mdb *memdbDatastore) revisionOutsideGCWindow(now revision.Decimal, revisio
 	if revisionRaw.Equals(mdb.headRevisionNoLock()) {
 		return false
 	}
+	// revisions among the most recent retention count are acceptable regardless of age
+	if mdb.revisionRetentionCount > 0 && revisionRaw.GreaterThanOrEqual(mdb.oldestRetainedRevisionNoLock()) {
+		return false
+	}
 	oldest := revision.NewFromDecimal(now.Add(mdb.negativeGCWindow))
 	return revisionRaw.LessThan(oldest)
 }
+
+func (mdb *memdbDatastore) oldestRetainedRevisionNoLock() decimal.Decimal {
+	oldestIndex := len(mdb.revisions) - mdb.revisionRetentionCount
+	if oldestIndex < 0 {
+		oldestIndex = 0
+	}
+	return mdb.revisions[oldestIndex].revision
+}
+
+// pruneStaleRevisionsCallerMustLock discards the snapshots of revisions which are both outside the
+// GC window and older than the most recent retention count revisions. Snapshots are only discarded
+// when a retention count is configured.
+func (mdb *memdbDatastore) pruneStaleRevisionsCallerMustLock() {
+	if mdb.revisionRetentionCount <= 0 {
+		return
+	}
+
+	oldestInWindow := revisionFromTimestamp(time.Now().UTC()).Add(mdb.negativeGCWindow)
+	prunable := 0
+	for prunable < len(mdb.revisions)-mdb.revisionRetentionCount && mdb.revisions[prunable].revision.LessThan(oldestInWindow) {
+		prunable++
+	}
+
+	if prunable > 0 {
+		mdb.revisions = append([]snapshot(nil), mdb.revisions[prunable:]...)
+	}
+}
+
+func (mdb *memdbDatastore) RevisionTimestamp(revisionRaw datastore.Revision) (time.Time, error) {
+	dr, ok := revisionRaw.(revision.Decimal)
+	if !ok {
+		return time.Time{}, datastore.NewInvalidRevisionErr(revisionRaw, datastore.CouldNotDetermineRevision)
+	}
+	return timestampFromRevision(dr), nil
+}
+
+func (mdb *memdbDatastore) NamespaceWatermark(_ context.Context, name string) (datastore.Revision, error) {
+	mdb.RLock()
+	defer mdb.RUnlock()
+
+	if watermark, ok := mdb.namespaceWatermarks[name]; ok {
+		return revision.NewFromDecimal(watermark), nil
+	}
+	return revision.NewFromDecimal(mdb.initialRevision), nil
+}
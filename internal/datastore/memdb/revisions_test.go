@@ -6,6 +6,9 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/pkg/datastore"
+	ns "github.com/authzed/spicedb/pkg/namespace"
 )
 
 func TestHeadRevision(t *testing.T) {
@@ -26,6 +29,62 @@ func TestHeadRevision(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestRevisionRetentionCount(t *testing.T) {
+	ctx := context.Background()
+
+	ds, err := NewMemdbDatastore(0, 0, 100*time.Millisecond, WithRevisionRetentionCount(2))
+	require.NoError(t, err)
+
+	writeRevision := func() datastore.Revision {
+		rev, err := ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+			return rwt.WriteNamespaces(ctx, ns.Namespace("user"))
+		})
+		require.NoError(t, err)
+		return rev
+	}
+
+	first := writeRevision()
+	second := writeRevision()
+	third := writeRevision()
+
+	time.Sleep(150 * time.Millisecond)
+
+	// GC window elapsed, only the two most recent revisions are retained
+	require.ErrorAs(t, ds.CheckRevision(ctx, first), &datastore.ErrInvalidRevision{})
+	require.NoError(t, ds.CheckRevision(ctx, second))
+	require.NoError(t, ds.CheckRevision(ctx, third))
+
+	fourth := writeRevision()
+	require.ErrorAs(t, ds.CheckRevision(ctx, second), &datastore.ErrInvalidRevision{})
+	require.NoError(t, ds.CheckRevision(ctx, third))
+	require.NoError(t, ds.CheckRevision(ctx, fourth))
+
+	// snapshots of the stale revisions have been discarded
+	require.Len(t, ds.(*memdbDatastore).revisions, 2)
+}
+
+func TestRevisionRetentionCountWithinGCWindow(t *testing.T) {
+	ctx := context.Background()
+
+	ds, err := NewMemdbDatastore(0, 0, time.Hour, WithRevisionRetentionCount(1))
+	require.NoError(t, err)
+
+	var revisions []datastore.Revision
+	for i := 0; i < 3; i++ {
+		rev, err := ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+			return rwt.WriteNamespaces(ctx, ns.Namespace("user"))
+		})
+		require.NoError(t, err)
+		revisions = append(revisions, rev)
+	}
+
+	// all revisions are within the GC window, so are retained beyond the count
+	for _, rev := range revisions {
+		require.NoError(t, ds.CheckRevision(ctx, rev))
+	}
+	require.Len(t, ds.(*memdbDatastore).revisions, 4)
+}
+
 func (mdb *memdbDatastore) ExampleRetryableError() error {
 	return errSerialization
 }
@@ -1,6 +1,8 @@
 package memdb
 
 import (
+	"fmt"
+
 	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
 	"github.com/hashicorp/go-memdb"
 	"github.com/jzelinskie/stringz"
@@ -20,6 +22,8 @@ const (
 	indexNamespaceAndRelation = "namespaceAndRelation"
 	indexSubjectNamespace     = "subjectNamespace"
 
+	indexSubjectNamespaceAndRelation = "subjectNamespaceAndRelation"
+
 	tableChangelog = "changelog"
 	indexRevision  = "id"
 )
@@ -118,76 +122,134 @@ type changelog struct {
 	changes       datastore.RevisionChanges
 }
 
-var schema = &memdb.DBSchema{
-	Tables: map[string]*memdb.TableSchema{
-		tableNamespace: {
-			Name: tableNamespace,
-			Indexes: map[string]*memdb.IndexSchema{
-				indexID: {
-					Name:    indexID,
-					Unique:  true,
-					Indexer: &memdb.StringFieldIndex{Field: "name"},
+// newSchema returns the memdb schema for the datastore. If any subject types are
+// specified, an additional index will be maintained over the subject namespace
+// and subject relation of relationships with those subject types.
+func newSchema(indexedSubjectTypes map[string]struct{}) *memdb.DBSchema {
+	schema := &memdb.DBSchema{
+		Tables: map[string]*memdb.TableSchema{
+			tableNamespace: {
+				Name: tableNamespace,
+				Indexes: map[string]*memdb.IndexSchema{
+					indexID: {
+						Name:    indexID,
+						Unique:  true,
+						Indexer: &memdb.StringFieldIndex{Field: "name"},
+					},
 				},
 			},
-		},
-		tableChangelog: {
-			Name: tableChangelog,
-			Indexes: map[string]*memdb.IndexSchema{
-				indexRevision: {
-					Name:    indexRevision,
-					Unique:  true,
-					Indexer: &memdb.IntFieldIndex{Field: "revisionNanos"},
+			tableChangelog: {
+				Name: tableChangelog,
+				Indexes: map[string]*memdb.IndexSchema{
+					indexRevision: {
+						Name:    indexRevision,
+						Unique:  true,
+						Indexer: &memdb.IntFieldIndex{Field: "revisionNanos"},
+					},
 				},
 			},
-		},
-		tableRelationship: {
-			Name: tableRelationship,
-			Indexes: map[string]*memdb.IndexSchema{
-				indexID: {
-					Name:   indexID,
-					Unique: true,
-					Indexer: &memdb.CompoundIndex{
-						Indexes: []memdb.Indexer{
-							&memdb.StringFieldIndex{Field: "namespace"},
-							&memdb.StringFieldIndex{Field: "resourceID"},
-							&memdb.StringFieldIndex{Field: "relation"},
-							&memdb.StringFieldIndex{Field: "subjectNamespace"},
-							&memdb.StringFieldIndex{Field: "subjectObjectID"},
-							&memdb.StringFieldIndex{Field: "subjectRelation"},
+			tableRelationship: {
+				Name: tableRelationship,
+				Indexes: map[string]*memdb.IndexSchema{
+					indexID: {
+						Name:   indexID,
+						Unique: true,
+						Indexer: &memdb.CompoundIndex{
+							Indexes: []memdb.Indexer{
+								&memdb.StringFieldIndex{Field: "namespace"},
+								&memdb.StringFieldIndex{Field: "resourceID"},
+								&memdb.StringFieldIndex{Field: "relation"},
+								&memdb.StringFieldIndex{Field: "subjectNamespace"},
+								&memdb.StringFieldIndex{Field: "subjectObjectID"},
+								&memdb.StringFieldIndex{Field: "subjectRelation"},
+							},
 						},
 					},
-				},
-				indexNamespace: {
-					Name:    indexNamespace,
-					Unique:  false,
-					Indexer: &memdb.StringFieldIndex{Field: "namespace"},
-				},
-				indexNamespaceAndRelation: {
-					Name:   indexNamespaceAndRelation,
-					Unique: false,
-					Indexer: &memdb.CompoundIndex{
-						Indexes: []memdb.Indexer{
-							&memdb.StringFieldIndex{Field: "namespace"},
-							&memdb.StringFieldIndex{Field: "relation"},
+					indexNamespace: {
+						Name:    indexNamespace,
+						Unique:  false,
+						Indexer: &memdb.StringFieldIndex{Field: "namespace"},
+					},
+					indexNamespaceAndRelation: {
+						Name:   indexNamespaceAndRelation,
+						Unique: false,
+						Indexer: &memdb.CompoundIndex{
+							Indexes: []memdb.Indexer{
+								&memdb.StringFieldIndex{Field: "namespace"},
+								&memdb.StringFieldIndex{Field: "relation"},
+							},
 						},
 					},
-				},
-				indexSubjectNamespace: {
-					Name:    indexSubjectNamespace,
-					Unique:  false,
-					Indexer: &memdb.StringFieldIndex{Field: "subjectNamespace"},
+					indexSubjectNamespace: {
+						Name:    indexSubjectNamespace,
+						Unique:  false,
+						Indexer: &memdb.StringFieldIndex{Field: "subjectNamespace"},
+					},
 				},
 			},
-		},
-		tableCaveats: {
-			Name: tableCaveats,
-			Indexes: map[string]*memdb.IndexSchema{
-				indexID: {
-					Name:    indexID,
-					Unique:  true,
-					Indexer: &memdb.StringFieldIndex{Field: "name"},
+			tableCaveats: {
+				Name: tableCaveats,
+				Indexes: map[string]*memdb.IndexSchema{
+					indexID: {
+						Name:    indexID,
+						Unique:  true,
+						Indexer: &memdb.StringFieldIndex{Field: "name"},
+					},
 				},
 			},
 		},
-	},
+	}
+
+	if len(indexedSubjectTypes) > 0 {
+		schema.Tables[tableRelationship].Indexes[indexSubjectNamespaceAndRelation] = &memdb.IndexSchema{
+			Name:         indexSubjectNamespaceAndRelation,
+			Unique:       false,
+			AllowMissing: true,
+			Indexer:      &subjectTypeIndexer{indexedSubjectTypes},
+		}
+	}
+
+	return schema
+}
+
+// subjectTypeIndexer indexes relationships by their subject namespace and subject relation,
+// but only for those subject namespaces which have been configured to be indexed.
+type subjectTypeIndexer struct {
+	indexedSubjectTypes map[string]struct{}
+}
+
+func (sti *subjectTypeIndexer) FromObject(raw any) (bool, []byte, error) {
+	rel, ok := raw.(*relationship)
+	if !ok {
+		return false, nil, fmt.Errorf("unexpected type for subject type index: %T", raw)
+	}
+
+	if _, ok := sti.indexedSubjectTypes[rel.subjectNamespace]; !ok {
+		return false, nil, nil
+	}
+
+	return true, subjectTypeIndexKey(rel.subjectNamespace, rel.subjectRelation), nil
+}
+
+func (sti *subjectTypeIndexer) FromArgs(args ...any) ([]byte, error) {
+	if len(args) != 2 {
+		return nil, fmt.Errorf("subject type index requires two arguments, got %d", len(args))
+	}
+
+	subjectNamespace, ok := args[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("subject namespace argument must be a string: %#v", args[0])
+	}
+
+	subjectRelation, ok := args[1].(string)
+	if !ok {
+		return nil, fmt.Errorf("subject relation argument must be a string: %#v", args[1])
+	}
+
+	return subjectTypeIndexKey(subjectNamespace, subjectRelation), nil
+}
+
+func subjectTypeIndexKey(subjectNamespace, subjectRelation string) []byte {
+	// Null terminate each value, matching the behavior of memdb.StringFieldIndex.
+	return []byte(subjectNamespace + "\x00" + subjectRelation + "\x00")
 }
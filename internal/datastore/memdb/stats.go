@@ -13,7 +13,7 @@ func (mdb *memdbDatastore) Statistics(ctx context.Context) (datastore.Stats, err
 		return datastore.Stats{}, fmt.Errorf("unable to compute head revision: %w", err)
 	}
 
-	count, err := mdb.countRelationships(ctx)
+	count, countByObjectType, err := mdb.countRelationships(ctx)
 	if err != nil {
 		return datastore.Stats{}, fmt.Errorf("unable to count relationships: %w", err)
 	}
@@ -23,14 +23,22 @@ func (mdb *memdbDatastore) Statistics(ctx context.Context) (datastore.Stats, err
 		return datastore.Stats{}, fmt.Errorf("unable to list object types: %w", err)
 	}
 
+	// Object types without any relationship are reported with a count of zero.
+	relationshipCounts := make(map[string]uint64, len(objTypes))
+	for _, objType := range objTypes {
+		relationshipCounts[objType.Definition.Name] = countByObjectType[objType.Definition.Name]
+	}
+
 	return datastore.Stats{
-		UniqueID:                   mdb.uniqueID,
-		EstimatedRelationshipCount: count,
-		ObjectTypeStatistics:       datastore.ComputeObjectTypeStats(objTypes),
+		UniqueID:                      mdb.uniqueID,
+		EstimatedRelationshipCount:    count,
+		ObjectTypeStatistics:          datastore.ComputeObjectTypeStats(objTypes),
+		RelationshipCountByObjectType: relationshipCounts,
 	}, nil
 }
 
-func (mdb *memdbDatastore) countRelationships(_ context.Context) (uint64, error) {
+// countRelationships returns the number of relationships stored, in total and by object type.
+func (mdb *memdbDatastore) countRelationships(_ context.Context) (uint64, map[string]uint64, error) {
 	mdb.RLock()
 	defer mdb.RUnlock()
 
@@ -39,13 +47,15 @@ func (mdb *memdbDatastore) countRelationships(_ context.Context) (uint64, error)
 
 	it, err := txn.LowerBound(tableRelationship, indexID)
 	if err != nil {
-		return 0, err
+		return 0, nil, err
 	}
 
 	var count uint64
+	countByObjectType := make(map[string]uint64)
 	for row := it.Next(); row != nil; row = it.Next() {
 		count++
+		countByObjectType[row.(*relationship).namespace]++
 	}
 
-	return count, nil
+	return count, countByObjectType, nil
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/hashicorp/go-memdb"
 
@@ -13,12 +14,24 @@ import (
 
 const errWatchError = "watch error: %w"
 
+// Watch streams the changes made after the given revision, read from the changelog of the
+// datastore. Resuming from a revision which has fallen outside of the GC window fails with an
+// ErrInvalidRevision, as the changes following it may no longer be complete.
 func (mdb *memdbDatastore) Watch(ctx context.Context, afterRevision datastore.Revision) (<-chan *datastore.RevisionChanges, <-chan error) {
-	ar := afterRevision.(revision.Decimal)
-
 	updates := make(chan *datastore.RevisionChanges, mdb.watchBufferLength)
 	errs := make(chan error, 1)
 
+	ar, ok := afterRevision.(revision.Decimal)
+	if !ok {
+		errs <- datastore.NewInvalidRevisionErr(afterRevision, datastore.CouldNotDetermineRevision)
+		return updates, errs
+	}
+
+	if mdb.watchRevisionOutsideGCWindow(ar) {
+		errs <- datastore.NewInvalidRevisionErr(afterRevision, datastore.RevisionStale)
+		return updates, errs
+	}
+
 	go func() {
 		defer close(updates)
 		defer close(errs)
@@ -69,6 +82,14 @@ func (mdb *memdbDatastore) Watch(ctx context.Context, afterRevision datastore.Re
 	return updates, errs
 }
 
+func (mdb *memdbDatastore) watchRevisionOutsideGCWindow(afterRevision revision.Decimal) bool {
+	mdb.RLock()
+	defer mdb.RUnlock()
+
+	now := revisionFromTimestamp(time.Now().UTC())
+	return mdb.revisionOutsideGCWindow(now, afterRevision)
+}
+
 func (mdb *memdbDatastore) loadChanges(_ context.Context, currentTxn int64) ([]*datastore.RevisionChanges, int64, <-chan struct{}, error) {
 	mdb.RLock()
 	defer mdb.RUnlock()
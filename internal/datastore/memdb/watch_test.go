@@ -0,0 +1,74 @@
+package memdb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/pkg/datastore"
+	ns "github.com/authzed/spicedb/pkg/namespace"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+func TestWatchResume(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ds, err := NewMemdbDatastore(16, 0, 1*time.Hour)
+	require.NoError(t, err)
+
+	writeRelationship := func(tpl string) datastore.Revision {
+		rev, err := ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+			return rwt.WriteRelationships(ctx, []*core.RelationTupleUpdate{
+				tuple.Touch(tuple.MustParse(tpl)),
+			})
+		})
+		require.NoError(t, err)
+		return rev
+	}
+
+	first := writeRelationship("document:first#viewer@user:tom")
+	writeRelationship("document:second#viewer@user:tom")
+
+	// Resuming from the first revision only streams the changes made after it.
+	updates, errs := ds.Watch(ctx, first)
+	select {
+	case change := <-updates:
+		require.Len(t, change.Changes, 1)
+		require.Equal(t, "document:second#viewer@user:tom", tuple.MustString(change.Changes[0].Tuple))
+	case err := <-errs:
+		require.NoError(t, err)
+	case <-time.After(1 * time.Second):
+		require.Fail(t, "timed out waiting for changes")
+	}
+}
+
+func TestWatchOutsideGCWindow(t *testing.T) {
+	ctx := context.Background()
+
+	ds, err := NewMemdbDatastore(16, 0, 100*time.Millisecond)
+	require.NoError(t, err)
+
+	writeNamespace := func() datastore.Revision {
+		rev, err := ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+			return rwt.WriteNamespaces(ctx, ns.Namespace("user"))
+		})
+		require.NoError(t, err)
+		return rev
+	}
+
+	stale := writeNamespace()
+	time.Sleep(150 * time.Millisecond)
+	writeNamespace()
+
+	_, errs := ds.Watch(ctx, stale)
+	select {
+	case err := <-errs:
+		require.ErrorAs(t, err, &datastore.ErrInvalidRevision{})
+	case <-time.After(1 * time.Second):
+		require.Fail(t, "timed out waiting for error")
+	}
+}
@@ -45,14 +45,17 @@ func DatastoreProxyTestCache(t testing.TB) cache.Cache {
 }
 
 // NewCachingDatastoreProxy creates a new datastore proxy which caches definitions that
-// are loaded at specific datastore revisions.
+// are loaded at specific datastore revisions. If the delegate tracks namespace watermarks,
+// a namespace definition is shared by all revisions since the namespace last changed.
 func NewCachingDatastoreProxy(delegate datastore.Datastore, c cache.Cache) datastore.Datastore {
 	if c == nil {
 		c = cache.NoopCache()
 	}
+	watermarker, _ := datastore.UnwrapAs[datastore.NamespaceWatermarker](delegate)
 	return &definitionCachingProxy{
-		Datastore: delegate,
-		c:         c,
+		Datastore:   delegate,
+		c:           c,
+		watermarker: watermarker,
 	}
 }
 
@@ -63,8 +66,9 @@ type schemaDefinition interface {
 
 type definitionCachingProxy struct {
 	datastore.Datastore
-	c         cache.Cache
-	readGroup singleflight.Group
+	c           cache.Cache
+	readGroup   singleflight.Group
+	watermarker datastore.NamespaceWatermarker
 }
 
 func (p *definitionCachingProxy) Close() error {
@@ -72,6 +76,10 @@ func (p *definitionCachingProxy) Close() error {
 	return p.Datastore.Close()
 }
 
+func (p *definitionCachingProxy) Unwrap() datastore.Datastore {
+	return p.Datastore
+}
+
 func (p *definitionCachingProxy) SnapshotReader(rev datastore.Revision) datastore.Reader {
 	delegateReader := p.Datastore.SnapshotReader(rev)
 	return &definitionCachingReader{delegateReader, rev, p}
@@ -143,6 +151,20 @@ func (r *definitionCachingReader) LookupCaveatsWithNames(
 		estimatedCaveatDefinitionSize)
 }
 
+// cacheKey returns the key under which the definition with the given name is cached for the
+// revision of the reader. A namespace which has not changed since the revision of the reader is
+// keyed by its watermark instead, as its definition is then the same at every revision since.
+func (r *definitionCachingReader) cacheKey(ctx context.Context, prefix string, name string) string {
+	rev := r.rev
+	if prefix == namespaceCacheKeyPrefix && r.p.watermarker != nil {
+		watermark, err := r.p.watermarker.NamespaceWatermark(ctx, name)
+		if err == nil && !watermark.GreaterThan(r.rev) {
+			rev = watermark
+		}
+	}
+	return prefix + ":" + name + "@" + rev.String()
+}
+
 func listAndCache[T schemaDefinition](
 	ctx context.Context,
 	r *definitionCachingReader,
@@ -160,8 +182,10 @@ func listAndCache[T schemaDefinition](
 	remainingToLoad.Extend(names)
 
 	foundDefs := make([]datastore.RevisionedDefinition[T], 0, len(names))
+	cacheRevisionKeys := make(map[string]string, len(names))
 	for _, name := range names {
-		cacheRevisionKey := prefix + ":" + name + "@" + r.rev.String()
+		cacheRevisionKey := r.cacheKey(ctx, prefix, name)
+		cacheRevisionKeys[name] = cacheRevisionKey
 		loadedRaw, found := r.p.c.Get(cacheRevisionKey)
 		if !found {
 			continue
@@ -185,7 +209,10 @@ func listAndCache[T schemaDefinition](
 		for _, def := range loadedDefs {
 			foundDefs = append(foundDefs, def)
 
-			cacheRevisionKey := prefix + ":" + def.Definition.GetName() + "@" + r.rev.String()
+			cacheRevisionKey, ok := cacheRevisionKeys[def.Definition.GetName()]
+			if !ok {
+				cacheRevisionKey = r.cacheKey(ctx, prefix, def.Definition.GetName())
+			}
 			estimatedDefinitionSize := estimator(def.Definition.SizeVT())
 			entry := &cacheEntry{def.Definition, def.LastWrittenRevision, estimatedDefinitionSize, err}
 			r.p.c.Set(cacheRevisionKey, entry, entry.Size())
@@ -208,7 +235,7 @@ func readAndCache[T schemaDefinition](
 	estimator func(sizeVT int) int64,
 ) (T, datastore.Revision, error) {
 	// Check the cache.
-	cacheRevisionKey := prefix + ":" + name + "@" + r.rev.String()
+	cacheRevisionKey := r.cacheKey(ctx, prefix, name)
 	loadedRaw, found := r.p.c.Get(cacheRevisionKey)
 	if !found {
 		// We couldn't use the cached entry, load one
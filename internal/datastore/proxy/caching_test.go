@@ -203,6 +203,55 @@ func TestSnapshotCaching(t *testing.T) {
 	}
 }
 
+type watermarkingMockDatastore struct {
+	*proxy_test.MockDatastore
+	watermarks map[string]datastore.Revision
+}
+
+func (ds watermarkingMockDatastore) NamespaceWatermark(_ context.Context, name string) (datastore.Revision, error) {
+	return ds.watermarks[name], nil
+}
+
+func TestSnapshotCachingByWatermark(t *testing.T) {
+	require := require.New(t)
+
+	dsMock := &proxy_test.MockDatastore{}
+
+	// The definition of nsA is only read once, as it has not changed since revision one. That of
+	// nsB changed at revision two, so it is read at each revision.
+	oneReader := &proxy_test.MockReader{}
+	dsMock.On("SnapshotReader", one).Return(oneReader)
+	oneReader.On("ReadNamespaceByName", nsA).Return(nil, old, nil).Once()
+	oneReader.On("ReadNamespaceByName", nsB).Return(nil, zero, nil).Once()
+
+	twoReader := &proxy_test.MockReader{}
+	dsMock.On("SnapshotReader", two).Return(twoReader)
+	twoReader.On("ReadNamespaceByName", nsB).Return(nil, two, nil).Once()
+
+	ds := NewCachingDatastoreProxy(watermarkingMockDatastore{dsMock, map[string]datastore.Revision{
+		nsA: one,
+		nsB: two,
+	}}, DatastoreProxyTestCache(t))
+
+	for _, rev := range []datastore.Revision{one, two} {
+		_, updatedA, err := ds.SnapshotReader(rev).ReadNamespaceByName(context.Background(), nsA)
+		require.NoError(err)
+		require.True(old.Equal(updatedA))
+	}
+
+	_, updatedOneB, err := ds.SnapshotReader(one).ReadNamespaceByName(context.Background(), nsB)
+	require.NoError(err)
+	require.True(zero.Equal(updatedOneB))
+
+	_, updatedTwoB, err := ds.SnapshotReader(two).ReadNamespaceByName(context.Background(), nsB)
+	require.NoError(err)
+	require.True(two.Equal(updatedTwoB))
+
+	dsMock.AssertExpectations(t)
+	oneReader.AssertExpectations(t)
+	twoReader.AssertExpectations(t)
+}
+
 func TestRWTCaching(t *testing.T) {
 	for _, tester := range testers {
 		tester := tester
@@ -404,6 +453,44 @@ func TestSnapshotCachingRealDatastore(t *testing.T) {
 	}
 }
 
+func TestSnapshotCachingSchemaRewrite(t *testing.T) {
+	rawDS, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	ds := NewCachingDatastoreProxy(rawDS, DatastoreProxyTestCache(t))
+
+	writeNamespace := func(nsDef *core.NamespaceDefinition) datastore.Revision {
+		rev, err := ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+			return rwt.WriteNamespaces(ctx, nsDef)
+		})
+		require.NoError(t, err)
+		return rev
+	}
+
+	original := ns.Namespace("document", ns.MustRelation("viewer", nil, ns.AllowedRelation("user", "...")))
+	rewritten := ns.Namespace("document",
+		ns.MustRelation("viewer", nil, ns.AllowedRelation("user", "...")),
+		ns.MustRelation("editor", nil, ns.AllowedRelation("user", "...")),
+	)
+
+	originalRev := writeNamespace(original)
+	found, _, err := ds.SnapshotReader(originalRev).ReadNamespaceByName(ctx, "document")
+	require.NoError(t, err)
+	testutil.RequireProtoEqual(t, original, found, "found different namespaces")
+
+	// Definitions are cached per revision, so the rewritten definition is read as soon as it is
+	// written, while reads at the earlier revision still find the original.
+	rewrittenRev := writeNamespace(rewritten)
+	found, _, err = ds.SnapshotReader(rewrittenRev).ReadNamespaceByName(ctx, "document")
+	require.NoError(t, err)
+	testutil.RequireProtoEqual(t, rewritten, found, "found different namespaces")
+
+	found, _, err = ds.SnapshotReader(originalRev).ReadNamespaceByName(ctx, "document")
+	require.NoError(t, err)
+	testutil.RequireProtoEqual(t, original, found, "found different namespaces")
+}
+
 type reader struct {
 	proxy_test.MockReader
 }
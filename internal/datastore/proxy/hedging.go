@@ -203,6 +203,10 @@ func (hp hedgingProxy) HeadRevision(ctx context.Context) (rev datastore.Revision
 	return
 }
 
+func (hp hedgingProxy) Unwrap() datastore.Datastore {
+	return hp.Datastore
+}
+
 func (hp hedgingProxy) SnapshotReader(rev datastore.Revision) datastore.Reader {
 	delegate := hp.Datastore.SnapshotReader(rev)
 	return &hedgingReader{delegate, hp}
@@ -136,6 +136,10 @@ func (p *observableProxy) ReadyState(ctx context.Context) (datastore.ReadyState,
 
 func (p *observableProxy) Close() error { return p.delegate.Close() }
 
+func (p *observableProxy) Unwrap() datastore.Datastore {
+	return p.delegate
+}
+
 type observableReader struct{ delegate datastore.Reader }
 
 func (r *observableReader) ReadCaveatByName(ctx context.Context, name string) (*core.CaveatDefinition, datastore.Revision, error) {
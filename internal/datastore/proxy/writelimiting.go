@@ -0,0 +1,43 @@
+package proxy
+
+import (
+	"context"
+
+	"github.com/authzed/spicedb/pkg/datastore"
+	"github.com/authzed/spicedb/pkg/datastore/options"
+)
+
+type writeLimitingDatastore struct {
+	datastore.Datastore
+	writeSlots chan struct{}
+}
+
+// NewWriteLimitingDatastore creates a proxy which allows at most maxConcurrentWrites
+// read-write transactions to run against the downstream delegate datastore at once.
+// Callers waiting for a slot are admitted in the order in which they arrived, so a
+// limit of one causes writes to be committed in submission order.
+func NewWriteLimitingDatastore(delegate datastore.Datastore, maxConcurrentWrites uint16) datastore.Datastore {
+	return writeLimitingDatastore{
+		Datastore:  delegate,
+		writeSlots: make(chan struct{}, maxConcurrentWrites),
+	}
+}
+
+func (wld writeLimitingDatastore) Unwrap() datastore.Datastore {
+	return wld.Datastore
+}
+
+func (wld writeLimitingDatastore) ReadWriteTx(
+	ctx context.Context,
+	f datastore.TxUserFunc,
+	opts ...options.RWTOptionsOption,
+) (datastore.Revision, error) {
+	select {
+	case wld.writeSlots <- struct{}{}:
+	case <-ctx.Done():
+		return datastore.NoRevision, ctx.Err()
+	}
+	defer func() { <-wld.writeSlots }()
+
+	return wld.Datastore.ReadWriteTx(ctx, f, opts...)
+}
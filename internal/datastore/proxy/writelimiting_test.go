@@ -0,0 +1,84 @@
+package proxy
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/internal/datastore/memdb"
+	"github.com/authzed/spicedb/pkg/datastore"
+)
+
+func TestWriteLimitingDatastore(t *testing.T) {
+	for _, maxConcurrentWrites := range []uint16{1, 3} {
+		maxConcurrentWrites := maxConcurrentWrites
+		t.Run("", func(t *testing.T) {
+			require := require.New(t)
+
+			delegate, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+			require.NoError(err)
+
+			ds := NewWriteLimitingDatastore(delegate, maxConcurrentWrites)
+			ctx := context.Background()
+
+			var running, maxRunning int64
+			var wg sync.WaitGroup
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					_, _ = ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+						current := atomic.AddInt64(&running, 1)
+						defer atomic.AddInt64(&running, -1)
+
+						for {
+							observed := atomic.LoadInt64(&maxRunning)
+							if current <= observed || atomic.CompareAndSwapInt64(&maxRunning, observed, current) {
+								break
+							}
+						}
+
+						time.Sleep(5 * time.Millisecond)
+						return nil
+					})
+				}()
+			}
+			wg.Wait()
+
+			require.LessOrEqual(maxRunning, int64(maxConcurrentWrites))
+		})
+	}
+}
+
+func TestWriteLimitingDatastoreCancellation(t *testing.T) {
+	require := require.New(t)
+
+	delegate, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(err)
+
+	ds := NewWriteLimitingDatastore(delegate, 1)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	go func() {
+		_, _ = ds.ReadWriteTx(context.Background(), func(rwt datastore.ReadWriteTransaction) error {
+			close(started)
+			<-release
+			return nil
+		})
+	}()
+	<-started
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err = ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		return nil
+	})
+	require.ErrorIs(err, context.DeadlineExceeded)
+}
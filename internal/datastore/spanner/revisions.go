@@ -46,3 +46,11 @@ func revisionFromTimestamp(t time.Time) revision.Decimal {
 func timestampFromRevision(r revision.Decimal) time.Time {
 	return time.Unix(0, r.IntPart())
 }
+
+func (sd spannerDatastore) RevisionTimestamp(revisionRaw datastore.Revision) (time.Time, error) {
+	r, ok := revisionRaw.(revision.Decimal)
+	if !ok {
+		return time.Time{}, datastore.NewInvalidRevisionErr(revisionRaw, datastore.CouldNotDetermineRevision)
+	}
+	return timestampFromRevision(r).UTC(), nil
+}
@@ -14,8 +14,11 @@ import (
 
 	"github.com/authzed/spicedb/internal/dispatch"
 	"github.com/authzed/spicedb/internal/dispatch/keys"
+	"github.com/authzed/spicedb/internal/graph"
 	"github.com/authzed/spicedb/pkg/cache"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
 	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
 )
 
 const (
@@ -30,14 +33,22 @@ type Dispatcher struct {
 	c          cache.Cache
 	keyHandler keys.Handler
 
+	// expandCache holds shallow expansion subtrees, separately from the results of all other
+	// operations so that large trees do not evict them. Checks of relations are answered from
+	// it when possible.
+	expandCache cache.Cache
+
 	checkTotalCounter                  prometheus.Counter
 	checkFromCacheCounter              prometheus.Counter
+	checkFromExpandCacheCounter        prometheus.Counter
 	reachableResourcesTotalCounter     prometheus.Counter
 	reachableResourcesFromCacheCounter prometheus.Counter
 	lookupResourcesTotalCounter        prometheus.Counter
 	lookupResourcesFromCacheCounter    prometheus.Counter
 	lookupSubjectsTotalCounter         prometheus.Counter
 	lookupSubjectsFromCacheCounter     prometheus.Counter
+	expandTotalCounter                 prometheus.Counter
+	expandFromCacheCounter             prometheus.Counter
 }
 
 func DispatchTestCache(t testing.TB) cache.Cache {
@@ -66,6 +77,11 @@ func NewCachingDispatcher(cacheInst cache.Cache, metricsEnabled bool, prometheus
 		Subsystem: prometheusSubsystem,
 		Name:      "check_from_cache_total",
 	})
+	checkFromExpandCacheCounter := prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: prometheusNamespace,
+		Subsystem: prometheusSubsystem,
+		Name:      "check_from_expand_cache_total",
+	})
 
 	lookupResourcesTotalCounter := prometheus.NewCounter(prometheus.CounterOpts{
 		Namespace: prometheusNamespace,
@@ -100,6 +116,17 @@ func NewCachingDispatcher(cacheInst cache.Cache, metricsEnabled bool, prometheus
 		Name:      "lookup_subjects_from_cache_total",
 	})
 
+	expandTotalCounter := prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: prometheusNamespace,
+		Subsystem: prometheusSubsystem,
+		Name:      "expand_total",
+	})
+	expandFromCacheCounter := prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: prometheusNamespace,
+		Subsystem: prometheusSubsystem,
+		Name:      "expand_from_cache_total",
+	})
+
 	if metricsEnabled && prometheusSubsystem != "" {
 		err := prometheus.Register(checkTotalCounter)
 		if err != nil {
@@ -133,6 +160,18 @@ func NewCachingDispatcher(cacheInst cache.Cache, metricsEnabled bool, prometheus
 		if err != nil {
 			return nil, fmt.Errorf(errCachingInitialization, err)
 		}
+		err = prometheus.Register(checkFromExpandCacheCounter)
+		if err != nil {
+			return nil, fmt.Errorf(errCachingInitialization, err)
+		}
+		err = prometheus.Register(expandTotalCounter)
+		if err != nil {
+			return nil, fmt.Errorf(errCachingInitialization, err)
+		}
+		err = prometheus.Register(expandFromCacheCounter)
+		if err != nil {
+			return nil, fmt.Errorf(errCachingInitialization, err)
+		}
 	}
 
 	if keyHandler == nil {
@@ -143,14 +182,18 @@ func NewCachingDispatcher(cacheInst cache.Cache, metricsEnabled bool, prometheus
 		d:                                  fakeDelegate{},
 		c:                                  cacheInst,
 		keyHandler:                         keyHandler,
+		expandCache:                        cache.NoopCache(),
 		checkTotalCounter:                  checkTotalCounter,
 		checkFromCacheCounter:              checkFromCacheCounter,
+		checkFromExpandCacheCounter:        checkFromExpandCacheCounter,
 		reachableResourcesTotalCounter:     reachableResourcesTotalCounter,
 		reachableResourcesFromCacheCounter: reachableResourcesFromCacheCounter,
 		lookupResourcesTotalCounter:        lookupResourcesTotalCounter,
 		lookupResourcesFromCacheCounter:    lookupResourcesFromCacheCounter,
 		lookupSubjectsTotalCounter:         lookupSubjectsTotalCounter,
 		lookupSubjectsFromCacheCounter:     lookupSubjectsFromCacheCounter,
+		expandTotalCounter:                 expandTotalCounter,
+		expandFromCacheCounter:             expandFromCacheCounter,
 	}, nil
 }
 
@@ -159,6 +202,16 @@ func (cd *Dispatcher) SetDelegate(delegate dispatch.Dispatcher) {
 	cd.d = delegate
 }
 
+// SetExpandCache enables the caching of shallow expansion subtrees in the given cache, which is
+// kept separate from the cache used for all other operations, and the answering of checks from
+// those subtrees. Expansions are not cached unless this is called.
+func (cd *Dispatcher) SetExpandCache(expandCache cache.Cache) {
+	if expandCache == nil {
+		expandCache = cache.NoopCache()
+	}
+	cd.expandCache = expandCache
+}
+
 // DispatchCheck implements dispatch.Check interface
 func (cd *Dispatcher) DispatchCheck(ctx context.Context, req *v1.DispatchCheckRequest) (*v1.DispatchCheckResponse, error) {
 	cd.checkTotalCounter.Inc()
@@ -191,6 +244,12 @@ func (cd *Dispatcher) DispatchCheck(ctx context.Context, req *v1.DispatchCheckRe
 			return &response, nil
 		}
 	}
+
+	if response, ok := cd.checkFromExpandCache(ctx, req); ok {
+		cd.checkFromExpandCacheCounter.Inc()
+		return response, nil
+	}
+
 	computed, err := cd.d.DispatchCheck(ctx, req)
 
 	// We only want to cache the result if there was no error
@@ -213,10 +272,163 @@ func (cd *Dispatcher) DispatchCheck(ctx context.Context, req *v1.DispatchCheckRe
 	return computed, err
 }
 
-// DispatchExpand implements dispatch.Expand interface and does not do any caching yet.
+// checkFromExpandCache answers the check request from the cached shallow expansions of each of
+// its resources, if all are cached and they are sufficient to decide the membership of the
+// subject. That is only the case when each expansion is a leaf of the direct subjects of a
+// relation without rewrite, and the subject is either found directly, without caveat, among
+// them or they contain no caveated or indirect subject through which it may also be reached.
+func (cd *Dispatcher) checkFromExpandCache(ctx context.Context, req *v1.DispatchCheckRequest) (*v1.DispatchCheckResponse, bool) {
+	if req.Debug != v1.DispatchCheckRequest_NO_DEBUG || req.Metadata.DepthRemaining == 0 {
+		return nil, false
+	}
+
+	results := make(map[string]*v1.ResourceCheckResult, len(req.ResourceIds))
+	for _, resourceID := range req.ResourceIds {
+		resource := &core.ObjectAndRelation{
+			Namespace: req.ResourceRelation.Namespace,
+			ObjectId:  resourceID,
+			Relation:  req.ResourceRelation.Relation,
+		}
+
+		// A resource is always a member of itself, which the expansion does not show.
+		if resource.EqualVT(req.Subject) {
+			results[resourceID] = &v1.ResourceCheckResult{Membership: v1.ResourceCheckResult_MEMBER}
+			continue
+		}
+
+		requestKey, err := cd.keyHandler.ExpandCacheKey(ctx, &v1.DispatchExpandRequest{
+			ResourceAndRelation: resource,
+			Metadata:            req.Metadata,
+			ExpansionMode:       v1.DispatchExpandRequest_SHALLOW,
+		})
+		if err != nil {
+			return nil, false
+		}
+
+		cachedResultRaw, found := cd.expandCache.Get(requestKey)
+		if !found {
+			return nil, false
+		}
+
+		var expanded v1.DispatchExpandResponse
+		if err := expanded.UnmarshalVT(cachedResultRaw.([]byte)); err != nil {
+			return nil, false
+		}
+
+		leaf := expanded.TreeNode.GetLeafNode()
+		if leaf == nil || expanded.TreeNode.CaveatExpression != nil {
+			return nil, false
+		}
+
+		isMember, decided := directMembership(leaf.Subjects, req.Subject)
+		if !decided {
+			return nil, false
+		}
+		if isMember {
+			results[resourceID] = &v1.ResourceCheckResult{Membership: v1.ResourceCheckResult_MEMBER}
+		}
+	}
+
+	return &v1.DispatchCheckResponse{
+		ResultsByResourceId: results,
+		Metadata: &v1.ResponseMeta{
+			CachedDispatchCount: 1,
+			DepthRequired:       1,
+		},
+	}, true
+}
+
+// directMembership returns whether the subject is found directly among the given subjects, and
+// whether that is sufficient to decide the subject's membership.
+func directMembership(subjects []*core.DirectSubject, subject *core.ObjectAndRelation) (isMember bool, decided bool) {
+	decided = true
+	for _, direct := range subjects {
+		found := direct.Subject.Namespace == subject.Namespace &&
+			direct.Subject.Relation == subject.Relation &&
+			(direct.Subject.ObjectId == subject.ObjectId || direct.Subject.ObjectId == tuple.PublicWildcard)
+
+		switch {
+		case direct.CaveatExpression != nil:
+			// The caveat must be evaluated by the check itself.
+			if found || direct.Subject.Relation != tuple.Ellipsis {
+				return false, false
+			}
+		case found:
+			return true, true
+		case direct.Subject.Relation != tuple.Ellipsis:
+			// The subject may be reached through this subject set.
+			decided = false
+		}
+	}
+	return false, decided
+}
+
+// DispatchExpand implements dispatch.Expand interface. Shallow expansions are cached in the
+// expand cache, if one has been set.
 func (cd *Dispatcher) DispatchExpand(ctx context.Context, req *v1.DispatchExpandRequest) (*v1.DispatchExpandResponse, error) {
-	resp, err := cd.d.DispatchExpand(ctx, req)
-	return resp, err
+	cd.expandTotalCounter.Inc()
+
+	// The cache key does not include the expansion mode, so only the shallow expansions issued
+	// by the API are cached.
+	if req.ExpansionMode != v1.DispatchExpandRequest_SHALLOW {
+		return cd.d.DispatchExpand(ctx, req)
+	}
+
+	requestKey, err := cd.keyHandler.ExpandCacheKey(ctx, req)
+	if err != nil {
+		return &v1.DispatchExpandResponse{Metadata: &v1.ResponseMeta{}}, err
+	}
+
+	if cachedResultRaw, found := cd.expandCache.Get(requestKey); found {
+		var response v1.DispatchExpandResponse
+		if err := response.UnmarshalVT(cachedResultRaw.([]byte)); err != nil {
+			return &v1.DispatchExpandResponse{Metadata: &v1.ResponseMeta{}}, err
+		}
+
+		if req.Metadata.DepthRemaining >= response.Metadata.DepthRequired {
+			cd.expandFromCacheCounter.Inc()
+			return &response, nil
+		}
+	}
+
+	computed, err := cd.d.DispatchExpand(ctx, req)
+
+	// Trees truncated at the maximum depth are only valid for the request which allowed the
+	// truncation, so they are never cached.
+	if err == nil && !containsTruncatedNode(computed.TreeNode) {
+		adjustedComputed := computed.CloneVT()
+		adjustedComputed.Metadata.CachedDispatchCount = adjustedComputed.Metadata.DispatchCount
+		adjustedComputed.Metadata.DispatchCount = 0
+		adjustedComputed.Metadata.DebugInfo = nil
+
+		adjustedBytes, err := adjustedComputed.MarshalVT()
+		if err != nil {
+			return &v1.DispatchExpandResponse{Metadata: &v1.ResponseMeta{}}, err
+		}
+
+		cd.expandCache.Set(requestKey, adjustedBytes, sliceSize(adjustedBytes))
+	}
+
+	return computed, err
+}
+
+func containsTruncatedNode(node *core.RelationTupleTreeNode) bool {
+	if node == nil {
+		return false
+	}
+
+	if graph.IsTruncatedNode(node) {
+		return true
+	}
+
+	if intermediate := node.GetIntermediateNode(); intermediate != nil {
+		for _, child := range intermediate.ChildNodes {
+			if containsTruncatedNode(child) {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 // DispatchReachableResources implements dispatch.ReachableResources interface.
@@ -426,9 +638,15 @@ func (cd *Dispatcher) Close() error {
 	prometheus.Unregister(cd.lookupResourcesFromCacheCounter)
 	prometheus.Unregister(cd.lookupSubjectsFromCacheCounter)
 	prometheus.Unregister(cd.lookupSubjectsTotalCounter)
+	prometheus.Unregister(cd.checkFromExpandCacheCounter)
+	prometheus.Unregister(cd.expandTotalCounter)
+	prometheus.Unregister(cd.expandFromCacheCounter)
 	if cache := cd.c; cache != nil {
 		cache.Close()
 	}
+	if cache := cd.expandCache; cache != nil {
+		cache.Close()
+	}
 
 	return nil
 }
@@ -146,6 +146,197 @@ func TestMaxDepthCaching(t *testing.T) {
 	}
 }
 
+func TestExpandCaching(t *testing.T) {
+	leafNode := &core.RelationTupleTreeNode{
+		NodeType: &core.RelationTupleTreeNode_LeafNode{
+			LeafNode: &core.DirectSubjects{},
+		},
+		Expanded: tuple.ParseONR("document:doc1#read"),
+	}
+	truncatedNode := &core.RelationTupleTreeNode{
+		Expanded: tuple.ParseONR("document:doc1#read"),
+	}
+
+	testCases := []struct {
+		name                  string
+		expandCacheEnabled    bool
+		mode                  v1.DispatchExpandRequest_ExpansionMode
+		tree                  *core.RelationTupleTreeNode
+		expectedDelegateCalls int
+	}{
+		{"cache disabled", false, v1.DispatchExpandRequest_SHALLOW, leafNode, 2},
+		{"shallow", true, v1.DispatchExpandRequest_SHALLOW, leafNode, 1},
+		{"recursive", true, v1.DispatchExpandRequest_RECURSIVE, leafNode, 2},
+		{"truncated", true, v1.DispatchExpandRequest_SHALLOW, truncatedNode, 2},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			req := &v1.DispatchExpandRequest{
+				ResourceAndRelation: tuple.ParseONR("document:doc1#read"),
+				Metadata: &v1.ResolverMeta{
+					AtRevision:     decimal.Zero.String(),
+					DepthRemaining: 50,
+				},
+				ExpansionMode: tc.mode,
+			}
+
+			delegate := delegateDispatchMock{&mock.Mock{}}
+			delegate.On("DispatchExpand", req).Return(&v1.DispatchExpandResponse{
+				TreeNode: tc.tree,
+				Metadata: &v1.ResponseMeta{
+					DispatchCount: 1,
+					DepthRequired: 1,
+				},
+			}, nil).Times(tc.expectedDelegateCalls)
+
+			dispatch, err := NewCachingDispatcher(DispatchTestCache(t), false, "", nil)
+			require.NoError(err)
+			dispatch.SetDelegate(delegate)
+			if tc.expandCacheEnabled {
+				dispatch.SetExpandCache(DispatchTestCache(t))
+			}
+			defer dispatch.Close()
+
+			for i := 0; i < 2; i++ {
+				resp, err := dispatch.DispatchExpand(context.Background(), req)
+				require.NoError(err)
+				require.Equal(tc.tree.Expanded.ObjectId, resp.TreeNode.Expanded.ObjectId)
+
+				// Let the cache converge before the next request.
+				time.Sleep(10 * time.Millisecond)
+			}
+
+			delegate.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCheckFromExpandCache(t *testing.T) {
+	directSubject := func(subject string, caveated bool) *core.DirectSubject {
+		direct := &core.DirectSubject{Subject: tuple.ParseSubjectONR(subject)}
+		if caveated {
+			direct.CaveatExpression = &core.CaveatExpression{
+				OperationOrCaveat: &core.CaveatExpression_Caveat{
+					Caveat: &core.ContextualizedCaveat{CaveatName: "somecaveat"},
+				},
+			}
+		}
+		return direct
+	}
+
+	testCases := []struct {
+		name               string
+		subjects           []*core.DirectSubject
+		subject            string
+		expectPassthrough  bool
+		expectedMembership v1.ResourceCheckResult_Membership
+	}{
+		{
+			"direct member",
+			[]*core.DirectSubject{directSubject("user:tom", false), directSubject("user:fred", false)},
+			"user:tom",
+			false,
+			v1.ResourceCheckResult_MEMBER,
+		},
+		{
+			"wildcard member",
+			[]*core.DirectSubject{directSubject("user:*", false)},
+			"user:tom",
+			false,
+			v1.ResourceCheckResult_MEMBER,
+		},
+		{
+			"not a member",
+			[]*core.DirectSubject{directSubject("user:fred", false), directSubject("user:sarah", true)},
+			"user:tom",
+			false,
+			v1.ResourceCheckResult_NOT_MEMBER,
+		},
+		{
+			"caveated member",
+			[]*core.DirectSubject{directSubject("user:tom", true)},
+			"user:tom",
+			true,
+			v1.ResourceCheckResult_MEMBER,
+		},
+		{
+			"reachable through subject set",
+			[]*core.DirectSubject{directSubject("user:fred", false), directSubject("group:eng#member", false)},
+			"user:tom",
+			true,
+			v1.ResourceCheckResult_MEMBER,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			metadata := &v1.ResolverMeta{
+				AtRevision:     decimal.Zero.String(),
+				DepthRemaining: 50,
+			}
+			expandReq := &v1.DispatchExpandRequest{
+				ResourceAndRelation: tuple.ParseONR("document:doc1#viewer"),
+				Metadata:            metadata,
+				ExpansionMode:       v1.DispatchExpandRequest_SHALLOW,
+			}
+			checkReq := &v1.DispatchCheckRequest{
+				ResourceRelation: RR("document", "viewer"),
+				ResourceIds:      []string{"doc1"},
+				Subject:          tuple.ParseSubjectONR(tc.subject),
+				Metadata:         metadata,
+			}
+
+			delegate := delegateDispatchMock{&mock.Mock{}}
+			delegate.On("DispatchExpand", expandReq).Return(&v1.DispatchExpandResponse{
+				TreeNode: &core.RelationTupleTreeNode{
+					NodeType: &core.RelationTupleTreeNode_LeafNode{
+						LeafNode: &core.DirectSubjects{Subjects: tc.subjects},
+					},
+					Expanded: expandReq.ResourceAndRelation,
+				},
+				Metadata: &v1.ResponseMeta{DispatchCount: 1, DepthRequired: 1},
+			}, nil).Times(1)
+			if tc.expectPassthrough {
+				delegate.On("DispatchCheck", checkReq).Return(&v1.DispatchCheckResponse{
+					ResultsByResourceId: map[string]*v1.ResourceCheckResult{
+						"doc1": {Membership: tc.expectedMembership},
+					},
+					Metadata: &v1.ResponseMeta{DispatchCount: 1, DepthRequired: 1},
+				}, nil).Times(1)
+			}
+
+			dispatch, err := NewCachingDispatcher(DispatchTestCache(t), false, "", nil)
+			require.NoError(err)
+			dispatch.SetDelegate(delegate)
+			dispatch.SetExpandCache(DispatchTestCache(t))
+			defer dispatch.Close()
+
+			_, err = dispatch.DispatchExpand(context.Background(), expandReq)
+			require.NoError(err)
+
+			// Let the cache converge before the check.
+			time.Sleep(10 * time.Millisecond)
+
+			resp, err := dispatch.DispatchCheck(context.Background(), checkReq)
+			require.NoError(err)
+			membership := v1.ResourceCheckResult_NOT_MEMBER
+			if result, ok := resp.ResultsByResourceId["doc1"]; ok {
+				membership = result.Membership
+			}
+			require.Equal(tc.expectedMembership, membership)
+
+			delegate.AssertExpectations(t)
+		})
+	}
+}
+
 type delegateDispatchMock struct {
 	*mock.Mock
 }
@@ -155,8 +346,9 @@ func (ddm delegateDispatchMock) DispatchCheck(_ context.Context, req *v1.Dispatc
 	return args.Get(0).(*v1.DispatchCheckResponse), args.Error(1)
 }
 
-func (ddm delegateDispatchMock) DispatchExpand(_ context.Context, _ *v1.DispatchExpandRequest) (*v1.DispatchExpandResponse, error) {
-	return &v1.DispatchExpandResponse{}, nil
+func (ddm delegateDispatchMock) DispatchExpand(_ context.Context, req *v1.DispatchExpandRequest) (*v1.DispatchExpandResponse, error) {
+	args := ddm.Called(req)
+	return args.Get(0).(*v1.DispatchExpandResponse), args.Error(1)
 }
 
 func (ddm delegateDispatchMock) DispatchReachableResources(_ *v1.DispatchReachableResourcesRequest, _ dispatch.ReachableResourcesStream) error {
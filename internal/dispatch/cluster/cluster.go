@@ -7,6 +7,7 @@ import (
 	"github.com/authzed/spicedb/internal/dispatch/caching"
 	"github.com/authzed/spicedb/internal/dispatch/graph"
 	"github.com/authzed/spicedb/internal/dispatch/keys"
+	maingraph "github.com/authzed/spicedb/internal/graph"
 	"github.com/authzed/spicedb/pkg/cache"
 )
 
@@ -14,11 +15,13 @@ import (
 type Option func(*optionState)
 
 type optionState struct {
-	metricsEnabled        bool
-	prometheusSubsystem   string
-	cache                 cache.Cache
-	concurrencyLimits     graph.ConcurrencyLimits
-	remoteDispatchTimeout time.Duration
+	metricsEnabled         bool
+	prometheusSubsystem    string
+	cache                  cache.Cache
+	concurrencyLimits      graph.ConcurrencyLimits
+	maxDirectRelationships uint32
+	emptyRewritePolicy     maingraph.EmptyRewritePolicy
+	remoteDispatchTimeout  time.Duration
 }
 
 // MetricsEnabled enables issuing prometheus metrics
@@ -49,6 +52,21 @@ func ConcurrencyLimits(limits graph.ConcurrencyLimits) Option {
 	}
 }
 
+// MaxDirectRelationshipsPerNode sets the maximum number of relationships read per resource
+// for each relation in Check and Expand. Zero is unlimited.
+func MaxDirectRelationshipsPerNode(maximum uint32) Option {
+	return func(state *optionState) {
+		state.maxDirectRelationships = maximum
+	}
+}
+
+// EmptyRewritePolicy sets how Check treats a permission whose rewrite has no operands.
+func EmptyRewritePolicy(policy maingraph.EmptyRewritePolicy) Option {
+	return func(state *optionState) {
+		state.emptyRewritePolicy = policy
+	}
+}
+
 // RemoteDispatchTimeout sets the maximum timeout for a remote dispatch.
 // Defaults to 60s (as defined in the remote dispatcher).
 func RemoteDispatchTimeout(remoteDispatchTimeout time.Duration) Option {
@@ -66,7 +84,7 @@ func NewClusterDispatcher(dispatch dispatch.Dispatcher, options ...Option) (disp
 		fn(&opts)
 	}
 
-	clusterDispatch := graph.NewDispatcher(dispatch, opts.concurrencyLimits)
+	clusterDispatch := graph.NewDispatcher(dispatch, opts.concurrencyLimits, graph.MaxDirectRelationshipsPerNode(opts.maxDirectRelationships), graph.EmptyRewritePolicy(opts.emptyRewritePolicy))
 
 	if opts.prometheusSubsystem == "" {
 		opts.prometheusSubsystem = "dispatch"
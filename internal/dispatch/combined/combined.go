@@ -14,6 +14,7 @@ import (
 	"github.com/authzed/spicedb/internal/dispatch/graph"
 	"github.com/authzed/spicedb/internal/dispatch/keys"
 	"github.com/authzed/spicedb/internal/dispatch/remote"
+	maingraph "github.com/authzed/spicedb/internal/graph"
 	log "github.com/authzed/spicedb/internal/logging"
 	"github.com/authzed/spicedb/pkg/cache"
 	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
@@ -23,15 +24,18 @@ import (
 type Option func(*optionState)
 
 type optionState struct {
-	metricsEnabled        bool
-	prometheusSubsystem   string
-	upstreamAddr          string
-	upstreamCAPath        string
-	grpcPresharedKey      string
-	grpcDialOpts          []grpc.DialOption
-	cache                 cache.Cache
-	concurrencyLimits     graph.ConcurrencyLimits
-	remoteDispatchTimeout time.Duration
+	metricsEnabled         bool
+	prometheusSubsystem    string
+	upstreamAddr           string
+	upstreamCAPath         string
+	grpcPresharedKey       string
+	grpcDialOpts           []grpc.DialOption
+	cache                  cache.Cache
+	expandCache            cache.Cache
+	concurrencyLimits      graph.ConcurrencyLimits
+	maxDirectRelationships uint32
+	emptyRewritePolicy     maingraph.EmptyRewritePolicy
+	remoteDispatchTimeout  time.Duration
 }
 
 // MetricsEnabled enables issuing prometheus metrics
@@ -86,6 +90,14 @@ func Cache(c cache.Cache) Option {
 	}
 }
 
+// ExpandCache sets the optional cache used for shallow expansions. If unset,
+// expansions are not cached.
+func ExpandCache(c cache.Cache) Option {
+	return func(state *optionState) {
+		state.expandCache = c
+	}
+}
+
 // ConcurrencyLimits sets the max number of goroutines per operation
 func ConcurrencyLimits(limits graph.ConcurrencyLimits) Option {
 	return func(state *optionState) {
@@ -93,6 +105,21 @@ func ConcurrencyLimits(limits graph.ConcurrencyLimits) Option {
 	}
 }
 
+// MaxDirectRelationshipsPerNode sets the maximum number of relationships read per resource
+// for each relation in Check and Expand. Zero is unlimited.
+func MaxDirectRelationshipsPerNode(maximum uint32) Option {
+	return func(state *optionState) {
+		state.maxDirectRelationships = maximum
+	}
+}
+
+// EmptyRewritePolicy sets how Check treats a permission whose rewrite has no operands.
+func EmptyRewritePolicy(policy maingraph.EmptyRewritePolicy) Option {
+	return func(state *optionState) {
+		state.emptyRewritePolicy = policy
+	}
+}
+
 // RemoteDispatchTimeout sets the maximum timeout for a remote dispatch.
 // Defaults to 60s (as defined in the remote dispatcher).
 func RemoteDispatchTimeout(remoteDispatchTimeout time.Duration) Option {
@@ -118,8 +145,11 @@ func NewDispatcher(options ...Option) (dispatch.Dispatcher, error) {
 	if err != nil {
 		return nil, err
 	}
+	if opts.expandCache != nil {
+		cachingRedispatch.SetExpandCache(opts.expandCache)
+	}
 
-	redispatch := graph.NewDispatcher(cachingRedispatch, opts.concurrencyLimits)
+	redispatch := graph.NewDispatcher(cachingRedispatch, opts.concurrencyLimits, graph.MaxDirectRelationshipsPerNode(opts.maxDirectRelationships), graph.EmptyRewritePolicy(opts.emptyRewritePolicy))
 
 	// If an upstream is specified, create a cluster dispatcher.
 	if opts.upstreamAddr != "" {
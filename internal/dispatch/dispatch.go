@@ -93,7 +93,7 @@ type DispatchableRequest interface {
 func CheckDepth(ctx context.Context, req DispatchableRequest) error {
 	metadata := req.GetMetadata()
 	if metadata == nil {
-		log.Ctx(ctx).Warn().Object("request", req).Msg("request missing metadata")
+		log.Ctx(ctx).Warn().Object("request", Loggable(req)).Msg("request missing metadata")
 		return fmt.Errorf("request missing metadata")
 	}
 
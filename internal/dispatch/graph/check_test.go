@@ -20,6 +20,7 @@ import (
 	"github.com/authzed/spicedb/internal/testfixtures"
 	"github.com/authzed/spicedb/pkg/datastore"
 	"github.com/authzed/spicedb/pkg/genutil/mapz"
+	ns "github.com/authzed/spicedb/pkg/namespace"
 	core "github.com/authzed/spicedb/pkg/proto/core/v1"
 	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
 	"github.com/authzed/spicedb/pkg/tuple"
@@ -187,6 +188,265 @@ func TestMaxDepth(t *testing.T) {
 	require.Error(err)
 }
 
+func TestMaxDirectRelationshipsPerNode(t *testing.T) {
+	schema := `
+		definition user {}
+
+		definition group {
+			relation member: user
+		}
+
+		definition document {
+			relation viewer: user | group#member
+			relation parent: group
+			permission view = viewer + parent->member
+		}
+	`
+
+	rels := []*core.RelationTuple{
+		tuple.MustParse("document:first#viewer@group:engineering#member"),
+		tuple.MustParse("document:first#viewer@group:sales#member"),
+		tuple.MustParse("document:first#viewer@group:legal#member"),
+		tuple.MustParse("document:second#parent@group:engineering"),
+		tuple.MustParse("document:second#parent@group:sales"),
+		tuple.MustParse("group:legal#member@user:tom"),
+	}
+
+	for _, tc := range []struct {
+		name            string
+		maximum         uint32
+		expectedFailure string
+	}{
+		{"unlimited", 0, ""},
+		{"at limit", 3, ""},
+		{"below limit for direct", 2, "first"},
+		{"below limit for arrow", 1, "first"},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			rawDS, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+			require.NoError(err)
+
+			ds, revision := testfixtures.DatastoreFromSchemaAndTestRelationships(rawDS, schema, rels, require)
+
+			ctx := log.Logger.WithContext(datastoremw.ContextWithHandle(context.Background()))
+			require.NoError(datastoremw.SetInContext(ctx, ds))
+
+			dispatch := NewLocalOnlyDispatcher(10, MaxDirectRelationshipsPerNode(tc.maximum))
+
+			checkResp, checkErr := dispatch.DispatchCheck(ctx, &v1.DispatchCheckRequest{
+				ResourceRelation: RR("document", "view"),
+				ResourceIds:      []string{"first"},
+				ResultsSetting:   v1.DispatchCheckRequest_ALLOW_SINGLE_RESULT,
+				Subject:          ONR("user", "tom", graph.Ellipsis),
+				Metadata: &v1.ResolverMeta{
+					AtRevision:     revision.String(),
+					DepthRemaining: 50,
+				},
+			})
+
+			_, expandErr := dispatch.DispatchExpand(ctx, &v1.DispatchExpandRequest{
+				ResourceAndRelation: ONR("document", "first", "viewer"),
+				Metadata: &v1.ResolverMeta{
+					AtRevision:     revision.String(),
+					DepthRemaining: 50,
+				},
+				ExpansionMode: v1.DispatchExpandRequest_SHALLOW,
+			})
+
+			_, arrowErr := dispatch.DispatchCheck(ctx, &v1.DispatchCheckRequest{
+				ResourceRelation: RR("document", "view"),
+				ResourceIds:      []string{"second"},
+				ResultsSetting:   v1.DispatchCheckRequest_ALLOW_SINGLE_RESULT,
+				Subject:          ONR("user", "tom", graph.Ellipsis),
+				Metadata: &v1.ResolverMeta{
+					AtRevision:     revision.String(),
+					DepthRemaining: 50,
+				},
+			})
+
+			if tc.expectedFailure == "" {
+				require.NoError(checkErr)
+				require.Equal(v1.ResourceCheckResult_MEMBER, checkResp.ResultsByResourceId["first"].Membership)
+				require.NoError(expandErr)
+				require.NoError(arrowErr)
+				return
+			}
+
+			for _, err := range []error{checkErr, expandErr} {
+				var fanOutErr graph.ErrRelationFanOutExceeded
+				require.ErrorAs(err, &fanOutErr)
+				require.Equal("document", fanOutErr.NamespaceName())
+				require.Equal("viewer", fanOutErr.RelationName())
+				require.Equal(tc.expectedFailure, fanOutErr.ResourceID())
+				require.Equal(tc.maximum, fanOutErr.Maximum())
+				require.Contains(err.Error(), "LookupSubjects")
+			}
+
+			if tc.maximum < 2 {
+				var fanOutErr graph.ErrRelationFanOutExceeded
+				require.ErrorAs(arrowErr, &fanOutErr)
+				require.Equal("parent", fanOutErr.RelationName())
+				require.Equal("second", fanOutErr.ResourceID())
+			} else {
+				require.NoError(arrowErr)
+			}
+		})
+	}
+}
+
+func TestEmptyRewritePolicy(t *testing.T) {
+	emptyUnion := &core.UsersetRewrite{
+		RewriteOperation: &core.UsersetRewrite_Union{Union: &core.SetOperation{}},
+	}
+
+	for _, tc := range []struct {
+		name          string
+		policy        graph.EmptyRewritePolicy
+		expectedError bool
+	}{
+		{"deny", graph.EmptyRewriteDeny, false},
+		{"error", graph.EmptyRewriteError, true},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			ds, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+			require.NoError(err)
+
+			// Empty rewrites are rejected by schema validation, so the definition is written directly.
+			revision, err := ds.ReadWriteTx(context.Background(), func(rwt datastore.ReadWriteTransaction) error {
+				return rwt.WriteNamespaces(context.Background(),
+					ns.Namespace("user"),
+					ns.Namespace("document", ns.MustRelation("view", emptyUnion)),
+				)
+			})
+			require.NoError(err)
+
+			ctx := log.Logger.WithContext(datastoremw.ContextWithHandle(context.Background()))
+			require.NoError(datastoremw.SetInContext(ctx, ds))
+
+			dispatch := NewLocalOnlyDispatcher(10, EmptyRewritePolicy(tc.policy))
+			checkResp, checkErr := dispatch.DispatchCheck(ctx, &v1.DispatchCheckRequest{
+				ResourceRelation: RR("document", "view"),
+				ResourceIds:      []string{"first"},
+				ResultsSetting:   v1.DispatchCheckRequest_ALLOW_SINGLE_RESULT,
+				Subject:          ONR("user", "tom", graph.Ellipsis),
+				Metadata: &v1.ResolverMeta{
+					AtRevision:     revision.String(),
+					DepthRemaining: 50,
+				},
+			})
+
+			if !tc.expectedError {
+				require.NoError(checkErr)
+				require.Empty(checkResp.ResultsByResourceId)
+				return
+			}
+
+			var emptyErr graph.ErrEmptyPermissionRewrite
+			require.ErrorAs(checkErr, &emptyErr)
+			require.Equal("document", emptyErr.NamespaceName())
+			require.Equal("view", emptyErr.PermissionName())
+		})
+	}
+}
+
+func TestSchemaCycle(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		namespaces    []*core.NamespaceDefinition
+		relationships []string
+		resourceID    string
+		expectedCycle []string
+	}{
+		{
+			"permission defined in terms of itself",
+			[]*core.NamespaceDefinition{
+				ns.Namespace("user"),
+				ns.Namespace("document",
+					ns.MustRelation("viewer", nil, ns.AllowedRelation("user", "...")),
+					ns.MustRelation("view", ns.Union(ns.ComputedUserset("viewer"), ns.ComputedUserset("edit"))),
+					ns.MustRelation("edit", ns.Union(ns.ComputedUserset("view"))),
+				),
+			},
+			nil,
+			"first",
+			[]string{"view", "edit", "view"},
+		},
+		{
+			"recursion through relationships",
+			[]*core.NamespaceDefinition{
+				ns.Namespace("user"),
+				ns.Namespace("folder",
+					ns.MustRelation("parent", nil, ns.AllowedRelation("folder", "...")),
+					ns.MustRelation("viewer", nil, ns.AllowedRelation("user", "...")),
+					ns.MustRelation("view", ns.Union(ns.ComputedUserset("viewer"), ns.TupleToUserset("parent", "view"))),
+				),
+			},
+			[]string{
+				"folder:child#parent@folder:middle",
+				"folder:middle#parent@folder:root",
+				"folder:root#viewer@user:tom",
+			},
+			"child",
+			nil,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			ds, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+			require.NoError(err)
+
+			// Cycles are not rejected by schema validation, so the definitions are written directly.
+			revision, err := ds.ReadWriteTx(context.Background(), func(rwt datastore.ReadWriteTransaction) error {
+				if err := rwt.WriteNamespaces(context.Background(), tc.namespaces...); err != nil {
+					return err
+				}
+
+				updates := make([]*core.RelationTupleUpdate, 0, len(tc.relationships))
+				for _, rel := range tc.relationships {
+					updates = append(updates, tuple.Create(tuple.MustParse(rel)))
+				}
+				return rwt.WriteRelationships(context.Background(), updates)
+			})
+			require.NoError(err)
+
+			ctx := log.Logger.WithContext(datastoremw.ContextWithHandle(context.Background()))
+			require.NoError(datastoremw.SetInContext(ctx, ds))
+
+			resourceType := tc.namespaces[1].Name
+			dispatch := NewLocalOnlyDispatcher(10)
+			checkResp, checkErr := dispatch.DispatchCheck(ctx, &v1.DispatchCheckRequest{
+				ResourceRelation: RR(resourceType, "view"),
+				ResourceIds:      []string{tc.resourceID},
+				ResultsSetting:   v1.DispatchCheckRequest_ALLOW_SINGLE_RESULT,
+				Subject:          ONR("user", "tom", graph.Ellipsis),
+				Metadata: &v1.ResolverMeta{
+					AtRevision:     revision.String(),
+					DepthRemaining: 50,
+				},
+			})
+
+			if tc.expectedCycle == nil {
+				require.NoError(checkErr)
+				require.Equal(v1.ResourceCheckResult_MEMBER, checkResp.ResultsByResourceId[tc.resourceID].Membership)
+				return
+			}
+
+			var cycleErr graph.ErrSchemaCycle
+			require.ErrorAs(checkErr, &cycleErr)
+			require.Equal(resourceType, cycleErr.NamespaceName())
+			require.Equal(tc.expectedCycle, cycleErr.RelationNames())
+		})
+	}
+}
+
 func TestCheckMetadata(t *testing.T) {
 	type expected struct {
 		relation              string
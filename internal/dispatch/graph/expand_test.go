@@ -307,6 +307,59 @@ func TestMaxDepthExpand(t *testing.T) {
 	require.Error(err)
 }
 
+func TestMaxDepthExpandWithTruncation(t *testing.T) {
+	defer goleak.VerifyNone(t, goleakIgnores...)
+
+	require := require.New(t)
+
+	rawDS, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(err)
+
+	ds, _ := testfixtures.StandardDatastoreWithSchema(rawDS, require)
+
+	tpl := tuple.Parse("folder:oops#parent@folder:oops")
+	ctx := datastoremw.ContextWithHandle(context.Background())
+
+	revision, err := common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, tpl)
+	require.NoError(err)
+	require.NoError(datastoremw.SetInContext(ctx, ds))
+
+	dispatch := NewLocalOnlyDispatcher(10)
+
+	expandResult, err := dispatch.DispatchExpand(expand.ContextWithExpandTruncation(ctx), &v1.DispatchExpandRequest{
+		ResourceAndRelation: ONR("folder", "oops", "view"),
+		Metadata: &v1.ResolverMeta{
+			AtRevision:     revision.String(),
+			DepthRemaining: 5,
+		},
+		ExpansionMode: v1.DispatchExpandRequest_SHALLOW,
+	})
+	require.NoError(err)
+	require.NotNil(expandResult.TreeNode)
+	require.False(expand.IsTruncatedNode(expandResult.TreeNode))
+
+	truncated := findTruncatedNodes(expandResult.TreeNode)
+	require.NotEmpty(truncated)
+	for _, node := range truncated {
+		require.Equal("folder", node.Expanded.Namespace)
+		require.Equal("oops", node.Expanded.ObjectId)
+	}
+}
+
+func findTruncatedNodes(node *core.RelationTupleTreeNode) []*core.RelationTupleTreeNode {
+	if expand.IsTruncatedNode(node) {
+		return []*core.RelationTupleTreeNode{node}
+	}
+
+	var found []*core.RelationTupleTreeNode
+	if intermediate := node.GetIntermediateNode(); intermediate != nil {
+		for _, child := range intermediate.ChildNodes {
+			found = append(found, findTruncatedNodes(child)...)
+		}
+	}
+	return found
+}
+
 func TestCaveatedExpand(t *testing.T) {
 	defer goleak.VerifyNone(t, goleakIgnores...)
 
@@ -74,20 +74,54 @@ func SharedConcurrencyLimits(concurrencyLimit uint16) ConcurrencyLimits {
 	}
 }
 
+// Option is a function-style option for configuring a graph Dispatcher.
+type Option func(*optionState)
+
+type optionState struct {
+	maxDirectRelationships uint32
+	emptyRewritePolicy     graph.EmptyRewritePolicy
+}
+
+// MaxDirectRelationshipsPerNode sets the maximum number of relationships which can be read for a
+// single resource while computing a relation in Check or Expand. Requests which would read more
+// fail with an error, rather than fanning out to every subject. Zero, the default, is unlimited.
+func MaxDirectRelationshipsPerNode(maximum uint32) Option {
+	return func(state *optionState) {
+		state.maxDirectRelationships = maximum
+	}
+}
+
+// EmptyRewritePolicy sets how Check treats a permission whose rewrite has no operands. Defaults to
+// denying every subject.
+func EmptyRewritePolicy(policy graph.EmptyRewritePolicy) Option {
+	return func(state *optionState) {
+		state.emptyRewritePolicy = policy
+	}
+}
+
+func optionStateFor(options []Option) optionState {
+	var opts optionState
+	for _, fn := range options {
+		fn(&opts)
+	}
+	return opts
+}
+
 // NewLocalOnlyDispatcher creates a dispatcher that consults with the graph to formulate a response.
-func NewLocalOnlyDispatcher(concurrencyLimit uint16) dispatch.Dispatcher {
-	return NewLocalOnlyDispatcherWithLimits(SharedConcurrencyLimits(concurrencyLimit))
+func NewLocalOnlyDispatcher(concurrencyLimit uint16, options ...Option) dispatch.Dispatcher {
+	return NewLocalOnlyDispatcherWithLimits(SharedConcurrencyLimits(concurrencyLimit), options...)
 }
 
 // NewLocalOnlyDispatcherWithLimits creates a dispatcher thatg consults with the graph to formulate a response
 // and has the defined concurrency limits per dispatch type.
-func NewLocalOnlyDispatcherWithLimits(concurrencyLimits ConcurrencyLimits) dispatch.Dispatcher {
+func NewLocalOnlyDispatcherWithLimits(concurrencyLimits ConcurrencyLimits, options ...Option) dispatch.Dispatcher {
 	d := &localDispatcher{}
+	opts := optionStateFor(options)
 
 	concurrencyLimits = limitsOrDefaults(concurrencyLimits, defaultConcurrencyLimit)
 
-	d.checker = graph.NewConcurrentChecker(d, concurrencyLimits.Check)
-	d.expander = graph.NewConcurrentExpander(d)
+	d.checker = graph.NewConcurrentChecker(d, concurrencyLimits.Check, opts.maxDirectRelationships, opts.emptyRewritePolicy)
+	d.expander = graph.NewConcurrentExpander(d, opts.maxDirectRelationships)
 	d.reachableResourcesHandler = graph.NewCursoredReachableResources(d, concurrencyLimits.ReachableResources)
 	d.lookupResourcesHandler = graph.NewCursoredLookupResources(d, d, concurrencyLimits.LookupResources)
 	d.lookupSubjectsHandler = graph.NewConcurrentLookupSubjects(d, concurrencyLimits.LookupSubjects)
@@ -97,11 +131,12 @@ func NewLocalOnlyDispatcherWithLimits(concurrencyLimits ConcurrencyLimits) dispa
 
 // NewDispatcher creates a dispatcher that consults with the graph and redispatches subproblems to
 // the provided redispatcher.
-func NewDispatcher(redispatcher dispatch.Dispatcher, concurrencyLimits ConcurrencyLimits) dispatch.Dispatcher {
+func NewDispatcher(redispatcher dispatch.Dispatcher, concurrencyLimits ConcurrencyLimits, options ...Option) dispatch.Dispatcher {
 	concurrencyLimits = limitsOrDefaults(concurrencyLimits, defaultConcurrencyLimit)
+	opts := optionStateFor(options)
 
-	checker := graph.NewConcurrentChecker(redispatcher, concurrencyLimits.Check)
-	expander := graph.NewConcurrentExpander(redispatcher)
+	checker := graph.NewConcurrentChecker(redispatcher, concurrencyLimits.Check, opts.maxDirectRelationships, opts.emptyRewritePolicy)
+	expander := graph.NewConcurrentExpander(redispatcher, opts.maxDirectRelationships)
 	reachableResourcesHandler := graph.NewCursoredReachableResources(redispatcher, concurrencyLimits.ReachableResources)
 	lookupResourcesHandler := graph.NewCursoredLookupResources(redispatcher, redispatcher, concurrencyLimits.LookupResources)
 	lookupSubjectsHandler := graph.NewConcurrentLookupSubjects(redispatcher, concurrencyLimits.LookupSubjects)
@@ -1,6 +1,8 @@
 package keys
 
 import (
+	"strconv"
+
 	"github.com/authzed/spicedb/pkg/caveats"
 	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
 	"github.com/authzed/spicedb/pkg/spiceerrors"
@@ -19,6 +21,7 @@ const (
 	expandPrefix             cachePrefix = "e"
 	reachableResourcesPrefix cachePrefix = "rr"
 	lookupSubjectsPrefix     cachePrefix = "ls"
+	scopedPrefix             cachePrefix = "sc"
 )
 
 var cachePrefixes = []cachePrefix{
@@ -28,6 +31,7 @@ var cachePrefixes = []cachePrefix{
 	expandPrefix,
 	reachableResourcesPrefix,
 	lookupSubjectsPrefix,
+	scopedPrefix,
 }
 
 // checkRequestToKey converts a check request into a cache key based on the relation
@@ -59,6 +63,16 @@ func checkRequestToKeyWithCanonical(req *v1.DispatchCheckRequest, canonicalKey s
 	return cacheKey, nil
 }
 
+// scopedKey converts a cache key into one which is unique to the given scope. As the key is
+// derived from both sums of the given key, its stable sum is only stable within this process.
+func scopedKey(key DispatchCacheKey, scope string) DispatchCacheKey {
+	return dispatchCacheKeyHash(scopedPrefix, "", computeBothHashes,
+		hashableString(scope),
+		hashableString(strconv.FormatUint(key.stableSum, 10)),
+		hashableString(strconv.FormatUint(key.processSpecificSum, 10)),
+	)
+}
+
 // expandRequestToKey converts an expand request into a cache key
 func expandRequestToKey(req *v1.DispatchExpandRequest, option dispatchCacheKeyHashComputeOption) DispatchCacheKey {
 	return dispatchCacheKeyHash(expandPrefix, req.Metadata.AtRevision, option,
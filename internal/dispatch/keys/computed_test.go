@@ -526,6 +526,28 @@ var generatorFuncs = map[string]generatorFunc{
 				subjectRelation.Relation,
 			}, resourceIds...)
 	},
+
+	// Scoped Check.
+	string(scopedPrefix): func(
+		resourceIds []string,
+		subjectIds []string,
+		resourceRelation *core.RelationReference,
+		subjectRelation *core.RelationReference,
+		metadata *v1.ResolverMeta,
+	) (DispatchCacheKey, []string) {
+		return scopedKey(checkRequestToKey(&v1.DispatchCheckRequest{
+				ResourceRelation: resourceRelation,
+				ResourceIds:      resourceIds,
+				Subject:          ONR(subjectRelation.Namespace, subjectIds[0], subjectRelation.Relation),
+				Metadata:         metadata,
+			}, computeBothHashes), "somescope"), append([]string{
+				resourceRelation.Namespace,
+				resourceRelation.Relation,
+				subjectRelation.Namespace,
+				subjectIds[0],
+				subjectRelation.Relation,
+			}, resourceIds...)
+	},
 }
 
 func TestCacheKeyNoOverlap(t *testing.T) {
@@ -0,0 +1,62 @@
+package keys
+
+import (
+	"context"
+
+	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
+)
+
+// ScopedKeyHandler is a key handler which makes the caching keys computed by another handler
+// unique to a scope found in the context of each request, such that requests in different scopes
+// never share cached results. This is used when a single cache is shared by requests against
+// separate datastores, whose revisions may be equal. Dispatch keys are left unchanged.
+type ScopedKeyHandler struct {
+	Handler
+	scopeFromContext func(ctx context.Context) string
+}
+
+// NewScopedKeyHandler creates a new ScopedKeyHandler which scopes the caching keys of the given
+// handler with the scope returned for the context of each request.
+func NewScopedKeyHandler(delegate Handler, scopeFromContext func(ctx context.Context) string) *ScopedKeyHandler {
+	return &ScopedKeyHandler{delegate, scopeFromContext}
+}
+
+func (s *ScopedKeyHandler) CheckCacheKey(ctx context.Context, req *v1.DispatchCheckRequest) (DispatchCacheKey, error) {
+	key, err := s.Handler.CheckCacheKey(ctx, req)
+	if err != nil {
+		return emptyDispatchCacheKey, err
+	}
+	return scopedKey(key, s.scopeFromContext(ctx)), nil
+}
+
+func (s *ScopedKeyHandler) LookupResourcesCacheKey(ctx context.Context, req *v1.DispatchLookupResourcesRequest) (DispatchCacheKey, error) {
+	key, err := s.Handler.LookupResourcesCacheKey(ctx, req)
+	if err != nil {
+		return emptyDispatchCacheKey, err
+	}
+	return scopedKey(key, s.scopeFromContext(ctx)), nil
+}
+
+func (s *ScopedKeyHandler) LookupSubjectsCacheKey(ctx context.Context, req *v1.DispatchLookupSubjectsRequest) (DispatchCacheKey, error) {
+	key, err := s.Handler.LookupSubjectsCacheKey(ctx, req)
+	if err != nil {
+		return emptyDispatchCacheKey, err
+	}
+	return scopedKey(key, s.scopeFromContext(ctx)), nil
+}
+
+func (s *ScopedKeyHandler) ExpandCacheKey(ctx context.Context, req *v1.DispatchExpandRequest) (DispatchCacheKey, error) {
+	key, err := s.Handler.ExpandCacheKey(ctx, req)
+	if err != nil {
+		return emptyDispatchCacheKey, err
+	}
+	return scopedKey(key, s.scopeFromContext(ctx)), nil
+}
+
+func (s *ScopedKeyHandler) ReachableResourcesCacheKey(ctx context.Context, req *v1.DispatchReachableResourcesRequest) (DispatchCacheKey, error) {
+	key, err := s.Handler.ReachableResourcesCacheKey(ctx, req)
+	if err != nil {
+		return emptyDispatchCacheKey, err
+	}
+	return scopedKey(key, s.scopeFromContext(ctx)), nil
+}
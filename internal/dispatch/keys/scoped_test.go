@@ -0,0 +1,54 @@
+package keys
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
+)
+
+type scopeKey struct{}
+
+func TestScopedKeyHandler(t *testing.T) {
+	handler := NewScopedKeyHandler(&DirectKeyHandler{}, func(ctx context.Context) string {
+		scope, _ := ctx.Value(scopeKey{}).(string)
+		return scope
+	})
+
+	req := &v1.DispatchCheckRequest{
+		ResourceRelation: RR("document", "view"),
+		ResourceIds:      []string{"foo"},
+		Subject:          ONR("user", "tom", "..."),
+		Metadata: &v1.ResolverMeta{
+			AtRevision: "1234",
+		},
+	}
+
+	firstCtx := context.WithValue(context.Background(), scopeKey{}, "first")
+	secondCtx := context.WithValue(context.Background(), scopeKey{}, "second")
+
+	firstKey, err := handler.CheckCacheKey(firstCtx, req)
+	require.NoError(t, err)
+
+	firstKeyAgain, err := handler.CheckCacheKey(firstCtx, req)
+	require.NoError(t, err)
+	require.Equal(t, firstKey, firstKeyAgain)
+
+	secondKey, err := handler.CheckCacheKey(secondCtx, req)
+	require.NoError(t, err)
+	require.NotEqual(t, firstKey, secondKey)
+
+	unscopedKey, err := (&DirectKeyHandler{}).CheckCacheKey(firstCtx, req)
+	require.NoError(t, err)
+	require.NotEqual(t, unscopedKey, firstKey)
+
+	// Dispatch keys are not scoped.
+	firstDispatchKey, err := handler.CheckDispatchKey(firstCtx, req)
+	require.NoError(t, err)
+
+	secondDispatchKey, err := handler.CheckDispatchKey(secondCtx, req)
+	require.NoError(t, err)
+	require.Equal(t, firstDispatchKey, secondDispatchKey)
+}
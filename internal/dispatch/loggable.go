@@ -0,0 +1,142 @@
+package dispatch
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/rs/zerolog"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	log "github.com/authzed/spicedb/internal/logging"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+// Loggable returns the given dispatch request or response for logging, with the object and
+// subject IDs it contains redacted as configured by log.SetIDRedaction. Other objects are
+// returned unchanged.
+func Loggable(obj zerolog.LogObjectMarshaler) zerolog.LogObjectMarshaler {
+	switch typed := obj.(type) {
+	case *v1.DispatchCheckRequest:
+		return loggableCheckRequest{typed}
+	case *v1.DispatchCheckResponse:
+		return loggableCheckResponse{typed}
+	case *v1.DispatchExpandRequest:
+		return loggableExpandRequest{typed}
+	case *v1.DispatchLookupResourcesRequest:
+		return loggableLookupResourcesRequest{typed}
+	case *v1.DispatchReachableResourcesRequest:
+		return loggableReachableResourcesRequest{typed}
+	case *v1.DispatchLookupSubjectsRequest:
+		return loggableLookupSubjectsRequest{typed}
+	default:
+		return obj
+	}
+}
+
+type loggableCheckRequest struct{ *v1.DispatchCheckRequest }
+
+func (cr loggableCheckRequest) MarshalZerologObject(e *zerolog.Event) {
+	e.Object("metadata", cr.Metadata)
+	e.Str("resource-type", tuple.StringRR(cr.ResourceRelation))
+	e.Str("subject", redactedONR(cr.Subject))
+	e.Array("resource-ids", redactedIDArray(cr.ResourceIds))
+}
+
+type loggableCheckResponse struct{ *v1.DispatchCheckResponse }
+
+func (cr loggableCheckResponse) MarshalZerologObject(e *zerolog.Event) {
+	e.Object("metadata", cr.Metadata)
+
+	results := zerolog.Dict()
+	for resourceID, result := range cr.ResultsByResourceId {
+		results.Str(log.RedactID(resourceID), v1.ResourceCheckResult_Membership_name[int32(result.Membership)])
+	}
+	e.Dict("results", results)
+}
+
+type loggableExpandRequest struct{ *v1.DispatchExpandRequest }
+
+func (er loggableExpandRequest) MarshalZerologObject(e *zerolog.Event) {
+	e.Object("metadata", er.Metadata)
+	e.Str("expand", redactedONR(er.ResourceAndRelation))
+	e.Stringer("mode", er.ExpansionMode)
+}
+
+type loggableLookupResourcesRequest struct {
+	*v1.DispatchLookupResourcesRequest
+}
+
+func (lr loggableLookupResourcesRequest) MarshalZerologObject(e *zerolog.Event) {
+	e.Object("metadata", lr.Metadata)
+	e.Str("object", tuple.StringRR(lr.ObjectRelation))
+	e.Str("subject", redactedONR(lr.Subject))
+	if !log.IDsRedacted() || lr.Context == nil {
+		e.Interface("context", lr.Context)
+		return
+	}
+	e.Object("context", redactedContext{lr.Context})
+}
+
+type loggableReachableResourcesRequest struct {
+	*v1.DispatchReachableResourcesRequest
+}
+
+func (lr loggableReachableResourcesRequest) MarshalZerologObject(e *zerolog.Event) {
+	e.Object("metadata", lr.Metadata)
+	e.Str("resource-type", tuple.StringRR(lr.ResourceRelation))
+	e.Str("subject-type", tuple.StringRR(lr.SubjectRelation))
+	e.Array("subject-ids", redactedIDArray(lr.SubjectIds))
+}
+
+type loggableLookupSubjectsRequest struct {
+	*v1.DispatchLookupSubjectsRequest
+}
+
+func (ls loggableLookupSubjectsRequest) MarshalZerologObject(e *zerolog.Event) {
+	e.Object("metadata", ls.Metadata)
+	e.Str("resource-type", tuple.StringRR(ls.ResourceRelation))
+	e.Str("subject-type", tuple.StringRR(ls.SubjectRelation))
+	e.Array("resource-ids", redactedIDArray(ls.ResourceIds))
+}
+
+// redactedIDArray is an array of object IDs, each written as redacted by the logging
+// configuration.
+type redactedIDArray []string
+
+func (ids redactedIDArray) MarshalZerologArray(a *zerolog.Array) {
+	for _, id := range ids {
+		a.Str(log.RedactID(id))
+	}
+}
+
+// redactedContext is a caveat context whose values, which may identify objects or subjects, are
+// each written as redacted by the logging configuration, keyed by parameter name.
+type redactedContext struct{ *structpb.Struct }
+
+func (rc redactedContext) MarshalZerologObject(e *zerolog.Event) {
+	names := make([]string, 0, len(rc.Fields))
+	for name := range rc.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		e.Str(name, log.RedactID(fmt.Sprint(rc.Fields[name].AsInterface())))
+	}
+}
+
+// redactedONR returns the string form of the given ONR, with its object ID redacted by the
+// logging configuration.
+func redactedONR(onr *core.ObjectAndRelation) string {
+	if onr == nil {
+		return ""
+	}
+
+	return tuple.StringONR(&core.ObjectAndRelation{
+		Namespace: onr.Namespace,
+		ObjectId:  log.RedactID(onr.ObjectId),
+		Relation:  onr.Relation,
+	})
+}
@@ -0,0 +1,77 @@
+package dispatch
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	log "github.com/authzed/spicedb/internal/logging"
+	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+func TestLoggableRedactsIDs(t *testing.T) {
+	t.Cleanup(func() { log.SetIDRedaction(log.IDRedactionNone) })
+
+	req := &v1.DispatchCheckRequest{
+		Metadata:         &v1.ResolverMeta{AtRevision: "1", DepthRemaining: 50},
+		ResourceRelation: tuple.RelationReference("document", "view"),
+		ResourceIds:      []string{"firstdoc", "seconddoc"},
+		Subject:          tuple.ParseSubjectONR("user:tom"),
+	}
+
+	logged := func() string {
+		var buf bytes.Buffer
+		logger := zerolog.New(&buf)
+		logger.Info().Object("request", Loggable(req)).Send()
+		return buf.String()
+	}
+
+	require.Contains(t, logged(), "firstdoc")
+	require.Contains(t, logged(), "user:tom")
+
+	log.SetIDRedaction(log.IDRedactionFull)
+	redacted := logged()
+	require.NotContains(t, redacted, "firstdoc")
+	require.NotContains(t, redacted, "seconddoc")
+	require.NotContains(t, redacted, "tom")
+	require.Contains(t, redacted, "document#view")
+}
+
+func TestLoggableRedactsCaveatContext(t *testing.T) {
+	t.Cleanup(func() { log.SetIDRedaction(log.IDRedactionNone) })
+
+	caveatContext, err := structpb.NewStruct(map[string]any{
+		"ip_address": "10.0.0.1",
+		"allowed":    []any{"tom", "fred"},
+	})
+	require.NoError(t, err)
+
+	req := &v1.DispatchLookupResourcesRequest{
+		Metadata:       &v1.ResolverMeta{AtRevision: "1", DepthRemaining: 50},
+		ObjectRelation: tuple.RelationReference("document", "view"),
+		Subject:        tuple.ParseSubjectONR("user:tom"),
+		Context:        caveatContext,
+	}
+
+	logged := func() string {
+		var buf bytes.Buffer
+		logger := zerolog.New(&buf)
+		logger.Info().Object("request", Loggable(req)).Send()
+		return buf.String()
+	}
+
+	require.Contains(t, logged(), "10.0.0.1")
+	require.Contains(t, logged(), "fred")
+
+	log.SetIDRedaction(log.IDRedactionFull)
+	redacted := logged()
+	require.NotContains(t, redacted, "10.0.0.1")
+	require.NotContains(t, redacted, "fred")
+	require.NotContains(t, redacted, "tom")
+	require.Contains(t, redacted, "ip_address")
+	require.Contains(t, redacted, "allowed")
+}
@@ -3,15 +3,24 @@
 package gateway
 
 import (
+	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
+	"time"
 
-	"github.com/authzed/authzed-go/proto"
+	"github.com/authzed/authzed-go/pkg/responsemeta"
+	authzedproto "github.com/authzed/authzed-go/proto"
 	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
 	"github.com/authzed/grpcutil"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/klauspost/compress/gzhttp"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -20,9 +29,16 @@ import (
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/propagation"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/authzed/spicedb/internal/middleware/revisiontimestamp"
+	"github.com/authzed/spicedb/internal/middleware/usagemetrics"
+	v1svc "github.com/authzed/spicedb/internal/services/v1"
+	"github.com/authzed/spicedb/pkg/x509util"
 )
 
 var histogram = promauto.NewHistogramVec(prometheus.HistogramOpts{
@@ -32,58 +48,278 @@ var histogram = promauto.NewHistogramVec(prometheus.HistogramOpts{
 	Help:      "A histogram of the duration spent processing requests to the SpiceDB REST Gateway.",
 }, []string{"method"})
 
-// NewHandler creates an REST gateway HTTP CloserHandler with the provided upstream
-// configuration.
-func NewHandler(ctx context.Context, upstreamAddr, upstreamTLSCertPath string) (*CloserHandler, error) {
-	if upstreamAddr == "" {
+// DefaultMaxRequestBodyBytes is the maximum size of a request body accepted by the gateway when
+// no other maximum is configured. It allows for the JSON encoding of a request being larger than
+// the 4MiB maximum size of a gRPC message received by the upstream.
+const DefaultMaxRequestBodyBytes int64 = 8 << 20
+
+// UpstreamTLSConfig configures the TLS connection of the gateway to its upstream.
+type UpstreamTLSConfig struct {
+	// CAPath is the path to the certificate(s) used to verify the upstream. If empty and no
+	// client certificate is configured, the connection to the upstream is not encrypted.
+	CAPath string
+
+	// ClientCertPath and ClientKeyPath are the paths to the certificate and key presented by the
+	// gateway to the upstream, for upstreams requiring mutual TLS. Both or neither must be given.
+	// When given, the upstream is verified against CAPath, or the system pool if empty.
+	ClientCertPath string
+	ClientKeyPath  string
+}
+
+func (c UpstreamTLSConfig) dialOption() (grpc.DialOption, error) {
+	switch {
+	case c.ClientCertPath == "" && c.ClientKeyPath == "":
+		if c.CAPath == "" {
+			return grpc.WithTransportCredentials(insecure.NewCredentials()), nil
+		}
+		return grpcutil.WithCustomCerts(grpcutil.SkipVerifyCA, c.CAPath)
+
+	case c.ClientCertPath != "" && c.ClientKeyPath != "":
+		clientCert, err := tls.LoadX509KeyPair(c.ClientCertPath, c.ClientKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load upstream TLS client certificate: %w", err)
+		}
+
+		var pool *x509.CertPool
+		if c.CAPath != "" {
+			pool, err = x509util.CustomCertPool(c.CAPath)
+		} else {
+			pool, err = x509.SystemCertPool()
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		return grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{
+			Certificates: []tls.Certificate{clientCert},
+			RootCAs:      pool,
+			MinVersion:   tls.VersionTLS12,
+		})), nil
+
+	default:
+		return nil, fmt.Errorf("both the upstream TLS client certificate and key must be provided")
+	}
+}
+
+// HandlerConfig is the configuration of a REST gateway handler.
+type HandlerConfig struct {
+	// UpstreamAddr is the address of the gRPC server to which requests are forwarded.
+	UpstreamAddr string
+
+	// UpstreamTLS configures the connection to the upstream.
+	UpstreamTLS UpstreamTLSConfig
+
+	// ForwardedHeaders are the HTTP headers whose values are forwarded to the upstream as gRPC
+	// metadata. The Authorization header, which the gateway already forwards, and headers with
+	// the reserved `grpc-` prefix cannot be forwarded.
+	ForwardedHeaders []string
+
+	// MaxRequestBodyBytes is the maximum size of request bodies; larger requests are rejected.
+	// If zero or less, DefaultMaxRequestBodyBytes applies.
+	MaxRequestBodyBytes int64
+
+	// RequestTimeout, if greater than zero, is applied as the deadline of each upstream call.
+	RequestTimeout time.Duration
+}
+
+// NewHandler creates an REST gateway HTTP CloserHandler with the provided configuration. The
+// responses of server-streaming methods are returned as newline-delimited JSON.
+func NewHandler(ctx context.Context, config HandlerConfig) (*CloserHandler, error) {
+	if config.UpstreamAddr == "" {
 		return nil, fmt.Errorf("upstreamAddr must not be empty")
 	}
 
+	if err := validateForwardedHeaders(config.ForwardedHeaders); err != nil {
+		return nil, err
+	}
+
+	marshaler, err := newStreamingMarshaler(v1.PermissionsService_ServiceDesc, v1.WatchService_ServiceDesc)
+	if err != nil {
+		return nil, err
+	}
+
+	credsOpt, err := config.UpstreamTLS.dialOption()
+	if err != nil {
+		return nil, err
+	}
+
 	opts := []grpc.DialOption{
 		grpc.WithUnaryInterceptor(otelgrpc.UnaryClientInterceptor()),
 		grpc.WithStreamInterceptor(otelgrpc.StreamClientInterceptor()),
-	}
-	if upstreamTLSCertPath == "" {
-		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
-	} else {
-		certsOpt, err := grpcutil.WithCustomCerts(grpcutil.SkipVerifyCA, upstreamTLSCertPath)
-		if err != nil {
-			return nil, err
-		}
-		opts = append(opts, certsOpt)
+		credsOpt,
 	}
 
-	healthConn, err := grpc.DialContext(ctx, upstreamAddr, opts...)
+	healthConn, err := grpc.DialContext(ctx, config.UpstreamAddr, opts...)
 	if err != nil {
 		return nil, err
 	}
 
-	gwMux := runtime.NewServeMux(runtime.WithMetadata(OtelAnnotator), runtime.WithHealthzEndpoint(healthpb.NewHealthClient(healthConn)))
-	schemaConn, err := registerHandler(ctx, gwMux, upstreamAddr, opts, v1.RegisterSchemaServiceHandler)
+	gwMux := runtime.NewServeMux(
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, marshaler),
+		runtime.WithMetadata(OtelAnnotator),
+		runtime.WithMetadata(HeaderForwardingAnnotator(config.ForwardedHeaders)),
+		runtime.WithMetadata(SchemaIfNoneMatchAnnotator),
+		runtime.WithForwardResponseOption(forwardSchemaETag),
+		runtime.WithHealthzEndpoint(healthpb.NewHealthClient(healthConn)),
+	)
+	schemaConn, err := registerHandler(ctx, gwMux, config.UpstreamAddr, opts, v1.RegisterSchemaServiceHandler)
 	if err != nil {
 		return nil, err
 	}
 
-	permissionsConn, err := registerHandler(ctx, gwMux, upstreamAddr, opts, v1.RegisterPermissionsServiceHandler)
+	permissionsConn, err := registerHandler(ctx, gwMux, config.UpstreamAddr, opts, v1.RegisterPermissionsServiceHandler)
 	if err != nil {
 		return nil, err
 	}
 
-	watchConn, err := registerHandler(ctx, gwMux, upstreamAddr, opts, v1.RegisterWatchServiceHandler)
+	watchConn, err := registerHandler(ctx, gwMux, config.UpstreamAddr, opts, v1.RegisterWatchServiceHandler)
 	if err != nil {
 		return nil, err
 	}
 
 	mux := http.NewServeMux()
 	mux.Handle("/openapi.json", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		_, _ = io.WriteString(w, proto.OpenAPISchema)
+		_, _ = io.WriteString(w, authzedproto.OpenAPISchema)
 	}))
-	mux.Handle("/", gwMux)
+	mux.Handle("/livez", LivenessHandler())
+	mux.Handle("/readyz", ReadinessHandler(healthpb.NewHealthClient(healthConn)))
 
-	finalHandler := promhttp.InstrumentHandlerDuration(histogram, otelhttp.NewHandler(mux, "gateway"))
+	// The gateway mux also serves /healthz, reflecting the gRPC health of the upstream.
+	mux.Handle("/", discardBodyAfterNotModified(gwMux))
+
+	maxRequestBodyBytes := config.MaxRequestBodyBytes
+	if maxRequestBodyBytes <= 0 {
+		maxRequestBodyBytes = DefaultMaxRequestBodyBytes
+	}
+
+	finalHandler := promhttp.InstrumentHandlerDuration(histogram, otelhttp.NewHandler(MaxRequestBodyHandler(RequestTimeoutHandler(mux, config.RequestTimeout), maxRequestBodyBytes), "gateway"))
 	return newCloserHandler(finalHandler, schemaConn, permissionsConn, watchConn, healthConn), nil
 }
 
+// readinessCheckTimeout is the maximum time spent checking the health of the upstream when
+// probing the readiness of the gateway.
+const readinessCheckTimeout = 2 * time.Second
+
+// probeResponse is the JSON body returned by the liveness and readiness handlers.
+type probeResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+func writeProbeResponse(w http.ResponseWriter, code int, resp probeResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// LivenessHandler returns a handler which always responds with a 200 OK status, indicating that
+// the gateway process is alive, regardless of the state of the upstream.
+func LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		writeProbeResponse(w, http.StatusOK, probeResponse{Status: "ok"})
+	})
+}
+
+// ReadinessHandler returns a handler which performs a gRPC health check of the upstream via the
+// given client, responding with a 200 OK status if the upstream is serving and with a 503 Service
+// Unavailable status and the reason in the JSON body otherwise.
+func ReadinessHandler(healthClient healthpb.HealthClient) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), readinessCheckTimeout)
+		defer cancel()
+
+		resp, err := healthClient.Check(ctx, &healthpb.HealthCheckRequest{})
+		if err != nil {
+			writeProbeResponse(w, http.StatusServiceUnavailable, probeResponse{
+				Status: "unavailable",
+				Error:  err.Error(),
+			})
+			return
+		}
+
+		if resp.Status != healthpb.HealthCheckResponse_SERVING {
+			writeProbeResponse(w, http.StatusServiceUnavailable, probeResponse{
+				Status: "unavailable",
+				Error:  fmt.Sprintf("upstream is %s", resp.Status),
+			})
+			return
+		}
+
+		writeProbeResponse(w, http.StatusOK, probeResponse{Status: "ready"})
+	})
+}
+
+// RequestTimeoutHandler returns a handler which invokes the delegate with the context of the
+// request bounded by the given timeout, if greater than zero, so that upstream calls made by the
+// delegate fail once it is exceeded. The gateway reports such failures with a 504 Gateway Timeout
+// status and the gRPC status as the JSON body.
+func RequestTimeoutHandler(delegate http.Handler, timeout time.Duration) http.Handler {
+	if timeout <= 0 {
+		return delegate
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		delegate.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// DefaultCompressionMinSize is the minimum size of a response body compressed by the handler
+// returned by CompressionHandler when no other minimum is configured.
+const DefaultCompressionMinSize = 1024
+
+// CompressionHandler returns a handler which gzip compresses the responses of the delegate for
+// clients accepting it, if they are at least minSize bytes; if zero or less,
+// DefaultCompressionMinSize applies. Responses which already have a Content-Encoding, or whose
+// content type is already compressed, are left as-is. Streamed responses are compressed as they
+// are flushed.
+func CompressionHandler(delegate http.Handler, minSize int) (http.Handler, error) {
+	if minSize <= 0 {
+		minSize = DefaultCompressionMinSize
+	}
+
+	wrapper, err := gzhttp.NewWrapper(gzhttp.MinSize(minSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create compression handler: %w", err)
+	}
+	return wrapper(delegate), nil
+}
+
+// MaxRequestBodyHandler returns a handler which responds with a 413 Request Entity Too Large
+// status to any request whose body is larger than maxBytes, and otherwise invokes the delegate.
+// The body is read in full before invoking the delegate, so that an oversized body is reported
+// as such rather than as a malformed request.
+func MaxRequestBodyHandler(delegate http.Handler, maxBytes int64) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Body == nil || r.Body == http.NoBody {
+			delegate.ServeHTTP(w, r)
+			return
+		}
+
+		if r.ContentLength > maxBytes {
+			http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBytes))
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+				return
+			}
+
+			http.Error(w, fmt.Sprintf("failed to read request body: %s", err), http.StatusBadRequest)
+			return
+		}
+
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		delegate.ServeHTTP(w, r)
+	})
+}
+
 // CloserHandler is a http.Handler and a io.Closer. Meant to keep track of resources to closer
 // for a handler.
 type CloserHandler struct {
@@ -153,3 +389,140 @@ func OtelAnnotator(ctx context.Context, r *http.Request) metadata.MD {
 	otelgrpc.Inject(ctx, &metadataCopy, defaultOtelOpts...)
 	return metadataCopy
 }
+
+// HeaderForwardingAnnotator returns an annotator which forwards the values of the given HTTP
+// headers, if present on the request, as gRPC metadata keyed by the lowercased header name.
+// Only the headers listed are forwarded, so that sensitive headers are not sent upstream
+// unintentionally.
+func HeaderForwardingAnnotator(headers []string) func(context.Context, *http.Request) metadata.MD {
+	return func(_ context.Context, r *http.Request) metadata.MD {
+		md := metadata.MD{}
+		for _, header := range headers {
+			values := r.Header.Values(header)
+			if len(values) == 0 {
+				continue
+			}
+			md.Append(strings.ToLower(header), values...)
+		}
+		return md
+	}
+}
+
+// validateForwardedHeaders returns an error if any of the given headers cannot be forwarded: the
+// Authorization header is already forwarded by the gateway, so forwarding it again would send it
+// twice, and metadata keys with the `grpc-` prefix are reserved by gRPC.
+func validateForwardedHeaders(headers []string) error {
+	for _, header := range headers {
+		key := strings.ToLower(header)
+		switch {
+		case key == "authorization":
+			return fmt.Errorf("the authorization header is always forwarded and cannot be listed in the forwarded headers")
+		case strings.HasPrefix(key, "grpc-"):
+			return fmt.Errorf("header %q cannot be forwarded: the grpc- prefix is reserved", header)
+		}
+	}
+	return nil
+}
+
+// CorsAllowedHeaders are the request headers read by the gateway itself which cross-origin callers
+// are allowed to send, in addition to the forwarded headers.
+var CorsAllowedHeaders = []string{"Authorization", "Content-Type", "If-None-Match"}
+
+// CorsExposedHeaders returns the headers of gateway responses which cross-origin callers are
+// allowed to read: the ETag of schema reads, and the response metadata of the upstream, which the
+// gateway returns as headers prefixed with Grpc-Metadata- and, for requests accepting trailers, as
+// trailers prefixed with Grpc-Trailer-.
+func CorsExposedHeaders() []string {
+	headers := []string{
+		"ETag",
+		runtime.MetadataHeaderPrefix + string(responsemeta.RequestID),
+		runtime.MetadataHeaderPrefix + string(responsemeta.ServerVersion),
+	}
+
+	for _, trailer := range []responsemeta.ResponseMetadataTrailerKey{
+		responsemeta.DispatchedOperationsCount,
+		responsemeta.CachedOperationsCount,
+		responsemeta.DebugInformation,
+		usagemetrics.DispatchDepthRequired,
+		usagemetrics.DispatchWallTime,
+		revisiontimestamp.RevisionTimestamp,
+		v1svc.CheckMatrix,
+		v1svc.DebugTraceOmittedSubtrees,
+		v1svc.DeleteDryRunCount,
+		v1svc.DeleteDryRunRelationships,
+		v1svc.BulkImportDuplicateCount,
+		v1svc.BulkImportDuplicateSample,
+		v1svc.SchemaFingerprint,
+		v1svc.SchemaCompatibility,
+		v1svc.SchemaNextPageCursor,
+		v1svc.SchemaDiff,
+		v1svc.SchemaOrphanedRelations,
+	} {
+		headers = append(headers, runtime.MetadataTrailerPrefix+string(trailer))
+	}
+	return headers
+}
+
+// SchemaIfNoneMatchAnnotator forwards the If-None-Match HTTP header to the upstream as the
+// ReadSchema ETag request header.
+func SchemaIfNoneMatchAnnotator(_ context.Context, r *http.Request) metadata.MD {
+	ifNoneMatch := r.Header.Values("If-None-Match")
+	if len(ifNoneMatch) == 0 {
+		return nil
+	}
+	return metadata.MD{v1svc.RequestSchemaIfNoneMatch: ifNoneMatch}
+}
+
+// forwardSchemaETag returns the ETag of a schema read as the ETag HTTP header, and responds with
+// a 304 Not Modified status if the schema matched the If-None-Match header. The body which the
+// gateway marshals afterwards is dropped by discardBodyAfterNotModified.
+func forwardSchemaETag(ctx context.Context, w http.ResponseWriter, _ proto.Message) error {
+	md, ok := runtime.ServerMetadataFromContext(ctx)
+	if !ok {
+		return nil
+	}
+
+	if etags := md.TrailerMD.Get(string(v1svc.SchemaETag)); len(etags) > 0 {
+		w.Header().Set("ETag", `"`+etags[0]+`"`)
+	}
+
+	if len(md.TrailerMD.Get(string(v1svc.SchemaNotModified))) > 0 {
+		w.WriteHeader(http.StatusNotModified)
+	}
+	return nil
+}
+
+// discardBodyAfterNotModified returns a handler which drops anything written by the given handler
+// after it has responded with a 304 Not Modified status, which must not have a body.
+func discardBodyAfterNotModified(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(&notModifiedResponseWriter{ResponseWriter: w}, r)
+	})
+}
+
+type notModifiedResponseWriter struct {
+	http.ResponseWriter
+	notModified bool
+}
+
+func (w *notModifiedResponseWriter) WriteHeader(code int) {
+	if w.notModified {
+		return
+	}
+	w.notModified = code == http.StatusNotModified
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *notModifiedResponseWriter) Write(b []byte) (int, error) {
+	if w.notModified {
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher, which the gateway requires to stream responses.
+func (w *notModifiedResponseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok && !w.notModified {
+		flusher.Flush()
+	}
+}
@@ -1,16 +1,41 @@
 package gateway
 
 import (
+	"compress/gzip"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"math/big"
 	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"github.com/stretchr/testify/require"
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/goleak"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	v1svc "github.com/authzed/spicedb/internal/services/v1"
 )
 
 func TestOtelForwarding(t *testing.T) {
@@ -44,10 +69,341 @@ func TestOtelForwarding(t *testing.T) {
 	require.Equal(t, traceID, spanCtx.TraceID())
 }
 
+func TestHeaderForwarding(t *testing.T) {
+	r, err := http.NewRequest(http.MethodPost, "/v1/schema/read", nil)
+	require.Nil(t, err)
+	r.Header.Set("X-Tenant-ID", "sometenant")
+	r.Header.Add("Baggage", "first=1")
+	r.Header.Add("Baggage", "second=2")
+	r.Header.Set("Cookie", "somesecret")
+
+	md := HeaderForwardingAnnotator([]string{"X-Tenant-ID", "baggage", "X-Missing"})(context.Background(), r)
+	require.Equal(t, metadata.MD{
+		"x-tenant-id": []string{"sometenant"},
+		"baggage":     []string{"first=1", "second=2"},
+	}, md)
+
+	require.NoError(t, validateForwardedHeaders([]string{"X-Tenant-ID", "baggage"}))
+	require.ErrorContains(t, validateForwardedHeaders([]string{"X-Tenant-ID", "Authorization"}), "authorization")
+	require.ErrorContains(t, validateForwardedHeaders([]string{"Grpc-Timeout"}), "reserved")
+}
+
+func TestForwardSchemaETag(t *testing.T) {
+	r, err := http.NewRequest(http.MethodPost, "/v1/schema/read", nil)
+	require.Nil(t, err)
+	r.Header.Set("If-None-Match", `"someetag"`)
+	require.Equal(t, metadata.MD{v1svc.RequestSchemaIfNoneMatch: []string{`"someetag"`}}, SchemaIfNoneMatchAnnotator(context.Background(), r))
+
+	ctx := runtime.NewServerMetadataContext(context.Background(), runtime.ServerMetadata{
+		TrailerMD: metadata.Pairs(string(v1svc.SchemaETag), "someetag"),
+	})
+	recorder := httptest.NewRecorder()
+	require.NoError(t, forwardSchemaETag(ctx, recorder, nil))
+	require.Equal(t, `"someetag"`, recorder.Header().Get("ETag"))
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	ctx = runtime.NewServerMetadataContext(context.Background(), runtime.ServerMetadata{
+		TrailerMD: metadata.Pairs(string(v1svc.SchemaETag), "someetag", string(v1svc.SchemaNotModified), "true"),
+	})
+	recorder = httptest.NewRecorder()
+	require.NoError(t, forwardSchemaETag(ctx, recorder, nil))
+	require.Equal(t, http.StatusNotModified, recorder.Code)
+
+	// Nothing is written after the 304, whatever the gateway then marshals.
+	handler := discardBodyAfterNotModified(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		require.NoError(t, forwardSchemaETag(ctx, w, nil))
+		w.WriteHeader(http.StatusOK)
+		_, err := w.Write([]byte(`{"schemaText":""}`))
+		require.NoError(t, err)
+	}))
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, r)
+	require.Equal(t, http.StatusNotModified, recorder.Code)
+	require.Empty(t, recorder.Body.String())
+}
+
+func TestRequestTimeoutHandler(t *testing.T) {
+	gwMux := runtime.NewServeMux()
+	err := gwMux.HandlePath(http.MethodPost, "/v1/permissions/check", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		// Stand in for an upstream call which does not complete before the deadline.
+		<-r.Context().Done()
+
+		_, outbound := runtime.MarshalerForRequest(gwMux, r)
+		runtime.HTTPError(r.Context(), gwMux, outbound, w, r, status.FromContextError(r.Context().Err()).Err())
+	})
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	RequestTimeoutHandler(gwMux, 10*time.Millisecond).ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/v1/permissions/check", nil))
+	require.Equal(t, http.StatusGatewayTimeout, recorder.Code)
+
+	var body struct {
+		Code int `json:"code"`
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+	require.Equal(t, int(codes.DeadlineExceeded), body.Code)
+
+	// Without a timeout, the delegate is used as-is.
+	require.Equal(t, http.Handler(gwMux), RequestTimeoutHandler(gwMux, 0))
+}
+
+func TestCompressionHandler(t *testing.T) {
+	largeBody := `{"relationships": [` + strings.Repeat(`{"resource": "document:somedoc"},`, 100) + `{}]}`
+	handler, err := CompressionHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/precompressed" {
+			w.Header().Set("Content-Encoding", "br")
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/small" {
+			_, _ = io.WriteString(w, `{}`)
+			return
+		}
+		_, _ = io.WriteString(w, largeBody)
+	}), 0)
+	require.NoError(t, err)
+
+	tcs := []struct {
+		name             string
+		path             string
+		acceptEncoding   string
+		expectedEncoding string
+	}{
+		{"large body", "/large", "gzip", "gzip"},
+		{"large body without gzip accepted", "/large", "", ""},
+		{"small body", "/small", "gzip", ""},
+		{"already compressed", "/precompressed", "gzip", "br"},
+	}
+
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, tc.path, nil)
+			if tc.acceptEncoding != "" {
+				r.Header.Set("Accept-Encoding", tc.acceptEncoding)
+			}
+
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, r)
+			require.Equal(t, http.StatusOK, recorder.Code)
+			require.Equal(t, tc.expectedEncoding, recorder.Header().Get("Content-Encoding"))
+
+			if tc.expectedEncoding == "gzip" {
+				reader, err := gzip.NewReader(recorder.Body)
+				require.NoError(t, err)
+
+				body, err := io.ReadAll(reader)
+				require.NoError(t, err)
+				require.Equal(t, largeBody, string(body))
+			}
+		})
+	}
+}
+
+func TestMaxRequestBodyHandler(t *testing.T) {
+	handler := MaxRequestBodyHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		_, _ = w.Write(body)
+	}), 10)
+
+	tcs := []struct {
+		name          string
+		body          string
+		unknownLength bool
+		expectedCode  int
+	}{
+		{"within limit", "0123456789", false, http.StatusOK},
+		{"declared length over limit", "0123456789a", false, http.StatusRequestEntityTooLarge},
+		{"unknown length within limit", "0123456789", true, http.StatusOK},
+		{"unknown length over limit", "0123456789a", true, http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/v1/relationships/write", strings.NewReader(tc.body))
+			if tc.unknownLength {
+				r.ContentLength = -1
+			}
+
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, r)
+			require.Equal(t, tc.expectedCode, recorder.Code)
+			if tc.expectedCode == http.StatusOK {
+				require.Equal(t, tc.body, recorder.Body.String())
+			}
+		})
+	}
+}
+
+func TestStreamingResponsesAsNDJSON(t *testing.T) {
+	marshaler, err := newStreamingMarshaler(v1.PermissionsService_ServiceDesc)
+	require.NoError(t, err)
+
+	gwMux := runtime.NewServeMux(runtime.WithMarshalerOption(runtime.MIMEWildcard, marshaler))
+	err = gwMux.HandlePath(http.MethodPost, "/v1/permissions/resources", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		responses := []proto.Message{
+			&v1.LookupResourcesResponse{ResourceObjectId: "first"},
+			&v1.LookupResourcesResponse{ResourceObjectId: "second"},
+		}
+
+		_, outbound := runtime.MarshalerForRequest(gwMux, r)
+		runtime.ForwardResponseStream(r.Context(), gwMux, outbound, w, r, func() (proto.Message, error) {
+			if len(responses) == 0 {
+				return nil, io.EOF
+			}
+
+			resp := responses[0]
+			responses = responses[1:]
+			return resp, nil
+		})
+	})
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	gwMux.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/v1/permissions/resources", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, NDJSONContentType, recorder.Header().Get("Content-Type"))
+
+	lines := strings.Split(strings.TrimSpace(recorder.Body.String()), "\n")
+	require.Len(t, lines, 2)
+	for i, expectedID := range []string{"first", "second"} {
+		var line struct {
+			Result struct {
+				ResourceObjectID string `json:"resourceObjectId"`
+			} `json:"result"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(lines[i]), &line))
+		require.Equal(t, expectedID, line.Result.ResourceObjectID)
+	}
+
+	// Unary responses keep the JSON content type.
+	require.Equal(t, "application/json", marshaler.ContentType(&v1.CheckPermissionResponse{}))
+}
+
+func writeSelfSignedKeyPair(t *testing.T) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "gateway"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	certBytes, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyBytes, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certPath := filepath.Join(t.TempDir(), "client.crt")
+	require.NoError(t, os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certBytes}), 0o600))
+
+	keyPath := filepath.Join(t.TempDir(), "client.key")
+	require.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyBytes}), 0o600))
+
+	return certPath, keyPath
+}
+
+func TestUpstreamTLSConfig(t *testing.T) {
+	certPath, keyPath := writeSelfSignedKeyPair(t)
+
+	tcs := []struct {
+		name          string
+		config        UpstreamTLSConfig
+		expectedError string
+	}{
+		{"plaintext", UpstreamTLSConfig{}, ""},
+		{"custom CA", UpstreamTLSConfig{CAPath: certPath}, ""},
+		{"client certificate", UpstreamTLSConfig{ClientCertPath: certPath, ClientKeyPath: keyPath}, ""},
+		{"client certificate with custom CA", UpstreamTLSConfig{CAPath: certPath, ClientCertPath: certPath, ClientKeyPath: keyPath}, ""},
+		{"client certificate without key", UpstreamTLSConfig{ClientCertPath: certPath}, "both the upstream TLS client certificate and key must be provided"},
+		{"missing client certificate", UpstreamTLSConfig{ClientCertPath: certPath + ".missing", ClientKeyPath: keyPath}, "failed to load upstream TLS client certificate"},
+		{"mismatched client certificate", UpstreamTLSConfig{ClientCertPath: keyPath, ClientKeyPath: keyPath}, "failed to load upstream TLS client certificate"},
+	}
+
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			opt, err := tc.config.dialOption()
+			if tc.expectedError != "" {
+				require.ErrorContains(t, err, tc.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			require.NotNil(t, opt)
+		})
+	}
+}
+
+type fakeHealthClient struct {
+	healthpb.HealthClient
+
+	status healthpb.HealthCheckResponse_ServingStatus
+	err    error
+}
+
+func (fhc fakeHealthClient) Check(context.Context, *healthpb.HealthCheckRequest, ...grpc.CallOption) (*healthpb.HealthCheckResponse, error) {
+	if fhc.err != nil {
+		return nil, fhc.err
+	}
+	return &healthpb.HealthCheckResponse{Status: fhc.status}, nil
+}
+
+func TestLivenessHandler(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	LivenessHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/livez", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.JSONEq(t, `{"status": "ok"}`, recorder.Body.String())
+}
+
+func TestReadinessHandler(t *testing.T) {
+	tcs := []struct {
+		name         string
+		client       fakeHealthClient
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			"serving",
+			fakeHealthClient{status: healthpb.HealthCheckResponse_SERVING},
+			http.StatusOK,
+			`{"status": "ready"}`,
+		},
+		{
+			"not serving",
+			fakeHealthClient{status: healthpb.HealthCheckResponse_NOT_SERVING},
+			http.StatusServiceUnavailable,
+			`{"status": "unavailable", "error": "upstream is NOT_SERVING"}`,
+		},
+		{
+			"unreachable",
+			fakeHealthClient{err: status.Error(codes.Unavailable, "connection refused")},
+			http.StatusServiceUnavailable,
+			`{"status": "unavailable", "error": "rpc error: code = Unavailable desc = connection refused"}`,
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			ReadinessHandler(tc.client).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			require.Equal(t, tc.expectedCode, recorder.Code)
+			require.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+			require.JSONEq(t, tc.expectedBody, recorder.Body.String())
+		})
+	}
+}
+
 func TestCloseConnections(t *testing.T) {
 	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
 
-	gatewayHandler, err := NewHandler(context.Background(), "192.0.2.0:4321", "")
+	gatewayHandler, err := NewHandler(context.Background(), HandlerConfig{UpstreamAddr: "192.0.2.0:4321"})
 	require.NoError(t, err)
 	// 3 conns for permission+schema+watch services, 1 for health check
 	require.Len(t, gatewayHandler.closers, 4)
@@ -0,0 +1,73 @@
+package gateway
+
+import (
+	"fmt"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// NDJSONContentType is the content type of the responses of server-streaming methods, in which
+// each message received from the upstream is written as a JSON object on its own line.
+const NDJSONContentType = "application/x-ndjson"
+
+// streamingMarshaler is a runtime.Marshaler which reports the responses of server-streaming
+// methods as newline-delimited JSON, so that clients can read them incrementally. The runtime
+// already writes each streamed message followed by a newline, so only the content type differs.
+type streamingMarshaler struct {
+	runtime.Marshaler
+	streamedTypes map[protoreflect.FullName]struct{}
+}
+
+// newStreamingMarshaler returns a marshaler encoding messages as the default gateway marshaler
+// does, which reports the responses of the server-streaming methods of the given services as
+// NDJSONContentType.
+func newStreamingMarshaler(services ...grpc.ServiceDesc) (runtime.Marshaler, error) {
+	streamedTypes := make(map[protoreflect.FullName]struct{})
+	for _, service := range services {
+		desc, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(service.ServiceName))
+		if err != nil {
+			return nil, fmt.Errorf("unable to find descriptor of service %s: %w", service.ServiceName, err)
+		}
+
+		serviceDesc, ok := desc.(protoreflect.ServiceDescriptor)
+		if !ok {
+			return nil, fmt.Errorf("descriptor of %s is not a service", service.ServiceName)
+		}
+
+		methods := serviceDesc.Methods()
+		for i := 0; i < methods.Len(); i++ {
+			if methods.Get(i).IsStreamingServer() {
+				streamedTypes[methods.Get(i).Output().FullName()] = struct{}{}
+			}
+		}
+	}
+
+	return &streamingMarshaler{
+		// Matches the default marshaler of the runtime.
+		Marshaler: &runtime.HTTPBodyMarshaler{
+			Marshaler: &runtime.JSONPb{
+				MarshalOptions: protojson.MarshalOptions{
+					EmitUnpopulated: true,
+				},
+				UnmarshalOptions: protojson.UnmarshalOptions{
+					DiscardUnknown: true,
+				},
+			},
+		},
+		streamedTypes: streamedTypes,
+	}, nil
+}
+
+func (sm *streamingMarshaler) ContentType(v interface{}) string {
+	if msg, ok := v.(proto.Message); ok {
+		if _, ok := sm.streamedTypes[msg.ProtoReflect().Descriptor().FullName()]; ok {
+			return NDJSONContentType
+		}
+	}
+	return sm.Marshaler.ContentType(v)
+}
@@ -0,0 +1,52 @@
+package graph
+
+import (
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// queuedTaskCount holds the number of tasks scheduled on task runners that have yet to
+// be picked up by a goroutine, across all requests being handled by this process.
+var queuedTaskCount atomic.Int64
+
+var queuedTaskCountGauge = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+	Namespace: "spicedb",
+	Subsystem: "dispatch",
+	Name:      "queued_tasks",
+	Help:      "the number of dispatch tasks waiting for a goroutine under the dispatch concurrency limits",
+}, func() float64 {
+	return float64(queuedTaskCount.Load())
+})
+
+// reverseTraversalTaskCount holds the number of reachable resources tasks currently running,
+// across all requests being handled by this process.
+var reverseTraversalTaskCount atomic.Int64
+
+var reverseTraversalTaskCountGauge = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+	Namespace: "spicedb",
+	Subsystem: "dispatch",
+	Name:      "reverse_traversal_tasks_in_flight",
+	Help:      "the number of reverse traversal tasks, for LookupResources and ReachableResources, currently running under the reachable resources concurrency limit",
+}, func() float64 {
+	return float64(reverseTraversalTaskCount.Load())
+})
+
+func init() {
+	prometheus.MustRegister(queuedTaskCountGauge)
+	prometheus.MustRegister(reverseTraversalTaskCountGauge)
+}
+
+// QueuedTaskCount returns the number of dispatch tasks currently waiting to run because
+// their task runners are at their concurrency limits. A large and growing value indicates
+// that the dispatcher is overloaded.
+func QueuedTaskCount() int64 {
+	return queuedTaskCount.Load()
+}
+
+// ReverseTraversalTaskCount returns the number of reverse traversal tasks, which walk from the
+// subject of a LookupResources or ReachableResources request towards its resources, currently
+// running. Each request runs at most the reachable resources concurrency limit of them at once.
+func ReverseTraversalTaskCount() int64 {
+	return reverseTraversalTaskCount.Load()
+}
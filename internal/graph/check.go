@@ -41,16 +41,19 @@ func init() {
 	prometheus.MustRegister(dispatchChunkCountHistogram)
 }
 
-// NewConcurrentChecker creates an instance of ConcurrentChecker.
-func NewConcurrentChecker(d dispatch.Check, concurrencyLimit uint16) *ConcurrentChecker {
-	return &ConcurrentChecker{d, concurrencyLimit}
+// NewConcurrentChecker creates an instance of ConcurrentChecker. A maxDirectRelationships of zero
+// allows any number of relationships to be read per resource for each relation checked.
+func NewConcurrentChecker(d dispatch.Check, concurrencyLimit uint16, maxDirectRelationships uint32, emptyRewritePolicy EmptyRewritePolicy) *ConcurrentChecker {
+	return &ConcurrentChecker{d, concurrencyLimit, maxDirectRelationships, emptyRewritePolicy}
 }
 
 // ConcurrentChecker exposes a method to perform Check requests, and delegates subproblems to the
 // provided dispatch.Check instance.
 type ConcurrentChecker struct {
-	d                dispatch.Check
-	concurrencyLimit uint16
+	d                      dispatch.Check
+	concurrencyLimit       uint16
+	maxDirectRelationships uint32
+	emptyRewritePolicy     EmptyRewritePolicy
 }
 
 // ValidatedCheckRequest represents a request after it has been validated and parsed for internal
@@ -211,7 +214,7 @@ type directDispatch struct {
 }
 
 func (cc *ConcurrentChecker) checkDirect(ctx context.Context, crc currentRequestContext, relation *core.Relation) CheckResult {
-	log.Ctx(ctx).Trace().Object("direct", crc.parentReq).Send()
+	log.Ctx(ctx).Trace().Object("direct", dispatch.Loggable(crc.parentReq.DispatchCheckRequest)).Send()
 	ds := datastoremw.MustFromContext(ctx).SnapshotReader(crc.parentReq.Revision)
 
 	// Build a filter for finding the direct relationships for the check. There are three
@@ -357,12 +360,17 @@ func (cc *ConcurrentChecker) checkDirect(ctx context.Context, crc currentRequest
 	// Find the subjects over which to dispatch.
 	subjectsToDispatch := tuple.NewONRByTypeSet()
 	relationshipsBySubjectONR := mapz.NewMultiMap[string, *core.RelationTuple]()
+	fanOut := newFanOutTracker(crc.parentReq.ResourceRelation.Namespace, crc.parentReq.ResourceRelation.Relation, cc.maxDirectRelationships)
 
 	for tpl := it.Next(); tpl != nil; tpl = it.Next() {
 		if it.Err() != nil {
 			return checkResultError(NewCheckFailureErr(it.Err()), emptyMetadata)
 		}
 
+		if err := fanOut.add(tpl.ResourceAndRelation.ObjectId); err != nil {
+			return checkResultError(err, emptyMetadata)
+		}
+
 		// Add the subject as an object over which to dispatch.
 		if tpl.Subject.Relation == Ellipsis {
 			return checkResultError(NewCheckFailureErr(fmt.Errorf("got a terminal for a non-terminal query")), emptyMetadata)
@@ -389,7 +397,7 @@ func (cc *ConcurrentChecker) checkDirect(ctx context.Context, crc currentRequest
 
 	// Dispatch and map to the associated resource ID(s).
 	result := union(ctx, crc, toDispatch, func(ctx context.Context, crc currentRequestContext, dd directDispatch) CheckResult {
-		childResult := cc.dispatch(ctx, crc, ValidatedCheckRequest{
+		childResult := cc.dispatch(withoutComputedUsersetPath(ctx), crc, ValidatedCheckRequest{
 			&v1.DispatchCheckRequest{
 				ResourceRelation: dd.resourceType,
 				ResourceIds:      dd.resourceIds,
@@ -435,6 +443,12 @@ func mapFoundResources(result CheckResult, resourceType *core.RelationReference,
 }
 
 func (cc *ConcurrentChecker) checkUsersetRewrite(ctx context.Context, crc currentRequestContext, rewrite *core.UsersetRewrite) CheckResult {
+	if cc.emptyRewritePolicy == EmptyRewriteError {
+		if so := setOperationForRewrite(rewrite); so != nil && len(so.Child) == 0 {
+			return checkResultError(NewEmptyPermissionRewriteErr(crc.parentReq.ResourceRelation.Namespace, crc.parentReq.ResourceRelation.Relation), emptyMetadata)
+		}
+	}
+
 	switch rw := rewrite.RewriteOperation.(type) {
 	case *core.UsersetRewrite_Union:
 		return union(ctx, crc, rw.Union.Child, cc.runSetOperation, cc.concurrencyLimit)
@@ -448,7 +462,7 @@ func (cc *ConcurrentChecker) checkUsersetRewrite(ctx context.Context, crc curren
 }
 
 func (cc *ConcurrentChecker) dispatch(ctx context.Context, _ currentRequestContext, req ValidatedCheckRequest) CheckResult {
-	log.Ctx(ctx).Trace().Object("dispatch", req).Send()
+	log.Ctx(ctx).Trace().Object("dispatch", dispatch.Loggable(req.DispatchCheckRequest)).Send()
 	result, err := cc.d.DispatchCheck(ctx, req.DispatchCheckRequest)
 	return CheckResult{result, err}
 }
@@ -515,6 +529,18 @@ func (cc *ConcurrentChecker) checkComputedUserset(ctx context.Context, crc curre
 		}
 	}
 
+	// Track the relations reached through computed usersets on the same resources, to report a
+	// cycle in the schema rather than recursing until the depth limit is reached.
+	if cu.Object == core.ComputedUserset_TUPLE_USERSET_OBJECT {
+		ctx = withoutComputedUsersetPath(ctx)
+	} else {
+		var err error
+		ctx, err = withComputedUsersetStep(ctx, crc.parentReq.ResourceRelation, cu.Relation)
+		if err != nil {
+			return checkResultError(err, emptyMetadata)
+		}
+	}
+
 	result := cc.dispatch(ctx, crc, ValidatedCheckRequest{
 		&v1.DispatchCheckRequest{
 			ResourceRelation: targetRR,
@@ -552,7 +578,7 @@ func removeIndexFromSlice[T any](s []T, index int) []T {
 }
 
 func (cc *ConcurrentChecker) checkTupleToUserset(ctx context.Context, crc currentRequestContext, ttu *core.TupleToUserset) CheckResult {
-	log.Ctx(ctx).Trace().Object("ttu", crc.parentReq).Send()
+	log.Ctx(ctx).Trace().Object("ttu", dispatch.Loggable(crc.parentReq.DispatchCheckRequest)).Send()
 	ds := datastoremw.MustFromContext(ctx).SnapshotReader(crc.parentReq.Revision)
 	it, err := ds.QueryRelationships(ctx, datastore.RelationshipsFilter{
 		ResourceType:             crc.parentReq.ResourceRelation.Namespace,
@@ -566,11 +592,16 @@ func (cc *ConcurrentChecker) checkTupleToUserset(ctx context.Context, crc curren
 
 	subjectsToDispatch := tuple.NewONRByTypeSet()
 	relationshipsBySubjectONR := mapz.NewMultiMap[string, *core.RelationTuple]()
+	fanOut := newFanOutTracker(crc.parentReq.ResourceRelation.Namespace, ttu.Tupleset.Relation, cc.maxDirectRelationships)
 	for tpl := it.Next(); tpl != nil; tpl = it.Next() {
 		if it.Err() != nil {
 			return checkResultError(NewCheckFailureErr(it.Err()), emptyMetadata)
 		}
 
+		if err := fanOut.add(tpl.ResourceAndRelation.ObjectId); err != nil {
+			return checkResultError(err, emptyMetadata)
+		}
+
 		subjectsToDispatch.Add(tpl.Subject)
 		relationshipsBySubjectONR.Add(tuple.StringONR(tpl.Subject), tpl)
 	}
@@ -644,7 +675,7 @@ func union[T any](
 	for i := 0; i < len(children); i++ {
 		select {
 		case result := <-resultChan:
-			log.Ctx(ctx).Trace().Object("anyResult", result.Resp).Send()
+			log.Ctx(ctx).Trace().Object("anyResult", dispatch.Loggable(result.Resp)).Send()
 			responseMetadata = combineResponseMetadata(responseMetadata, result.Resp.Metadata)
 			if result.Err != nil {
 				return checkResultError(result.Err, responseMetadata)
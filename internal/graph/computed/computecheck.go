@@ -73,6 +73,43 @@ func ComputeBulkCheck(
 	return computeCheck(ctx, d, params, resourceIDs)
 }
 
+// ComputeCheckMatrix computes check results for each of the given permissions over each of the
+// given resources, all for the same subject and at the revision found in the parameters. The
+// relation of the parameters' resource type is ignored in favor of each of the permissions. The
+// resources of each permission are dispatched together as a single batch, so sub-problems shared
+// by those resources are computed once; nothing is shared across permissions beyond what the
+// dispatcher itself caches. Results and response metadata are keyed by permission and then by
+// resource ID.
+func ComputeCheckMatrix(
+	ctx context.Context,
+	d dispatch.Check,
+	params CheckParameters,
+	permissions []string,
+	resourceIDs []string,
+) (map[string]map[string]*v1.ResourceCheckResult, map[string]map[string]*v1.ResponseMeta, error) {
+	results := make(map[string]map[string]*v1.ResourceCheckResult, len(permissions))
+	resultMetadatas := make(map[string]map[string]*v1.ResponseMeta, len(permissions))
+	for _, permission := range permissions {
+		if _, ok := results[permission]; ok {
+			continue
+		}
+
+		permissionParams := params
+		permissionParams.ResourceType = &core.RelationReference{
+			Namespace: params.ResourceType.Namespace,
+			Relation:  permission,
+		}
+
+		permissionResults, permissionMetas, err := computeCheck(ctx, d, permissionParams, resourceIDs)
+		resultMetadatas[permission] = permissionMetas
+		if err != nil {
+			return nil, resultMetadatas, err
+		}
+		results[permission] = permissionResults
+	}
+	return results, resultMetadatas, nil
+}
+
 func computeCheck(ctx context.Context,
 	d dispatch.Check,
 	params CheckParameters,
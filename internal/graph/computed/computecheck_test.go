@@ -933,6 +933,77 @@ func TestComputeBulkCheck(t *testing.T) {
 	require.Equal(t, resp["third"].Membership, v1.ResourceCheckResult_NOT_MEMBER)
 }
 
+func TestComputeCheckMatrix(t *testing.T) {
+	ds, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(t, err)
+
+	dispatch := graph.NewLocalOnlyDispatcher(10)
+	ctx := log.Logger.WithContext(datastoremw.ContextWithHandle(context.Background()))
+	require.NoError(t, datastoremw.SetInContext(ctx, ds))
+
+	revision, err := writeCaveatedTuples(ctx, t, ds, `
+	definition user {}
+
+	caveat somecaveat(somecondition int) {
+		somecondition == 42
+	}
+
+	definition document {
+		relation viewer: user | user with somecaveat
+		relation editor: user
+		permission edit = editor
+		permission view = viewer + edit
+	}
+	`, []caveatedUpdate{
+		{core.RelationTupleUpdate_CREATE, "document:first#editor@user:tom", "", nil},
+		{core.RelationTupleUpdate_CREATE, "document:second#viewer@user:tom", "", nil},
+		{core.RelationTupleUpdate_CREATE, "document:third#viewer@user:tom", "somecaveat", map[string]any{}},
+	})
+	require.NoError(t, err)
+
+	resp, metas, err := computed.ComputeCheckMatrix(ctx, dispatch,
+		computed.CheckParameters{
+			ResourceType: &core.RelationReference{
+				Namespace: "document",
+			},
+			Subject: &core.ObjectAndRelation{
+				Namespace: "user",
+				ObjectId:  "tom",
+				Relation:  "...",
+			},
+			CaveatContext: nil,
+			AtRevision:    revision,
+			MaximumDepth:  50,
+			DebugOption:   computed.NoDebugging,
+		},
+		[]string{"view", "edit"},
+		[]string{"first", "second", "third", "fourth"},
+	)
+	require.NoError(t, err)
+	require.Len(t, metas, 2)
+
+	expected := map[string]map[string]v1.ResourceCheckResult_Membership{
+		"view": {
+			"first":  v1.ResourceCheckResult_MEMBER,
+			"second": v1.ResourceCheckResult_MEMBER,
+			"third":  v1.ResourceCheckResult_CAVEATED_MEMBER,
+			"fourth": v1.ResourceCheckResult_NOT_MEMBER,
+		},
+		"edit": {
+			"first":  v1.ResourceCheckResult_MEMBER,
+			"second": v1.ResourceCheckResult_NOT_MEMBER,
+			"third":  v1.ResourceCheckResult_NOT_MEMBER,
+			"fourth": v1.ResourceCheckResult_NOT_MEMBER,
+		},
+	}
+
+	for permission, expectedResults := range expected {
+		for resourceID, membership := range expectedResults {
+			require.Equal(t, membership, resp[permission][resourceID].Membership, "%s#%s", resourceID, permission)
+		}
+	}
+}
+
 func writeCaveatedTuples(ctx context.Context, _ *testing.T, ds datastore.Datastore, schema string, updates []caveatedUpdate) (datastore.Revision, error) {
 	empty := ""
 	compiled, err := compiler.Compile(compiler.InputSchema{
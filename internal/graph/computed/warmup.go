@@ -0,0 +1,95 @@
+package computed
+
+import (
+	"context"
+	"sync/atomic"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/authzed/spicedb/internal/dispatch"
+	log "github.com/authzed/spicedb/internal/logging"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+// WarmupParameters are the parameters for the WarmCheckCache call.
+type WarmupParameters struct {
+	AtRevision       datastore.Revision
+	MaximumDepth     uint32
+	ConcurrencyLimit uint16
+}
+
+// WarmupResult summarizes a call to WarmCheckCache.
+type WarmupResult struct {
+	// Completed is the number of checks that were computed.
+	Completed uint64
+
+	// Failed is the number of checks whose computation returned an error.
+	Failed uint64
+
+	// Skipped is the number of checks that were not started before the context was done.
+	Skipped uint64
+}
+
+// WarmCheckCache computes the given checks and discards their results, so that when the
+// dispatcher caches, the first real requests for the same checks at the same revision are cache
+// hits. Each check is given as a relationship from the resource and permission to the subject. At
+// most ConcurrencyLimit checks are computed at once, and any check not yet started when the
+// context is done is skipped, allowing the caller to bound the warm-up with a deadline.
+func WarmCheckCache(
+	ctx context.Context,
+	d dispatch.Check,
+	params WarmupParameters,
+	checks []*core.RelationTuple,
+) (WarmupResult, error) {
+	var completed, failed atomic.Uint64
+
+	g := &errgroup.Group{}
+	if params.ConcurrencyLimit > 0 {
+		g.SetLimit(int(params.ConcurrencyLimit))
+	}
+
+	for _, check := range checks {
+		if ctx.Err() != nil {
+			break
+		}
+
+		check := check
+		g.Go(func() error {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			_, _, err := ComputeCheck(ctx, d, CheckParameters{
+				ResourceType: &core.RelationReference{
+					Namespace: check.ResourceAndRelation.Namespace,
+					Relation:  check.ResourceAndRelation.Relation,
+				},
+				Subject:      check.Subject,
+				AtRevision:   params.AtRevision,
+				MaximumDepth: params.MaximumDepth,
+				DebugOption:  NoDebugging,
+			}, check.ResourceAndRelation.ObjectId)
+			if err != nil {
+				redacted := check.CloneVT()
+				redacted.ResourceAndRelation.ObjectId = log.RedactID(redacted.ResourceAndRelation.ObjectId)
+				redacted.Subject.ObjectId = log.RedactID(redacted.Subject.ObjectId)
+				log.Ctx(ctx).Debug().Err(err).Str("check", tuple.MustString(redacted)).Msg("failed to warm check")
+				failed.Add(1)
+				return nil
+			}
+
+			completed.Add(1)
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	result := WarmupResult{
+		Completed: completed.Load(),
+		Failed:    failed.Load(),
+	}
+	result.Skipped = uint64(len(checks)) - result.Completed - result.Failed
+	return result, ctx.Err()
+}
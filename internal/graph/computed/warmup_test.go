@@ -0,0 +1,61 @@
+package computed_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/internal/datastore/memdb"
+	"github.com/authzed/spicedb/internal/dispatch/graph"
+	"github.com/authzed/spicedb/internal/graph/computed"
+	log "github.com/authzed/spicedb/internal/logging"
+	datastoremw "github.com/authzed/spicedb/internal/middleware/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+func TestWarmCheckCache(t *testing.T) {
+	ds, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(t, err)
+
+	dispatch := graph.NewLocalOnlyDispatcher(10)
+	ctx := log.Logger.WithContext(datastoremw.ContextWithHandle(context.Background()))
+	require.NoError(t, datastoremw.SetInContext(ctx, ds))
+
+	revision, err := writeCaveatedTuples(ctx, t, ds, `
+	definition user {}
+
+	definition document {
+		relation viewer: user
+		permission view = viewer
+	}
+	`, []caveatedUpdate{
+		{core.RelationTupleUpdate_CREATE, "document:first#viewer@user:tom", "", nil},
+	})
+	require.NoError(t, err)
+
+	checks := []*core.RelationTuple{
+		tuple.MustParse("document:first#view@user:tom"),
+		tuple.MustParse("document:second#view@user:tom"),
+		tuple.MustParse("document:first#view@user:fred"),
+		tuple.MustParse("unknown:first#view@user:tom"),
+	}
+
+	params := computed.WarmupParameters{
+		AtRevision:       revision,
+		MaximumDepth:     50,
+		ConcurrencyLimit: 2,
+	}
+
+	result, err := computed.WarmCheckCache(ctx, dispatch, params, checks)
+	require.NoError(t, err)
+	require.Equal(t, computed.WarmupResult{Completed: 3, Failed: 1}, result)
+
+	canceledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+
+	result, err = computed.WarmCheckCache(canceledCtx, dispatch, params, checks)
+	require.ErrorIs(t, err, context.Canceled)
+	require.Equal(t, computed.WarmupResult{Skipped: 4}, result)
+}
@@ -370,7 +370,9 @@ func withInternalParallelizedStreamingIterableInCursor[T any, Q any](
 			// subsequent invocations should jump right to this item.
 			ictx, istream, icursor := stream.forTaskIndex(ctx, taskIndex, ici)
 
+			reverseTraversalTaskCount.Add(1)
 			err = handler(ictx, icursor, item, istream)
+			reverseTraversalTaskCount.Add(-1)
 			if err != nil {
 				// If the branch was canceled explicitly by *this* streaming iterable because other branches have fulfilled
 				// the configured limit, then we can safely ignore this error.
@@ -190,6 +190,8 @@ func TestWithParallelizedStreamingIterableInCursor(t *testing.T) {
 		parentStream,
 		2,
 		func(ctx context.Context, cc cursorInformation, item int, stream dispatch.Stream[int]) error {
+			require.GreaterOrEqual(t, ReverseTraversalTaskCount(), int64(1))
+
 			err := stream.Publish(item * 10)
 			if err != nil {
 				return err
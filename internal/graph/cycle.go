@@ -0,0 +1,54 @@
+package graph
+
+import (
+	"context"
+
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+)
+
+type computedUsersetPathKey struct{}
+
+// computedUsersetPath is the chain of relations reached on the current dispatch path through
+// computed usersets on the same resources, starting with the relation first checked. As every
+// step checks the same resources, a relation found twice in the path can only be caused by a
+// cycle in the schema, regardless of the relationships stored.
+type computedUsersetPath struct {
+	namespace string
+	relations []string
+}
+
+// withComputedUsersetStep returns a context recording the step of the path from the given relation
+// to the relation with the given name under the same namespace, or an ErrSchemaCycle if that
+// relation was already reached on the path.
+//
+// The path is carried in the context, and so is only tracked for dispatches handled within this
+// process; dispatches to other nodes fall back to the depth limit.
+func withComputedUsersetStep(ctx context.Context, from *core.RelationReference, relationName string) (context.Context, error) {
+	path, ok := ctx.Value(computedUsersetPathKey{}).(computedUsersetPath)
+	if !ok || path.namespace != from.Namespace || path.relations[len(path.relations)-1] != from.Relation {
+		path = computedUsersetPath{namespace: from.Namespace, relations: []string{from.Relation}}
+	}
+
+	for index, reached := range path.relations {
+		if reached == relationName {
+			cycle := make([]string, 0, len(path.relations)-index+1)
+			cycle = append(cycle, path.relations[index:]...)
+			cycle = append(cycle, relationName)
+			return ctx, NewSchemaCycleErr(path.namespace, cycle)
+		}
+	}
+
+	relations := make([]string, 0, len(path.relations)+1)
+	relations = append(relations, path.relations...)
+	relations = append(relations, relationName)
+	return context.WithValue(ctx, computedUsersetPathKey{}, computedUsersetPath{path.namespace, relations}), nil
+}
+
+// withoutComputedUsersetPath returns a context for dispatching a check of other resources, on
+// which no path is recorded.
+func withoutComputedUsersetPath(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(computedUsersetPathKey{}).(computedUsersetPath); !ok {
+		return ctx
+	}
+	return context.WithValue(ctx, computedUsersetPathKey{}, nil)
+}
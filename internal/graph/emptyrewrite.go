@@ -0,0 +1,49 @@
+package graph
+
+import (
+	"fmt"
+
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+)
+
+// EmptyRewritePolicy defines how Check treats a rewrite of a permission which has no operands.
+// Such rewrites are rejected by schema validation, but may be found in definitions written by
+// older versions of SpiceDB or written without validation.
+type EmptyRewritePolicy int
+
+const (
+	// EmptyRewriteDeny treats an empty rewrite as having no members, denying every subject.
+	EmptyRewriteDeny EmptyRewritePolicy = iota
+
+	// EmptyRewriteError fails the check with an ErrEmptyPermissionRewrite.
+	EmptyRewriteError
+)
+
+var emptyRewritePolicyNames = map[string]EmptyRewritePolicy{
+	"deny":  EmptyRewriteDeny,
+	"error": EmptyRewriteError,
+}
+
+// ParseEmptyRewritePolicy parses the name of an EmptyRewritePolicy: one of `deny` or `error`.
+func ParseEmptyRewritePolicy(name string) (EmptyRewritePolicy, error) {
+	policy, ok := emptyRewritePolicyNames[name]
+	if !ok {
+		return EmptyRewriteDeny, fmt.Errorf("unknown empty rewrite policy `%s`; must be one of `deny` or `error`", name)
+	}
+	return policy, nil
+}
+
+// setOperationForRewrite returns the set operation of the given rewrite, or nil if the rewrite
+// has an unknown operator.
+func setOperationForRewrite(rewrite *core.UsersetRewrite) *core.SetOperation {
+	switch rw := rewrite.RewriteOperation.(type) {
+	case *core.UsersetRewrite_Union:
+		return rw.Union
+	case *core.UsersetRewrite_Intersection:
+		return rw.Intersection
+	case *core.UsersetRewrite_Exclusion:
+		return rw.Exclusion
+	default:
+		return nil
+	}
+}
@@ -3,6 +3,8 @@ package graph
 import (
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/rs/zerolog"
 	"google.golang.org/grpc/codes"
@@ -194,3 +196,182 @@ func (err ErrInvalidCursor) GRPCStatus() *status.Status {
 		),
 	)
 }
+
+// ErrRelationFanOutExceeded occurs when a single resource has more relationships for a relation
+// than the configured maximum allowed to be read while computing a check or expand.
+type ErrRelationFanOutExceeded struct {
+	error
+	namespaceName string
+	relationName  string
+	resourceID    string
+	maximum       uint32
+}
+
+// NamespaceName returns the name of the namespace of the resource.
+func (err ErrRelationFanOutExceeded) NamespaceName() string {
+	return err.namespaceName
+}
+
+// RelationName returns the name of the relation whose fan-out was exceeded.
+func (err ErrRelationFanOutExceeded) RelationName() string {
+	return err.relationName
+}
+
+// ResourceID returns the ID of the resource whose fan-out was exceeded.
+func (err ErrRelationFanOutExceeded) ResourceID() string {
+	return err.resourceID
+}
+
+// Maximum returns the maximum number of relationships allowed per resource.
+func (err ErrRelationFanOutExceeded) Maximum() uint32 {
+	return err.maximum
+}
+
+func (err ErrRelationFanOutExceeded) MarshalZerologObject(e *zerolog.Event) {
+	e.Err(err.error).Str("namespace", err.namespaceName).Str("relation", err.relationName).Uint32("maximum", err.maximum)
+}
+
+// DetailsMetadata returns the metadata for details for this error.
+func (err ErrRelationFanOutExceeded) DetailsMetadata() map[string]string {
+	return map[string]string{
+		"definition_name":             err.namespaceName,
+		"relation_or_permission_name": err.relationName,
+		"resource_object_id":          err.resourceID,
+		"maximum_relationships":       strconv.FormatUint(uint64(err.maximum), 10),
+	}
+}
+
+// GRPCStatus implements retrieving the gRPC status for the error.
+func (err ErrRelationFanOutExceeded) GRPCStatus() *status.Status {
+	return spiceerrors.WithCodeAndDetails(
+		err,
+		codes.ResourceExhausted,
+		spiceerrors.ForReason(
+			v1.ErrorReason_ERROR_REASON_UNSPECIFIED,
+			err.DetailsMetadata(),
+		),
+	)
+}
+
+// NewRelationFanOutExceededErr constructs a new relation fan-out exceeded error.
+func NewRelationFanOutExceededErr(nsName string, relationName string, resourceID string, maximum uint32) error {
+	return ErrRelationFanOutExceeded{
+		error: fmt.Errorf(
+			"relation `%s` of resource `%s:%s` has more than the maximum of %d relationships allowed to be read per resource; to find its subjects, use LookupSubjects with pagination instead",
+			relationName, nsName, resourceID, maximum,
+		),
+		namespaceName: nsName,
+		relationName:  relationName,
+		resourceID:    resourceID,
+		maximum:       maximum,
+	}
+}
+
+// ErrEmptyPermissionRewrite occurs when a permission being checked has a rewrite with no operands
+// and Check is configured to fail on such rewrites.
+type ErrEmptyPermissionRewrite struct {
+	error
+	namespaceName  string
+	permissionName string
+}
+
+// NamespaceName returns the name of the namespace containing the permission.
+func (err ErrEmptyPermissionRewrite) NamespaceName() string {
+	return err.namespaceName
+}
+
+// PermissionName returns the name of the permission with the empty rewrite.
+func (err ErrEmptyPermissionRewrite) PermissionName() string {
+	return err.permissionName
+}
+
+func (err ErrEmptyPermissionRewrite) MarshalZerologObject(e *zerolog.Event) {
+	e.Err(err.error).Str("namespace", err.namespaceName).Str("permission", err.permissionName)
+}
+
+// DetailsMetadata returns the metadata for details for this error.
+func (err ErrEmptyPermissionRewrite) DetailsMetadata() map[string]string {
+	return map[string]string{
+		"definition_name": err.namespaceName,
+		"permission_name": err.permissionName,
+	}
+}
+
+// GRPCStatus implements retrieving the gRPC status for the error.
+func (err ErrEmptyPermissionRewrite) GRPCStatus() *status.Status {
+	return spiceerrors.WithCodeAndDetails(
+		err,
+		codes.FailedPrecondition,
+		spiceerrors.ForReason(
+			v1.ErrorReason_ERROR_REASON_UNSPECIFIED,
+			err.DetailsMetadata(),
+		),
+	)
+}
+
+// NewEmptyPermissionRewriteErr constructs a new empty permission rewrite error.
+func NewEmptyPermissionRewriteErr(nsName string, permissionName string) error {
+	return ErrEmptyPermissionRewrite{
+		error: fmt.Errorf(
+			"permission `%s` under definition `%s` has an operation with no operands; please rewrite your schema",
+			permissionName, nsName,
+		),
+		namespaceName:  nsName,
+		permissionName: permissionName,
+	}
+}
+
+// ErrSchemaCycle occurs when a relation or permission being checked is defined in terms of itself,
+// through a chain of relations and permissions of the same resource.
+type ErrSchemaCycle struct {
+	error
+	namespaceName string
+	relationNames []string
+}
+
+// NamespaceName returns the name of the namespace containing the cycle.
+func (err ErrSchemaCycle) NamespaceName() string {
+	return err.namespaceName
+}
+
+// RelationNames returns the names of the relations and permissions forming the cycle, in the order
+// in which they were reached, starting and ending with the same name.
+func (err ErrSchemaCycle) RelationNames() []string {
+	return err.relationNames
+}
+
+func (err ErrSchemaCycle) MarshalZerologObject(e *zerolog.Event) {
+	e.Err(err.error).Str("namespace", err.namespaceName).Strs("relations", err.relationNames)
+}
+
+// DetailsMetadata returns the metadata for details for this error.
+func (err ErrSchemaCycle) DetailsMetadata() map[string]string {
+	return map[string]string{
+		"definition_name": err.namespaceName,
+		"cycle":           strings.Join(err.relationNames, " -> "),
+	}
+}
+
+// GRPCStatus implements retrieving the gRPC status for the error.
+func (err ErrSchemaCycle) GRPCStatus() *status.Status {
+	return spiceerrors.WithCodeAndDetails(
+		err,
+		codes.FailedPrecondition,
+		spiceerrors.ForReason(
+			v1.ErrorReason_ERROR_REASON_UNSPECIFIED,
+			err.DetailsMetadata(),
+		),
+	)
+}
+
+// NewSchemaCycleErr constructs a new schema cycle error.
+func NewSchemaCycleErr(nsName string, relationNames []string) error {
+	return ErrSchemaCycle{
+		error: fmt.Errorf(
+			"`%s` under definition `%s` is defined in terms of itself: %s; please rewrite your schema",
+			relationNames[0], nsName, strings.Join(relationNames, " -> "),
+		),
+		namespaceName: nsName,
+		relationNames: relationNames,
+	}
+}
@@ -17,15 +17,41 @@ import (
 	"github.com/authzed/spicedb/pkg/spiceerrors"
 )
 
-// NewConcurrentExpander creates an instance of ConcurrentExpander
-func NewConcurrentExpander(d dispatch.Expand) *ConcurrentExpander {
-	return &ConcurrentExpander{d: d}
+// NewConcurrentExpander creates an instance of ConcurrentExpander. A maxDirectRelationships of
+// zero allows any number of relationships to be read for each relation expanded.
+func NewConcurrentExpander(d dispatch.Expand, maxDirectRelationships uint32) *ConcurrentExpander {
+	return &ConcurrentExpander{d: d, maxDirectRelationships: maxDirectRelationships}
 }
 
 // ConcurrentExpander exposes a method to perform Expand requests, and delegates subproblems to the
 // provided dispatch.Expand instance.
 type ConcurrentExpander struct {
-	d dispatch.Expand
+	d                      dispatch.Expand
+	maxDirectRelationships uint32
+}
+
+type expandTruncationKeyType struct{}
+
+var expandTruncationKey expandTruncationKeyType = struct{}{}
+
+// ContextWithExpandTruncation returns a context under which expansions return a truncated node in
+// place of any subproblem that would exceed the maximum dispatch depth, rather than failing the
+// entire expansion. As the option is carried by the context, it only applies to subproblems
+// expanded by the dispatcher serving the request.
+func ContextWithExpandTruncation(ctx context.Context) context.Context {
+	return context.WithValue(ctx, expandTruncationKey, true)
+}
+
+func isExpandTruncationAllowed(ctx context.Context) bool {
+	allowed, _ := ctx.Value(expandTruncationKey).(bool)
+	return allowed
+}
+
+// IsTruncatedNode returns whether the given node marks a point at which the expansion was truncated
+// for having reached the maximum dispatch depth. Truncated nodes have their Expanded field set but
+// neither an intermediate nor a leaf node.
+func IsTruncatedNode(node *core.RelationTupleTreeNode) bool {
+	return node.NodeType == nil
 }
 
 // ValidatedExpandRequest represents a request after it has been validated and parsed for internal
@@ -37,7 +63,7 @@ type ValidatedExpandRequest struct {
 
 // Expand performs an expand request with the provided request and context.
 func (ce *ConcurrentExpander) Expand(ctx context.Context, req ValidatedExpandRequest, relation *core.Relation) (*v1.DispatchExpandResponse, error) {
-	log.Ctx(ctx).Trace().Object("expand", req).Send()
+	log.Ctx(ctx).Trace().Object("expand", dispatch.Loggable(req.DispatchExpandRequest)).Send()
 
 	var directFunc ReduceableExpandFunc
 	if relation.UsersetRewrite == nil {
@@ -55,7 +81,7 @@ func (ce *ConcurrentExpander) expandDirect(
 	ctx context.Context,
 	req ValidatedExpandRequest,
 ) ReduceableExpandFunc {
-	log.Ctx(ctx).Trace().Object("direct", req).Send()
+	log.Ctx(ctx).Trace().Object("direct", dispatch.Loggable(req.DispatchExpandRequest)).Send()
 	return func(ctx context.Context, resultChan chan<- ExpandResult) {
 		ds := datastoremw.MustFromContext(ctx).SnapshotReader(req.Revision)
 		it, err := ds.QueryRelationships(ctx, datastore.RelationshipsFilter{
@@ -71,12 +97,18 @@ func (ce *ConcurrentExpander) expandDirect(
 
 		var foundNonTerminalUsersets []*core.DirectSubject
 		var foundTerminalUsersets []*core.DirectSubject
+		fanOut := newFanOutTracker(req.ResourceAndRelation.Namespace, req.ResourceAndRelation.Relation, ce.maxDirectRelationships)
 		for tpl := it.Next(); tpl != nil; tpl = it.Next() {
 			if it.Err() != nil {
 				resultChan <- expandResultError(NewExpansionFailureErr(it.Err()), emptyMetadata)
 				return
 			}
 
+			if err := fanOut.add(tpl.ResourceAndRelation.ObjectId); err != nil {
+				resultChan <- expandResultError(err, emptyMetadata)
+				return
+			}
+
 			ds := &core.DirectSubject{
 				Subject:          tpl.Subject,
 				CaveatExpression: caveats.CaveatAsExpr(tpl.Caveat),
@@ -205,7 +237,12 @@ func (ce *ConcurrentExpander) expandSetOperation(ctx context.Context, req Valida
 
 func (ce *ConcurrentExpander) dispatch(req ValidatedExpandRequest) ReduceableExpandFunc {
 	return func(ctx context.Context, resultChan chan<- ExpandResult) {
-		log.Ctx(ctx).Trace().Object("dispatchExpand", req).Send()
+		log.Ctx(ctx).Trace().Object("dispatchExpand", dispatch.Loggable(req.DispatchExpandRequest)).Send()
+		if req.Metadata.DepthRemaining == 0 && isExpandTruncationAllowed(ctx) {
+			resultChan <- expandResult(&core.RelationTupleTreeNode{Expanded: req.ResourceAndRelation}, emptyMetadata)
+			return
+		}
+
 		result, err := ce.d.DispatchExpand(ctx, req.DispatchExpandRequest)
 		resultChan <- ExpandResult{result, err}
 	}
@@ -268,12 +305,18 @@ func (ce *ConcurrentExpander) expandTupleToUserset(_ context.Context, req Valida
 		defer it.Close()
 
 		var requestsToDispatch []ReduceableExpandFunc
+		fanOut := newFanOutTracker(req.ResourceAndRelation.Namespace, ttu.Tupleset.Relation, ce.maxDirectRelationships)
 		for tpl := it.Next(); tpl != nil; tpl = it.Next() {
 			if it.Err() != nil {
 				resultChan <- expandResultError(NewExpansionFailureErr(it.Err()), emptyMetadata)
 				return
 			}
 
+			if err := fanOut.add(tpl.ResourceAndRelation.ObjectId); err != nil {
+				resultChan <- expandResultError(err, emptyMetadata)
+				return
+			}
+
 			toDispatch := ce.expandComputedUserset(ctx, req, ttu.ComputedUserset, tpl)
 			requestsToDispatch = append(requestsToDispatch, decorateWithCaveatIfNecessary(toDispatch, caveats.CaveatAsExpr(tpl.Caveat)))
 		}
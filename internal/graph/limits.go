@@ -83,3 +83,40 @@ func (lt *limitTracker) markAlreadyPublished(count uint32) error {
 func (lt *limitTracker) hasExhaustedLimit() bool {
 	return lt.hasLimit && lt.currentLimit == 0
 }
+
+// fanOutTracker is a helper struct for counting the relationships read for each resource while
+// computing a single relation node, and failing once any resource exceeds the configured maximum.
+type fanOutTracker struct {
+	namespaceName string
+	relationName  string
+	maximum       uint32
+	counts        map[string]uint32
+}
+
+// newFanOutTracker creates a new fan-out tracker for the given relation node. A maximum of zero
+// allows any number of relationships.
+func newFanOutTracker(namespaceName string, relationName string, maximum uint32) *fanOutTracker {
+	return &fanOutTracker{
+		namespaceName: namespaceName,
+		relationName:  relationName,
+		maximum:       maximum,
+	}
+}
+
+// add counts a relationship read for the given resource, returning an ErrRelationFanOutExceeded
+// if the resource now has more relationships than the maximum.
+func (ft *fanOutTracker) add(resourceID string) error {
+	if ft.maximum == 0 {
+		return nil
+	}
+
+	if ft.counts == nil {
+		ft.counts = make(map[string]uint32)
+	}
+
+	ft.counts[resourceID]++
+	if ft.counts[resourceID] > ft.maximum {
+		return NewRelationFanOutExceededErr(ft.namespaceName, ft.relationName, resourceID, ft.maximum)
+	}
+	return nil
+}
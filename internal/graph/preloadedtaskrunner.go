@@ -43,6 +43,7 @@ func newPreloadedTaskRunner(ctx context.Context, concurrencyLimit uint16, initia
 func (tr *preloadedTaskRunner) add(f TaskFunc) {
 	tr.tasks = append(tr.tasks, f)
 	tr.wg.Add(1)
+	queuedTaskCount.Add(1)
 }
 
 // start starts running the tasks in the task runner. This does *not* wait for the tasks
@@ -116,6 +117,7 @@ func (tr *preloadedTaskRunner) selectTask() TaskFunc {
 		return nil
 	}
 
+	queuedTaskCount.Add(-1)
 	task := tr.tasks[0]
 	tr.tasks[0] = nil // to free the reference once the task completes.
 	tr.tasks = tr.tasks[1:]
@@ -140,6 +142,7 @@ func (tr *preloadedTaskRunner) emptyForCancel() {
 		tr.err = tr.ctx.Err()
 	}
 
+	queuedTaskCount.Add(-int64(len(tr.tasks)))
 	for {
 		if len(tr.tasks) == 0 {
 			break
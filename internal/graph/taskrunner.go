@@ -114,6 +114,7 @@ func (tr *TaskRunner) addTask(f TaskFunc) bool {
 
 	tr.wg.Add(1)
 	tr.tasks = append(tr.tasks, f)
+	queuedTaskCount.Add(1)
 	return true
 }
 
@@ -125,6 +126,7 @@ func (tr *TaskRunner) selectTask() TaskFunc {
 		return nil
 	}
 
+	queuedTaskCount.Add(-1)
 	task := tr.tasks[0]
 	tr.tasks = tr.tasks[1:]
 	return task
@@ -148,6 +150,7 @@ func (tr *TaskRunner) emptyForCancel() {
 		tr.err = tr.ctx.Err()
 	}
 
+	queuedTaskCount.Add(-int64(len(tr.tasks)))
 	for {
 		if len(tr.tasks) == 0 {
 			break
@@ -0,0 +1,127 @@
+package logging
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sync/atomic"
+
+	"github.com/rs/zerolog"
+)
+
+// IDRedaction defines how object and subject IDs are written to the logs.
+type IDRedaction int32
+
+const (
+	// IDRedactionNone writes IDs as-is.
+	IDRedactionNone IDRedaction = iota
+
+	// IDRedactionHash writes a keyed hash of each ID in place of the ID. The key is generated
+	// when the process starts, so the same ID is written as the same hash for the lifetime of
+	// the process, allowing log lines about the same object to be correlated, but hashes
+	// cannot be compared across processes or reversed by hashing candidate IDs.
+	IDRedactionHash
+
+	// IDRedactionFull writes a placeholder in place of each ID.
+	IDRedactionFull
+)
+
+const (
+	redactedIDPlaceholder = "<redacted>"
+	publicWildcard        = "*"
+)
+
+var idRedactionNames = map[string]IDRedaction{
+	"none":   IDRedactionNone,
+	"hash":   IDRedactionHash,
+	"redact": IDRedactionFull,
+}
+
+// ParseIDRedaction parses the name of an IDRedaction: one of `none`, `hash` or `redact`.
+func ParseIDRedaction(name string) (IDRedaction, error) {
+	redaction, ok := idRedactionNames[name]
+	if !ok {
+		return IDRedactionNone, fmt.Errorf("unknown ID redaction `%s`; must be one of `none`, `hash` or `redact`", name)
+	}
+	return redaction, nil
+}
+
+var (
+	idRedaction atomic.Int32
+	idHashKey   = newIDHashKey()
+
+	// objectReferenceRegex matches an object reference, `namespace:id`, as written in the
+	// text of errors and messages, capturing the prefix and the ID separately.
+	objectReferenceRegex = regexp.MustCompile(`((?:[a-z][a-z0-9_]{1,61}[a-z0-9]/)?[a-z][a-z0-9_]{1,62}[a-z0-9]:)([a-zA-Z0-9_|\-=+*][a-zA-Z0-9/_|\-=+]*)`)
+
+	defaultErrorMarshalFunc = zerolog.ErrorMarshalFunc
+)
+
+func newIDHashKey() []byte {
+	key := make([]byte, sha256.Size)
+	if _, err := rand.Read(key); err != nil {
+		panic(fmt.Sprintf("failed to generate ID redaction key: %s", err))
+	}
+	return key
+}
+
+// SetIDRedaction sets how object and subject IDs are written to the logs by RedactID and
+// RedactText for the remainder of the process. When IDs are redacted, errors written to the
+// logs are also written as their text with the IDs it references redacted.
+func SetIDRedaction(redaction IDRedaction) {
+	idRedaction.Store(int32(redaction))
+
+	if redaction == IDRedactionNone {
+		zerolog.ErrorMarshalFunc = defaultErrorMarshalFunc
+		return
+	}
+
+	zerolog.ErrorMarshalFunc = func(err error) interface{} {
+		if err == nil {
+			return nil
+		}
+		return RedactText(err.Error())
+	}
+}
+
+// IDsRedacted returns whether object and subject IDs are currently redacted in the logs.
+func IDsRedacted() bool {
+	return IDRedaction(idRedaction.Load()) != IDRedactionNone
+}
+
+// RedactID returns the given object or subject ID as it should be written to the logs under
+// the configured IDRedaction. The public wildcard identifies no one, so is never redacted.
+func RedactID(id string) string {
+	if id == publicWildcard {
+		return id
+	}
+
+	switch IDRedaction(idRedaction.Load()) {
+	case IDRedactionHash:
+		mac := hmac.New(sha256.New, idHashKey)
+		mac.Write([]byte(id))
+		return "h:" + hex.EncodeToString(mac.Sum(nil)[:8])
+
+	case IDRedactionFull:
+		return redactedIDPlaceholder
+
+	default:
+		return id
+	}
+}
+
+// RedactText returns the given text, such as an error message, with the ID of every object
+// reference (`namespace:id`) within it redacted under the configured IDRedaction.
+func RedactText(text string) string {
+	if !IDsRedacted() {
+		return text
+	}
+
+	return objectReferenceRegex.ReplaceAllStringFunc(text, func(reference string) string {
+		parts := objectReferenceRegex.FindStringSubmatch(reference)
+		return parts[1] + RedactID(parts[2])
+	})
+}
@@ -0,0 +1,68 @@
+package logging
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactID(t *testing.T) {
+	t.Cleanup(func() { SetIDRedaction(IDRedactionNone) })
+
+	require.Equal(t, "tom", RedactID("tom"))
+
+	redaction, err := ParseIDRedaction("hash")
+	require.NoError(t, err)
+	SetIDRedaction(redaction)
+
+	hashed := RedactID("tom")
+	require.True(t, strings.HasPrefix(hashed, "h:"))
+	require.NotContains(t, hashed, "tom")
+	require.Equal(t, hashed, RedactID("tom"))
+	require.NotEqual(t, hashed, RedactID("fred"))
+	require.Equal(t, "*", RedactID("*"))
+
+	redaction, err = ParseIDRedaction("redact")
+	require.NoError(t, err)
+	SetIDRedaction(redaction)
+	require.Equal(t, redactedIDPlaceholder, RedactID("tom"))
+
+	_, err = ParseIDRedaction("unknown")
+	require.Error(t, err)
+}
+
+func TestRedactText(t *testing.T) {
+	t.Cleanup(func() { SetIDRedaction(IDRedactionNone) })
+
+	text := "relationship document:firstdoc#viewer@user:tom and org/team:eng... for user:*"
+	require.Equal(t, text, RedactText(text))
+
+	SetIDRedaction(IDRedactionFull)
+	require.Equal(t,
+		"relationship document:<redacted>#viewer@user:<redacted> and org/team:<redacted>... for user:*",
+		RedactText(text),
+	)
+
+	SetIDRedaction(IDRedactionHash)
+	redacted := RedactText(text)
+	require.NotContains(t, redacted, "firstdoc")
+	require.NotContains(t, redacted, "tom")
+	require.Contains(t, redacted, "document:h:")
+	require.Contains(t, redacted, "user:*")
+}
+
+func TestRedactedErrorMarshaling(t *testing.T) {
+	t.Cleanup(func() { SetIDRedaction(IDRedactionNone) })
+
+	err := errors.New("object definition `document:firstdoc` not found")
+	require.Equal(t, err, zerolog.ErrorMarshalFunc(err))
+
+	SetIDRedaction(IDRedactionFull)
+	require.Equal(t, "object definition `document:<redacted>` not found", zerolog.ErrorMarshalFunc(err))
+
+	SetIDRedaction(IDRedactionNone)
+	require.Equal(t, err, zerolog.ErrorMarshalFunc(err))
+}
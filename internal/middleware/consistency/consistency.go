@@ -59,10 +59,14 @@ func RevisionFromContext(ctx context.Context) (datastore.Revision, *v1.ZedToken,
 
 // AddRevisionToContext adds a revision to the given context, based on the consistency block found
 // in the given request (if applicable).
-func AddRevisionToContext(ctx context.Context, req interface{}, ds datastore.Datastore) error {
+func AddRevisionToContext(ctx context.Context, req interface{}, ds datastore.Datastore, opts ...Option) error {
+	return addRevisionToContext(ctx, req, ds, newOptions(opts))
+}
+
+func addRevisionToContext(ctx context.Context, req interface{}, ds datastore.Datastore, opts *options) error {
 	switch req := req.(type) {
 	case hasConsistency:
-		return addRevisionToContextFromConsistency(ctx, req, ds)
+		return addRevisionToContextFromConsistency(ctx, req, ds, opts)
 	default:
 		return nil
 	}
@@ -70,13 +74,14 @@ func AddRevisionToContext(ctx context.Context, req interface{}, ds datastore.Dat
 
 // addRevisionToContextFromConsistency adds a revision to the given context, based on the consistency block found
 // in the given request (if applicable).
-func addRevisionToContextFromConsistency(ctx context.Context, req hasConsistency, ds datastore.Datastore) error {
+func addRevisionToContextFromConsistency(ctx context.Context, req hasConsistency, ds datastore.Datastore, opts *options) error {
 	handle := ctx.Value(revisionKey)
 	if handle == nil {
 		return nil
 	}
 
 	var revision datastore.Revision
+	var source string
 	consistency := req.GetConsistency()
 
 	withOptionalCursor, hasOptionalCursor := req.(hasOptionalCursor)
@@ -95,6 +100,16 @@ func addRevisionToContextFromConsistency(ctx context.Context, req hasConsistency
 		}
 
 		revision = requestedRev
+		source = "cursor"
+
+	case consistency == nil && opts.defaultConsistencyFor(req) == FullyConsistentDefault:
+		// Namespace default of Fully Consistent: Use the datastore's synchronized revision.
+		databaseRev, err := ds.HeadRevision(ctx)
+		if err != nil {
+			return rewriteDatastoreError(ctx, err)
+		}
+		revision = databaseRev
+		source = "namespace_default_fully_consistent"
 
 	case consistency == nil || consistency.GetMinimizeLatency():
 		// Minimize Latency: Use the datastore's current revision, whatever it may be.
@@ -103,6 +118,7 @@ func addRevisionToContextFromConsistency(ctx context.Context, req hasConsistency
 			return rewriteDatastoreError(ctx, err)
 		}
 		revision = databaseRev
+		source = "minimize_latency"
 
 	case consistency.GetFullyConsistent():
 		// Fully Consistent: Use the datastore's synchronized revision.
@@ -111,6 +127,7 @@ func addRevisionToContextFromConsistency(ctx context.Context, req hasConsistency
 			return rewriteDatastoreError(ctx, err)
 		}
 		revision = databaseRev
+		source = "fully_consistent"
 
 	case consistency.GetAtLeastAsFresh() != nil:
 		// At least as fresh as: Pick one of the datastore's revision and that specified, which
@@ -120,6 +137,7 @@ func addRevisionToContextFromConsistency(ctx context.Context, req hasConsistency
 			return rewriteDatastoreError(ctx, err)
 		}
 		revision = picked
+		source = "at_least_as_fresh"
 
 	case consistency.GetAtExactSnapshot() != nil:
 		// Exact snapshot: Use the revision as encoded in the zed token.
@@ -134,11 +152,17 @@ func addRevisionToContextFromConsistency(ctx context.Context, req hasConsistency
 		}
 
 		revision = requestedRev
+		source = "at_exact_snapshot"
 
 	default:
 		return fmt.Errorf("missing handling of consistency case in %v", consistency)
 	}
 
+	log.Ctx(ctx).Debug().
+		Str("consistency", source).
+		Stringer("revision", revision).
+		Msg("resolved revision for request")
+
 	handle.(*revisionHandle).revision = revision
 	return nil
 }
@@ -150,7 +174,8 @@ var bypassServiceWhitelist = map[string]struct{}{
 
 // UnaryServerInterceptor returns a new unary server interceptor that performs per-request exchange of
 // the specified consistency configuration for the revision at which to perform the request.
-func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+func UnaryServerInterceptor(opts ...Option) grpc.UnaryServerInterceptor {
+	o := newOptions(opts)
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
 		for bypass := range bypassServiceWhitelist {
 			if strings.HasPrefix(info.FullMethod, bypass) {
@@ -159,7 +184,7 @@ func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
 		}
 		ds := datastoremw.MustFromContext(ctx)
 		newCtx := ContextWithHandle(ctx)
-		if err := AddRevisionToContext(newCtx, req, ds); err != nil {
+		if err := addRevisionToContext(newCtx, req, ds, o); err != nil {
 			return nil, err
 		}
 
@@ -169,21 +194,23 @@ func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
 
 // StreamServerInterceptor returns a new stream server interceptor that performs per-request exchange of
 // the specified consistency configuration for the revision at which to perform the request.
-func StreamServerInterceptor() grpc.StreamServerInterceptor {
+func StreamServerInterceptor(opts ...Option) grpc.StreamServerInterceptor {
+	o := newOptions(opts)
 	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
 		for bypass := range bypassServiceWhitelist {
 			if strings.HasPrefix(info.FullMethod, bypass) {
 				return handler(srv, stream)
 			}
 		}
-		wrapper := &recvWrapper{stream, ContextWithHandle(stream.Context())}
+		wrapper := &recvWrapper{stream, ContextWithHandle(stream.Context()), o}
 		return handler(srv, wrapper)
 	}
 }
 
 type recvWrapper struct {
 	grpc.ServerStream
-	ctx context.Context
+	ctx  context.Context
+	opts *options
 }
 
 func (s *recvWrapper) Context() context.Context {
@@ -196,7 +223,7 @@ func (s *recvWrapper) RecvMsg(m interface{}) error {
 	}
 	ds := datastoremw.MustFromContext(s.ctx)
 
-	return AddRevisionToContext(s.ctx, m, ds)
+	return addRevisionToContext(s.ctx, m, ds, s.opts)
 }
 
 func pickBestRevision(ctx context.Context, requested *v1.ZedToken, ds datastore.Datastore) (datastore.Revision, error) {
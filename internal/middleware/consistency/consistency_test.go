@@ -192,3 +192,79 @@ func TestAddRevisionToContextWithCursor(t *testing.T) {
 	require.True(optimized.Equal(rev))
 	ds.AssertExpectations(t)
 }
+
+func TestAddRevisionToContextNamespaceDefaults(t *testing.T) {
+	defaults, err := ParseNamespaceDefaults(map[string]string{
+		"secret": "fully_consistent",
+		"public": "minimize_latency",
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name             string
+		req              any
+		expectedRevision revision.Decimal
+		expectedCall     string
+	}{
+		{
+			name: "namespace default applies when unspecified",
+			req: &v1.CheckPermissionRequest{
+				Resource: &v1.ObjectReference{ObjectType: "secret", ObjectId: "foo"},
+			},
+			expectedRevision: head,
+			expectedCall:     "HeadRevision",
+		},
+		{
+			name: "explicit consistency overrides namespace default",
+			req: &v1.CheckPermissionRequest{
+				Consistency: &v1.Consistency{
+					Requirement: &v1.Consistency_MinimizeLatency{MinimizeLatency: true},
+				},
+				Resource: &v1.ObjectReference{ObjectType: "secret", ObjectId: "foo"},
+			},
+			expectedRevision: optimized,
+			expectedCall:     "OptimizedRevision",
+		},
+		{
+			name: "namespace default of minimize latency",
+			req: &v1.LookupResourcesRequest{
+				ResourceObjectType: "public",
+			},
+			expectedRevision: optimized,
+			expectedCall:     "OptimizedRevision",
+		},
+		{
+			name: "unlisted namespace uses global default",
+			req: &v1.ReadRelationshipsRequest{
+				RelationshipFilter: &v1.RelationshipFilter{ResourceType: "other"},
+			},
+			expectedRevision: optimized,
+			expectedCall:     "OptimizedRevision",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+
+			ds := &proxy_test.MockDatastore{}
+			ds.On(tt.expectedCall).Return(tt.expectedRevision, nil).Once()
+
+			updated := ContextWithHandle(context.Background())
+			err := AddRevisionToContext(updated, tt.req, ds, WithNamespaceDefaults(defaults))
+			require.NoError(err)
+
+			rev, _, err := RevisionFromContext(updated)
+			require.NoError(err)
+
+			require.True(tt.expectedRevision.Equal(rev))
+			ds.AssertExpectations(t)
+		})
+	}
+}
+
+func TestParseNamespaceDefaultsInvalid(t *testing.T) {
+	_, err := ParseNamespaceDefaults(map[string]string{"secret": "eventually"})
+	require.ErrorContains(t, err, `invalid default consistency for namespace "secret"`)
+}
@@ -0,0 +1,121 @@
+package consistency
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
+)
+
+// DefaultConsistency is the consistency applied to a request which does not specify one.
+type DefaultConsistency int
+
+const (
+	// MinimizeLatencyDefault resolves requests without a consistency at the datastore's optimized
+	// revision. This is the global default.
+	MinimizeLatencyDefault DefaultConsistency = iota
+
+	// FullyConsistentDefault resolves requests without a consistency at the datastore's head revision.
+	FullyConsistentDefault
+)
+
+var defaultConsistencyNames = map[string]DefaultConsistency{
+	"minimize_latency": MinimizeLatencyDefault,
+	"fully_consistent": FullyConsistentDefault,
+}
+
+// ParseDefaultConsistency parses the name of a default consistency, which must be one of
+// `minimize_latency` or `fully_consistent`.
+func ParseDefaultConsistency(name string) (DefaultConsistency, error) {
+	found, ok := defaultConsistencyNames[strings.ToLower(name)]
+	if !ok {
+		names := make([]string, 0, len(defaultConsistencyNames))
+		for name := range defaultConsistencyNames {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return MinimizeLatencyDefault, fmt.Errorf("unknown default consistency %q; must be one of: %s", name, strings.Join(names, ", "))
+	}
+	return found, nil
+}
+
+// NamespaceDefaults maps the names of namespaces to the consistency applied to requests targeting
+// them which do not specify a consistency. Namespaces not found fall back to the global default.
+type NamespaceDefaults map[string]DefaultConsistency
+
+// ParseNamespaceDefaults parses a map of namespace names to default consistency names into
+// NamespaceDefaults.
+func ParseNamespaceDefaults(defaults map[string]string) (NamespaceDefaults, error) {
+	parsed := make(NamespaceDefaults, len(defaults))
+	for namespaceName, consistencyName := range defaults {
+		found, err := ParseDefaultConsistency(consistencyName)
+		if err != nil {
+			return nil, fmt.Errorf("invalid default consistency for namespace %q: %w", namespaceName, err)
+		}
+		parsed[namespaceName] = found
+	}
+	return parsed, nil
+}
+
+// Option instances control how the middleware is initialized.
+type Option func(*options)
+
+// WithNamespaceDefaults sets the consistency applied to requests targeting the given namespaces
+// which do not specify a consistency.
+//
+// default: all namespaces use the global default of minimize_latency
+func WithNamespaceDefaults(defaults NamespaceDefaults) Option {
+	return func(opts *options) {
+		opts.namespaceDefaults = defaults
+	}
+}
+
+type options struct {
+	namespaceDefaults NamespaceDefaults
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// defaultConsistencyFor returns the consistency to apply to the given request, if it does not
+// specify one.
+func (o *options) defaultConsistencyFor(req any) DefaultConsistency {
+	if len(o.namespaceDefaults) == 0 {
+		return MinimizeLatencyDefault
+	}
+
+	namespaceName, ok := requestNamespace(req)
+	if !ok {
+		return MinimizeLatencyDefault
+	}
+
+	found, ok := o.namespaceDefaults[namespaceName]
+	if !ok {
+		return MinimizeLatencyDefault
+	}
+	return found
+}
+
+// requestNamespace returns the name of the namespace targeted by the request, if any.
+func requestNamespace(req any) (string, bool) {
+	switch req := req.(type) {
+	case *v1.CheckPermissionRequest:
+		return req.GetResource().GetObjectType(), true
+	case *v1.ExpandPermissionTreeRequest:
+		return req.GetResource().GetObjectType(), true
+	case *v1.LookupResourcesRequest:
+		return req.GetResourceObjectType(), true
+	case *v1.LookupSubjectsRequest:
+		return req.GetResource().GetObjectType(), true
+	case *v1.ReadRelationshipsRequest:
+		return req.GetRelationshipFilter().GetResourceType(), true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,99 @@
+package errorverbosity
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	log "github.com/authzed/spicedb/internal/logging"
+	"github.com/authzed/spicedb/pkg/middleware/requestid"
+)
+
+// Verbosity defines how much detail about a failed request is returned to the client.
+type Verbosity int
+
+const (
+	// Verbose returns errors to the client as-is, including the full message and details.
+	Verbose Verbosity = iota
+
+	// Safe returns errors to the client with only their code and a generic message naming the
+	// request ID. The full error is written to the server logs, whose entries for the request carry
+	// the same request ID.
+	Safe
+)
+
+var verbosityNames = map[string]Verbosity{
+	"verbose": Verbose,
+	"safe":    Safe,
+}
+
+// ParseVerbosity parses the name of a Verbosity: one of `verbose` or `safe`.
+func ParseVerbosity(name string) (Verbosity, error) {
+	verbosity, ok := verbosityNames[name]
+	if !ok {
+		return Verbose, fmt.Errorf("unknown error verbosity `%s`; must be one of `verbose` or `safe`", name)
+	}
+	return verbosity, nil
+}
+
+// UnaryServerInterceptor returns a new unary server interceptor that returns errors to the client
+// with the given verbosity. Must run after the request ID has been assigned and added to the
+// logger of the context.
+func UnaryServerInterceptor(verbosity Verbosity) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		resp, err := handler(ctx, req)
+		if err != nil {
+			return resp, rewriteError(ctx, verbosity, err)
+		}
+		return resp, nil
+	}
+}
+
+// StreamServerInterceptor returns a new stream server interceptor that returns errors to the
+// client with the given verbosity. Must run after the request ID has been assigned and added to
+// the logger of the context.
+func StreamServerInterceptor(verbosity Verbosity) grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := handler(srv, stream); err != nil {
+			return rewriteError(stream.Context(), verbosity, err)
+		}
+		return nil
+	}
+}
+
+func rewriteError(ctx context.Context, verbosity Verbosity, err error) error {
+	if verbosity != Safe {
+		return err
+	}
+
+	code := status.Code(err)
+	if code == codes.OK {
+		return err
+	}
+
+	requestID := requestIDFromContext(ctx)
+	log.Ctx(ctx).Info().Err(err).Str("code", code.String()).Msg("returned generic error to client in place of this error")
+
+	message := fmt.Sprintf("request failed with code %s", code)
+	if requestID != "" {
+		message = fmt.Sprintf("%s; see the server logs for request ID `%s` for details", message, requestID)
+	}
+	return status.Error(code, message)
+}
+
+func requestIDFromContext(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	requestIDs := md.Get(requestid.RequestIDMetadataKey)
+	if len(requestIDs) == 0 {
+		return ""
+	}
+	return requestIDs[0]
+}
@@ -0,0 +1,83 @@
+package errorverbosity
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/authzed/spicedb/pkg/middleware/requestid"
+)
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	handlerErr := status.Error(codes.FailedPrecondition, "relation `document#viewer` not found")
+
+	for _, tc := range []struct {
+		name            string
+		verbosity       Verbosity
+		requestID       string
+		expectedMessage string
+	}{
+		{
+			name:            "verbose",
+			verbosity:       Verbose,
+			requestID:       "abc123",
+			expectedMessage: "relation `document#viewer` not found",
+		},
+		{
+			name:            "safe",
+			verbosity:       Safe,
+			requestID:       "abc123",
+			expectedMessage: "request failed with code FailedPrecondition; see the server logs for request ID `abc123` for details",
+		},
+		{
+			name:            "safe without request ID",
+			verbosity:       Safe,
+			expectedMessage: "request failed with code FailedPrecondition",
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tc.requestID != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(requestid.RequestIDMetadataKey, tc.requestID))
+			}
+
+			interceptor := UnaryServerInterceptor(tc.verbosity)
+			_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{}, func(context.Context, any) (any, error) {
+				return nil, handlerErr
+			})
+
+			grpcStatus, ok := status.FromError(err)
+			require.True(t, ok)
+			require.Equal(t, codes.FailedPrecondition, grpcStatus.Code())
+			require.Equal(t, tc.expectedMessage, grpcStatus.Message())
+		})
+	}
+}
+
+func TestUnaryServerInterceptorSuccess(t *testing.T) {
+	interceptor := UnaryServerInterceptor(Safe)
+	resp, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{}, func(context.Context, any) (any, error) {
+		return "ok", nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, "ok", resp)
+}
+
+func TestParseVerbosity(t *testing.T) {
+	verbosity, err := ParseVerbosity("safe")
+	require.NoError(t, err)
+	require.Equal(t, Safe, verbosity)
+
+	verbosity, err = ParseVerbosity("verbose")
+	require.NoError(t, err)
+	require.Equal(t, Verbose, verbosity)
+
+	_, err = ParseVerbosity("quiet")
+	require.ErrorContains(t, err, "must be one of")
+}
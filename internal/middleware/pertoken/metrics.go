@@ -0,0 +1,63 @@
+package pertoken
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	log "github.com/authzed/spicedb/internal/logging"
+)
+
+var (
+	tokenDatastoresDesc = prometheus.NewDesc(
+		"spicedb_testserver_token_datastores",
+		"number of token datastores currently kept",
+		nil, nil,
+	)
+
+	tokenDatastoreRelationshipsDesc = prometheus.NewDesc(
+		"spicedb_testserver_token_datastore_relationships",
+		"distribution of the number of relationships in each token datastore",
+		nil, nil,
+	)
+
+	tokenDatastoreRelationshipsBuckets = prometheus.ExponentialBuckets(10, 10, 6)
+)
+
+// Describe implements prometheus.Collector.
+func (m *MiddlewareForTesting) Describe(ch chan<- *prometheus.Desc) {
+	ch <- tokenDatastoresDesc
+	ch <- tokenDatastoreRelationshipsDesc
+}
+
+// Collect implements prometheus.Collector, reporting the number of token datastores kept and
+// the distribution of their sizes, so that their memory usage can be followed over time. Tokens
+// are not used as labels, to keep them private and the number of series bounded.
+func (m *MiddlewareForTesting) Collect(ch chan<- prometheus.Metric) {
+	ctx := context.Background()
+
+	count := uint64(0)
+	sum := 0.0
+	buckets := make(map[float64]uint64, len(tokenDatastoreRelationshipsBuckets))
+	m.datastoreByToken.Range(func(key, value any) bool {
+		count++
+
+		stats, err := value.(*tokenDatastore).Statistics(ctx)
+		if err != nil {
+			log.Ctx(ctx).Warn().Err(err).Msg("could not compute statistics of token datastore")
+			return true
+		}
+
+		relationships := float64(stats.EstimatedRelationshipCount)
+		sum += relationships
+		for _, bound := range tokenDatastoreRelationshipsBuckets {
+			if relationships <= bound {
+				buckets[bound]++
+			}
+		}
+		return true
+	})
+
+	ch <- prometheus.MustNewConstMetric(tokenDatastoresDesc, prometheus.GaugeValue, float64(count))
+	ch <- prometheus.MustNewConstHistogram(tokenDatastoreRelationshipsDesc, count, sum, buckets)
+}
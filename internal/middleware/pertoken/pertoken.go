@@ -4,13 +4,17 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/benbjohnson/clock"
+	"github.com/google/uuid"
 	middleware "github.com/grpc-ecosystem/go-grpc-middleware/v2"
 	grpcauth "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/auth"
 	"google.golang.org/grpc"
 
 	"github.com/authzed/spicedb/internal/datastore/memdb"
+	"github.com/authzed/spicedb/internal/datastore/proxy"
 	log "github.com/authzed/spicedb/internal/logging"
 	datastoremw "github.com/authzed/spicedb/internal/middleware/datastore"
 	"github.com/authzed/spicedb/pkg/datastore"
@@ -18,64 +22,330 @@ import (
 )
 
 const (
-	gcWindow             = 1 * time.Hour
-	revisionQuantization = 10 * time.Millisecond
+	// DefaultGCWindow is the GC window of the datastore of each token, unless overridden with
+	// WithGCWindow.
+	DefaultGCWindow = 1 * time.Hour
+
+	// DefaultRevisionQuantization is the revision quantization interval of the datastore of each
+	// token, unless overridden with WithRevisionQuantization.
+	DefaultRevisionQuantization = 10 * time.Millisecond
 )
 
+// Option configures the datastores created by the middleware.
+type Option func(*MiddlewareForTesting)
+
+// WithGCWindow sets the GC window of the datastore of each token.
+//
+// default: DefaultGCWindow
+func WithGCWindow(gcWindow time.Duration) Option {
+	return func(m *MiddlewareForTesting) {
+		m.gcWindow = gcWindow
+	}
+}
+
+// WithRevisionQuantization sets the revision quantization interval of the datastore of each token.
+//
+// default: DefaultRevisionQuantization
+func WithRevisionQuantization(revisionQuantization time.Duration) Option {
+	return func(m *MiddlewareForTesting) {
+		m.revisionQuantization = revisionQuantization
+	}
+}
+
+// WithDatastoreKey sets the function returning the key under which the datastore for a request is
+// kept, such as the tenant of an authenticated request.
+//
+// default: the bearer token of the request
+func WithDatastoreKey(keyFunc func(ctx context.Context) string) Option {
+	return func(m *MiddlewareForTesting) {
+		m.datastoreKey = keyFunc
+	}
+}
+
+// WithMaxConcurrentWritesPerToken sets the number of writes allowed to execute concurrently against
+// the datastore of any single token, with waiting writes admitted in submission order. Zero allows
+// any number of concurrent writes.
+//
+// default: 0
+func WithMaxConcurrentWritesPerToken(maxConcurrentWrites uint16) Option {
+	return func(m *MiddlewareForTesting) {
+		m.maxConcurrentWritesPerToken = maxConcurrentWrites
+	}
+}
+
+// WithTokenTTL sets the duration after which the datastore of a token that has not been accessed is
+// discarded, so that the next request for the token starts from a new datastore initialized from
+// the config files. Zero keeps the datastores of all tokens indefinitely.
+//
+// default: 0
+func WithTokenTTL(tokenTTL time.Duration) Option {
+	return func(m *MiddlewareForTesting) {
+		m.tokenTTL = tokenTTL
+	}
+}
+
+func bearerToken(ctx context.Context) string {
+	tokenStr, _ := grpcauth.AuthFromMD(ctx, "bearer")
+	return tokenStr
+}
+
 // MiddlewareForTesting is used to create a unique datastore for each token. It is intended for use in the
 // testserver only.
 type MiddlewareForTesting struct {
-	datastoreByToken *sync.Map
-	configFilePaths  []string
+	datastoreByToken            *sync.Map
+	configFilePaths             []string
+	maxConcurrentWritesPerToken uint16
+	tokenTTL                    time.Duration
+	timeSource                  clock.Clock
+	lastExpiryNanos             atomic.Int64
+	gcWindow                    time.Duration
+	revisionQuantization        time.Duration
+	datastoreKey                func(ctx context.Context) string
+	resetAllDatastores          bool
+	lastLoad                    atomic.Pointer[configLoad]
+}
+
+// configLoad is the outcome of the last load of the config files into a new datastore.
+type configLoad struct {
+	err error
 }
 
 // NewMiddleware returns a new per-token datastore middleware that initializes each datastore with the data in the
 // config files.
-func NewMiddleware(configFilePaths []string) *MiddlewareForTesting {
-	return &MiddlewareForTesting{
-		datastoreByToken: &sync.Map{},
-		configFilePaths:  configFilePaths,
+func NewMiddleware(configFilePaths []string, opts ...Option) *MiddlewareForTesting {
+	m := &MiddlewareForTesting{
+		datastoreByToken:     &sync.Map{},
+		configFilePaths:      configFilePaths,
+		timeSource:           clock.New(),
+		gcWindow:             DefaultGCWindow,
+		revisionQuantization: DefaultRevisionQuantization,
+		datastoreKey:         bearerToken,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// tokenDatastore is the datastore of a single token, along with the time at which it was last accessed and the
+// named snapshots of the datastore created by the token.
+type tokenDatastore struct {
+	datastore.Datastore
+	scope           string
+	lastAccessNanos atomic.Int64
+	snapshots       sync.Map
+}
+
+func (td *tokenDatastore) touch(now time.Time) {
+	td.lastAccessNanos.Store(now.UnixNano())
+}
+
+func (td *tokenDatastore) idleSince(now time.Time) time.Duration {
+	return now.Sub(time.Unix(0, td.lastAccessNanos.Load()))
+}
+
+// close closes the datastore along with those of its snapshots.
+func (td *tokenDatastore) close() error {
+	var err error
+	td.snapshots.Range(func(_, value any) bool {
+		if serr := value.(*snapshotDatastore).Close(); serr != nil && err == nil {
+			err = serr
+		}
+		return true
+	})
+
+	if cerr := td.Close(); cerr != nil && err == nil {
+		err = cerr
 	}
+	return err
+}
+
+// expireIdleDatastores discards the datastores of all tokens that have been idle for at least the TTL. To avoid
+// scanning every token on each request, it runs at most once per TTL.
+func (m *MiddlewareForTesting) expireIdleDatastores(ctx context.Context, now time.Time) {
+	lastExpiry := m.lastExpiryNanos.Load()
+	if now.Sub(time.Unix(0, lastExpiry)) < m.tokenTTL || !m.lastExpiryNanos.CompareAndSwap(lastExpiry, now.UnixNano()) {
+		return
+	}
+
+	m.datastoreByToken.Range(func(key, value any) bool {
+		td := value.(*tokenDatastore)
+		if td.idleSince(now) >= m.tokenTTL && m.datastoreByToken.CompareAndDelete(key, td) {
+			log.Ctx(ctx).Debug().Str("token", key.(string)).Msg("discarded idle datastore for token")
+			if err := td.close(); err != nil {
+				log.Ctx(ctx).Warn().Err(err).Str("token", key.(string)).Msg("failed to close idle datastore")
+			}
+		}
+		return true
+	})
 }
 
 type squashable interface {
 	SquashRevisionsForTesting()
 }
 
-func (m *MiddlewareForTesting) getOrCreateDatastore(ctx context.Context) (datastore.Datastore, error) {
-	tokenStr, _ := grpcauth.AuthFromMD(ctx, "bearer")
-	tokenDatastore, ok := m.datastoreByToken.Load(tokenStr)
-	if ok {
-		return tokenDatastore.(datastore.Datastore), nil
+func (m *MiddlewareForTesting) getOrCreateDatastore(ctx context.Context) (*tokenDatastore, error) {
+	tokenStr := m.datastoreKey(ctx)
+	now := m.timeSource.Now()
+	if m.tokenTTL > 0 {
+		m.expireIdleDatastores(ctx, now)
+	}
+
+	if existing, ok := m.datastoreByToken.Load(tokenStr); ok {
+		td := existing.(*tokenDatastore)
+		if m.tokenTTL == 0 || td.idleSince(now) < m.tokenTTL {
+			td.touch(now)
+			return td, nil
+		}
+
+		if m.datastoreByToken.CompareAndDelete(tokenStr, td) {
+			log.Ctx(ctx).Debug().Str("token", tokenStr).Msg("discarded idle datastore for token")
+			if err := td.close(); err != nil {
+				log.Ctx(ctx).Warn().Err(err).Str("token", tokenStr).Msg("failed to close idle datastore")
+			}
+		}
 	}
 
 	log.Ctx(ctx).Debug().Str("token", tokenStr).Msg("initializing new upstream for token")
-	ds, err := memdb.NewMemdbDatastore(0, revisionQuantization, gcWindow)
+	td, err := m.newTokenDatastore(ctx)
+	if err != nil {
+		return nil, err
+	}
+	td.touch(now)
+
+	actual, loaded := m.datastoreByToken.LoadOrStore(tokenStr, td)
+	if loaded {
+		// Another request for the token stored its datastore first.
+		if err := td.close(); err != nil {
+			log.Ctx(ctx).Warn().Err(err).Str("token", tokenStr).Msg("failed to close unused datastore")
+		}
+	}
+	return actual.(*tokenDatastore), nil
+}
+
+// newTokenDatastore returns a new datastore initialized with the data in the config files.
+func (m *MiddlewareForTesting) newTokenDatastore(ctx context.Context) (*tokenDatastore, error) {
+	ds, err := memdb.NewMemdbDatastore(0, m.revisionQuantization, m.gcWindow)
 	if err != nil {
 		return nil, fmt.Errorf("failed to init datastore: %w", err)
 	}
 
-	_, _, err = validationfile.PopulateFromFiles(ctx, ds, m.configFilePaths)
+	populated, _, err := validationfile.PopulateFromFiles(ctx, ds, m.configFilePaths)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load config files: %w", err)
+		if cerr := ds.Close(); cerr != nil {
+			log.Ctx(ctx).Warn().Err(cerr).Msg("failed to close datastore of config files that failed to load")
+		}
+		err = fmt.Errorf("failed to load config files: %w", err)
+		m.lastLoad.Store(&configLoad{err: err})
+		return nil, err
+	}
+	m.lastLoad.Store(&configLoad{})
+
+	for name, sources := range populated.NamespaceSources {
+		log.Ctx(ctx).Debug().Str("namespace", name).Strs("sources", sources).Msg("loaded namespace from config files")
+	}
+
+	for name, sources := range populated.ConflictingNamespaceSources() {
+		log.Ctx(ctx).Warn().Str("namespace", name).Strs("sources", sources).Msg("namespace is defined in multiple config files")
 	}
 
 	// Squash the revisions so that the caller sees all the populated data.
 	ds.(squashable).SquashRevisionsForTesting()
 
-	m.datastoreByToken.Store(tokenStr, ds)
-	return ds, nil
+	if m.maxConcurrentWritesPerToken > 0 {
+		ds = proxy.NewWriteLimitingDatastore(ds, m.maxConcurrentWritesPerToken)
+	}
+
+	return &tokenDatastore{Datastore: ds, scope: uuid.NewString()}, nil
+}
+
+// ReadyState reports whether new token datastores can be created, which requires the config files
+// to load successfully. Once the files have loaded, readiness follows the outcome of the last load
+// for a token, so that probes do not build a datastore each time; until then, or after a failed
+// load, the files are loaded again on each probe.
+func (m *MiddlewareForTesting) ReadyState(ctx context.Context) (datastore.ReadyState, error) {
+	if last := m.lastLoad.Load(); last != nil && last.err == nil {
+		return datastore.ReadyState{IsReady: true}, nil
+	}
+
+	td, err := m.newTokenDatastore(ctx)
+	if err != nil {
+		return datastore.ReadyState{Message: err.Error(), IsReady: false}, nil
+	}
+
+	if err := td.close(); err != nil {
+		return datastore.ReadyState{}, err
+	}
+	return datastore.ReadyState{IsReady: true}, nil
+}
+
+// datastoreForRequest returns the datastore against which the request should be handled, along with
+// its scope. If readOnly, requests with headers that would change the datastores are rejected before
+// any header is applied.
+func (m *MiddlewareForTesting) datastoreForRequest(ctx context.Context, readOnly bool) (datastore.Datastore, string, error) {
+	if readOnly {
+		if err := rejectReadOnlyHeaders(ctx); err != nil {
+			return nil, "", err
+		}
+	}
+
+	if err := m.applyResetHeader(ctx); err != nil {
+		return nil, "", err
+	}
+
+	td, err := m.getOrCreateDatastore(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := m.applyReloadHeader(ctx, td); err != nil {
+		return nil, "", err
+	}
+
+	ds, scope, err := m.applySnapshotHeaders(ctx, td)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := m.applyStatisticsHeader(ctx, ds); err != nil {
+		return nil, "", err
+	}
+
+	return ds, scope, nil
 }
 
 // UnaryServerInterceptor returns a new unary server interceptor that sets a separate in-memory datastore per token
 func (m *MiddlewareForTesting) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return m.unaryServerInterceptor(false)
+}
+
+// StreamServerInterceptor returns a new stream server interceptor that sets a separate in-memory datastore per token
+func (m *MiddlewareForTesting) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return m.streamServerInterceptor(false)
+}
+
+// ReadOnlyUnaryServerInterceptor returns a new unary server interceptor for read-only servers, which
+// sets a separate in-memory datastore per token after rejecting requests with headers that would
+// change the datastores.
+func (m *MiddlewareForTesting) ReadOnlyUnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return m.unaryServerInterceptor(true)
+}
+
+// ReadOnlyStreamServerInterceptor returns a new stream server interceptor for read-only servers,
+// which sets a separate in-memory datastore per token after rejecting requests with headers that
+// would change the datastores.
+func (m *MiddlewareForTesting) ReadOnlyStreamServerInterceptor() grpc.StreamServerInterceptor {
+	return m.streamServerInterceptor(true)
+}
+
+func (m *MiddlewareForTesting) unaryServerInterceptor(readOnly bool) grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
-		tokenDatastore, err := m.getOrCreateDatastore(ctx)
+		tokenDatastore, scope, err := m.datastoreForRequest(ctx, readOnly)
 		if err != nil {
 			return nil, err
 		}
 
-		newCtx := datastoremw.ContextWithHandle(ctx)
+		newCtx := datastoremw.ContextWithHandle(context.WithValue(ctx, datastoreScopeKey{}, scope))
 		if err := datastoremw.SetInContext(newCtx, tokenDatastore); err != nil {
 			return nil, err
 		}
@@ -84,19 +354,29 @@ func (m *MiddlewareForTesting) UnaryServerInterceptor() grpc.UnaryServerIntercep
 	}
 }
 
-// StreamServerInterceptor returns a new stream server interceptor that sets a separate in-memory datastore per token
-func (m *MiddlewareForTesting) StreamServerInterceptor() grpc.StreamServerInterceptor {
+func (m *MiddlewareForTesting) streamServerInterceptor(readOnly bool) grpc.StreamServerInterceptor {
 	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
-		tokenDatastore, err := m.getOrCreateDatastore(stream.Context())
+		tokenDatastore, scope, err := m.datastoreForRequest(stream.Context(), readOnly)
 		if err != nil {
 			return err
 		}
 
 		wrapped := middleware.WrapServerStream(stream)
-		wrapped.WrappedContext = datastoremw.ContextWithHandle(wrapped.WrappedContext)
+		wrapped.WrappedContext = datastoremw.ContextWithHandle(context.WithValue(wrapped.WrappedContext, datastoreScopeKey{}, scope))
 		if err := datastoremw.SetInContext(wrapped.WrappedContext, tokenDatastore); err != nil {
 			return err
 		}
 		return handler(srv, wrapped)
 	}
 }
+
+type datastoreScopeKey struct{}
+
+// DatastoreScope returns the scope of the datastore set in the context by the middleware, or an
+// empty string if none was set. Each datastore created by the middleware, whether for a token or
+// for a snapshot, has its own scope, which is never reused. As the revisions of separate datastores
+// may be equal, results cached across requests must be keyed by scope as well as by revision.
+func DatastoreScope(ctx context.Context) string {
+	scope, _ := ctx.Value(datastoreScopeKey{}).(string)
+	return scope
+}
@@ -0,0 +1,565 @@
+package pertoken
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/pkg/datastore"
+	ns "github.com/authzed/spicedb/pkg/namespace"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+func contextWithToken(token string, headers ...string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs(append([]string{"authorization", "bearer " + token}, headers...)...))
+}
+
+// requireClosed asserts that the datastore, or the datastore it wraps, has been closed, which fails
+// all writes to it.
+func requireClosed(t *testing.T, ds datastore.Datastore) {
+	t.Helper()
+	for {
+		wrapped, ok := ds.(datastore.UnwrappableDatastore)
+		if !ok {
+			break
+		}
+		ds = wrapped.Unwrap()
+	}
+
+	_, err := ds.ReadWriteTx(context.Background(), func(rwt datastore.ReadWriteTransaction) error {
+		return nil
+	})
+	require.Error(t, err)
+}
+
+func TestTokenDatastoreTTL(t *testing.T) {
+	mockTime := clock.NewMock()
+	m := NewMiddleware(nil, WithTokenTTL(10*time.Minute))
+	m.timeSource = mockTime
+
+	first, err := m.getOrCreateDatastore(contextWithToken("first"))
+	require.NoError(t, err)
+
+	second, err := m.getOrCreateDatastore(contextWithToken("second"))
+	require.NoError(t, err)
+	require.NotSame(t, first, second)
+
+	// Accessing a token before it expires keeps its datastore alive.
+	mockTime.Add(6 * time.Minute)
+	again, err := m.getOrCreateDatastore(contextWithToken("first"))
+	require.NoError(t, err)
+	require.Same(t, first, again)
+
+	// The idle token is discarded by the expiry run on access of another token.
+	mockTime.Add(6 * time.Minute)
+	again, err = m.getOrCreateDatastore(contextWithToken("first"))
+	require.NoError(t, err)
+	require.Same(t, first, again)
+
+	_, ok := m.datastoreByToken.Load("second")
+	require.False(t, ok)
+	requireClosed(t, second)
+
+	// An expired token is rebuilt on its next access.
+	rebuilt, err := m.getOrCreateDatastore(contextWithToken("second"))
+	require.NoError(t, err)
+	require.NotSame(t, second, rebuilt)
+
+	mockTime.Add(10 * time.Minute)
+	rebuilt, err = m.getOrCreateDatastore(contextWithToken("first"))
+	require.NoError(t, err)
+	require.NotSame(t, first, rebuilt)
+	requireClosed(t, first)
+}
+
+func TestTokenDatastoreWithoutTTL(t *testing.T) {
+	mockTime := clock.NewMock()
+	m := NewMiddleware(nil)
+	m.timeSource = mockTime
+
+	first, err := m.getOrCreateDatastore(contextWithToken("first"))
+	require.NoError(t, err)
+
+	mockTime.Add(24 * time.Hour)
+	again, err := m.getOrCreateDatastore(contextWithToken("first"))
+	require.NoError(t, err)
+	require.Same(t, first, again)
+}
+
+func TestDatastoreKey(t *testing.T) {
+	m := NewMiddleware(nil, WithDatastoreKey(func(ctx context.Context) string {
+		md, _ := metadata.FromIncomingContext(ctx)
+		return md.Get("tenant")[0]
+	}))
+
+	first, err := m.getOrCreateDatastore(contextWithToken("first", "tenant", "sometenant"))
+	require.NoError(t, err)
+
+	// Requests of the same tenant share a datastore, whatever their token.
+	second, err := m.getOrCreateDatastore(contextWithToken("second", "tenant", "sometenant"))
+	require.NoError(t, err)
+	require.Same(t, first, second)
+
+	other, err := m.getOrCreateDatastore(contextWithToken("first", "tenant", "othertenant"))
+	require.NoError(t, err)
+	require.NotSame(t, first, other)
+}
+
+func TestSnapshots(t *testing.T) {
+	m := NewMiddleware(nil)
+	ctx := contextWithToken("sometoken")
+
+	current, currentScope, err := m.datastoreForRequest(ctx, false)
+	require.NoError(t, err)
+
+	_, err = current.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		return rwt.WriteNamespaces(ctx,
+			ns.Namespace("user"),
+			ns.Namespace("document", ns.MustRelation("viewer", nil, ns.AllowedRelation("user", "..."))),
+		)
+	})
+	require.NoError(t, err)
+
+	_, err = common.WriteTuples(ctx, current, core.RelationTupleUpdate_CREATE, tuple.MustParse("document:first#viewer@user:tom"))
+	require.NoError(t, err)
+
+	// Creating a snapshot does not change the datastore used for the request.
+	ds, scope, err := m.datastoreForRequest(contextWithToken("sometoken", RequestCreateSnapshot, "before"), false)
+	require.NoError(t, err)
+	require.Same(t, current, ds)
+	require.Equal(t, currentScope, scope)
+
+	_, err = common.WriteTuples(ctx, current, core.RelationTupleUpdate_CREATE, tuple.MustParse("document:second#viewer@user:tom"))
+	require.NoError(t, err)
+
+	countRelationships := func(ds datastore.Datastore) int {
+		rev, err := ds.HeadRevision(ctx)
+		require.NoError(t, err)
+
+		iter, err := ds.SnapshotReader(rev).QueryRelationships(ctx, datastore.RelationshipsFilter{ResourceType: "document"})
+		require.NoError(t, err)
+		defer iter.Close()
+
+		count := 0
+		for tpl := iter.Next(); tpl != nil; tpl = iter.Next() {
+			count++
+		}
+		require.NoError(t, iter.Err())
+		return count
+	}
+
+	// Snapshots have their own scope, as their revisions may be equal to those of the token's datastore.
+	snapshot, snapshotScope, err := m.datastoreForRequest(contextWithToken("sometoken", RequestSnapshot, "before"), false)
+	require.NoError(t, err)
+	require.NotEqual(t, currentScope, snapshotScope)
+	require.Equal(t, 1, countRelationships(snapshot))
+	require.Equal(t, 2, countRelationships(current))
+
+	_, err = common.WriteTuples(ctx, snapshot, core.RelationTupleUpdate_CREATE, tuple.MustParse("document:third#viewer@user:tom"))
+	require.Error(t, err)
+
+	// Snapshots are only visible to the token which created them.
+	_, _, err = m.datastoreForRequest(contextWithToken("othertoken", RequestSnapshot, "before"), false)
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	// Replacing a snapshot closes the one replaced.
+	replaced, _, err := m.datastoreForRequest(contextWithToken("sometoken", RequestCreateSnapshot, "before", RequestSnapshot, "before"), false)
+	require.NoError(t, err)
+	require.Equal(t, 2, countRelationships(replaced))
+	requireClosed(t, snapshot)
+
+	_, _, err = m.datastoreForRequest(contextWithToken("sometoken", RequestDeleteSnapshot, "before"), false)
+	require.NoError(t, err)
+	requireClosed(t, replaced)
+
+	_, _, err = m.datastoreForRequest(contextWithToken("sometoken", RequestSnapshot, "before"), false)
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestReloadConfigs(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig := func(relationships string) {
+		require.NoError(t, os.WriteFile(configFile, []byte(`---
+schema: >-
+  definition user {}
+
+  definition document {
+      relation viewer: user
+  }
+relationships: >-
+  `+relationships+`
+`), 0o600))
+	}
+
+	writeConfig("document:first#viewer@user:tom")
+
+	m := NewMiddleware([]string{configFile})
+	ctx := contextWithToken("sometoken")
+
+	td, err := m.getOrCreateDatastore(ctx)
+	require.NoError(t, err)
+
+	_, err = td.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		return rwt.WriteRelationships(ctx, []*core.RelationTupleUpdate{
+			tuple.Create(tuple.MustParse("document:first#viewer@user:sarah")),
+		})
+	})
+	require.NoError(t, err)
+
+	requireRelationships := func(expected ...string) {
+		found, err := relationshipsByKey(ctx, td)
+		require.NoError(t, err)
+
+		keys := make([]string, 0, len(found))
+		for key := range found {
+			keys = append(keys, key)
+		}
+		require.ElementsMatch(t, expected, keys)
+	}
+
+	// Adding keeps the relationships written since the config files were loaded.
+	writeConfig("document:first#viewer@user:tom\n\n  document:second#viewer@user:tom")
+	summary, err := m.reloadConfigs(ctx, td, ReloadAdd)
+	require.NoError(t, err)
+	require.Equal(t, ReloadSummary{RelationshipsAdded: 1}, summary)
+	requireRelationships(
+		"document:first#viewer@user:tom",
+		"document:first#viewer@user:sarah",
+		"document:second#viewer@user:tom",
+	)
+
+	// Reconciling deletes them.
+	summary, err = m.reloadConfigs(ctx, td, ReloadReconcile)
+	require.NoError(t, err)
+	require.Equal(t, ReloadSummary{RelationshipsRemoved: 1}, summary)
+	requireRelationships(
+		"document:first#viewer@user:tom",
+		"document:second#viewer@user:tom",
+	)
+
+	// An unknown mode is rejected.
+	_, _, err = m.datastoreForRequest(contextWithToken("sometoken", RequestReloadConfigs, "replace"), false)
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestWatchConfigs(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config.yaml")
+	writeConfig := func(relationships string) {
+		require.NoError(t, os.WriteFile(configFile, []byte(`---
+schema: >-
+  definition user {}
+
+  definition document {
+      relation viewer: user
+  }
+relationships: >-
+  `+relationships+`
+`), 0o600))
+	}
+
+	writeConfig("document:first#viewer@user:tom")
+
+	m := NewMiddleware([]string{configFile})
+	ctx, cancel := context.WithCancel(contextWithToken("sometoken"))
+	t.Cleanup(cancel)
+
+	initial, err := m.getOrCreateDatastore(ctx)
+	require.NoError(t, err)
+
+	watchErr := make(chan error, 1)
+	go func() {
+		watchErr <- m.WatchConfigs(ctx)
+	}()
+
+	currentRelationships := func() []string {
+		td, err := m.getOrCreateDatastore(ctx)
+		require.NoError(t, err)
+
+		found, err := relationshipsByKey(ctx, td)
+		require.NoError(t, err)
+
+		keys := make([]string, 0, len(found))
+		for key := range found {
+			keys = append(keys, key)
+		}
+		return keys
+	}
+
+	// Changing the file replaces the datastore of the token with one loaded from the new file.
+	require.Eventually(t, func() bool {
+		writeConfig("document:first#viewer@user:tom\n\n  document:second#viewer@user:tom")
+		return len(currentRelationships()) == 2
+	}, 5*time.Second, 200*time.Millisecond)
+	require.ElementsMatch(t, []string{"document:first#viewer@user:tom", "document:second#viewer@user:tom"}, currentRelationships())
+
+	rebuilt, err := m.getOrCreateDatastore(ctx)
+	require.NoError(t, err)
+	require.NotSame(t, initial, rebuilt)
+	require.NotEqual(t, initial.scope, rebuilt.scope)
+
+	// The replaced datastore is closed.
+	requireClosed(t, initial)
+
+	// An invalid file keeps the existing datastore.
+	writeConfig("document:first#unknown@user:tom")
+	_, err = m.rebuildDatastores(ctx)
+	require.Error(t, err)
+
+	current, err := m.getOrCreateDatastore(ctx)
+	require.NoError(t, err)
+	require.Same(t, rebuilt, current)
+
+	// Files outside of the config paths are ignored.
+	require.True(t, m.isConfigFile(configFile))
+	require.False(t, m.isConfigFile(filepath.Join(dir, "other.yaml")))
+
+	cancel()
+	require.NoError(t, <-watchErr)
+}
+
+func TestConfigPathsWatched(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "fixtures"), 0o700))
+
+	m := NewMiddleware([]string{
+		filepath.Join(dir, "config.yaml"),
+		filepath.Join(dir, "fixtures"),
+		filepath.Join(dir, "patterns", "*.yaml"),
+		"https://example.com/config.yaml",
+	})
+
+	require.Equal(t, []string{dir, filepath.Join(dir, "fixtures"), filepath.Join(dir, "patterns")}, m.configDirectories())
+
+	require.True(t, m.isConfigFile(filepath.Join(dir, "config.yaml")))
+	require.True(t, m.isConfigFile(filepath.Join(dir, "fixtures", "new.yml")))
+	require.False(t, m.isConfigFile(filepath.Join(dir, "fixtures", "notes.txt")))
+	require.True(t, m.isConfigFile(filepath.Join(dir, "patterns", "added.yaml")))
+	require.False(t, m.isConfigFile(filepath.Join(dir, "patterns", "added.json")))
+}
+
+func TestResetDatastore(t *testing.T) {
+	m := NewMiddleware(nil)
+
+	first, _, err := m.datastoreForRequest(contextWithToken("first"), false)
+	require.NoError(t, err)
+
+	second, _, err := m.datastoreForRequest(contextWithToken("second"), false)
+	require.NoError(t, err)
+
+	// Resetting the token of the request handles it against a new datastore.
+	reset, _, err := m.datastoreForRequest(contextWithToken("first", RequestResetDatastore, "token"), false)
+	require.NoError(t, err)
+	require.NotSame(t, first, reset)
+	requireClosed(t, first)
+
+	current, _, err := m.datastoreForRequest(contextWithToken("first"), false)
+	require.NoError(t, err)
+	require.Same(t, reset, current)
+
+	current, _, err = m.datastoreForRequest(contextWithToken("second"), false)
+	require.NoError(t, err)
+	require.Same(t, second, current)
+
+	// Resetting all tokens must be enabled.
+	_, _, err = m.datastoreForRequest(contextWithToken("first", RequestResetDatastore, "all"), false)
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, _, err = m.datastoreForRequest(contextWithToken("first", RequestResetDatastore, "some"), false)
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	WithResetAllDatastores(true)(m)
+	_, _, err = m.datastoreForRequest(contextWithToken("first", RequestResetDatastore, "all"), false)
+	require.NoError(t, err)
+
+	current, _, err = m.datastoreForRequest(contextWithToken("second"), false)
+	require.NoError(t, err)
+	require.NotSame(t, second, current)
+	requireClosed(t, second)
+	requireClosed(t, reset)
+}
+
+func TestReadOnlyRejectsMutatingHeaders(t *testing.T) {
+	m := NewMiddleware(nil)
+
+	initial, _, err := m.datastoreForRequest(contextWithToken("sometoken"), true)
+	require.NoError(t, err)
+
+	_, _, err = m.datastoreForRequest(contextWithToken("sometoken", RequestCreateSnapshot, "existing"), false)
+	require.NoError(t, err)
+
+	for _, headers := range [][]string{
+		{RequestResetDatastore, "token"},
+		{RequestCreateSnapshot, "other"},
+		{RequestDeleteSnapshot, "existing"},
+		{RequestReloadConfigs, "add"},
+	} {
+		_, _, err := m.datastoreForRequest(contextWithToken("sometoken", headers...), true)
+		require.Equal(t, codes.PermissionDenied, status.Code(err), headers[0])
+	}
+
+	// The datastore of the token and its snapshots are left in place.
+	current, _, err := m.datastoreForRequest(contextWithToken("sometoken"), true)
+	require.NoError(t, err)
+	require.Same(t, initial, current)
+
+	_, _, err = m.datastoreForRequest(contextWithToken("sometoken", RequestSnapshot, "other"), true)
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	// Existing snapshots can still be selected.
+	_, _, err = m.datastoreForRequest(contextWithToken("sometoken", RequestSnapshot, "existing"), true)
+	require.NoError(t, err)
+}
+
+func TestTokenDatastoreMetrics(t *testing.T) {
+	m := NewMiddleware(nil)
+
+	td, err := m.getOrCreateDatastore(contextWithToken("first"))
+	require.NoError(t, err)
+
+	_, err = m.getOrCreateDatastore(contextWithToken("second"))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	_, err = td.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		if err := rwt.WriteNamespaces(ctx, ns.Namespace("user"), ns.Namespace("document", ns.MustRelation("viewer", nil, ns.AllowedRelation("user", "...")))); err != nil {
+			return err
+		}
+		return rwt.WriteRelationships(ctx, []*core.RelationTupleUpdate{
+			tuple.Create(tuple.MustParse("document:first#viewer@user:tom")),
+			tuple.Create(tuple.MustParse("document:first#viewer@user:sarah")),
+		})
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, promtestutil.CollectAndCompare(m, strings.NewReader(`
+# HELP spicedb_testserver_token_datastore_relationships distribution of the number of relationships in each token datastore
+# TYPE spicedb_testserver_token_datastore_relationships histogram
+spicedb_testserver_token_datastore_relationships_bucket{le="10"} 2
+spicedb_testserver_token_datastore_relationships_bucket{le="100"} 2
+spicedb_testserver_token_datastore_relationships_bucket{le="1000"} 2
+spicedb_testserver_token_datastore_relationships_bucket{le="10000"} 2
+spicedb_testserver_token_datastore_relationships_bucket{le="100000"} 2
+spicedb_testserver_token_datastore_relationships_bucket{le="1e+06"} 2
+spicedb_testserver_token_datastore_relationships_bucket{le="+Inf"} 2
+spicedb_testserver_token_datastore_relationships_sum 2
+spicedb_testserver_token_datastore_relationships_count 2
+# HELP spicedb_testserver_token_datastores number of token datastores currently kept
+# TYPE spicedb_testserver_token_datastores gauge
+spicedb_testserver_token_datastores 2
+`)))
+}
+
+func TestReadyState(t *testing.T) {
+	state, err := NewMiddleware(nil).ReadyState(context.Background())
+	require.NoError(t, err)
+	require.True(t, state.IsReady)
+
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte("schema: [unclosed"), 0o600))
+
+	m := NewMiddleware([]string{configFile})
+	state, err = m.ReadyState(context.Background())
+	require.NoError(t, err)
+	require.False(t, state.IsReady)
+	require.Contains(t, state.Message, "failed to load config files")
+
+	// A failed load is retried on the next probe.
+	require.NoError(t, os.WriteFile(configFile, []byte("schema: definition user {}"), 0o600))
+	state, err = m.ReadyState(context.Background())
+	require.NoError(t, err)
+	require.True(t, state.IsReady)
+
+	// A successful load is kept until the files are next loaded for a token.
+	require.NoError(t, os.WriteFile(configFile, []byte("schema: [unclosed"), 0o600))
+	state, err = m.ReadyState(context.Background())
+	require.NoError(t, err)
+	require.True(t, state.IsReady)
+
+	_, err = m.getOrCreateDatastore(contextWithToken("sometoken"))
+	require.Error(t, err)
+
+	state, err = m.ReadyState(context.Background())
+	require.NoError(t, err)
+	require.False(t, state.IsReady)
+}
+
+func TestDatastoreStatistics(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte(`---
+schema: >-
+  definition user {}
+
+  definition document {
+      relation viewer: user
+  }
+relationships: >-
+  document:first#viewer@user:tom
+
+  document:second#viewer@user:tom
+`), 0o600))
+
+	// Statistics are computed through the proxy limiting concurrent writes.
+	m := NewMiddleware([]string{configFile}, WithMaxConcurrentWritesPerToken(1))
+	ctx := contextWithToken("sometoken", RequestStatistics, "true")
+
+	ds, _, err := m.datastoreForRequest(ctx, false)
+	require.NoError(t, err)
+
+	stats, err := statisticsOf(ctx, ds)
+	require.NoError(t, err)
+	require.Equal(t, datastoreStatistics{
+		Relationships:            2,
+		Namespaces:               2,
+		RelationshipsByNamespace: map[string]uint64{"document": 2, "user": 0},
+	}, stats)
+}
+
+func TestDatastoreStatisticsEncodeWithMaxSize(t *testing.T) {
+	stats := datastoreStatistics{
+		Relationships:            1006,
+		Namespaces:               1000,
+		RelationshipsByNamespace: make(map[string]uint64, 1000),
+	}
+	for i := 0; i < 1000; i++ {
+		stats.RelationshipsByNamespace[fmt.Sprintf("namespace%04d", i)] = 1
+	}
+	stats.RelationshipsByNamespace["namespace0999"] = 7
+
+	encoded, err := stats.encodeWithMaxSize(maxStatisticsTrailerSize)
+	require.NoError(t, err)
+	require.LessOrEqual(t, len(encoded), maxStatisticsTrailerSize)
+
+	var decoded datastoreStatistics
+	require.NoError(t, json.Unmarshal(encoded, &decoded))
+	require.Equal(t, stats.Relationships, decoded.Relationships)
+	require.Equal(t, stats.Namespaces, decoded.Namespaces)
+	require.Positive(t, decoded.OmittedNamespaces)
+	require.Equal(t, 1000, len(decoded.RelationshipsByNamespace)+decoded.OmittedNamespaces)
+
+	// The namespaces with the most relationships are kept.
+	require.Equal(t, uint64(7), decoded.RelationshipsByNamespace["namespace0999"])
+	require.Contains(t, decoded.RelationshipsByNamespace, "namespace0000")
+
+	// Statistics within the size are encoded in full.
+	encoded, err = stats.encodeWithMaxSize(1024 * 1024)
+	require.NoError(t, err)
+
+	decoded = datastoreStatistics{}
+	require.NoError(t, json.Unmarshal(encoded, &decoded))
+	require.Equal(t, stats, decoded)
+}
@@ -0,0 +1,34 @@
+package pertoken
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// readOnlyRejectedHeaders are the request headers which change the datastores of the middleware,
+// and are therefore rejected by its read-only interceptors.
+var readOnlyRejectedHeaders = []string{
+	RequestResetDatastore,
+	RequestCreateSnapshot,
+	RequestDeleteSnapshot,
+	RequestReloadConfigs,
+}
+
+// rejectReadOnlyHeaders returns a PermissionDenied error if the request has any of the headers
+// rejected on read-only servers.
+func rejectReadOnlyHeaders(ctx context.Context) error {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil
+	}
+
+	for _, header := range readOnlyRejectedHeaders {
+		if len(md.Get(header)) > 0 {
+			return status.Errorf(codes.PermissionDenied, "the %s header is not allowed on the read-only server", header)
+		}
+	}
+	return nil
+}
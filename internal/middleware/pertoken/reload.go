@@ -0,0 +1,172 @@
+package pertoken
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/authzed/authzed-go/pkg/responsemeta"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	log "github.com/authzed/spicedb/internal/logging"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+	"github.com/authzed/spicedb/pkg/validationfile"
+)
+
+const (
+	// RequestReloadConfigs is the request header which, when present, reloads the config files
+	// into the token's datastore before the request is handled, updating the datastore in place.
+	// With a value of `add`, the schema and relationships of the files are written over the
+	// existing data. With a value of `reconcile`, relationships not found in any of the files are
+	// then also deleted. The reloaded data is written at new revisions, so requests reading it
+	// should use full consistency. It is rejected by read-only servers.
+	RequestReloadConfigs = "io.spicedb.requestreloadconfigs"
+
+	// ReloadedRelationshipsAdded is the response trailer containing the number of relationships
+	// written by a reload which were not previously found in the token's datastore.
+	ReloadedRelationshipsAdded responsemeta.ResponseMetadataTrailerKey = "io.spicedb.respmeta.reloadedrelationshipsadded"
+
+	// ReloadedRelationshipsRemoved is the response trailer containing the number of relationships
+	// deleted by a reload in `reconcile` mode.
+	ReloadedRelationshipsRemoved responsemeta.ResponseMetadataTrailerKey = "io.spicedb.respmeta.reloadedrelationshipsremoved"
+)
+
+// ReloadMode defines whether a reload of the config files deletes the relationships not found in
+// the files.
+type ReloadMode int
+
+const (
+	// ReloadAdd writes the contents of the config files over the existing data.
+	ReloadAdd ReloadMode = iota
+
+	// ReloadReconcile writes the contents of the config files over the existing data, and then
+	// deletes the relationships not found in any of the files.
+	ReloadReconcile
+)
+
+var reloadModeNames = map[string]ReloadMode{
+	"add":       ReloadAdd,
+	"reconcile": ReloadReconcile,
+}
+
+// ReloadSummary describes the changes made by a reload of the config files.
+type ReloadSummary struct {
+	// RelationshipsAdded is the number of relationships written which were not previously found.
+	RelationshipsAdded int
+
+	// RelationshipsRemoved is the number of relationships deleted because they were not found in
+	// any of the config files.
+	RelationshipsRemoved int
+}
+
+// applyReloadHeader reloads the config files into the datastore of the token if requested in the
+// request headers, and reports the changes made in the response trailers.
+func (m *MiddlewareForTesting) applyReloadHeader(ctx context.Context, td *tokenDatastore) error {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil
+	}
+
+	modes := md.Get(RequestReloadConfigs)
+	if len(modes) == 0 {
+		return nil
+	}
+
+	mode, ok := reloadModeNames[modes[0]]
+	if !ok {
+		return status.Errorf(codes.InvalidArgument, "unknown reload mode `%s`; must be one of `add` or `reconcile`", modes[0])
+	}
+
+	summary, err := m.reloadConfigs(ctx, td.Datastore, mode)
+	if err != nil {
+		return status.Errorf(codes.FailedPrecondition, "failed to reload config files: %s", err)
+	}
+
+	log.Ctx(ctx).Info().
+		Int("relationshipsAdded", summary.RelationshipsAdded).
+		Int("relationshipsRemoved", summary.RelationshipsRemoved).
+		Msg("reloaded config files for token")
+
+	if err := responsemeta.SetResponseTrailerMetadata(ctx, map[responsemeta.ResponseMetadataTrailerKey]string{
+		ReloadedRelationshipsAdded:   strconv.Itoa(summary.RelationshipsAdded),
+		ReloadedRelationshipsRemoved: strconv.Itoa(summary.RelationshipsRemoved),
+	}); err != nil {
+		log.Ctx(ctx).Warn().Err(err).Msg("could not report reload of config files")
+	}
+	return nil
+}
+
+// reloadConfigs writes the contents of each config file into the given datastore, in a separate
+// transaction per file, and in ReloadReconcile mode then deletes, in a final transaction, the
+// relationships not found in any of the files. A failure to load a file leaves the changes made by
+// the files before it in place.
+func (m *MiddlewareForTesting) reloadConfigs(ctx context.Context, ds datastore.Datastore, mode ReloadMode) (ReloadSummary, error) {
+	existing, err := relationshipsByKey(ctx, ds)
+	if err != nil {
+		return ReloadSummary{}, err
+	}
+
+	var summary ReloadSummary
+	loaded := make(map[string]struct{}, len(existing))
+	for _, filePath := range m.configFilePaths {
+		populated, _, err := validationfile.PopulateFromFiles(ctx, ds, []string{filePath})
+		if err != nil {
+			return summary, err
+		}
+
+		for _, tpl := range populated.Tuples {
+			key := tuple.StringWithoutCaveat(tpl)
+			if _, ok := existing[key]; !ok {
+				if _, ok := loaded[key]; !ok {
+					summary.RelationshipsAdded++
+				}
+			}
+			loaded[key] = struct{}{}
+		}
+	}
+
+	if mode != ReloadReconcile {
+		return summary, nil
+	}
+
+	var deletions []*core.RelationTupleUpdate
+	for key, tpl := range existing {
+		if _, ok := loaded[key]; !ok {
+			deletions = append(deletions, tuple.Delete(tpl))
+		}
+	}
+
+	if len(deletions) == 0 {
+		return summary, nil
+	}
+
+	if _, err := ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		return rwt.WriteRelationships(ctx, deletions)
+	}); err != nil {
+		return summary, err
+	}
+
+	summary.RelationshipsRemoved = len(deletions)
+	return summary, nil
+}
+
+// relationshipsByKey returns the relationships found in the given datastore at its head revision,
+// keyed by their string form without caveats.
+func relationshipsByKey(ctx context.Context, ds datastore.Datastore) (map[string]*core.RelationTuple, error) {
+	state, err := datastore.ReadHeadState(ctx, ds)
+	if err != nil {
+		return nil, err
+	}
+
+	relationships := make(map[string]*core.RelationTuple)
+	if err := state.ForEachRelationship(ctx, func(tpl *core.RelationTuple) error {
+		relationships[tuple.StringWithoutCaveat(tpl)] = tpl.CloneVT()
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return relationships, nil
+}
@@ -0,0 +1,73 @@
+package pertoken
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	log "github.com/authzed/spicedb/internal/logging"
+)
+
+// RequestResetDatastore is the request header which, when present, discards datastores before
+// the request is handled, so that the next request for each token discarded starts from a new
+// datastore initialized from the config files, including the request itself. With a value of
+// `token`, only the datastore of the token of the request is discarded. With a value of `all`,
+// the datastores of all tokens are discarded, if enabled with WithResetAllDatastores. It is
+// rejected by read-only servers.
+const RequestResetDatastore = "io.spicedb.requestresetdatastore"
+
+// WithResetAllDatastores sets whether requests may discard the datastores of all tokens with the
+// RequestResetDatastore header.
+//
+// default: false
+func WithResetAllDatastores(enabled bool) Option {
+	return func(m *MiddlewareForTesting) {
+		m.resetAllDatastores = enabled
+	}
+}
+
+// applyResetHeader discards the datastores requested in the request headers.
+func (m *MiddlewareForTesting) applyResetHeader(ctx context.Context) error {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil
+	}
+
+	modes := md.Get(RequestResetDatastore)
+	if len(modes) == 0 {
+		return nil
+	}
+
+	switch modes[0] {
+	case "token":
+		tokenStr := m.datastoreKey(ctx)
+		if existing, loaded := m.datastoreByToken.LoadAndDelete(tokenStr); loaded {
+			if err := existing.(*tokenDatastore).close(); err != nil {
+				log.Ctx(ctx).Warn().Err(err).Str("token", tokenStr).Msg("failed to close reset datastore")
+			}
+		}
+		log.Ctx(ctx).Debug().Str("token", tokenStr).Msg("reset datastore for token")
+		return nil
+
+	case "all":
+		if !m.resetAllDatastores {
+			return status.Errorf(codes.PermissionDenied, "resetting the datastores of all tokens is not enabled")
+		}
+
+		m.datastoreByToken.Range(func(key, _ any) bool {
+			if existing, loaded := m.datastoreByToken.LoadAndDelete(key); loaded {
+				if err := existing.(*tokenDatastore).close(); err != nil {
+					log.Ctx(ctx).Warn().Err(err).Str("token", key.(string)).Msg("failed to close reset datastore")
+				}
+			}
+			return true
+		})
+		log.Ctx(ctx).Info().Msg("reset datastores for all tokens")
+		return nil
+
+	default:
+		return status.Errorf(codes.InvalidArgument, "unknown reset mode `%s`; must be one of `token` or `all`", modes[0])
+	}
+}
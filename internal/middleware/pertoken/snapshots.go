@@ -0,0 +1,129 @@
+package pertoken
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/authzed/spicedb/internal/datastore/memdb"
+	"github.com/authzed/spicedb/internal/datastore/proxy"
+	log "github.com/authzed/spicedb/internal/logging"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+const (
+	// RequestCreateSnapshot is the request header which, when present, stores a copy of the
+	// current state of the token's datastore as a snapshot with the given name before the request
+	// is handled, replacing any existing snapshot with that name. It is rejected by read-only
+	// servers.
+	RequestCreateSnapshot = "io.spicedb.requestcreatesnapshot"
+
+	// RequestDeleteSnapshot is the request header which, when present, deletes the token's
+	// snapshot with the given name before the request is handled, if it exists. It is rejected by
+	// read-only servers.
+	RequestDeleteSnapshot = "io.spicedb.requestdeletesnapshot"
+
+	// RequestSnapshot is the request header which, when present, handles the request against the
+	// token's snapshot with the given name rather than its current datastore. Snapshots are
+	// read-only, and have their own revisions, so requests against them should not use ZedTokens
+	// issued by the current datastore.
+	RequestSnapshot = "io.spicedb.requestsnapshot"
+)
+
+// snapshotDatastore is the read-only datastore of a snapshot, along with its scope.
+type snapshotDatastore struct {
+	datastore.Datastore
+	scope string
+}
+
+// applySnapshotHeaders deletes or creates the snapshots of the token named in the request
+// headers, and returns the datastore against which the request should be handled, along with its
+// scope.
+func (m *MiddlewareForTesting) applySnapshotHeaders(ctx context.Context, td *tokenDatastore) (datastore.Datastore, string, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return td.Datastore, td.scope, nil
+	}
+
+	for _, name := range md.Get(RequestDeleteSnapshot) {
+		if existing, loaded := td.snapshots.LoadAndDelete(name); loaded {
+			if err := existing.(*snapshotDatastore).Close(); err != nil {
+				log.Ctx(ctx).Warn().Err(err).Str("snapshot", name).Msg("failed to close deleted snapshot")
+			}
+		}
+		log.Ctx(ctx).Debug().Str("snapshot", name).Msg("deleted snapshot for token")
+	}
+
+	for _, name := range md.Get(RequestCreateSnapshot) {
+		snapshot, err := m.copyDatastore(ctx, td.Datastore)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to create snapshot `%s`: %w", name, err)
+		}
+		if existing, loaded := td.snapshots.Swap(name, &snapshotDatastore{snapshot, uuid.NewString()}); loaded {
+			if err := existing.(*snapshotDatastore).Close(); err != nil {
+				log.Ctx(ctx).Warn().Err(err).Str("snapshot", name).Msg("failed to close replaced snapshot")
+			}
+		}
+		log.Ctx(ctx).Debug().Str("snapshot", name).Msg("created snapshot for token")
+	}
+
+	names := md.Get(RequestSnapshot)
+	if len(names) == 0 {
+		return td.Datastore, td.scope, nil
+	}
+
+	snapshot, ok := td.snapshots.Load(names[0])
+	if !ok {
+		return nil, "", status.Errorf(codes.NotFound, "snapshot `%s` not found", names[0])
+	}
+	sd := snapshot.(*snapshotDatastore)
+	return sd.Datastore, sd.scope, nil
+}
+
+// copyDatastore returns a new read-only datastore holding the schema and relationships found in
+// the given datastore at its head revision.
+func (m *MiddlewareForTesting) copyDatastore(ctx context.Context, ds datastore.Datastore) (datastore.Datastore, error) {
+	state, err := datastore.ReadHeadState(ctx, ds)
+	if err != nil {
+		return nil, err
+	}
+
+	var updates []*core.RelationTupleUpdate
+	if err := state.ForEachRelationship(ctx, func(tpl *core.RelationTuple) error {
+		updates = append(updates, tuple.Create(tpl.CloneVT()))
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	snapshot, err := memdb.NewMemdbDatastore(0, m.revisionQuantization, m.gcWindow)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = snapshot.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		if err := rwt.WriteCaveats(ctx, datastore.DefinitionsOf(state.Caveats)); err != nil {
+			return err
+		}
+
+		if err := rwt.WriteNamespaces(ctx, datastore.DefinitionsOf(state.Namespaces)...); err != nil {
+			return err
+		}
+
+		return rwt.WriteRelationships(ctx, updates)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Squash the revisions so that the caller sees all the copied data.
+	snapshot.(squashable).SquashRevisionsForTesting()
+
+	return proxy.NewReadonlyDatastore(snapshot), nil
+}
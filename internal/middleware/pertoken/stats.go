@@ -0,0 +1,137 @@
+package pertoken
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+
+	"github.com/authzed/authzed-go/pkg/responsemeta"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	log "github.com/authzed/spicedb/internal/logging"
+	"github.com/authzed/spicedb/pkg/datastore"
+)
+
+const (
+	// RequestStatistics is the request header which, when present, reports the statistics of the
+	// datastore against which the request is handled, as found before the request is handled, in
+	// the DatastoreStatisticsTrailer response trailer.
+	RequestStatistics = "io.spicedb.requeststatistics"
+
+	// DatastoreStatisticsTrailer is the response trailer containing the statistics requested with
+	// the RequestStatistics header, encoded as a JSON object with the `relationships`, `namespaces`,
+	// `relationshipsByNamespace` and `omittedNamespaces` fields. The namespaces with the fewest
+	// relationships are omitted from `relationshipsByNamespace` as necessary to keep the trailer
+	// within maxStatisticsTrailerSize, and counted in `omittedNamespaces`.
+	DatastoreStatisticsTrailer responsemeta.ResponseMetadataTrailerKey = "io.spicedb.respmeta.datastorestatistics"
+)
+
+// maxStatisticsTrailerSize is the maximum size, in bytes, of the DatastoreStatisticsTrailer. Proxies
+// commonly limit the combined size of the headers and trailers of a response to a few tens of KiB.
+const maxStatisticsTrailerSize = 8 * 1024
+
+// datastoreStatistics describes the size of the datastore of a token at its head revision.
+type datastoreStatistics struct {
+	// Relationships is the total number of relationships.
+	Relationships uint64 `json:"relationships"`
+
+	// Namespaces is the number of namespaces defined.
+	Namespaces int `json:"namespaces"`
+
+	// RelationshipsByNamespace is the number of relationships whose resource is of each defined
+	// namespace, keyed by namespace name.
+	RelationshipsByNamespace map[string]uint64 `json:"relationshipsByNamespace"`
+
+	// OmittedNamespaces is the number of namespaces omitted from RelationshipsByNamespace by
+	// encodeWithMaxSize.
+	OmittedNamespaces int `json:"omittedNamespaces,omitempty"`
+}
+
+// statisticsOf returns the statistics of the given datastore, which must compute the number of
+// relationships of each namespace, as memdb does.
+func statisticsOf(ctx context.Context, ds datastore.Datastore) (datastoreStatistics, error) {
+	stats, err := ds.Statistics(ctx)
+	if err != nil {
+		return datastoreStatistics{}, err
+	}
+
+	return datastoreStatistics{
+		Relationships:            stats.EstimatedRelationshipCount,
+		Namespaces:               len(stats.ObjectTypeStatistics),
+		RelationshipsByNamespace: stats.RelationshipCountByObjectType,
+	}, nil
+}
+
+// encodeWithMaxSize returns the statistics encoded as JSON in at most maxSize bytes, omitting the
+// namespaces with the fewest relationships from RelationshipsByNamespace as necessary and recording
+// how many were omitted.
+func (stats datastoreStatistics) encodeWithMaxSize(maxSize int) ([]byte, error) {
+	names := make([]string, 0, len(stats.RelationshipsByNamespace))
+	for name := range stats.RelationshipsByNamespace {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		left, right := stats.RelationshipsByNamespace[names[i]], stats.RelationshipsByNamespace[names[j]]
+		if left != right {
+			return left > right
+		}
+		return names[i] < names[j]
+	})
+
+	kept := len(names)
+	for {
+		summarized := datastoreStatistics{
+			Relationships:            stats.Relationships,
+			Namespaces:               stats.Namespaces,
+			RelationshipsByNamespace: make(map[string]uint64, kept),
+			OmittedNamespaces:        len(names) - kept,
+		}
+		for _, name := range names[:kept] {
+			summarized.RelationshipsByNamespace[name] = stats.RelationshipsByNamespace[name]
+		}
+
+		encoded, err := json.Marshal(summarized)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(encoded) <= maxSize || kept == 0 {
+			return encoded, nil
+		}
+
+		// Shrink in proportion to the excess, and by at least one namespace.
+		shrunk := kept * maxSize / len(encoded)
+		if shrunk >= kept {
+			shrunk = kept - 1
+		}
+		kept = shrunk
+	}
+}
+
+// applyStatisticsHeader reports the statistics of the given datastore in the response trailers if
+// requested in the request headers.
+func (m *MiddlewareForTesting) applyStatisticsHeader(ctx context.Context, ds datastore.Datastore) error {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok || len(md.Get(RequestStatistics)) == 0 {
+		return nil
+	}
+
+	stats, err := statisticsOf(ctx, ds)
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to compute datastore statistics: %s", err)
+	}
+
+	encoded, err := stats.encodeWithMaxSize(maxStatisticsTrailerSize)
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to encode datastore statistics: %s", err)
+	}
+
+	if err := responsemeta.SetResponseTrailerMetadata(ctx, map[responsemeta.ResponseMetadataTrailerKey]string{
+		DatastoreStatisticsTrailer: string(encoded),
+	}); err != nil {
+		log.Ctx(ctx).Warn().Err(err).Msg("could not report datastore statistics")
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package pertoken
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	log "github.com/authzed/spicedb/internal/logging"
+	"github.com/authzed/spicedb/pkg/validationfile"
+)
+
+// configWatchDebounce is the time to wait after a change to the config files before reloading
+// them, so that a file written in several steps, as many editors do, is only reloaded once.
+const configWatchDebounce = 100 * time.Millisecond
+
+// WatchConfigs watches the config files until the context is canceled, and whenever any of them
+// changes, replaces the datastore of every token with a new datastore initialized from the files.
+// Relationships written and snapshots created by the tokens are discarded. Directories and glob
+// patterns are watched for files being added and removed as well. If the changed files cannot be
+// loaded, the error is logged and the existing datastores are kept.
+func (m *MiddlewareForTesting) WatchConfigs(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to watch config files: %w", err)
+	}
+	defer watcher.Close()
+
+	// Directories rather than files are watched, so that files replaced by a rename, as many
+	// editors do, are still watched after they are replaced.
+	for _, dir := range m.configDirectories() {
+		if err := watcher.Add(dir); err != nil {
+			log.Ctx(ctx).Warn().Err(err).Str("directory", dir).Msg("could not watch config directory")
+		}
+	}
+
+	var reload <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+
+			if !m.isConfigFile(event.Name) || event.Op == fsnotify.Chmod {
+				continue
+			}
+
+			log.Ctx(ctx).Debug().Str("file", event.Name).Str("op", event.Op.String()).Msg("config file changed")
+			reload = m.timeSource.After(configWatchDebounce)
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			log.Ctx(ctx).Warn().Err(err).Msg("error watching config files")
+
+		case <-reload:
+			reload = nil
+
+			rebuilt, err := m.rebuildDatastores(ctx)
+			if err != nil {
+				log.Ctx(ctx).Error().Err(err).Msg("failed to reload changed config files; keeping the existing datastores")
+				continue
+			}
+			log.Ctx(ctx).Info().Int("tokens", rebuilt).Msg("reloaded changed config files")
+		}
+	}
+}
+
+// rebuildDatastores replaces the datastore of every token with a new datastore initialized from
+// the config files, returning the number of datastores replaced. The replaced datastores are
+// closed, so writes still in flight against them fail.
+func (m *MiddlewareForTesting) rebuildDatastores(ctx context.Context) (int, error) {
+	// Load the files before replacing any datastore, so that invalid files are reported even
+	// without any token, and leave the datastores of all tokens in place. The datastore loaded
+	// replaces that of the first token.
+	next, err := m.newTokenDatastore(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	rebuilt := 0
+	var rerr error
+	m.datastoreByToken.Range(func(key, value any) bool {
+		if next == nil {
+			next, rerr = m.newTokenDatastore(ctx)
+			if rerr != nil {
+				return false
+			}
+		}
+
+		existing := value.(*tokenDatastore)
+		next.lastAccessNanos.Store(existing.lastAccessNanos.Load())
+		if !m.datastoreByToken.CompareAndSwap(key, existing, next) {
+			return true
+		}
+
+		rebuilt++
+		next = nil
+		if err := existing.close(); err != nil {
+			log.Ctx(ctx).Warn().Err(err).Str("token", key.(string)).Msg("failed to close replaced datastore")
+		}
+		return true
+	})
+
+	if next != nil {
+		if err := next.close(); err != nil {
+			log.Ctx(ctx).Warn().Err(err).Msg("failed to close unused datastore")
+		}
+	}
+	return rebuilt, rerr
+}
+
+// configDirectories returns the local directories in which the config files are found, or may
+// be added.
+func (m *MiddlewareForTesting) configDirectories() []string {
+	seen := map[string]struct{}{}
+	var dirs []string
+	add := func(dir string) {
+		dir = filepath.Clean(dir)
+		if _, ok := seen[dir]; !ok {
+			seen[dir] = struct{}{}
+			dirs = append(dirs, dir)
+		}
+	}
+
+	for _, configPath := range m.configFilePaths {
+		switch validationfile.KindOfPath(configPath) {
+		case validationfile.RemotePath:
+			continue
+
+		case validationfile.PatternPath:
+			// Watch the directories of the files currently matched, as well as the deepest
+			// directory without any pattern, in which new files may be matched.
+			matches, _ := filepath.Glob(configPath)
+			for _, match := range matches {
+				add(filepath.Dir(match))
+			}
+
+			dir := filepath.Dir(configPath)
+			for validationfile.KindOfPath(dir) == validationfile.PatternPath {
+				dir = filepath.Dir(dir)
+			}
+			add(dir)
+			continue
+		}
+
+		if info, err := os.Stat(configPath); err == nil && info.IsDir() {
+			add(configPath)
+			continue
+		}
+
+		add(filepath.Dir(configPath))
+	}
+	return dirs
+}
+
+// isConfigFile returns whether the file at the given path is, or would be, loaded from the config
+// file paths.
+func (m *MiddlewareForTesting) isConfigFile(filePath string) bool {
+	filePath = filepath.Clean(filePath)
+	for _, configPath := range m.configFilePaths {
+		switch validationfile.KindOfPath(configPath) {
+		case validationfile.RemotePath:
+			continue
+
+		case validationfile.PatternPath:
+			if matched, _ := filepath.Match(filepath.Clean(configPath), filePath); matched {
+				return true
+			}
+			continue
+		}
+
+		if filepath.Clean(configPath) == filePath {
+			return true
+		}
+
+		ext := filepath.Ext(filePath)
+		if filepath.Dir(filePath) == filepath.Clean(configPath) && (ext == ".yaml" || ext == ".yml") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,157 @@
+package revisiontimestamp
+
+import (
+	"context"
+	"time"
+
+	"github.com/authzed/authzed-go/pkg/responsemeta"
+	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	log "github.com/authzed/spicedb/internal/logging"
+	datastoremw "github.com/authzed/spicedb/internal/middleware/datastore"
+	"github.com/authzed/spicedb/pkg/datastore"
+	"github.com/authzed/spicedb/pkg/zedtoken"
+)
+
+// RequestRevisionTimestamp is the request header which, when present, asks that the
+// timestamp of the revision encoded in the response's ZedToken be returned.
+const RequestRevisionTimestamp = "io.spicedb.requestrevisiontimestamp"
+
+// RevisionTimestamp is the response trailer containing the timestamp, in RFC 3339
+// format, of the revision encoded in the ZedToken found in the response.
+const RevisionTimestamp responsemeta.ResponseMetadataTrailerKey = "io.spicedb.respmeta.revisiontimestamp"
+
+var zedTokenMessageName = (&v1.ZedToken{}).ProtoReflect().Descriptor().FullName()
+
+type reporter struct{}
+
+func (r *reporter) ServerReporter(ctx context.Context, _ interceptors.CallMeta) (interceptors.Reporter, context.Context) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return interceptors.NoopReporter{}, ctx
+	}
+
+	if _, isRequestingTimestamp := md[RequestRevisionTimestamp]; !isRequestingTimestamp {
+		return interceptors.NoopReporter{}, ctx
+	}
+
+	return &serverReporter{ctx: ctx}, ctx
+}
+
+type serverReporter struct {
+	interceptors.NoopReporter
+	ctx      context.Context
+	zedToken *v1.ZedToken
+}
+
+func (r *serverReporter) PostMsgSend(resp any, err error, _ time.Duration) {
+	if err != nil {
+		return
+	}
+
+	if found := zedTokenFromResponse(resp); found != nil {
+		r.zedToken = found
+	}
+}
+
+func (r *serverReporter) PostCall(err error, _ time.Duration) {
+	if err != nil || r.zedToken == nil {
+		return
+	}
+
+	ds := datastoremw.FromContext(r.ctx)
+	if ds == nil {
+		return
+	}
+
+	timestamp, ok, terr := revisionTimestamp(r.zedToken, ds)
+	if terr != nil {
+		log.Ctx(r.ctx).Warn().Err(terr).Msg("revisiontimestamp: could not compute timestamp for revision")
+		return
+	}
+
+	if !ok {
+		return
+	}
+
+	serr := responsemeta.SetResponseTrailerMetadata(r.ctx, map[responsemeta.ResponseMetadataTrailerKey]string{
+		RevisionTimestamp: timestamp.Format(time.RFC3339Nano),
+	})
+	// if context is cancelled, the stream will be closed, and gRPC will return ErrIllegalHeaderWrite
+	// this prevents logging unnecessary error messages
+	if r.ctx.Err() != nil {
+		return
+	}
+	if serr != nil {
+		log.Ctx(r.ctx).Warn().Err(serr).Msg("revisiontimestamp: could not report metadata")
+	}
+}
+
+// revisionTimestamp returns the timestamp of the revision encoded in the given ZedToken, if the
+// datastore supports mapping revisions to timestamps.
+func revisionTimestamp(token *v1.ZedToken, ds datastore.Datastore) (time.Time, bool, error) {
+	timestamper, ok := datastore.UnwrapAs[datastore.RevisionTimestamper](ds)
+	if !ok {
+		return time.Time{}, false, nil
+	}
+
+	rev, err := zedtoken.DecodeRevision(token, ds)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	timestamp, err := timestamper.RevisionTimestamp(rev)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	return timestamp, true, nil
+}
+
+// zedTokenFromResponse returns the first top-level ZedToken found in the response message,
+// if any.
+func zedTokenFromResponse(resp any) *v1.ZedToken {
+	msg, ok := resp.(proto.Message)
+	if !ok {
+		return nil
+	}
+
+	var found *v1.ZedToken
+	msg.ProtoReflect().Range(func(fd protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+		if fd.Kind() != protoreflect.MessageKind || fd.IsList() || fd.IsMap() {
+			return true
+		}
+
+		if fd.Message().FullName() != zedTokenMessageName {
+			return true
+		}
+
+		token, ok := value.Message().Interface().(*v1.ZedToken)
+		if !ok {
+			return true
+		}
+
+		found = token
+		return false
+	})
+	return found
+}
+
+// UnaryServerInterceptor returns a new interceptor which returns the timestamp of the
+// revision found in the response's ZedToken, when requested via the
+// RequestRevisionTimestamp header.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return interceptors.UnaryServerInterceptor(&reporter{})
+}
+
+// StreamServerInterceptor returns a new interceptor which returns the timestamp of the
+// revision found in the last ZedToken sent on the stream, when requested via the
+// RequestRevisionTimestamp header.
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return interceptors.StreamServerInterceptor(&reporter{})
+}
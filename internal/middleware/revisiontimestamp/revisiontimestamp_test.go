@@ -0,0 +1,42 @@
+package revisiontimestamp
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/internal/datastore/memdb"
+	"github.com/authzed/spicedb/internal/datastore/proxy"
+	"github.com/authzed/spicedb/pkg/datastore/revision"
+	"github.com/authzed/spicedb/pkg/zedtoken"
+)
+
+func TestZedTokenFromResponse(t *testing.T) {
+	token := &v1.ZedToken{Token: "sometoken"}
+
+	require.Nil(t, zedTokenFromResponse(nil))
+	require.Nil(t, zedTokenFromResponse(&v1.CheckPermissionResponse{}))
+	require.Equal(t, token, zedTokenFromResponse(&v1.CheckPermissionResponse{CheckedAt: token}))
+	require.Equal(t, token, zedTokenFromResponse(&v1.WriteRelationshipsResponse{WrittenAt: token}))
+	require.Equal(t, token, zedTokenFromResponse(&v1.ReadRelationshipsResponse{ReadAt: token}))
+}
+
+func TestRevisionTimestamp(t *testing.T) {
+	require := require.New(t)
+
+	ds, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(err)
+
+	expected := time.Date(2023, 7, 1, 14, 5, 0, 0, time.UTC)
+	token, err := zedtoken.NewFromRevision(revision.NewFromDecimal(decimal.NewFromInt(expected.UnixNano())))
+	require.NoError(err)
+
+	// Ensure the timestamp is found even when the datastore has been wrapped.
+	timestamp, ok, err := revisionTimestamp(token, proxy.NewObservableDatastoreProxy(ds))
+	require.NoError(err)
+	require.True(ok)
+	require.True(expected.Equal(timestamp))
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 
 	log "github.com/authzed/spicedb/internal/logging"
 	dispatch "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
@@ -33,27 +34,57 @@ var (
 	}, DispatchedCountLabels)
 )
 
+// RequestDispatchStats is the request header which, when present, adds the DispatchDepthRequired
+// and DispatchWallTime trailers to the response, alongside the dispatched and cached operation
+// counts returned for every request.
+const RequestDispatchStats = "io.spicedb.requestdispatchstats"
+
+// DispatchDepthRequired is the response trailer containing the maximum dispatch depth reached
+// while computing the response, returned when requested with RequestDispatchStats.
+const DispatchDepthRequired responsemeta.ResponseMetadataTrailerKey = "io.spicedb.respmeta.dispatchdepthrequired"
+
+// DispatchWallTime is the response trailer containing the time taken to handle the request, as a
+// duration such as `12.5ms`, returned when requested with RequestDispatchStats.
+const DispatchWallTime responsemeta.ResponseMetadataTrailerKey = "io.spicedb.respmeta.dispatchwalltime"
+
 type reporter struct{}
 
 func (r *reporter) ServerReporter(ctx context.Context, callMeta interceptors.CallMeta) (interceptors.Reporter, context.Context) {
 	_, methodName := grpcutil.SplitMethodName(callMeta.FullMethod())
 	ctx = ContextWithHandle(ctx)
-	return &serverReporter{ctx: ctx, methodName: methodName}, ctx
+	return &serverReporter{ctx: ctx, methodName: methodName, withStats: dispatchStatsRequested(ctx)}, ctx
+}
+
+func dispatchStatsRequested(ctx context.Context) bool {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return false
+	}
+
+	_, ok = md[RequestDispatchStats]
+	return ok
 }
 
 type serverReporter struct {
 	interceptors.NoopReporter
 	ctx        context.Context
 	methodName string
+	withStats  bool
 }
 
-func (r *serverReporter) PostCall(_ error, _ time.Duration) {
+func (r *serverReporter) PostCall(_ error, duration time.Duration) {
 	responseMeta := FromContext(r.ctx)
 	if responseMeta == nil {
 		responseMeta = &dispatch.ResponseMeta{}
 	}
 
 	err := annotateAndReportForMetadata(r.ctx, r.methodName, responseMeta)
+	if err == nil && r.withStats {
+		err = responsemeta.SetResponseTrailerMetadata(r.ctx, map[responsemeta.ResponseMetadataTrailerKey]string{
+			DispatchDepthRequired: strconv.Itoa(int(responseMeta.DepthRequired)),
+			DispatchWallTime:      duration.String(),
+		})
+	}
 	// if context is cancelled, the stream will be closed, and gRPC will return ErrIllegalHeaderWrite
 	// this prevents logging unnecessary error messages
 	if r.ctx.Err() != nil {
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"testing"
+	"time"
 
 	"github.com/authzed/authzed-go/pkg/responsemeta"
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/testing/testpb"
@@ -26,6 +27,7 @@ func (t testServer) PingEmpty(ctx context.Context, _ *testpb.PingEmptyRequest) (
 	SetInContext(ctx, &dispatch.ResponseMeta{
 		DispatchCount:       1,
 		CachedDispatchCount: 1,
+		DepthRequired:       3,
 	})
 	return &testpb.PingEmptyResponse{}, nil
 }
@@ -132,3 +134,24 @@ func (s *metricsMiddlewareTestSuite) TestTrailers_Stream() {
 	require.NoError(s.T(), err)
 	require.Equal(s.T(), 1, cachedCount)
 }
+
+func (s *metricsMiddlewareTestSuite) TestTrailers_DispatchStats() {
+	var trailerMD metadata.MD
+	_, err := s.Client.PingEmpty(s.SimpleCtx(), &testpb.PingEmptyRequest{}, grpc.Trailer(&trailerMD))
+	require.NoError(s.T(), err)
+	require.Empty(s.T(), trailerMD.Get(string(DispatchDepthRequired)))
+	require.Empty(s.T(), trailerMD.Get(string(DispatchWallTime)))
+
+	ctx := metadata.AppendToOutgoingContext(s.SimpleCtx(), RequestDispatchStats, "")
+	_, err = s.Client.PingEmpty(ctx, &testpb.PingEmptyRequest{}, grpc.Trailer(&trailerMD))
+	require.NoError(s.T(), err)
+
+	depthRequired, err := responsemeta.GetIntResponseTrailerMetadata(trailerMD, DispatchDepthRequired)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), 3, depthRequired)
+
+	wallTime, err := responsemeta.GetResponseTrailerMetadata(trailerMD, DispatchWallTime)
+	require.NoError(s.T(), err)
+	_, err = time.ParseDuration(wallTime)
+	require.NoError(s.T(), err)
+}
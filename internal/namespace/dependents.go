@@ -0,0 +1,133 @@
+package namespace
+
+import (
+	"sort"
+
+	"github.com/authzed/spicedb/pkg/graph"
+	nspkg "github.com/authzed/spicedb/pkg/namespace"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	iv1 "github.com/authzed/spicedb/pkg/proto/impl/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+// DependentPermissions returns the permissions, across all of the given definitions, whose
+// computation transitively includes the given relation or permission. A permission includes a
+// relation if it references it directly, references it on the subject of an arrow, or references
+// a relation or permission which in turn includes it, including via a subject relation such as
+// `relation viewer: group#member`. The returned permissions are sorted and never include the
+// starting relation itself.
+//
+// This is the forward complement of finding the relations upon which a permission depends: it
+// answers which permissions are affected by granting the given relation.
+func DependentPermissions(defs []*core.NamespaceDefinition, start *core.RelationReference) ([]*core.RelationReference, error) {
+	relations := make(map[string]*core.Relation)
+	foundNamespace := false
+	for _, def := range defs {
+		if def.Name == start.Namespace {
+			foundNamespace = true
+		}
+
+		for _, rel := range def.Relation {
+			relations[tuple.JoinRelRef(def.Name, rel.Name)] = rel
+		}
+	}
+
+	if !foundNamespace {
+		return nil, NewNamespaceNotFoundErr(start.Namespace)
+	}
+
+	startKey := tuple.JoinRelRef(start.Namespace, start.Relation)
+	if _, ok := relations[startKey]; !ok {
+		return nil, NewRelationNotFoundErr(start.Namespace, start.Relation)
+	}
+
+	dependents, err := buildDependentsGraph(defs, relations)
+	if err != nil {
+		return nil, err
+	}
+
+	visited := map[string]struct{}{startKey: {}}
+	toVisit := []string{startKey}
+	found := make([]*core.RelationReference, 0)
+	for len(toVisit) > 0 {
+		current := toVisit[0]
+		toVisit = toVisit[1:]
+
+		for _, dependent := range dependents[current] {
+			if _, ok := visited[dependent.key]; ok {
+				continue
+			}
+			visited[dependent.key] = struct{}{}
+			toVisit = append(toVisit, dependent.key)
+
+			if nspkg.GetRelationKind(relations[dependent.key]) == iv1.RelationMetadata_PERMISSION {
+				found = append(found, dependent.ref)
+			}
+		}
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		return tuple.StringRR(found[i]) < tuple.StringRR(found[j])
+	})
+	return found, nil
+}
+
+type dependentRelation struct {
+	key string
+	ref *core.RelationReference
+}
+
+// buildDependentsGraph returns a map from each relation or permission to those which directly
+// reference it.
+func buildDependentsGraph(defs []*core.NamespaceDefinition, relations map[string]*core.Relation) (map[string][]dependentRelation, error) {
+	dependents := make(map[string][]dependentRelation)
+	addEdge := func(namespaceName, relationName string, dependent dependentRelation) {
+		key := tuple.JoinRelRef(namespaceName, relationName)
+		if _, ok := relations[key]; !ok {
+			return
+		}
+		dependents[key] = append(dependents[key], dependent)
+	}
+
+	for _, def := range defs {
+		for _, rel := range def.Relation {
+			dependent := dependentRelation{
+				key: tuple.JoinRelRef(def.Name, rel.Name),
+				ref: &core.RelationReference{Namespace: def.Name, Relation: rel.Name},
+			}
+
+			// Subject relations, such as `group#member`, flow into the relation.
+			for _, allowed := range rel.GetTypeInformation().GetAllowedDirectRelations() {
+				if allowed.GetRelation() != "" && allowed.GetRelation() != tuple.Ellipsis {
+					addEdge(allowed.Namespace, allowed.GetRelation(), dependent)
+				}
+			}
+
+			if _, err := graph.WalkRewrite(rel.UsersetRewrite, func(childOneof *core.SetOperation_Child) interface{} {
+				switch child := childOneof.ChildType.(type) {
+				case *core.SetOperation_Child_ComputedUserset:
+					addEdge(def.Name, child.ComputedUserset.Relation, dependent)
+
+				case *core.SetOperation_Child_TupleToUserset:
+					tuplesetName := child.TupleToUserset.Tupleset.Relation
+					addEdge(def.Name, tuplesetName, dependent)
+
+					// The computed relation is found on each of the subject types of the tupleset.
+					tupleset, ok := relations[tuple.JoinRelRef(def.Name, tuplesetName)]
+					if !ok {
+						return nil
+					}
+
+					for _, allowed := range tupleset.GetTypeInformation().GetAllowedDirectRelations() {
+						addEdge(allowed.Namespace, child.TupleToUserset.ComputedUserset.Relation, dependent)
+					}
+				}
+				return nil
+			}); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return dependents, nil
+}
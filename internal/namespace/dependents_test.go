@@ -0,0 +1,117 @@
+package namespace
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/schemadsl/compiler"
+	"github.com/authzed/spicedb/pkg/schemadsl/input"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+func TestDependentPermissions(t *testing.T) {
+	schema := `definition user {}
+
+	definition group {
+		relation member: user | group#member
+		permission membership = member
+	}
+
+	definition folder {
+		relation viewer: user | group#member
+		permission view = viewer
+	}
+
+	definition document {
+		relation parent: folder
+		relation viewer: user
+		relation banned: user
+		permission view = (viewer + parent->view) - banned
+		permission comment = view
+		permission unrelated = banned
+	}`
+
+	testCases := []struct {
+		name          string
+		start         *core.RelationReference
+		expected      []string
+		expectedError string
+	}{
+		{
+			"direct relation",
+			rr("document", "viewer"),
+			[]string{"document#comment", "document#view"},
+			"",
+		},
+		{
+			"exclusion",
+			rr("document", "banned"),
+			[]string{"document#comment", "document#unrelated", "document#view"},
+			"",
+		},
+		{
+			"through arrow",
+			rr("folder", "viewer"),
+			[]string{"document#comment", "document#view", "folder#view"},
+			"",
+		},
+		{
+			"tupleset relation",
+			rr("document", "parent"),
+			[]string{"document#comment", "document#view"},
+			"",
+		},
+		{
+			"through subject relation",
+			rr("group", "member"),
+			[]string{"document#comment", "document#view", "folder#view", "group#membership"},
+			"",
+		},
+		{
+			"permission with no dependents",
+			rr("document", "comment"),
+			[]string{},
+			"",
+		},
+		{
+			"unknown relation",
+			rr("document", "unknown"),
+			nil,
+			"relation/permission `unknown` not found",
+		},
+		{
+			"unknown namespace",
+			rr("unknown", "viewer"),
+			nil,
+			"object definition `unknown` not found",
+		},
+	}
+
+	empty := ""
+	compiled, err := compiler.Compile(compiler.InputSchema{
+		Source:       input.Source("schema"),
+		SchemaString: schema,
+	}, &empty)
+	require.NoError(t, err)
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			found, err := DependentPermissions(compiled.ObjectDefinitions, tc.start)
+			if tc.expectedError != "" {
+				require.ErrorContains(t, err, tc.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+
+			foundStrings := make([]string, 0, len(found))
+			for _, ref := range found {
+				foundStrings = append(foundStrings, tuple.StringRR(ref))
+			}
+			require.Equal(t, tc.expected, foundStrings)
+		})
+	}
+}
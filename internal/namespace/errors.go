@@ -127,6 +127,26 @@ func (err ErrPermissionUsedOnLeftOfArrow) DetailsMetadata() map[string]string {
 	}
 }
 
+// ErrEmptyPermissionRewrite occurs when a permission contains an operation without any operands.
+type ErrEmptyPermissionRewrite struct {
+	error
+	namespaceName  string
+	permissionName string
+}
+
+// MarshalZerologObject implements zerolog object marshalling.
+func (err ErrEmptyPermissionRewrite) MarshalZerologObject(e *zerolog.Event) {
+	e.Err(err.error).Str("namespace", err.namespaceName).Str("permission", err.permissionName)
+}
+
+// DetailsMetadata returns the metadata for details for this error.
+func (err ErrEmptyPermissionRewrite) DetailsMetadata() map[string]string {
+	return map[string]string{
+		"definition_name": err.namespaceName,
+		"permission_name": err.permissionName,
+	}
+}
+
 // ErrWildcardUsedInArrow occurs when an arrow operates over a relation that contains a wildcard.
 type ErrWildcardUsedInArrow struct {
 	error
@@ -306,6 +326,15 @@ func NewPermissionUsedOnLeftOfArrowErr(nsName string, parentPermissionName strin
 	}
 }
 
+// NewEmptyPermissionRewriteErr constructs an error indicating that a permission contains an operation without any operands.
+func NewEmptyPermissionRewriteErr(nsName string, permissionName string) error {
+	return ErrEmptyPermissionRewrite{
+		error:          fmt.Errorf("permission `%s` under definition `%s` contains an operation without any operands", permissionName, nsName),
+		namespaceName:  nsName,
+		permissionName: permissionName,
+	}
+}
+
 // NewWildcardUsedInArrowErr constructs an error indicating that an arrow operated over a relation with a wildcard type.
 func NewWildcardUsedInArrowErr(nsName string, parentPermissionName string, foundRelationName string, wildcardTypeName string, wildcardRelationName string) error {
 	return ErrWildcardUsedInArrow{
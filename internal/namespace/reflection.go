@@ -0,0 +1,151 @@
+package namespace
+
+import (
+	"strings"
+
+	nspkg "github.com/authzed/spicedb/pkg/namespace"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	iv1 "github.com/authzed/spicedb/pkg/proto/impl/v1"
+	"github.com/authzed/spicedb/pkg/spiceerrors"
+)
+
+// PermissionExpressionKind is the kind of a node in a PermissionExpression tree.
+type PermissionExpressionKind string
+
+const (
+	// PermissionExpressionUnion includes the subjects found by any of its children.
+	PermissionExpressionUnion PermissionExpressionKind = "union"
+
+	// PermissionExpressionIntersection includes the subjects found by all of its children.
+	PermissionExpressionIntersection PermissionExpressionKind = "intersection"
+
+	// PermissionExpressionExclusion includes the subjects found by its first child and not by any
+	// of its remaining children.
+	PermissionExpressionExclusion PermissionExpressionKind = "exclusion"
+
+	// PermissionExpressionRelation includes the subjects of the referenced relation or permission
+	// on the same resource.
+	PermissionExpressionRelation PermissionExpressionKind = "relation"
+
+	// PermissionExpressionArrow includes the subjects of the computed relation or permission on
+	// each subject of the tupleset relation, e.g. `parent->view`.
+	PermissionExpressionArrow PermissionExpressionKind = "arrow"
+
+	// PermissionExpressionNil includes no subjects.
+	PermissionExpressionNil PermissionExpressionKind = "nil"
+)
+
+// PermissionExpression is a node in the tree of the rewrite expression of a permission.
+type PermissionExpression struct {
+	// Kind is the kind of the node.
+	Kind PermissionExpressionKind `json:"kind"`
+
+	// Children are the operands of a union, intersection or exclusion, in order.
+	Children []*PermissionExpression `json:"children,omitempty"`
+
+	// RelationName is the relation or permission referenced by a relation node, or the tupleset
+	// relation of an arrow node.
+	RelationName string `json:"relation,omitempty"`
+
+	// ComputedRelationName is the relation or permission referenced on the subjects of an arrow
+	// node.
+	ComputedRelationName string `json:"computed_relation,omitempty"`
+}
+
+// ReflectedPermission is a permission of a definition along with its rewrite expression.
+type ReflectedPermission struct {
+	// DefinitionName is the name of the definition containing the permission.
+	DefinitionName string `json:"definition"`
+
+	// Name is the name of the permission.
+	Name string `json:"name"`
+
+	// Comment is the doc comment of the permission, if any.
+	Comment string `json:"comment,omitempty"`
+
+	// Expression is the rewrite expression computing the permission.
+	Expression *PermissionExpression `json:"expression"`
+}
+
+// ReflectPermissions returns each permission of the given definition, in the order defined, along
+// with its rewrite expression as a tree. The tree is built from the same rewrites used to compute
+// the permission by Check, and so preserves their nesting.
+func ReflectPermissions(def *core.NamespaceDefinition) ([]ReflectedPermission, error) {
+	permissions := make([]ReflectedPermission, 0, len(def.Relation))
+	for _, rel := range def.Relation {
+		if rel.UsersetRewrite == nil {
+			continue
+		}
+
+		if nspkg.GetRelationKind(rel) == iv1.RelationMetadata_RELATION {
+			continue
+		}
+
+		expr, err := reflectRewrite(rel.UsersetRewrite)
+		if err != nil {
+			return nil, err
+		}
+
+		permissions = append(permissions, ReflectedPermission{
+			DefinitionName: def.Name,
+			Name:           rel.Name,
+			Comment:        strings.Join(nspkg.GetComments(rel.Metadata), "\n"),
+			Expression:     expr,
+		})
+	}
+	return permissions, nil
+}
+
+func reflectRewrite(rewrite *core.UsersetRewrite) (*PermissionExpression, error) {
+	switch rw := rewrite.RewriteOperation.(type) {
+	case *core.UsersetRewrite_Union:
+		return reflectSetOperation(PermissionExpressionUnion, rw.Union)
+
+	case *core.UsersetRewrite_Intersection:
+		return reflectSetOperation(PermissionExpressionIntersection, rw.Intersection)
+
+	case *core.UsersetRewrite_Exclusion:
+		return reflectSetOperation(PermissionExpressionExclusion, rw.Exclusion)
+
+	default:
+		return nil, spiceerrors.MustBugf("unknown rewrite kind %v", rw)
+	}
+}
+
+func reflectSetOperation(kind PermissionExpressionKind, so *core.SetOperation) (*PermissionExpression, error) {
+	children := make([]*PermissionExpression, 0, len(so.Child))
+	for _, childOneof := range so.Child {
+		switch child := childOneof.ChildType.(type) {
+		case *core.SetOperation_Child_XThis:
+			return nil, spiceerrors.MustBugf("use of _this is disallowed")
+
+		case *core.SetOperation_Child_ComputedUserset:
+			children = append(children, &PermissionExpression{
+				Kind:         PermissionExpressionRelation,
+				RelationName: child.ComputedUserset.Relation,
+			})
+
+		case *core.SetOperation_Child_UsersetRewrite:
+			expr, err := reflectRewrite(child.UsersetRewrite)
+			if err != nil {
+				return nil, err
+			}
+			children = append(children, expr)
+
+		case *core.SetOperation_Child_TupleToUserset:
+			children = append(children, &PermissionExpression{
+				Kind:                 PermissionExpressionArrow,
+				RelationName:         child.TupleToUserset.Tupleset.Relation,
+				ComputedRelationName: child.TupleToUserset.ComputedUserset.Relation,
+			})
+
+		case *core.SetOperation_Child_XNil:
+			children = append(children, &PermissionExpression{Kind: PermissionExpressionNil})
+
+		default:
+			return nil, spiceerrors.MustBugf("unknown set operation child %T", child)
+		}
+	}
+
+	return &PermissionExpression{Kind: kind, Children: children}, nil
+}
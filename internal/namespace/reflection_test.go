@@ -0,0 +1,84 @@
+package namespace
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/pkg/schemadsl/compiler"
+	"github.com/authzed/spicedb/pkg/schemadsl/input"
+)
+
+func TestReflectPermissions(t *testing.T) {
+	schema := `definition user {}
+
+	definition folder {
+		relation viewer: user
+		permission view = viewer
+	}
+
+	definition document {
+		relation parent: folder
+		relation viewer: user
+		relation editor: user
+		relation banned: user
+
+		// view is granted to viewers, editors and viewers of the parent folder, unless banned
+		permission view = (viewer + editor + parent->view) - banned
+		permission edit = editor & parent->view
+		permission nothing = nil
+	}`
+
+	empty := ""
+	compiled, err := compiler.Compile(compiler.InputSchema{
+		Source:       input.Source("schema"),
+		SchemaString: schema,
+	}, &empty)
+	require.NoError(t, err)
+
+	found, err := ReflectPermissions(compiled.ObjectDefinitions[2])
+	require.NoError(t, err)
+	require.Len(t, found, 3)
+
+	relation := func(name string) *PermissionExpression {
+		return &PermissionExpression{Kind: PermissionExpressionRelation, RelationName: name}
+	}
+	arrow := func(tupleset string, computed string) *PermissionExpression {
+		return &PermissionExpression{Kind: PermissionExpressionArrow, RelationName: tupleset, ComputedRelationName: computed}
+	}
+
+	require.Equal(t, "document", found[0].DefinitionName)
+	require.Equal(t, "view", found[0].Name)
+	require.Contains(t, found[0].Comment, "view is granted to viewers")
+	require.Equal(t, &PermissionExpression{
+		Kind: PermissionExpressionExclusion,
+		Children: []*PermissionExpression{
+			{
+				Kind:     PermissionExpressionUnion,
+				Children: []*PermissionExpression{relation("viewer"), relation("editor"), arrow("parent", "view")},
+			},
+			relation("banned"),
+		},
+	}, found[0].Expression)
+
+	require.Equal(t, "edit", found[1].Name)
+	require.Empty(t, found[1].Comment)
+	require.Equal(t, &PermissionExpression{
+		Kind:     PermissionExpressionIntersection,
+		Children: []*PermissionExpression{relation("editor"), arrow("parent", "view")},
+	}, found[1].Expression)
+
+	require.Equal(t, "nothing", found[2].Name)
+	require.Equal(t, &PermissionExpression{
+		Kind:     PermissionExpressionUnion,
+		Children: []*PermissionExpression{{Kind: PermissionExpressionNil}},
+	}, found[2].Expression)
+
+	folderPermissions, err := ReflectPermissions(compiled.ObjectDefinitions[1])
+	require.NoError(t, err)
+	require.Len(t, folderPermissions, 1)
+	require.Equal(t, &PermissionExpression{
+		Kind:     PermissionExpressionUnion,
+		Children: []*PermissionExpression{relation("viewer")},
+	}, folderPermissions[0].Expression)
+}
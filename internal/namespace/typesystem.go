@@ -437,6 +437,19 @@ func (nts *TypeSystem) Validate(ctx context.Context) (*ValidatedNamespaceTypeSys
 			return nil, err
 		}
 
+		// Ensure the rewrite does not contain an operation without operands.
+		hasEmptySetOperation, err := graph.HasEmptySetOperation(usersetRewrite)
+		if err != nil {
+			return nil, err
+		}
+		if hasEmptySetOperation {
+			return nil, newTypeErrorWithSource(
+				NewEmptyPermissionRewriteErr(nts.nsDef.Name, relation.Name),
+				relation,
+				relation.Name,
+			)
+		}
+
 		// Validate type information.
 		typeInfo := relation.TypeInformation
 		if typeInfo == nil {
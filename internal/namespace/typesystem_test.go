@@ -349,6 +349,34 @@ func TestTypeSystem(t *testing.T) {
 			},
 			"",
 		},
+		{
+			"empty permission rewrite",
+			ns.Namespace(
+				"document",
+				ns.MustRelation("viewer", &core.UsersetRewrite{
+					RewriteOperation: &core.UsersetRewrite_Union{Union: &core.SetOperation{}},
+				}),
+			),
+			[]*core.NamespaceDefinition{},
+			nil,
+			"permission `viewer` under definition `document` contains an operation without any operands",
+		},
+		{
+			"nested empty permission rewrite",
+			ns.Namespace(
+				"document",
+				ns.MustRelation("owner", nil),
+				ns.MustRelation("viewer", ns.Union(
+					ns.ComputedUserset("owner"),
+					ns.Rewrite(&core.UsersetRewrite{
+						RewriteOperation: &core.UsersetRewrite_Intersection{Intersection: &core.SetOperation{}},
+					}),
+				)),
+			),
+			[]*core.NamespaceDefinition{},
+			nil,
+			"permission `viewer` under definition `document` contains an operation without any operands",
+		},
 	}
 
 	for _, tc := range testCases {
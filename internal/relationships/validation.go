@@ -2,6 +2,7 @@ package relationships
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/samber/lo"
 
@@ -15,6 +16,37 @@ import (
 	"github.com/authzed/spicedb/pkg/tuple"
 )
 
+// ValidationStrictness defines how thoroughly relationships are validated before being written.
+type ValidationStrictness int
+
+const (
+	// ValidationFull validates that the namespaces and relations referenced exist, that the subject
+	// is of an allowed type for the relation and that any caveat and its context are valid.
+	ValidationFull ValidationStrictness = iota
+
+	// ValidationTypesOnly validates only that the namespaces and relations referenced exist and that
+	// the relationship is not written to a permission.
+	ValidationTypesOnly
+
+	// ValidationNone performs no validation against the schema.
+	ValidationNone
+)
+
+var validationStrictnessNames = map[string]ValidationStrictness{
+	"full":       ValidationFull,
+	"types-only": ValidationTypesOnly,
+	"none":       ValidationNone,
+}
+
+// ParseValidationStrictness returns the validation strictness with the given name.
+func ParseValidationStrictness(name string) (ValidationStrictness, error) {
+	strictness, ok := validationStrictnessNames[name]
+	if !ok {
+		return ValidationFull, fmt.Errorf("unknown relationship validation strictness `%s`; must be one of `full`, `types-only` or `none`", name)
+	}
+	return strictness, nil
+}
+
 // ValidateRelationshipUpdates performs validation on the given relationship updates, ensuring that
 // they can be applied against the datastore.
 func ValidateRelationshipUpdates(
@@ -22,10 +54,39 @@ func ValidateRelationshipUpdates(
 	reader datastore.Reader,
 	updates []*core.RelationTupleUpdate,
 ) error {
+	return ValidateRelationshipUpdatesWithStrictness(ctx, reader, updates, ValidationFull)
+}
+
+// ValidateRelationshipUpdatesWithStrictness performs validation on the given relationship updates
+// to the given strictness.
+func ValidateRelationshipUpdatesWithStrictness(
+	ctx context.Context,
+	reader datastore.Reader,
+	updates []*core.RelationTupleUpdate,
+	strictness ValidationStrictness,
+) error {
+	if strictness == ValidationNone {
+		return nil
+	}
+
 	rels := lo.Map(updates, func(item *core.RelationTupleUpdate, _ int) *core.RelationTuple {
 		return item.Tuple
 	})
 
+	if strictness == ValidationTypesOnly {
+		referencedNamespaceMap, err := loadNamespaces(ctx, rels, reader)
+		if err != nil {
+			return err
+		}
+
+		for _, rel := range rels {
+			if _, err := validateRelationshipTypes(referencedNamespaceMap, rel); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
 	// Load namespaces and caveats.
 	referencedNamespaceMap, referencedCaveatMap, err := loadNamespacesAndCaveats(ctx, rels, reader)
 	if err != nil {
@@ -82,37 +143,62 @@ func ValidateRelationshipsForCreateOrTouch(
 	return nil
 }
 
-func loadNamespacesAndCaveats(ctx context.Context, rels []*core.RelationTuple, reader datastore.Reader) (map[string]*namespace.TypeSystem, map[string]*core.CaveatDefinition, error) {
-	referencedNamespaceNames := mapz.NewSet[string]()
-	referencedCaveatNamesWithContext := mapz.NewSet[string]()
-	for _, rel := range rels {
-		referencedNamespaceNames.Add(rel.ResourceAndRelation.Namespace)
-		referencedNamespaceNames.Add(rel.Subject.Namespace)
-		if hasNonEmptyCaveatContext(rel) {
-			referencedCaveatNamesWithContext.Add(rel.Caveat.CaveatName)
+// ValidateRelationshipsAgainstDefinitions performs validation on the given relationships to be
+// written against the given object and caveat definitions, rather than those in a datastore. Used
+// to validate relationships written alongside the schema defining them, before that schema is
+// readable.
+func ValidateRelationshipsAgainstDefinitions(
+	objectDefs []*core.NamespaceDefinition,
+	caveatDefs []*core.CaveatDefinition,
+	rels []*core.RelationTuple,
+) error {
+	resolver := namespace.ResolverForPredefinedDefinitions(namespace.PredefinedElements{
+		Namespaces: objectDefs,
+		Caveats:    caveatDefs,
+	})
+
+	namespaceMap := make(map[string]*namespace.TypeSystem, len(objectDefs))
+	for _, nsDef := range objectDefs {
+		nts, err := namespace.NewNamespaceTypeSystem(nsDef, resolver)
+		if err != nil {
+			return err
 		}
+		namespaceMap[nsDef.Name] = nts
 	}
 
-	var referencedNamespaceMap map[string]*namespace.TypeSystem
-	var referencedCaveatMap map[string]*core.CaveatDefinition
+	caveatMap := make(map[string]*core.CaveatDefinition, len(caveatDefs))
+	for _, caveatDef := range caveatDefs {
+		caveatMap[caveatDef.Name] = caveatDef
+	}
 
-	if !referencedNamespaceNames.IsEmpty() {
-		foundNamespaces, err := reader.LookupNamespacesWithNames(ctx, referencedNamespaceNames.AsSlice())
-		if err != nil {
-			return nil, nil, err
+	for _, rel := range rels {
+		if err := ValidateOneRelationship(
+			namespaceMap,
+			caveatMap,
+			rel,
+			ValidateRelationshipForCreateOrTouch,
+		); err != nil {
+			return err
 		}
+	}
 
-		referencedNamespaceMap = make(map[string]*namespace.TypeSystem, len(foundNamespaces))
-		for _, nsDef := range foundNamespaces {
-			nts, err := namespace.NewNamespaceTypeSystem(nsDef.Definition, namespace.ResolverForDatastoreReader(reader))
-			if err != nil {
-				return nil, nil, err
-			}
+	return nil
+}
 
-			referencedNamespaceMap[nsDef.Definition.Name] = nts
+func loadNamespacesAndCaveats(ctx context.Context, rels []*core.RelationTuple, reader datastore.Reader) (map[string]*namespace.TypeSystem, map[string]*core.CaveatDefinition, error) {
+	referencedNamespaceMap, err := loadNamespaces(ctx, rels, reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	referencedCaveatNamesWithContext := mapz.NewSet[string]()
+	for _, rel := range rels {
+		if hasNonEmptyCaveatContext(rel) {
+			referencedCaveatNamesWithContext.Add(rel.Caveat.CaveatName)
 		}
 	}
 
+	var referencedCaveatMap map[string]*core.CaveatDefinition
 	if !referencedCaveatNamesWithContext.IsEmpty() {
 		foundCaveats, err := reader.LookupCaveatsWithNames(ctx, referencedCaveatNamesWithContext.AsSlice())
 		if err != nil {
@@ -127,6 +213,34 @@ func loadNamespacesAndCaveats(ctx context.Context, rels []*core.RelationTuple, r
 	return referencedNamespaceMap, referencedCaveatMap, nil
 }
 
+func loadNamespaces(ctx context.Context, rels []*core.RelationTuple, reader datastore.Reader) (map[string]*namespace.TypeSystem, error) {
+	referencedNamespaceNames := mapz.NewSet[string]()
+	for _, rel := range rels {
+		referencedNamespaceNames.Add(rel.ResourceAndRelation.Namespace)
+		referencedNamespaceNames.Add(rel.Subject.Namespace)
+	}
+
+	if referencedNamespaceNames.IsEmpty() {
+		return nil, nil
+	}
+
+	foundNamespaces, err := reader.LookupNamespacesWithNames(ctx, referencedNamespaceNames.AsSlice())
+	if err != nil {
+		return nil, err
+	}
+
+	referencedNamespaceMap := make(map[string]*namespace.TypeSystem, len(foundNamespaces))
+	for _, nsDef := range foundNamespaces {
+		nts, err := namespace.NewNamespaceTypeSystem(nsDef.Definition, namespace.ResolverForDatastoreReader(reader))
+		if err != nil {
+			return nil, err
+		}
+
+		referencedNamespaceMap[nsDef.Definition.Name] = nts
+	}
+	return referencedNamespaceMap, nil
+}
+
 // ValidationRelationshipRule is the rule to use for the validation.
 type ValidationRelationshipRule int
 
@@ -145,42 +259,11 @@ func ValidateOneRelationship(
 	rel *core.RelationTuple,
 	rule ValidationRelationshipRule,
 ) error {
-	// Validate the IDs of the resource and subject.
-	if err := tuple.ValidateResourceID(rel.ResourceAndRelation.ObjectId); err != nil {
-		return err
-	}
-
-	if err := tuple.ValidateSubjectID(rel.Subject.ObjectId); err != nil {
+	resourceTS, err := validateRelationshipTypes(namespaceMap, rel)
+	if err != nil {
 		return err
 	}
 
-	// Validate the namespace and relation for the resource.
-	resourceTS, ok := namespaceMap[rel.ResourceAndRelation.Namespace]
-	if !ok {
-		return namespace.NewNamespaceNotFoundErr(rel.ResourceAndRelation.Namespace)
-	}
-
-	if !resourceTS.HasRelation(rel.ResourceAndRelation.Relation) {
-		return namespace.NewRelationNotFoundErr(rel.ResourceAndRelation.Namespace, rel.ResourceAndRelation.Relation)
-	}
-
-	// Validate the namespace and relation for the subject.
-	subjectTS, ok := namespaceMap[rel.Subject.Namespace]
-	if !ok {
-		return namespace.NewNamespaceNotFoundErr(rel.Subject.Namespace)
-	}
-
-	if rel.Subject.Relation != tuple.Ellipsis {
-		if !subjectTS.HasRelation(rel.Subject.Relation) {
-			return namespace.NewRelationNotFoundErr(rel.Subject.Namespace, rel.Subject.Relation)
-		}
-	}
-
-	// Validate that the relationship is not writing to a permission.
-	if resourceTS.IsPermission(rel.ResourceAndRelation.Relation) {
-		return NewCannotWriteToPermissionError(rel)
-	}
-
 	// Validate the subject against the allowed relation(s).
 	var caveat *core.AllowedCaveat
 	if rel.Caveat != nil {
@@ -259,6 +342,51 @@ func ValidateOneRelationship(
 	return nil
 }
 
+// validateRelationshipTypes validates the IDs of the relationship and that the namespaces and
+// relations it references exist, returning the type system of the resource's namespace.
+func validateRelationshipTypes(
+	namespaceMap map[string]*namespace.TypeSystem,
+	rel *core.RelationTuple,
+) (*namespace.TypeSystem, error) {
+	// Validate the IDs of the resource and subject.
+	if err := tuple.ValidateResourceID(rel.ResourceAndRelation.ObjectId); err != nil {
+		return nil, err
+	}
+
+	if err := tuple.ValidateSubjectID(rel.Subject.ObjectId); err != nil {
+		return nil, err
+	}
+
+	// Validate the namespace and relation for the resource.
+	resourceTS, ok := namespaceMap[rel.ResourceAndRelation.Namespace]
+	if !ok {
+		return nil, namespace.NewNamespaceNotFoundErr(rel.ResourceAndRelation.Namespace)
+	}
+
+	if !resourceTS.HasRelation(rel.ResourceAndRelation.Relation) {
+		return nil, namespace.NewRelationNotFoundErr(rel.ResourceAndRelation.Namespace, rel.ResourceAndRelation.Relation)
+	}
+
+	// Validate the namespace and relation for the subject.
+	subjectTS, ok := namespaceMap[rel.Subject.Namespace]
+	if !ok {
+		return nil, namespace.NewNamespaceNotFoundErr(rel.Subject.Namespace)
+	}
+
+	if rel.Subject.Relation != tuple.Ellipsis {
+		if !subjectTS.HasRelation(rel.Subject.Relation) {
+			return nil, namespace.NewRelationNotFoundErr(rel.Subject.Namespace, rel.Subject.Relation)
+		}
+	}
+
+	// Validate that the relationship is not writing to a permission.
+	if resourceTS.IsPermission(rel.ResourceAndRelation.Relation) {
+		return nil, NewCannotWriteToPermissionError(rel)
+	}
+
+	return resourceTS, nil
+}
+
 func hasNonEmptyCaveatContext(update *core.RelationTuple) bool {
 	return update.Caveat != nil &&
 		update.Caveat.CaveatName != "" &&
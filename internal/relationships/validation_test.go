@@ -236,3 +236,98 @@ func TestValidateRelationshipOperations(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateRelationshipUpdatesWithStrictness(t *testing.T) {
+	schema := `
+		definition user {}
+
+		caveat somecaveat(somecondition int) {
+			somecondition == 42
+		}
+
+		definition resource {
+			relation viewer: user
+			relation caveated_viewer: user with somecaveat
+			permission view = viewer
+		}
+	`
+
+	tcs := []struct {
+		relationship      string
+		expectedErrorFull string
+		expectedErrorType string
+	}{
+		{
+			"resource:foo#viewer@user:tom",
+			"",
+			"",
+		},
+		{
+			"resource:foo#unknown@user:tom",
+			"relation/permission `unknown` not found",
+			"relation/permission `unknown` not found",
+		},
+		{
+			"unknown:foo#viewer@user:tom",
+			"object definition `unknown` not found",
+			"object definition `unknown` not found",
+		},
+		{
+			"resource:foo#view@user:tom",
+			"cannot write a relationship to permission",
+			"cannot write a relationship to permission",
+		},
+		{
+			"resource:foo#viewer@resource:bar",
+			"subjects of type `resource` are not allowed on relation `resource#viewer`",
+			"",
+		},
+		{
+			"resource:foo#viewer@user:tom[somecaveat]",
+			"subjects of type `user with somecaveat` are not allowed on relation `resource#viewer`",
+			"",
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.relationship, func(t *testing.T) {
+			req := require.New(t)
+
+			ds, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+			req.NoError(err)
+
+			uds, rev := testfixtures.DatastoreFromSchemaAndTestRelationships(ds, schema, nil, req)
+			reader := uds.SnapshotReader(rev)
+
+			updates := []*core.RelationTupleUpdate{tuple.Create(tuple.MustParse(tc.relationship))}
+			for strictness, expectedError := range map[ValidationStrictness]string{
+				ValidationFull:      tc.expectedErrorFull,
+				ValidationTypesOnly: tc.expectedErrorType,
+				ValidationNone:      "",
+			} {
+				err := ValidateRelationshipUpdatesWithStrictness(context.Background(), reader, updates, strictness)
+				if expectedError != "" {
+					req.ErrorContains(err, expectedError)
+				} else {
+					req.NoError(err)
+				}
+			}
+		})
+	}
+}
+
+func TestParseValidationStrictness(t *testing.T) {
+	for name, expected := range map[string]ValidationStrictness{
+		"full":       ValidationFull,
+		"types-only": ValidationTypesOnly,
+		"none":       ValidationNone,
+	} {
+		strictness, err := ParseValidationStrictness(name)
+		require.NoError(t, err)
+		require.Equal(t, expected, strictness)
+	}
+
+	_, err := ParseValidationStrictness("unknown")
+	require.Error(t, err)
+}
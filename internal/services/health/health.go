@@ -9,18 +9,45 @@ import (
 	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 
 	"github.com/authzed/spicedb/internal/dispatch"
+	"github.com/authzed/spicedb/internal/graph"
 	log "github.com/authzed/spicedb/internal/logging"
 	"github.com/authzed/spicedb/pkg/datastore"
 )
 
-const datastoreReadyTimeout = time.Millisecond * 500
+const (
+	datastoreReadyTimeout = time.Millisecond * 500
+	backlogCheckInterval  = time.Second
+)
 
 // NewHealthManager creates and returns a new health manager that checks the IsReady
 // status of the given dispatcher and datastore checker and sets the health check to
 // return healthy once both have gone to true.
-func NewHealthManager(dispatcher dispatch.Dispatcher, dsc DatastoreChecker) Manager {
+func NewHealthManager(dispatcher dispatch.Dispatcher, dsc DatastoreChecker, opts ...Option) Manager {
 	healthSvc := grpcutil.NewAuthlessHealthServer()
-	return &healthManager{healthSvc, dispatcher, dsc, map[string]struct{}{}}
+	hm := &healthManager{
+		healthSvc:    healthSvc,
+		dispatcher:   dispatcher,
+		dsc:          dsc,
+		serviceNames: map[string]struct{}{},
+		queueDepth:   graph.QueuedTaskCount,
+	}
+	for _, opt := range opts {
+		opt(hm)
+	}
+	return hm
+}
+
+// Option is an option for the health manager.
+type Option func(hm *healthManager)
+
+// WithDispatchBacklogThreshold configures the health manager to continue checking the
+// dispatch backlog once the services are serving, reporting them as NOT_SERVING while
+// more than the given number of dispatch tasks are waiting to run. A threshold of zero
+// disables the check.
+func WithDispatchBacklogThreshold(threshold int64) Option {
+	return func(hm *healthManager) {
+		hm.backlogThreshold = threshold
+	}
 }
 
 // DatastoreChecker is an interface for determining if the datastore is ready for
@@ -48,6 +75,9 @@ type healthManager struct {
 	dispatcher   dispatch.Dispatcher
 	dsc          DatastoreChecker
 	serviceNames map[string]struct{}
+
+	backlogThreshold int64
+	queueDepth       func() int64
 }
 
 func (hm *healthManager) HealthSvc() *grpcutil.AuthlessHealthServer {
@@ -82,8 +112,9 @@ func (hm *healthManager) Checker(ctx context.Context) func() error {
 
 			isReady := hm.checkIsReady(ctx)
 			if isReady {
-				for serviceName := range hm.serviceNames {
-					hm.healthSvc.Server.SetServingStatus(serviceName, healthpb.HealthCheckResponse_SERVING)
+				hm.setServingStatus(healthpb.HealthCheckResponse_SERVING)
+				if hm.backlogThreshold > 0 {
+					hm.monitorBacklog(ctx)
 				}
 				return nil
 			}
@@ -98,6 +129,50 @@ func (hm *healthManager) Checker(ctx context.Context) func() error {
 	}
 }
 
+func (hm *healthManager) setServingStatus(servingStatus healthpb.HealthCheckResponse_ServingStatus) {
+	for serviceName := range hm.serviceNames {
+		hm.healthSvc.Server.SetServingStatus(serviceName, servingStatus)
+	}
+}
+
+// monitorBacklog periodically compares the dispatch backlog against the configured
+// threshold, toggling the serving status of the services whenever it crosses it, until
+// the context is canceled.
+func (hm *healthManager) monitorBacklog(ctx context.Context) {
+	ticker := time.NewTicker(backlogCheckInterval)
+	defer ticker.Stop()
+
+	overloaded := false
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+
+		overloaded = hm.checkBacklog(ctx, overloaded)
+	}
+}
+
+// checkBacklog updates the serving status of the services if the overload state has
+// changed, returning the new overload state.
+func (hm *healthManager) checkBacklog(ctx context.Context, wasOverloaded bool) bool {
+	queued := hm.queueDepth()
+	overloaded := queued > hm.backlogThreshold
+	if overloaded == wasOverloaded {
+		return overloaded
+	}
+
+	if overloaded {
+		log.Ctx(ctx).Warn().Int64("queuedTasks", queued).Int64("threshold", hm.backlogThreshold).Msg("dispatch backlog exceeds threshold; reporting as not serving")
+		hm.setServingStatus(healthpb.HealthCheckResponse_NOT_SERVING)
+	} else {
+		log.Ctx(ctx).Info().Int64("queuedTasks", queued).Int64("threshold", hm.backlogThreshold).Msg("dispatch backlog back under threshold; reporting as serving")
+		hm.setServingStatus(healthpb.HealthCheckResponse_SERVING)
+	}
+	return overloaded
+}
+
 func (hm *healthManager) checkIsReady(ctx context.Context) bool {
 	log.Ctx(ctx).Debug().Msg("checking if datastore and dispatcher are ready")
 
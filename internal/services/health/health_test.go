@@ -0,0 +1,39 @@
+package health
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestCheckBacklog(t *testing.T) {
+	require := require.New(t)
+
+	queued := int64(0)
+	hm := NewHealthManager(nil, nil, WithDispatchBacklogThreshold(10)).(*healthManager)
+	hm.queueDepth = func() int64 { return queued }
+	hm.RegisterReportedService("test")
+	hm.setServingStatus(healthpb.HealthCheckResponse_SERVING)
+
+	requireStatus := func(expected healthpb.HealthCheckResponse_ServingStatus) {
+		resp, err := hm.healthSvc.Server.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "test"})
+		require.NoError(err)
+		require.Equal(expected, resp.Status)
+	}
+
+	overloaded := hm.checkBacklog(context.Background(), false)
+	require.False(overloaded)
+	requireStatus(healthpb.HealthCheckResponse_SERVING)
+
+	queued = 11
+	overloaded = hm.checkBacklog(context.Background(), overloaded)
+	require.True(overloaded)
+	requireStatus(healthpb.HealthCheckResponse_NOT_SERVING)
+
+	queued = 10
+	overloaded = hm.checkBacklog(context.Background(), overloaded)
+	require.False(overloaded)
+	requireStatus(healthpb.HealthCheckResponse_SERVING)
+}
@@ -0,0 +1,132 @@
+package shared
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strings"
+
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/schemadsl/generator"
+)
+
+// SchemaCompatibility describes how a schema relates to the schema from which an expected
+// fingerprint was computed.
+type SchemaCompatibility string
+
+const (
+	// SchemaIdentical indicates that the schema defines exactly the expected definitions,
+	// relations and permissions.
+	SchemaIdentical SchemaCompatibility = "identical"
+
+	// SchemaSuperset indicates that the schema defines all the expected definitions, relations
+	// and permissions unchanged, along with others that were not expected.
+	SchemaSuperset SchemaCompatibility = "superset"
+
+	// SchemaIncompatible indicates that at least one expected definition, relation or permission
+	// is missing from the schema or has been changed.
+	SchemaIncompatible SchemaCompatibility = "incompatible"
+)
+
+const (
+	schemaFingerprintPrefix = "v1:"
+	schemaElementHashLength = 8
+)
+
+// ComputeSchemaFingerprint computes a fingerprint of the schema formed by the given definitions.
+// The fingerprint is made up of a hash of each definition, relation, permission and caveat found
+// in the schema, so that it can be used to determine whether another schema contains them all.
+// Comments and the order of the definitions do not affect the fingerprint.
+func ComputeSchemaFingerprint(nsDefs []*core.NamespaceDefinition, caveatDefs []*core.CaveatDefinition) (string, error) {
+	elements := make([]string, 0, len(nsDefs)+len(caveatDefs))
+	for _, nsDef := range nsDefs {
+		// Each relation is hashed on its own, within an otherwise empty definition, so that
+		// adding a relation to a definition does not change the hashes of the existing ones.
+		source, _, err := generator.GenerateSource(&core.NamespaceDefinition{Name: nsDef.Name})
+		if err != nil {
+			return "", err
+		}
+		elements = append(elements, source)
+
+		for _, relation := range nsDef.Relation {
+			stripped := relation.CloneVT()
+			stripped.Metadata = nil
+
+			source, _, err := generator.GenerateSource(&core.NamespaceDefinition{
+				Name:     nsDef.Name,
+				Relation: []*core.Relation{stripped},
+			})
+			if err != nil {
+				return "", err
+			}
+			elements = append(elements, source)
+		}
+	}
+
+	for _, caveatDef := range caveatDefs {
+		stripped := caveatDef.CloneVT()
+		stripped.Metadata = nil
+
+		source, _, err := generator.GenerateCaveatSource(stripped)
+		if err != nil {
+			return "", err
+		}
+		elements = append(elements, source)
+	}
+
+	hashes := make([]string, 0, len(elements))
+	for _, element := range elements {
+		sum := sha256.Sum256([]byte(element))
+		hashes = append(hashes, string(sum[:schemaElementHashLength]))
+	}
+	sort.Strings(hashes)
+
+	return schemaFingerprintPrefix + base64.RawURLEncoding.EncodeToString([]byte(strings.Join(hashes, ""))), nil
+}
+
+// CompareSchemaFingerprints determines whether the schema with the current fingerprint is
+// compatible with the one from which the expected fingerprint was computed.
+func CompareSchemaFingerprints(expected string, current string) (SchemaCompatibility, error) {
+	expectedHashes, err := decodeSchemaFingerprint(expected)
+	if err != nil {
+		return SchemaIncompatible, err
+	}
+
+	currentHashes, err := decodeSchemaFingerprint(current)
+	if err != nil {
+		return SchemaIncompatible, err
+	}
+
+	for hash := range expectedHashes {
+		if _, ok := currentHashes[hash]; !ok {
+			return SchemaIncompatible, nil
+		}
+	}
+
+	if len(currentHashes) == len(expectedHashes) {
+		return SchemaIdentical, nil
+	}
+	return SchemaSuperset, nil
+}
+
+func decodeSchemaFingerprint(fingerprint string) (map[string]struct{}, error) {
+	if !strings.HasPrefix(fingerprint, schemaFingerprintPrefix) {
+		return nil, fmt.Errorf("unsupported schema fingerprint version")
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(fingerprint, schemaFingerprintPrefix))
+	if err != nil {
+		return nil, fmt.Errorf("invalid encoding of schema fingerprint: %w", err)
+	}
+
+	if len(decoded)%schemaElementHashLength != 0 {
+		return nil, fmt.Errorf("schema fingerprint has an invalid length")
+	}
+
+	hashes := make(map[string]struct{}, len(decoded)/schemaElementHashLength)
+	for i := 0; i < len(decoded); i += schemaElementHashLength {
+		hashes[string(decoded[i:i+schemaElementHashLength])] = struct{}{}
+	}
+	return hashes, nil
+}
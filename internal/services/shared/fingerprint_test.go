@@ -0,0 +1,149 @@
+package shared
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/pkg/schemadsl/compiler"
+	"github.com/authzed/spicedb/pkg/schemadsl/input"
+)
+
+func fingerprintFor(t *testing.T, schema string) string {
+	emptyDefaultPrefix := ""
+	compiled, err := compiler.Compile(compiler.InputSchema{
+		Source:       input.Source("schema"),
+		SchemaString: schema,
+	}, &emptyDefaultPrefix)
+	require.NoError(t, err)
+
+	fingerprint, err := ComputeSchemaFingerprint(compiled.ObjectDefinitions, compiled.CaveatDefinitions)
+	require.NoError(t, err)
+	return fingerprint
+}
+
+func TestCompareSchemaFingerprints(t *testing.T) {
+	expected := fingerprintFor(t, `
+		definition user {}
+
+		caveat somecaveat(value int) {
+			value == 42
+		}
+
+		definition document {
+			relation viewer: user with somecaveat
+			permission view = viewer
+		}
+	`)
+
+	tcs := []struct {
+		name     string
+		schema   string
+		expected SchemaCompatibility
+	}{
+		{
+			"reordered with comments",
+			`
+			definition document {
+				// the viewers of the document
+				relation viewer: user with somecaveat
+				permission view = viewer
+			}
+
+			/** somecaveat is a caveat */
+			caveat somecaveat(value int) {
+				value == 42
+			}
+
+			definition user {}
+			`,
+			SchemaIdentical,
+		},
+		{
+			"added permission",
+			`
+			definition user {}
+
+			caveat somecaveat(value int) {
+				value == 42
+			}
+
+			definition document {
+				relation viewer: user with somecaveat
+				relation editor: user
+				permission view = viewer + editor
+				permission edit = editor
+			}
+			`,
+			SchemaIncompatible,
+		},
+		{
+			"added relation and definition",
+			`
+			definition user {}
+
+			definition team {}
+
+			caveat somecaveat(value int) {
+				value == 42
+			}
+
+			definition document {
+				relation viewer: user with somecaveat
+				relation editor: user
+				permission view = viewer
+			}
+			`,
+			SchemaSuperset,
+		},
+		{
+			"changed caveat",
+			`
+			definition user {}
+
+			caveat somecaveat(value int) {
+				value == 43
+			}
+
+			definition document {
+				relation viewer: user with somecaveat
+				permission view = viewer
+			}
+			`,
+			SchemaIncompatible,
+		},
+		{
+			"removed definition",
+			`
+			definition user {}
+
+			caveat somecaveat(value int) {
+				value == 42
+			}
+
+			definition document {
+				relation viewer: user with somecaveat
+			}
+			`,
+			SchemaIncompatible,
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			compatibility, err := CompareSchemaFingerprints(expected, fingerprintFor(t, tc.schema))
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, compatibility)
+		})
+	}
+}
+
+func TestCompareSchemaFingerprintsInvalid(t *testing.T) {
+	current := fingerprintFor(t, `definition user {}`)
+
+	for _, invalid := range []string{"", "v2:abcd", "v1:!!!", "v1:AAAA"} {
+		_, err := CompareSchemaFingerprints(invalid, current)
+		require.Error(t, err, invalid)
+	}
+}
@@ -2,10 +2,13 @@ package shared
 
 import (
 	"context"
+	"fmt"
+	"sort"
 
 	"github.com/authzed/spicedb/internal/caveats"
 	log "github.com/authzed/spicedb/internal/logging"
 	"github.com/authzed/spicedb/internal/namespace"
+	"github.com/authzed/spicedb/internal/relationships"
 	"github.com/authzed/spicedb/pkg/datastore"
 	"github.com/authzed/spicedb/pkg/datastore/options"
 	"github.com/authzed/spicedb/pkg/genutil/mapz"
@@ -14,17 +17,52 @@ import (
 	"github.com/authzed/spicedb/pkg/tuple"
 )
 
+// OrphanedRelationshipsPolicy defines how relationships left orphaned by a schema change, such as
+// the removal of a relation or of one of its allowed subject types, are handled when applying
+// schema changes.
+type OrphanedRelationshipsPolicy int
+
+const (
+	// OrphanedRelationshipsStrict rejects schema changes which would leave orphaned relationships.
+	OrphanedRelationshipsStrict OrphanedRelationshipsPolicy = iota
+
+	// OrphanedRelationshipsCascade deletes the orphaned relationships in the same transaction as
+	// the schema change.
+	OrphanedRelationshipsCascade
+
+	// OrphanedRelationshipsPermissive leaves the orphaned relationships in place and reports the
+	// affected relations as a warning.
+	OrphanedRelationshipsPermissive
+)
+
+var orphanedRelationshipsPolicyNames = map[string]OrphanedRelationshipsPolicy{
+	"strict":     OrphanedRelationshipsStrict,
+	"cascade":    OrphanedRelationshipsCascade,
+	"permissive": OrphanedRelationshipsPermissive,
+}
+
+// ParseOrphanedRelationshipsPolicy parses the name of an OrphanedRelationshipsPolicy: one of
+// `strict`, `cascade` or `permissive`.
+func ParseOrphanedRelationshipsPolicy(name string) (OrphanedRelationshipsPolicy, error) {
+	policy, ok := orphanedRelationshipsPolicyNames[name]
+	if !ok {
+		return OrphanedRelationshipsStrict, fmt.Errorf("unknown orphaned relationships policy `%s`; must be one of `strict`, `cascade` or `permissive`", name)
+	}
+	return policy, nil
+}
+
 // ValidatedSchemaChanges is a set of validated schema changes that can be applied to the datastore.
 type ValidatedSchemaChanges struct {
 	compiled          *compiler.CompiledSchema
 	newCaveatDefNames *mapz.Set[string]
 	newObjectDefNames *mapz.Set[string]
 	additiveOnly      bool
+	orphanPolicy      OrphanedRelationshipsPolicy
 }
 
 // ValidateSchemaChanges validates the schema found in the compiled schema and returns a
 // ValidatedSchemaChanges, if fully validated.
-func ValidateSchemaChanges(ctx context.Context, compiled *compiler.CompiledSchema, additiveOnly bool) (*ValidatedSchemaChanges, error) {
+func ValidateSchemaChanges(ctx context.Context, compiled *compiler.CompiledSchema, additiveOnly bool, orphanPolicy OrphanedRelationshipsPolicy) (*ValidatedSchemaChanges, error) {
 	// 1) Validate the caveats defined.
 	newCaveatDefNames := mapz.NewSet[string]()
 	for _, caveatDef := range compiled.CaveatDefinitions {
@@ -64,6 +102,7 @@ func ValidateSchemaChanges(ctx context.Context, compiled *compiler.CompiledSchem
 		newCaveatDefNames: newCaveatDefNames,
 		newObjectDefNames: newObjectDefNames,
 		additiveOnly:      additiveOnly,
+		orphanPolicy:      orphanPolicy,
 	}, nil
 }
 
@@ -84,6 +123,16 @@ type AppliedSchemaChanges struct {
 
 	// RemovedCaveatDefNames contains the names of the removed caveat definitions.
 	RemovedCaveatDefNames []string
+
+	// OrphanedRelations contains the relations, in `definition#relation` form, and the removed
+	// definitions, in `definition` form, for which relationships were left orphaned by the schema
+	// change. Only populated under the permissive policy. As the datastore deletes the relationships
+	// under a removed definition along with it, only those referencing it as a subject are left.
+	OrphanedRelations []string
+
+	// DeletedOrphanedRelationshipCount holds the number of orphaned relationships deleted under
+	// the cascade policy.
+	DeletedOrphanedRelationshipCount uint64
 }
 
 // ApplySchemaChanges applies schema changes found in the validated changes struct, via the specified
@@ -102,6 +151,47 @@ func ApplySchemaChanges(ctx context.Context, rwt datastore.ReadWriteTransaction,
 	return ApplySchemaChangesOverExisting(ctx, rwt, validated, datastore.DefinitionsOf(existingCaveats), datastore.DefinitionsOf(existingObjectDefs))
 }
 
+// ApplySchemaChangesWithRelationships applies the schema changes found in the validated changes
+// struct and creates the given relationships, via the specified ReadWriteTransaction, so that both
+// become visible at the same revision. The relationships are validated against the definitions in
+// the validated changes before anything is written, and so may only reference those definitions.
+// As the relationships are created, the transaction fails if any of them already exist.
+func ApplySchemaChangesWithRelationships(
+	ctx context.Context,
+	rwt datastore.ReadWriteTransaction,
+	validated *ValidatedSchemaChanges,
+	rels []*core.RelationTuple,
+) (*AppliedSchemaChanges, error) {
+	if err := relationships.ValidateRelationshipsAgainstDefinitions(
+		validated.compiled.ObjectDefinitions,
+		validated.compiled.CaveatDefinitions,
+		rels,
+	); err != nil {
+		return nil, err
+	}
+
+	applied, err := ApplySchemaChanges(ctx, rwt, validated)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rels) == 0 {
+		return applied, nil
+	}
+
+	updates := make([]*core.RelationTupleUpdate, 0, len(rels))
+	for _, rel := range rels {
+		updates = append(updates, tuple.Create(rel))
+	}
+
+	if err := rwt.WriteRelationships(ctx, updates); err != nil {
+		return nil, err
+	}
+
+	applied.TotalOperationCount += uint32(len(rels))
+	return applied, nil
+}
+
 // ApplySchemaChangesOverExisting applies schema changes found in the validated changes struct, against
 // existing caveat and object definitions given.
 func ApplySchemaChangesOverExisting(
@@ -146,8 +236,9 @@ func ApplySchemaChangesOverExisting(
 	// For each definition, perform a diff and ensure the changes will not result in any
 	// breaking changes.
 	objectDefsWithChanges := make([]*core.NamespaceDefinition, 0, len(validated.compiled.ObjectDefinitions))
+	orphans := newOrphanedRelationships(validated.orphanPolicy)
 	for _, nsdef := range validated.compiled.ObjectDefinitions {
-		diff, err := sanityCheckNamespaceChanges(ctx, rwt, nsdef, existingObjectDefMap)
+		diff, err := sanityCheckNamespaceChanges(ctx, rwt, nsdef, existingObjectDefMap, orphans)
 		if err != nil {
 			return nil, err
 		}
@@ -157,25 +248,43 @@ func ApplySchemaChangesOverExisting(
 		}
 	}
 
-	log.Ctx(ctx).
-		Trace().
-		Int("objectDefinitions", len(validated.compiled.ObjectDefinitions)).
-		Int("caveatDefinitions", len(validated.compiled.CaveatDefinitions)).
-		Int("objectDefsWithChanges", len(objectDefsWithChanges)).
-		Int("caveatDefsWithChanges", len(caveatDefsWithChanges)).
-		Msg("validated namespace definitions")
-
-	// Ensure that deleting namespaces will not result in any relationships left without associated
-	// schema.
+	// Handle the relationships left without associated schema by deleting namespaces, as directed
+	// by the policy.
 	removedObjectDefNames := existingObjectDefNames.Subtract(validated.newObjectDefNames)
 	if !validated.additiveOnly {
 		if err := removedObjectDefNames.ForEach(func(nsdefName string) error {
-			return ensureNoRelationshipsExist(ctx, rwt, nsdefName)
+			return checkRemovedDefinition(ctx, rwt, nsdefName, orphans)
 		}); err != nil {
 			return nil, err
 		}
 	}
 
+	// Delete any relationships orphaned by the schema change, under the cascade policy.
+	if len(orphans.deletes) > 0 {
+		updates := make([]*core.RelationTupleUpdate, 0, len(orphans.deletes))
+		for _, update := range orphans.deletes {
+			updates = append(updates, update)
+		}
+
+		if err := rwt.WriteRelationships(ctx, updates); err != nil {
+			return nil, err
+		}
+	}
+
+	orphanedRelations := orphans.relations.AsSlice()
+	sort.Strings(orphanedRelations)
+	for _, relation := range orphanedRelations {
+		log.Ctx(ctx).Warn().Str("relation", relation).Msg("schema change left relationships orphaned; leaving them in place")
+	}
+
+	log.Ctx(ctx).
+		Trace().
+		Int("objectDefinitions", len(validated.compiled.ObjectDefinitions)).
+		Int("caveatDefinitions", len(validated.compiled.CaveatDefinitions)).
+		Int("objectDefsWithChanges", len(objectDefsWithChanges)).
+		Int("caveatDefsWithChanges", len(caveatDefsWithChanges)).
+		Msg("validated namespace definitions")
+
 	// Write the new/changes caveats.
 	if len(caveatDefsWithChanges) > 0 {
 		if err := rwt.WriteCaveats(ctx, caveatDefsWithChanges); err != nil {
@@ -221,6 +330,9 @@ func ApplySchemaChangesOverExisting(
 		RemovedObjectDefNames: removedObjectDefNames.AsSlice(),
 		NewCaveatDefNames:     validated.newCaveatDefNames.Subtract(existingCaveatDefNames).AsSlice(),
 		RemovedCaveatDefNames: removedCaveatDefNames.AsSlice(),
+
+		OrphanedRelations:                orphanedRelations,
+		DeletedOrphanedRelationshipCount: uint64(len(orphans.deletes)),
 	}, nil
 }
 
@@ -253,7 +365,7 @@ func sanityCheckCaveatChanges(
 }
 
 // ensureNoRelationshipsExist ensures that no relationships exist within the namespace with the given name.
-func ensureNoRelationshipsExist(ctx context.Context, rwt datastore.ReadWriteTransaction, namespaceName string) error {
+func ensureNoRelationshipsExist(ctx context.Context, rwt datastore.Reader, namespaceName string) error {
 	qy, qyErr := rwt.QueryRelationships(
 		ctx,
 		datastore.RelationshipsFilter{ResourceType: namespaceName},
@@ -287,14 +399,51 @@ func ensureNoRelationshipsExist(ctx context.Context, rwt datastore.ReadWriteTran
 	return nil
 }
 
+// checkRemovedDefinition handles, as directed by the policy, the relationships which would be left
+// without associated schema by removing the object definition with the given name.
+func checkRemovedDefinition(ctx context.Context, rwt datastore.Reader, namespaceName string, orphans *orphanedRelationships) error {
+	qy, qyErr := rwt.QueryRelationships(
+		ctx,
+		datastore.RelationshipsFilter{ResourceType: namespaceName},
+		options.WithLimit(orphans.queryLimit()),
+	)
+	if err := orphans.handle(
+		ctx,
+		qy,
+		qyErr,
+		namespaceName,
+		"",
+		namespaceName,
+		"cannot delete object definition `%s`, as a relationship exists under it",
+		namespaceName,
+	); err != nil {
+		return err
+	}
+
+	qy, qyErr = rwt.ReverseQueryRelationships(ctx, datastore.SubjectsFilter{
+		SubjectType: namespaceName,
+	}, options.WithLimitForReverse(orphans.queryLimit()))
+	return orphans.handle(
+		ctx,
+		qy,
+		qyErr,
+		namespaceName,
+		"",
+		namespaceName,
+		"cannot delete object definition `%s`, as a relationship references it",
+		namespaceName,
+	)
+}
+
 // sanityCheckNamespaceChanges ensures that a namespace definition being written does not result
 // in breaking changes, such as relationships without associated defined schema object definitions
 // and relations.
 func sanityCheckNamespaceChanges(
 	ctx context.Context,
-	rwt datastore.ReadWriteTransaction,
+	rwt datastore.Reader,
 	nsdef *core.NamespaceDefinition,
 	existingDefs map[string]*core.NamespaceDefinition,
+	orphans *orphanedRelationships,
 ) (*namespace.Diff, error) {
 	// Ensure that the updated namespace does not break the existing tuple data.
 	existing := existingDefs[nsdef.Name]
@@ -309,12 +458,15 @@ func sanityCheckNamespaceChanges(
 			qy, qyErr := rwt.QueryRelationships(ctx, datastore.RelationshipsFilter{
 				ResourceType:             nsdef.Name,
 				OptionalResourceRelation: delta.RelationName,
-			})
+			}, options.WithLimit(orphans.queryLimit()))
 
-			err = errorIfTupleIteratorReturnsTuples(
+			err = orphans.handle(
 				ctx,
 				qy,
 				qyErr,
+				nsdef.Name,
+				delta.RelationName,
+				orphanedChangeKey(nsdef.Name, delta),
 				"cannot delete relation `%s` in object definition `%s`, as a relationship exists under it", delta.RelationName, nsdef.Name)
 			if err != nil {
 				return diff, err
@@ -326,11 +478,14 @@ func sanityCheckNamespaceChanges(
 				RelationFilter: datastore.SubjectRelationFilter{
 					NonEllipsisRelation: delta.RelationName,
 				},
-			}, options.WithLimitForReverse(options.LimitOne))
-			err = errorIfTupleIteratorReturnsTuples(
+			}, options.WithLimitForReverse(orphans.queryLimit()))
+			err = orphans.handle(
 				ctx,
 				qy,
 				qyErr,
+				nsdef.Name,
+				delta.RelationName,
+				orphanedChangeKey(nsdef.Name, delta),
 				"cannot delete relation `%s` in object definition `%s`, as a relationship references it", delta.RelationName, nsdef.Name)
 			qy.Close()
 			if err != nil {
@@ -368,12 +523,15 @@ func sanityCheckNamespaceChanges(
 					},
 					OptionalCaveatName: optionalCaveatName,
 				},
-				options.WithLimit(options.LimitOne),
+				options.WithLimit(orphans.queryLimit()),
 			)
-			err = errorIfTupleIteratorReturnsTuples(
+			err = orphans.handle(
 				ctx,
 				qyr,
 				qyrErr,
+				nsdef.Name,
+				delta.RelationName,
+				orphanedChangeKey(nsdef.Name, delta),
 				"cannot remove allowed type `%s` from relation `%s` in object definition `%s`, as a relationship exists with it",
 				namespace.SourceForAllowedRelation(delta.AllowedType), delta.RelationName, nsdef.Name)
 			qyr.Close()
@@ -385,6 +543,74 @@ func sanityCheckNamespaceChanges(
 	return diff, nil
 }
 
+// orphanedRelationships collects the relationships orphaned by a schema change, as directed by
+// the policy.
+type orphanedRelationships struct {
+	policy    OrphanedRelationshipsPolicy
+	relations *mapz.Set[string]
+	changes   *mapz.Set[string]
+	deletes   map[string]*core.RelationTupleUpdate
+}
+
+func newOrphanedRelationships(policy OrphanedRelationshipsPolicy) *orphanedRelationships {
+	return &orphanedRelationships{
+		policy:    policy,
+		relations: mapz.NewSet[string](),
+		changes:   mapz.NewSet[string](),
+		deletes:   make(map[string]*core.RelationTupleUpdate),
+	}
+}
+
+// orphanedChangeKey returns the key under which the permissive policy records a change to the
+// object definition with the given name as orphaning relationships: the relation changed, along
+// with the allowed type removed from it, if any.
+func orphanedChangeKey(namespaceName string, delta namespace.Delta) string {
+	key := tuple.JoinRelRef(namespaceName, delta.RelationName)
+	if delta.AllowedType != nil {
+		key += "@" + namespace.SourceForAllowedRelation(delta.AllowedType)
+	}
+	return key
+}
+
+// queryLimit returns the limit to apply when querying for orphaned relationships: all of them
+// are needed in order to cascade their deletion, while otherwise only their existence matters.
+func (o *orphanedRelationships) queryLimit() *uint64 {
+	if o.policy == OrphanedRelationshipsCascade {
+		return nil
+	}
+	return options.LimitOne
+}
+
+// handle processes the relationships returned by the iterator, which would be orphaned for the
+// given relation, or for the given definition if the relation is empty, by the change with the
+// given key, according to the policy. Under the strict policy, an error with the given message is
+// returned if any such relationship exists.
+func (o *orphanedRelationships) handle(ctx context.Context, qy datastore.RelationshipIterator, qyErr error, namespaceName string, relationName string, changeKey string, message string, args ...interface{}) error {
+	if o.policy == OrphanedRelationshipsStrict {
+		return errorIfTupleIteratorReturnsTuples(ctx, qy, qyErr, message, args...)
+	}
+
+	if qyErr != nil {
+		return qyErr
+	}
+	defer qy.Close()
+
+	for rt := qy.Next(); rt != nil; rt = qy.Next() {
+		if o.policy == OrphanedRelationshipsPermissive {
+			if relationName == "" {
+				o.relations.Add(namespaceName)
+			} else {
+				o.relations.Add(tuple.JoinRelRef(namespaceName, relationName))
+			}
+			o.changes.Add(changeKey)
+			break
+		}
+
+		o.deletes[tuple.MustString(rt)] = tuple.Delete(rt.CloneVT())
+	}
+	return qy.Err()
+}
+
 // errorIfTupleIteratorReturnsTuples takes a tuple iterator and any error that was generated
 // when the original iterator was created, and returns an error if iterator contains any tuples.
 func errorIfTupleIteratorReturnsTuples(_ context.Context, qy datastore.RelationshipIterator, qyErr error, message string, args ...interface{}) error {
@@ -9,8 +9,10 @@ import (
 	"github.com/authzed/spicedb/internal/datastore/memdb"
 	"github.com/authzed/spicedb/internal/testfixtures"
 	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
 	"github.com/authzed/spicedb/pkg/schemadsl/compiler"
 	"github.com/authzed/spicedb/pkg/schemadsl/input"
+	"github.com/authzed/spicedb/pkg/tuple"
 )
 
 func TestApplySchemaChanges(t *testing.T) {
@@ -48,7 +50,7 @@ func TestApplySchemaChanges(t *testing.T) {
 	}, &emptyDefaultPrefix)
 	require.NoError(err)
 
-	validated, err := ValidateSchemaChanges(context.Background(), compiled, false)
+	validated, err := ValidateSchemaChanges(context.Background(), compiled, false, OrphanedRelationshipsStrict)
 	require.NoError(err)
 
 	_, err = ds.ReadWriteTx(context.Background(), func(rwt datastore.ReadWriteTransaction) error {
@@ -63,3 +65,305 @@ func TestApplySchemaChanges(t *testing.T) {
 	})
 	require.NoError(err)
 }
+
+func TestApplySchemaChangesOrphanedRelationships(t *testing.T) {
+	for _, tc := range []struct {
+		name                      string
+		policy                    OrphanedRelationshipsPolicy
+		expectedError             string
+		expectedOrphanedRelations []string
+		expectedDeletedCount      uint64
+		expectedRemaining         []string
+	}{
+		{
+			name:          "strict",
+			policy:        OrphanedRelationshipsStrict,
+			expectedError: "as a relationship",
+		},
+		{
+			name:                      "permissive",
+			policy:                    OrphanedRelationshipsPermissive,
+			expectedOrphanedRelations: []string{"document#editor", "document#viewer"},
+			expectedRemaining: []string{
+				"document:first#editor@user:tom",
+				"document:first#viewer@user:fred",
+				"document:second#viewer@document:first#editor",
+			},
+		},
+		{
+			name:                 "cascade",
+			policy:               OrphanedRelationshipsCascade,
+			expectedDeletedCount: 2,
+			expectedRemaining:    []string{"document:first#viewer@user:fred"},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+			rawDS, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+			require.NoError(err)
+
+			ds, _ := testfixtures.DatastoreFromSchemaAndTestRelationships(rawDS, `
+				definition user {}
+
+				definition document {
+					relation viewer: user | document#editor
+					relation editor: user
+				}
+			`, []*core.RelationTuple{
+				tuple.MustParse("document:first#viewer@user:fred"),
+				tuple.MustParse("document:first#editor@user:tom"),
+				tuple.MustParse("document:second#viewer@document:first#editor"),
+			}, require)
+
+			emptyDefaultPrefix := ""
+			compiled, err := compiler.Compile(compiler.InputSchema{
+				Source: input.Source("schema"),
+				SchemaString: `
+					definition user {}
+
+					definition document {
+						relation viewer: user | document#viewer
+					}
+				`,
+			}, &emptyDefaultPrefix)
+			require.NoError(err)
+
+			validated, err := ValidateSchemaChanges(context.Background(), compiled, false, tc.policy)
+			require.NoError(err)
+
+			rev, err := ds.ReadWriteTx(context.Background(), func(rwt datastore.ReadWriteTransaction) error {
+				applied, err := ApplySchemaChanges(context.Background(), rwt, validated)
+				if err != nil {
+					return err
+				}
+
+				require.ElementsMatch(tc.expectedOrphanedRelations, applied.OrphanedRelations)
+				require.Equal(tc.expectedDeletedCount, applied.DeletedOrphanedRelationshipCount)
+				return nil
+			})
+			if tc.expectedError != "" {
+				require.ErrorContains(err, tc.expectedError)
+				return
+			}
+			require.NoError(err)
+
+			iter, err := ds.SnapshotReader(rev).QueryRelationships(context.Background(), datastore.RelationshipsFilter{
+				ResourceType: "document",
+			})
+			require.NoError(err)
+			defer iter.Close()
+
+			var remaining []string
+			for rt := iter.Next(); rt != nil; rt = iter.Next() {
+				remaining = append(remaining, tuple.MustString(rt))
+			}
+			require.NoError(iter.Err())
+			require.ElementsMatch(tc.expectedRemaining, remaining)
+		})
+	}
+}
+
+func TestApplySchemaChangesRemovedDefinitionOrphanedRelationships(t *testing.T) {
+	for _, tc := range []struct {
+		name                      string
+		policy                    OrphanedRelationshipsPolicy
+		expectedError             string
+		expectedOrphanedRelations []string
+		expectedDeletedCount      uint64
+		expectedRemaining         []string
+	}{
+		{
+			name:          "strict",
+			policy:        OrphanedRelationshipsStrict,
+			expectedError: "as a relationship",
+		},
+		{
+			name:                      "permissive",
+			policy:                    OrphanedRelationshipsPermissive,
+			expectedOrphanedRelations: []string{"document#viewer", "folder"},
+			expectedRemaining: []string{
+				"document:first#viewer@user:fred",
+				"document:first#viewer@folder:root#member",
+			},
+		},
+		{
+			name:                 "cascade",
+			policy:               OrphanedRelationshipsCascade,
+			expectedDeletedCount: 2,
+			expectedRemaining:    []string{"document:first#viewer@user:fred"},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+			rawDS, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+			require.NoError(err)
+
+			ds, _ := testfixtures.DatastoreFromSchemaAndTestRelationships(rawDS, `
+				definition user {}
+
+				definition folder {
+					relation member: user
+				}
+
+				definition document {
+					relation viewer: user | folder#member
+				}
+			`, []*core.RelationTuple{
+				tuple.MustParse("document:first#viewer@user:fred"),
+				tuple.MustParse("document:first#viewer@folder:root#member"),
+				tuple.MustParse("folder:root#member@user:tom"),
+			}, require)
+
+			emptyDefaultPrefix := ""
+			compiled, err := compiler.Compile(compiler.InputSchema{
+				Source: input.Source("schema"),
+				SchemaString: `
+					definition user {}
+
+					definition document {
+						relation viewer: user
+					}
+				`,
+			}, &emptyDefaultPrefix)
+			require.NoError(err)
+
+			validated, err := ValidateSchemaChanges(context.Background(), compiled, false, tc.policy)
+			require.NoError(err)
+
+			rev, err := ds.ReadWriteTx(context.Background(), func(rwt datastore.ReadWriteTransaction) error {
+				applied, err := ApplySchemaChanges(context.Background(), rwt, validated)
+				if err != nil {
+					return err
+				}
+
+				require.ElementsMatch(tc.expectedOrphanedRelations, applied.OrphanedRelations)
+				require.Equal(tc.expectedDeletedCount, applied.DeletedOrphanedRelationshipCount)
+				require.Equal([]string{"folder"}, applied.RemovedObjectDefNames)
+				return nil
+			})
+			if tc.expectedError != "" {
+				require.ErrorContains(err, tc.expectedError)
+				return
+			}
+			require.NoError(err)
+
+			var remaining []string
+			for _, resourceType := range []string{"document", "folder"} {
+				iter, err := ds.SnapshotReader(rev).QueryRelationships(context.Background(), datastore.RelationshipsFilter{
+					ResourceType: resourceType,
+				})
+				require.NoError(err)
+
+				for rt := iter.Next(); rt != nil; rt = iter.Next() {
+					remaining = append(remaining, tuple.MustString(rt))
+				}
+				require.NoError(iter.Err())
+				iter.Close()
+			}
+			require.ElementsMatch(tc.expectedRemaining, remaining)
+		})
+	}
+}
+
+func TestApplySchemaChangesWithRelationships(t *testing.T) {
+	for _, tc := range []struct {
+		name              string
+		rels              []*core.RelationTuple
+		expectedError     string
+		expectedNewDefs   []string
+		expectedRemaining []string
+	}{
+		{
+			name:              "writes schema and relationships",
+			rels:              []*core.RelationTuple{tuple.MustParse("organization:acme#admin@user:tom")},
+			expectedNewDefs:   []string{"organization"},
+			expectedRemaining: []string{"organization:acme#admin@user:tom"},
+		},
+		{
+			name:            "writes schema without relationships",
+			expectedNewDefs: []string{"organization"},
+		},
+		{
+			name:          "relationship to unknown relation",
+			rels:          []*core.RelationTuple{tuple.MustParse("organization:acme#member@user:tom")},
+			expectedError: "member",
+		},
+		{
+			name:          "relationship to definition not in schema",
+			rels:          []*core.RelationTuple{tuple.MustParse("document:first#viewer@user:tom")},
+			expectedError: "document",
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+			rawDS, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+			require.NoError(err)
+
+			ds, _ := testfixtures.DatastoreFromSchemaAndTestRelationships(rawDS, `
+				definition user {}
+
+				definition document {
+					relation viewer: user
+				}
+			`, nil, require)
+
+			startRevision, err := ds.HeadRevision(context.Background())
+			require.NoError(err)
+
+			emptyDefaultPrefix := ""
+			compiled, err := compiler.Compile(compiler.InputSchema{
+				Source: input.Source("schema"),
+				SchemaString: `
+					definition user {}
+
+					definition organization {
+						relation admin: user
+					}
+				`,
+			}, &emptyDefaultPrefix)
+			require.NoError(err)
+
+			validated, err := ValidateSchemaChanges(context.Background(), compiled, false, OrphanedRelationshipsStrict)
+			require.NoError(err)
+
+			rev, err := ds.ReadWriteTx(context.Background(), func(rwt datastore.ReadWriteTransaction) error {
+				applied, err := ApplySchemaChangesWithRelationships(context.Background(), rwt, validated, tc.rels)
+				if err != nil {
+					return err
+				}
+
+				require.Equal(tc.expectedNewDefs, applied.NewObjectDefNames)
+				return nil
+			})
+			if tc.expectedError != "" {
+				require.ErrorContains(err, tc.expectedError)
+
+				// Ensure the schema was left untouched.
+				headRevision, err := ds.HeadRevision(context.Background())
+				require.NoError(err)
+				require.True(startRevision.Equal(headRevision))
+				return
+			}
+			require.NoError(err)
+
+			_, _, err = ds.SnapshotReader(rev).ReadNamespaceByName(context.Background(), "organization")
+			require.NoError(err)
+
+			iter, err := ds.SnapshotReader(rev).QueryRelationships(context.Background(), datastore.RelationshipsFilter{
+				ResourceType: "organization",
+			})
+			require.NoError(err)
+			defer iter.Close()
+
+			var remaining []string
+			for rt := iter.Next(); rt != nil; rt = iter.Next() {
+				remaining = append(remaining, tuple.MustString(rt))
+			}
+			require.NoError(iter.Err())
+			require.ElementsMatch(tc.expectedRemaining, remaining)
+		})
+	}
+}
@@ -0,0 +1,245 @@
+package shared
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+
+	"github.com/authzed/spicedb/internal/caveats"
+	"github.com/authzed/spicedb/internal/namespace"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/schemadsl/compiler"
+)
+
+// SchemaChange is a single change made to an object definition by a proposed schema.
+type SchemaChange struct {
+	// Definition is the name of the object definition changed.
+	Definition string `json:"definition"`
+
+	// Type is the type of the change.
+	Type namespace.DeltaType `json:"type"`
+
+	// Name is the name of the relation or permission changed, if any.
+	Name string `json:"name,omitempty"`
+
+	// AllowedType is the allowed subject type added to or removed from the relation, if any.
+	AllowedType string `json:"allowedType,omitempty"`
+
+	// Breaking is true if stored relationships would be left orphaned by the change.
+	Breaking bool `json:"breaking,omitempty"`
+}
+
+// CaveatChange is a single change made to a caveat definition by a proposed schema.
+type CaveatChange struct {
+	// Caveat is the name of the caveat definition changed.
+	Caveat string `json:"caveat"`
+
+	// Type is the type of the change.
+	Type caveats.DeltaType `json:"type"`
+
+	// Parameter is the name of the parameter changed, if any.
+	Parameter string `json:"parameter,omitempty"`
+
+	// Breaking is true if relationships written with the caveat's existing parameters would no
+	// longer match its definition.
+	Breaking bool `json:"breaking,omitempty"`
+}
+
+// SchemaDiff holds the changes made to the stored object and caveat definitions by a proposed
+// schema.
+type SchemaDiff struct {
+	// Changes contains the changes to object definitions, ordered by object definition name.
+	Changes []SchemaChange `json:"changes"`
+
+	// CaveatChanges contains the changes to caveat definitions, ordered by caveat name.
+	CaveatChanges []CaveatChange `json:"caveatChanges"`
+
+	// Breaking is true if any of the changes, including those omitted, is breaking.
+	Breaking bool `json:"breaking"`
+
+	// OmittedChanges is the number of changes omitted from the end of the diff by EncodeWithMaxSize.
+	OmittedChanges int `json:"omittedChanges,omitempty"`
+}
+
+// HasBreakingChanges returns true if any of the changes would leave stored relationships orphaned
+// or no longer matching their caveat.
+func (sd *SchemaDiff) HasBreakingChanges() bool {
+	for _, change := range sd.Changes {
+		if change.Breaking {
+			return true
+		}
+	}
+	for _, change := range sd.CaveatChanges {
+		if change.Breaking {
+			return true
+		}
+	}
+	return false
+}
+
+// EncodeWithMaxSize returns the diff encoded as JSON in at most maxSize bytes, omitting changes
+// from the end of the diff, caveat changes first, as necessary and recording how many were omitted.
+// Breaking reflects all the changes, including those omitted.
+func (sd *SchemaDiff) EncodeWithMaxSize(maxSize int) ([]byte, error) {
+	total := len(sd.Changes) + len(sd.CaveatChanges)
+	kept := total
+	for {
+		summarized := SchemaDiff{
+			Changes:        sd.Changes,
+			CaveatChanges:  sd.CaveatChanges,
+			Breaking:       sd.HasBreakingChanges(),
+			OmittedChanges: total - kept,
+		}
+		if kept < len(sd.Changes) {
+			summarized.Changes = sd.Changes[:kept]
+			summarized.CaveatChanges = []CaveatChange{}
+		} else {
+			summarized.CaveatChanges = sd.CaveatChanges[:kept-len(sd.Changes)]
+		}
+
+		encoded, err := json.Marshal(summarized)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(encoded) <= maxSize || kept == 0 {
+			return encoded, nil
+		}
+
+		// Shrink in proportion to the excess, and by at least one change.
+		shrunk := kept * maxSize / len(encoded)
+		if shrunk >= kept {
+			shrunk = kept - 1
+		}
+		kept = shrunk
+	}
+}
+
+// DiffSchema computes the changes made by the object definitions of the compiled schema to those
+// stored in the datastore, flagging as breaking those which would orphan stored relationships.
+func DiffSchema(ctx context.Context, reader datastore.Reader, compiled *compiler.CompiledSchema) (*SchemaDiff, error) {
+	existingObjectDefs, err := reader.ListAllNamespaces(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	existingDefs := make(map[string]*core.NamespaceDefinition, len(existingObjectDefs))
+	for _, existing := range existingObjectDefs {
+		existingDefs[existing.Definition.Name] = existing.Definition
+	}
+
+	diff := &SchemaDiff{Changes: []SchemaChange{}}
+	newObjectDefNames := make(map[string]struct{}, len(compiled.ObjectDefinitions))
+	for _, nsdef := range compiled.ObjectDefinitions {
+		newObjectDefNames[nsdef.Name] = struct{}{}
+
+		// The permissive policy only records the relations that would be orphaned.
+		orphans := newOrphanedRelationships(OrphanedRelationshipsPermissive)
+		nsdiff, err := sanityCheckNamespaceChanges(ctx, reader, nsdef, existingDefs, orphans)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, delta := range nsdiff.Deltas() {
+			change := SchemaChange{
+				Definition: nsdef.Name,
+				Type:       delta.Type,
+				Name:       delta.RelationName,
+			}
+			if delta.AllowedType != nil {
+				change.AllowedType = namespace.SourceForAllowedRelation(delta.AllowedType)
+			}
+
+			switch delta.Type {
+			case namespace.RemovedRelation, namespace.RelationAllowedTypeRemoved:
+				change.Breaking = orphans.changes.Has(orphanedChangeKey(nsdef.Name, delta))
+			}
+			diff.Changes = append(diff.Changes, change)
+		}
+	}
+
+	for name := range existingDefs {
+		if _, ok := newObjectDefNames[name]; ok {
+			continue
+		}
+
+		err := ensureNoRelationshipsExist(ctx, reader, name)
+		if err != nil && AsValidationError(err) == nil {
+			return nil, err
+		}
+
+		diff.Changes = append(diff.Changes, SchemaChange{
+			Definition: name,
+			Type:       namespace.NamespaceRemoved,
+			Breaking:   err != nil,
+		})
+	}
+
+	sort.SliceStable(diff.Changes, func(i, j int) bool {
+		return diff.Changes[i].Definition < diff.Changes[j].Definition
+	})
+
+	caveatChanges, err := diffCaveats(ctx, reader, compiled)
+	if err != nil {
+		return nil, err
+	}
+	diff.CaveatChanges = caveatChanges
+	diff.Breaking = diff.HasBreakingChanges()
+	return diff, nil
+}
+
+// diffCaveats computes the changes made by the caveat definitions of the compiled schema to those
+// stored in the datastore. Removing a parameter or changing its type is breaking, as relationships
+// may already have been written with a context for the existing parameter.
+func diffCaveats(ctx context.Context, reader datastore.Reader, compiled *compiler.CompiledSchema) ([]CaveatChange, error) {
+	existingCaveatDefs, err := reader.ListAllCaveats(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	existingDefs := make(map[string]*core.CaveatDefinition, len(existingCaveatDefs))
+	for _, existing := range existingCaveatDefs {
+		existingDefs[existing.Definition.Name] = existing.Definition
+	}
+
+	changes := []CaveatChange{}
+	addChanges := func(name string, existing *core.CaveatDefinition, updated *core.CaveatDefinition) error {
+		cdiff, err := caveats.DiffCaveats(existing, updated)
+		if err != nil {
+			return err
+		}
+
+		for _, delta := range cdiff.Deltas() {
+			changes = append(changes, CaveatChange{
+				Caveat:    name,
+				Type:      delta.Type,
+				Parameter: delta.ParameterName,
+				Breaking:  delta.Type == caveats.RemovedParameter || delta.Type == caveats.ParameterTypeChanged,
+			})
+		}
+		return nil
+	}
+
+	newCaveatDefNames := make(map[string]struct{}, len(compiled.CaveatDefinitions))
+	for _, caveatDef := range compiled.CaveatDefinitions {
+		newCaveatDefNames[caveatDef.Name] = struct{}{}
+		if err := addChanges(caveatDef.Name, existingDefs[caveatDef.Name], caveatDef); err != nil {
+			return nil, err
+		}
+	}
+
+	for name, existing := range existingDefs {
+		if _, ok := newCaveatDefNames[name]; ok {
+			continue
+		}
+		if err := addChanges(name, existing, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].Caveat < changes[j].Caveat
+	})
+	return changes, nil
+}
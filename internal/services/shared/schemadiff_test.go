@@ -0,0 +1,203 @@
+package shared
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/internal/caveats"
+	"github.com/authzed/spicedb/internal/datastore/memdb"
+	"github.com/authzed/spicedb/internal/namespace"
+	"github.com/authzed/spicedb/internal/testfixtures"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/schemadsl/compiler"
+	"github.com/authzed/spicedb/pkg/schemadsl/input"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+func TestDiffSchema(t *testing.T) {
+	require := require.New(t)
+	rawDS, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(err)
+
+	ds, revision := testfixtures.DatastoreFromSchemaAndTestRelationships(rawDS, `
+		definition user {}
+
+		definition team {
+			relation member: user
+		}
+
+		definition folder {}
+
+		definition document {
+			relation viewer: user | team#member
+			relation editor: user
+			permission view = viewer
+		}
+	`, []*core.RelationTuple{
+		tuple.MustParse("document:firstdoc#viewer@team:engineering#member"),
+		tuple.MustParse("team:engineering#member@user:tom"),
+	}, require)
+
+	emptyDefaultPrefix := ""
+	compiled, err := compiler.Compile(compiler.InputSchema{
+		Source: input.Source("schema"),
+		SchemaString: `
+			definition user {}
+
+			definition organization {}
+
+			definition document {
+				relation viewer: user
+				permission view = viewer + owner
+				relation owner: user
+			}
+		`,
+	}, &emptyDefaultPrefix)
+	require.NoError(err)
+
+	diff, err := DiffSchema(context.Background(), ds.SnapshotReader(revision), compiled)
+	require.NoError(err)
+	require.Equal([]SchemaChange{
+		{Definition: "document", Type: namespace.RemovedRelation, Name: "editor"},
+		{Definition: "document", Type: namespace.AddedRelation, Name: "owner"},
+		{Definition: "document", Type: namespace.ChangedPermissionImpl, Name: "view"},
+		{Definition: "document", Type: namespace.RelationAllowedTypeRemoved, Name: "viewer", AllowedType: "team#member", Breaking: true},
+		{Definition: "folder", Type: namespace.NamespaceRemoved},
+		{Definition: "organization", Type: namespace.NamespaceAdded},
+		{Definition: "team", Type: namespace.NamespaceRemoved, Breaking: true},
+	}, diff.Changes)
+	require.True(diff.HasBreakingChanges())
+}
+
+func TestDiffSchemaAllowedTypeRemoved(t *testing.T) {
+	require := require.New(t)
+	rawDS, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(err)
+
+	ds, revision := testfixtures.DatastoreFromSchemaAndTestRelationships(rawDS, `
+		definition user {}
+
+		definition group {}
+
+		definition team {}
+
+		definition document {
+			relation viewer: user | group
+		}
+	`, []*core.RelationTuple{
+		tuple.MustParse("document:firstdoc#viewer@user:tom"),
+	}, require)
+
+	emptyDefaultPrefix := ""
+	compiled, err := compiler.Compile(compiler.InputSchema{
+		Source: input.Source("schema"),
+		SchemaString: `
+			definition user {}
+
+			definition group {}
+
+			definition team {}
+
+			definition document {
+				relation viewer: team
+			}
+		`,
+	}, &emptyDefaultPrefix)
+	require.NoError(err)
+
+	// Only the removal of the allowed type of the stored relationship is breaking.
+	diff, err := DiffSchema(context.Background(), ds.SnapshotReader(revision), compiled)
+	require.NoError(err)
+	require.ElementsMatch([]SchemaChange{
+		{Definition: "document", Type: namespace.RelationAllowedTypeAdded, Name: "viewer", AllowedType: "team"},
+		{Definition: "document", Type: namespace.RelationAllowedTypeRemoved, Name: "viewer", AllowedType: "user", Breaking: true},
+		{Definition: "document", Type: namespace.RelationAllowedTypeRemoved, Name: "viewer", AllowedType: "group"},
+	}, diff.Changes)
+}
+
+func TestDiffSchemaCaveats(t *testing.T) {
+	require := require.New(t)
+	rawDS, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(err)
+
+	ds, revision := testfixtures.DatastoreFromSchemaAndTestRelationships(rawDS, `
+		caveat first(a int, b string) {
+			a > 1
+		}
+
+		caveat second(c int) {
+			c > 1
+		}
+
+		definition user {}
+	`, nil, require)
+
+	emptyDefaultPrefix := ""
+	compiled, err := compiler.Compile(compiler.InputSchema{
+		Source: input.Source("schema"),
+		SchemaString: `
+			caveat first(a int) {
+				a > 1
+			}
+
+			caveat third(d int) {
+				d > 1
+			}
+
+			definition user {}
+		`,
+	}, &emptyDefaultPrefix)
+	require.NoError(err)
+
+	diff, err := DiffSchema(context.Background(), ds.SnapshotReader(revision), compiled)
+	require.NoError(err)
+	require.Empty(diff.Changes)
+	// The stored expression is compared as serialized, so may also be reported as changed.
+	require.Subset(diff.CaveatChanges, []CaveatChange{
+		{Caveat: "first", Type: caveats.RemovedParameter, Parameter: "b", Breaking: true},
+		{Caveat: "second", Type: caveats.CaveatRemoved},
+		{Caveat: "third", Type: caveats.CaveatAdded},
+	})
+	require.True(diff.Breaking)
+}
+
+func TestSchemaDiffEncodeWithMaxSize(t *testing.T) {
+	require := require.New(t)
+
+	diff := &SchemaDiff{CaveatChanges: []CaveatChange{{Caveat: "somecaveat", Type: caveats.CaveatAdded}}}
+	for i := 0; i < 1000; i++ {
+		diff.Changes = append(diff.Changes, SchemaChange{
+			Definition: fmt.Sprintf("definition%04d", i),
+			Type:       namespace.NamespaceAdded,
+		})
+	}
+	diff.Changes[999].Breaking = true
+
+	encoded, err := diff.EncodeWithMaxSize(2048)
+	require.NoError(err)
+	require.LessOrEqual(len(encoded), 2048)
+
+	var decoded SchemaDiff
+	require.NoError(json.Unmarshal(encoded, &decoded))
+	require.NotEmpty(decoded.Changes)
+	require.Empty(decoded.CaveatChanges)
+	require.Equal(1001, len(decoded.Changes)+decoded.OmittedChanges)
+	require.Equal(diff.Changes[:len(decoded.Changes)], decoded.Changes)
+
+	// The omitted breaking change is still reflected in the summary.
+	require.True(decoded.Breaking)
+
+	// Diffs within the size are encoded in full.
+	encoded, err = diff.EncodeWithMaxSize(1 << 20)
+	require.NoError(err)
+
+	var full SchemaDiff
+	require.NoError(json.Unmarshal(encoded, &full))
+	require.Len(full.Changes, 1000)
+	require.Len(full.CaveatChanges, 1)
+	require.Zero(full.OmittedChanges)
+}
@@ -0,0 +1,128 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/authzed/authzed-go/pkg/responsemeta"
+	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/authzed/spicedb/internal/graph/computed"
+	"github.com/authzed/spicedb/internal/namespace"
+	"github.com/authzed/spicedb/pkg/datastore"
+)
+
+const (
+	// RequestCheckMatrixResourceIDs is the request header which, when present on a CheckPermission
+	// request, contains comma-separated IDs of further resources of the requested resource type to
+	// check, alongside the requested resource, for each permission of the check matrix. The results
+	// are returned in the CheckMatrix response trailer.
+	RequestCheckMatrixResourceIDs = "io.spicedb.requestcheckmatrixresourceids"
+
+	// RequestCheckMatrixPermissions is the request header which, when present on a CheckPermission
+	// request, contains comma-separated further permissions to check, alongside the requested
+	// permission, for each resource of the check matrix. The results are returned in the
+	// CheckMatrix response trailer.
+	RequestCheckMatrixPermissions = "io.spicedb.requestcheckmatrixpermissions"
+
+	// CheckMatrix is the response trailer containing the JSON-encoded results of the check matrix
+	// requested with the RequestCheckMatrixResourceIDs or RequestCheckMatrixPermissions headers,
+	// keyed by permission and then by resource ID. Each result is one of `HAS_PERMISSION`,
+	// `NO_PERMISSION` or `CONDITIONAL_PERMISSION`. All results are computed at the revision of
+	// the response.
+	CheckMatrix responsemeta.ResponseMetadataTrailerKey = "io.spicedb.respmeta.checkmatrix"
+
+	// maxCheckMatrixCells is the maximum number of results in a check matrix, which bounds the size
+	// of the CheckMatrix response trailer.
+	maxCheckMatrixCells = 256
+)
+
+// checkMatrixFromContext returns the resource IDs and permissions of the check matrix requested
+// in the request headers, each starting with those of the request, if any was requested.
+func checkMatrixFromContext(ctx context.Context, req *v1.CheckPermissionRequest) ([]string, []string, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil, nil, false
+	}
+
+	extraResourceIDs := md.Get(RequestCheckMatrixResourceIDs)
+	extraPermissions := md.Get(RequestCheckMatrixPermissions)
+	if len(extraResourceIDs) == 0 && len(extraPermissions) == 0 {
+		return nil, nil, false
+	}
+
+	return withCommaSeparated(req.Resource.ObjectId, extraResourceIDs), withCommaSeparated(req.Permission, extraPermissions), true
+}
+
+// withCommaSeparated returns the first value followed by the distinct comma-separated values
+// found in the header values, in order.
+func withCommaSeparated(first string, headerValues []string) []string {
+	values := []string{first}
+	seen := map[string]struct{}{first: {}}
+	for _, headerValue := range headerValues {
+		for _, value := range strings.Split(headerValue, ",") {
+			value = strings.TrimSpace(value)
+			if _, ok := seen[value]; ok || value == "" {
+				continue
+			}
+			seen[value] = struct{}{}
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// computeCheckMatrix checks each of the permissions over each of the resources of the requested
+// resource type, with the parameters of the request, and returns the results in the CheckMatrix
+// response trailer.
+func (ps *permissionServer) computeCheckMatrix(
+	ctx context.Context,
+	reader datastore.Reader,
+	params computed.CheckParameters,
+	resourceIDs []string,
+	permissions []string,
+) error {
+	if cells := len(resourceIDs) * len(permissions); cells > maxCheckMatrixCells {
+		return NewCheckMatrixTooLargeErr(cells, maxCheckMatrixCells)
+	}
+
+	toCheck := make([]namespace.TypeAndRelationToCheck, 0, len(permissions))
+	for _, permission := range permissions {
+		toCheck = append(toCheck, namespace.TypeAndRelationToCheck{
+			NamespaceName: params.ResourceType.Namespace,
+			RelationName:  permission,
+			AllowEllipsis: false,
+		})
+	}
+	if err := namespace.CheckNamespaceAndRelations(ctx, toCheck, reader); err != nil {
+		return err
+	}
+
+	results, _, err := computed.ComputeCheckMatrix(ctx, ps.dispatch, params, permissions, resourceIDs)
+	if err != nil {
+		return err
+	}
+
+	matrix := make(map[string]map[string]string, len(permissions))
+	for permission, permissionResults := range results {
+		matrix[permission] = make(map[string]string, len(permissionResults))
+		for resourceID, result := range permissionResults {
+			permissionship, _, err := ps.permissionshipOf(result)
+			if err != nil {
+				return err
+			}
+			matrix[permission][resourceID] = strings.TrimPrefix(permissionship.String(), "PERMISSIONSHIP_")
+		}
+	}
+
+	encoded, err := json.Marshal(matrix)
+	if err != nil {
+		return err
+	}
+
+	return responsemeta.SetResponseTrailerMetadata(ctx, map[responsemeta.ResponseMetadataTrailerKey]string{
+		CheckMatrix: string(encoded),
+	})
+}
@@ -6,9 +6,12 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/authzed/authzed-go/pkg/responsemeta"
 	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
+	"google.golang.org/protobuf/proto"
 
 	cexpr "github.com/authzed/spicedb/internal/caveats"
+	log "github.com/authzed/spicedb/internal/logging"
 	"github.com/authzed/spicedb/pkg/datastore"
 	dispatch "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
 	"github.com/authzed/spicedb/pkg/schemadsl/compiler"
@@ -16,6 +19,31 @@ import (
 	"github.com/authzed/spicedb/pkg/tuple"
 )
 
+// RequestDebugTraceMaxDepth is the request header which, when present alongside the request for debug
+// information, bounds the depth of the returned check trace. Its value must be a positive integer.
+const RequestDebugTraceMaxDepth = "io.spicedb.requestdebugtracemaxdepth"
+
+// DebugTraceOmittedSubtrees is the response trailer containing, as a JSON array of
+// OmittedCheckTraces, the number of check traces omitted below each trace returned without its
+// sub-problems because it was found at the requested maximum depth.
+const DebugTraceOmittedSubtrees responsemeta.ResponseMetadataTrailerKey = "io.spicedb.respmeta.debugtraceomittedsubtrees"
+
+// OmittedCheckTraces summarizes the check traces omitted below a single trace found at the
+// maximum depth of the debug information.
+type OmittedCheckTraces struct {
+	// Resource is the resource of the trace, in `type:id` form.
+	Resource string `json:"resource"`
+
+	// Permission is the permission or relation of the trace.
+	Permission string `json:"permission"`
+
+	// Subject is the subject of the trace, in `type:id` or `type:id#relation` form.
+	Subject string `json:"subject"`
+
+	// Count is the number of traces omitted below the trace, at any depth.
+	Count uint32 `json:"count"`
+}
+
 // ConvertCheckDispatchDebugInformation converts dispatch debug information found in the response metadata
 // into DebugInformation returnable to the API.
 func ConvertCheckDispatchDebugInformation(
@@ -24,19 +52,35 @@ func ConvertCheckDispatchDebugInformation(
 	metadata *dispatch.ResponseMeta,
 	reader datastore.Reader,
 ) (*v1.DebugInformation, error) {
+	converted, _, err := ConvertCheckDispatchDebugInformationWithMaxDepth(ctx, caveatContext, metadata, reader, 0)
+	return converted, err
+}
+
+// ConvertCheckDispatchDebugInformationWithMaxDepth converts dispatch debug information found in the
+// response metadata into DebugInformation returnable to the API, including only traces found at most
+// maxTraceDepth levels deep, with the top-level trace at depth one. Traces found at the maximum depth
+// are returned without their sub-problems, and the number of traces omitted below each of them is
+// returned, ordered by resource and permission. A maxTraceDepth of zero includes the full trace.
+func ConvertCheckDispatchDebugInformationWithMaxDepth(
+	ctx context.Context,
+	caveatContext map[string]any,
+	metadata *dispatch.ResponseMeta,
+	reader datastore.Reader,
+	maxTraceDepth uint32,
+) (*v1.DebugInformation, []OmittedCheckTraces, error) {
 	debugInfo := metadata.DebugInfo
 	if debugInfo == nil {
-		return nil, nil
+		return nil, nil, nil
 	}
 
 	caveats, err := reader.ListAllCaveats(ctx)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	namespaces, err := reader.ListAllNamespaces(ctx)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	defs := make([]compiler.SchemaDefinition, 0, len(namespaces)+len(caveats))
@@ -49,21 +93,61 @@ func ConvertCheckDispatchDebugInformation(
 
 	schema, _, err := generator.GenerateSchema(defs)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	converted, err := convertCheckTrace(ctx, caveatContext, debugInfo.Check, reader)
+	converter := &checkTraceConverter{
+		caveatContext: caveatContext,
+		reader:        reader,
+		maxDepth:      maxTraceDepth,
+	}
+	converted, err := converter.convert(ctx, debugInfo.Check, 1)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	return &v1.DebugInformation{
 		Check:      converted,
 		SchemaUsed: strings.TrimSpace(schema),
-	}, nil
+	}, converter.omitted, nil
+}
+
+type checkTraceConverter struct {
+	caveatContext map[string]any
+	reader        datastore.Reader
+	maxDepth      uint32
+	omitted       []OmittedCheckTraces
 }
 
-func convertCheckTrace(ctx context.Context, caveatContext map[string]any, ct *dispatch.CheckDebugTrace, reader datastore.Reader) (*v1.CheckDebugTrace, error) {
+func countCheckTraces(traces []*dispatch.CheckDebugTrace) uint32 {
+	count := uint32(len(traces))
+	for _, trace := range traces {
+		count += countCheckTraces(trace.SubProblems)
+	}
+	return count
+}
+
+// addOmitted records the sub-problems of the given trace as omitted, keeping the summaries ordered.
+func (c *checkTraceConverter) addOmitted(ct *dispatch.CheckDebugTrace) {
+	omitted := OmittedCheckTraces{
+		Resource:   tuple.JoinObjectRef(ct.Request.ResourceRelation.Namespace, strings.Join(ct.Request.ResourceIds, ",")),
+		Permission: ct.Request.ResourceRelation.Relation,
+		Subject:    tuple.StringONR(ct.Request.Subject),
+		Count:      countCheckTraces(ct.SubProblems),
+	}
+
+	index := sort.Search(len(c.omitted), func(i int) bool {
+		if c.omitted[i].Resource != omitted.Resource {
+			return c.omitted[i].Resource > omitted.Resource
+		}
+		return c.omitted[i].Permission > omitted.Permission
+	})
+	c.omitted = append(c.omitted, OmittedCheckTraces{})
+	copy(c.omitted[index+1:], c.omitted[index:])
+	c.omitted[index] = omitted
+}
+
+func (c *checkTraceConverter) convert(ctx context.Context, ct *dispatch.CheckDebugTrace, depth uint32) (*v1.CheckDebugTrace, error) {
 	permissionType := v1.CheckDebugTrace_PERMISSION_TYPE_UNSPECIFIED
 	if ct.ResourceRelationType == dispatch.CheckDebugTrace_PERMISSION {
 		permissionType = v1.CheckDebugTrace_PERMISSION_TYPE_PERMISSION
@@ -93,7 +177,7 @@ func convertCheckTrace(ctx context.Context, caveatContext map[string]any, ct *di
 	var caveatEvalInfo *v1.CaveatEvalInfo
 	if permissionship == v1.CheckDebugTrace_PERMISSIONSHIP_CONDITIONAL_PERMISSION && len(partialResults) == 1 {
 		partialCheckResult := partialResults[0]
-		computedResult, err := cexpr.RunCaveatExpression(ctx, partialCheckResult.Expression, caveatContext, reader, cexpr.RunCaveatExpressionWithDebugInformation)
+		computedResult, err := cexpr.RunCaveatExpression(ctx, partialCheckResult.Expression, c.caveatContext, c.reader, cexpr.RunCaveatExpressionWithDebugInformation)
 		if err != nil {
 			return nil, err
 		}
@@ -136,13 +220,19 @@ func convertCheckTrace(ctx context.Context, caveatContext map[string]any, ct *di
 
 	if len(ct.SubProblems) > 0 {
 		subProblems := make([]*v1.CheckDebugTrace, 0, len(ct.SubProblems))
-		for _, subProblem := range ct.SubProblems {
-			converted, err := convertCheckTrace(ctx, caveatContext, subProblem, reader)
-			if err != nil {
-				return nil, err
-			}
+		if c.maxDepth > 0 && depth >= c.maxDepth {
+			// The traversal itself went deeper; only the trace is bounded, so summarize the
+			// sub-problems that are not returned.
+			c.addOmitted(ct)
+		} else {
+			for _, subProblem := range ct.SubProblems {
+				converted, err := c.convert(ctx, subProblem, depth+1)
+				if err != nil {
+					return nil, err
+				}
 
-			subProblems = append(subProblems, converted)
+				subProblems = append(subProblems, converted)
+			}
 		}
 
 		sort.Sort(sortByResource(subProblems))
@@ -202,3 +292,43 @@ func (a sortByResource) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
 func (a sortByResource) Less(i, j int) bool {
 	return strings.Compare(tuple.StringObjectRef(a[i].Resource), tuple.StringObjectRef(a[j].Resource)) < 0
 }
+
+// loggableDebugInformation returns a copy of the given debug information for logging, with the
+// object and subject IDs of its check trace redacted as configured by log.SetIDRedaction. Caveat
+// context may also hold IDs, so it is omitted whenever IDs are redacted.
+func loggableDebugInformation(debugInfo *v1.DebugInformation) *v1.DebugInformation {
+	if !log.IDsRedacted() {
+		return debugInfo
+	}
+
+	redacted := proto.Clone(debugInfo).(*v1.DebugInformation)
+	redactCheckDebugTrace(redacted.Check)
+	return redacted
+}
+
+func redactCheckDebugTrace(trace *v1.CheckDebugTrace) {
+	if trace == nil {
+		return
+	}
+
+	if trace.Resource != nil {
+		// The resource of a trace can name several resource IDs, joined by commas.
+		resourceIDs := strings.Split(trace.Resource.ObjectId, ",")
+		for index, resourceID := range resourceIDs {
+			resourceIDs[index] = log.RedactID(resourceID)
+		}
+		trace.Resource.ObjectId = strings.Join(resourceIDs, ",")
+	}
+
+	if trace.Subject.GetObject() != nil {
+		trace.Subject.Object.ObjectId = log.RedactID(trace.Subject.Object.ObjectId)
+	}
+
+	if trace.CaveatEvaluationInfo != nil {
+		trace.CaveatEvaluationInfo.Context = nil
+	}
+
+	for _, subProblem := range trace.GetSubProblems().GetTraces() {
+		redactCheckDebugTrace(subProblem)
+	}
+}
@@ -0,0 +1,49 @@
+package v1
+
+import (
+	"testing"
+
+	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	log "github.com/authzed/spicedb/internal/logging"
+)
+
+func TestLoggableDebugInformation(t *testing.T) {
+	t.Cleanup(func() { log.SetIDRedaction(log.IDRedactionNone) })
+
+	caveatContext, err := structpb.NewStruct(map[string]any{"owner": "tom"})
+	require.NoError(t, err)
+
+	debugInfo := &v1.DebugInformation{
+		Check: &v1.CheckDebugTrace{
+			Resource:   &v1.ObjectReference{ObjectType: "document", ObjectId: "firstdoc,seconddoc"},
+			Permission: "view",
+			Subject:    &v1.SubjectReference{Object: &v1.ObjectReference{ObjectType: "user", ObjectId: "tom"}},
+			Resolution: &v1.CheckDebugTrace_SubProblems_{
+				SubProblems: &v1.CheckDebugTrace_SubProblems{
+					Traces: []*v1.CheckDebugTrace{{
+						Resource:             &v1.ObjectReference{ObjectType: "document", ObjectId: "firstdoc"},
+						Permission:           "viewer",
+						Subject:              &v1.SubjectReference{Object: &v1.ObjectReference{ObjectType: "user", ObjectId: "tom"}},
+						CaveatEvaluationInfo: &v1.CaveatEvalInfo{Context: caveatContext},
+					}},
+				},
+			},
+		},
+	}
+
+	require.Same(t, debugInfo, loggableDebugInformation(debugInfo))
+
+	log.SetIDRedaction(log.IDRedactionFull)
+	marshaled, err := protojson.Marshal(loggableDebugInformation(debugInfo))
+	require.NoError(t, err)
+	require.NotContains(t, string(marshaled), "firstdoc")
+	require.NotContains(t, string(marshaled), "tom")
+	require.Contains(t, string(marshaled), "document")
+
+	// The original is left unchanged.
+	require.Equal(t, "firstdoc,seconddoc", debugInfo.Check.Resource.ObjectId)
+}
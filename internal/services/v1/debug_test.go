@@ -2,6 +2,7 @@ package v1_test
 
 import (
 	"context"
+	"encoding/json"
 	"sort"
 	"strings"
 	"testing"
@@ -17,6 +18,7 @@ import (
 	"google.golang.org/protobuf/types/known/structpb"
 
 	"github.com/authzed/spicedb/internal/datastore/memdb"
+	v1svc "github.com/authzed/spicedb/internal/services/v1"
 	tf "github.com/authzed/spicedb/internal/testfixtures"
 	"github.com/authzed/spicedb/internal/testserver"
 	"github.com/authzed/spicedb/pkg/datastore"
@@ -508,3 +510,121 @@ func TestCheckPermissionWithDebug(t *testing.T) {
 		})
 	}
 }
+
+func traceDepth(checkTrace *v1.CheckDebugTrace) int {
+	maxDepth := 0
+	if subProblems := checkTrace.GetSubProblems(); subProblems != nil {
+		for _, sp := range subProblems.Traces {
+			if depth := traceDepth(sp); depth > maxDepth {
+				maxDepth = depth
+			}
+		}
+	}
+	return maxDepth + 1
+}
+
+func TestCheckPermissionWithDebugTraceMaxDepth(t *testing.T) {
+	req := require.New(t)
+	conn, cleanup, _, revision := testserver.NewTestServer(req, testTimedeltas[0], memdb.DisableGC, true,
+		func(ds datastore.Datastore, require *require.Assertions) (datastore.Datastore, datastore.Revision) {
+			return tf.DatastoreFromSchemaAndTestRelationships(ds, `definition user {}
+
+			definition folder {
+				relation parent: folder
+				relation fviewer: user
+				permission fview = fviewer + parent->fview
+			}
+
+			definition document {
+				relation folder: folder
+				relation viewer: user
+				permission view = viewer + folder->fview
+			}`, []*core.RelationTuple{
+				tuple.MustParse("document:first#folder@folder:f1"),
+				tuple.MustParse("folder:f1#parent@folder:f2"),
+				tuple.MustParse("folder:f2#parent@folder:f3"),
+				tuple.MustParse("folder:f3#fviewer@user:sarah"),
+			}, require)
+		})
+
+	client := v1.NewPermissionsServiceClient(conn)
+	t.Cleanup(cleanup)
+
+	checkWithDepth := func(maxDepth string) (*v1.DebugInformation, metadata.MD, error) {
+		ctx := requestmeta.AddRequestHeaders(context.Background(), requestmeta.RequestDebugInformation)
+		if maxDepth != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, v1svc.RequestDebugTraceMaxDepth, maxDepth)
+		}
+
+		var trailer metadata.MD
+		_, err := client.CheckPermission(ctx, &v1.CheckPermissionRequest{
+			Consistency: &v1.Consistency{
+				Requirement: &v1.Consistency_AtLeastAsFresh{
+					AtLeastAsFresh: zedtoken.MustNewFromRevision(revision),
+				},
+			},
+			Resource:   obj("document", "first"),
+			Permission: "view",
+			Subject:    sub("user", "sarah", ""),
+		}, grpc.Trailer(&trailer))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		encodedDebugInfo, err := responsemeta.GetResponseTrailerMetadataOrNil(trailer, responsemeta.DebugInformation)
+		req.NoError(err)
+		req.NotNil(encodedDebugInfo)
+
+		debugInfo := &v1.DebugInformation{}
+		req.NoError(protojson.Unmarshal([]byte(*encodedDebugInfo), debugInfo))
+		return debugInfo, trailer, nil
+	}
+
+	fullInfo, fullTrailer, err := checkWithDepth("")
+	req.NoError(err)
+	fullDepth := traceDepth(fullInfo.Check)
+	req.Greater(fullDepth, 2)
+
+	omitted, err := responsemeta.GetResponseTrailerMetadataOrNil(fullTrailer, v1svc.DebugTraceOmittedSubtrees)
+	req.NoError(err)
+	req.Nil(omitted)
+
+	boundedInfo, boundedTrailer, err := checkWithDepth("2")
+	req.NoError(err)
+	req.Equal(2, traceDepth(boundedInfo.Check))
+	req.Equal(fullInfo.Check.Result, boundedInfo.Check.Result)
+
+	omitted, err = responsemeta.GetResponseTrailerMetadataOrNil(boundedTrailer, v1svc.DebugTraceOmittedSubtrees)
+	req.NoError(err)
+	req.NotNil(omitted)
+
+	// Each trace returned at the maximum depth without its sub-problems is summarized separately.
+	var subtrees []v1svc.OmittedCheckTraces
+	req.NoError(json.Unmarshal([]byte(*omitted), &subtrees))
+	req.NotEmpty(subtrees)
+
+	leaves := 0
+	var countLeaves func(trace *v1.CheckDebugTrace, depth int)
+	countLeaves = func(trace *v1.CheckDebugTrace, depth int) {
+		if depth == 2 {
+			if len(trace.GetSubProblems().GetTraces()) == 0 {
+				leaves++
+			}
+			return
+		}
+		for _, sub := range trace.GetSubProblems().GetTraces() {
+			countLeaves(sub, depth+1)
+		}
+	}
+	countLeaves(boundedInfo.Check, 1)
+	req.LessOrEqual(len(subtrees), leaves)
+
+	for _, subtree := range subtrees {
+		req.Equal("user:sarah", subtree.Subject)
+		req.NotZero(subtree.Count)
+	}
+
+	_, _, err = checkWithDepth("0")
+	req.Error(err)
+	req.Contains(err.Error(), "maximum debug trace depth must be a positive integer")
+}
@@ -3,6 +3,7 @@ package v1
 import (
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/rs/zerolog"
 	"google.golang.org/grpc/codes"
@@ -301,6 +302,265 @@ func (err ErrInvalidCursor) GRPCStatus() *status.Status {
 	)
 }
 
+// ErrInvalidDebugTraceMaxDepth indicates that an invalid maximum depth was requested for the debug trace.
+type ErrInvalidDebugTraceMaxDepth struct {
+	error
+	value string
+}
+
+// NewInvalidDebugTraceMaxDepthErr constructs a new invalid debug trace maximum depth error.
+func NewInvalidDebugTraceMaxDepthErr(value string) ErrInvalidDebugTraceMaxDepth {
+	return ErrInvalidDebugTraceMaxDepth{
+		error: fmt.Errorf(
+			"the maximum debug trace depth must be a positive integer, found `%s`",
+			value,
+		),
+		value: value,
+	}
+}
+
+// GRPCStatus implements retrieving the gRPC status for the error.
+func (err ErrInvalidDebugTraceMaxDepth) GRPCStatus() *status.Status {
+	return spiceerrors.WithCodeAndDetails(
+		err,
+		codes.InvalidArgument,
+		spiceerrors.ForReason(
+			v1.ErrorReason_ERROR_REASON_UNSPECIFIED,
+			map[string]string{
+				"value": err.value,
+			},
+		),
+	)
+}
+
+// ErrCheckMatrixTooLarge indicates that a check matrix with too many results was requested.
+type ErrCheckMatrixTooLarge struct {
+	error
+	cells    int
+	maxCells int
+}
+
+// NewCheckMatrixTooLargeErr constructs a new check matrix too large error.
+func NewCheckMatrixTooLargeErr(cells int, maxCells int) ErrCheckMatrixTooLarge {
+	return ErrCheckMatrixTooLarge{
+		error: fmt.Errorf(
+			"the check matrix requested has %d results, which exceeds the maximum of %d",
+			cells,
+			maxCells,
+		),
+		cells:    cells,
+		maxCells: maxCells,
+	}
+}
+
+// GRPCStatus implements retrieving the gRPC status for the error.
+func (err ErrCheckMatrixTooLarge) GRPCStatus() *status.Status {
+	return spiceerrors.WithCodeAndDetails(
+		err,
+		codes.InvalidArgument,
+		spiceerrors.ForReason(
+			v1.ErrorReason_ERROR_REASON_UNSPECIFIED,
+			map[string]string{
+				"result_count":            strconv.Itoa(err.cells),
+				"maximum_results_allowed": strconv.Itoa(err.maxCells),
+			},
+		),
+	)
+}
+
+// ErrInvalidBulkImportDuplicateMode indicates that an unknown duplicate mode was requested for a bulk import.
+type ErrInvalidBulkImportDuplicateMode struct {
+	error
+	value string
+}
+
+// NewInvalidBulkImportDuplicateModeErr constructs a new invalid bulk import duplicate mode error.
+func NewInvalidBulkImportDuplicateModeErr(value string) ErrInvalidBulkImportDuplicateMode {
+	return ErrInvalidBulkImportDuplicateMode{
+		error: fmt.Errorf(
+			"the bulk import duplicate mode must be one of `report` or `strict`, found `%s`",
+			value,
+		),
+		value: value,
+	}
+}
+
+// GRPCStatus implements retrieving the gRPC status for the error.
+func (err ErrInvalidBulkImportDuplicateMode) GRPCStatus() *status.Status {
+	return spiceerrors.WithCodeAndDetails(
+		err,
+		codes.InvalidArgument,
+		spiceerrors.ForReason(
+			v1.ErrorReason_ERROR_REASON_UNSPECIFIED,
+			map[string]string{
+				"value": err.value,
+			},
+		),
+	)
+}
+
+// ErrInvalidDeleteDryRunMode indicates that an unknown dry-run mode was requested for a deletion.
+type ErrInvalidDeleteDryRunMode struct {
+	error
+	value string
+}
+
+// NewInvalidDeleteDryRunModeErr constructs a new invalid delete dry-run mode error.
+func NewInvalidDeleteDryRunModeErr(value string) ErrInvalidDeleteDryRunMode {
+	return ErrInvalidDeleteDryRunMode{
+		error: fmt.Errorf(
+			"the delete dry-run mode must be one of `count` or `relationships`, found `%s`",
+			value,
+		),
+		value: value,
+	}
+}
+
+// GRPCStatus implements retrieving the gRPC status for the error.
+func (err ErrInvalidDeleteDryRunMode) GRPCStatus() *status.Status {
+	return spiceerrors.WithCodeAndDetails(
+		err,
+		codes.InvalidArgument,
+		spiceerrors.ForReason(
+			v1.ErrorReason_ERROR_REASON_UNSPECIFIED,
+			map[string]string{
+				"value": err.value,
+			},
+		),
+	)
+}
+
+// ErrInvalidSubjectUsersetsOnlyFilter indicates that only userset subjects were requested without a
+// subject filter, or with a subject filter that already has a relation.
+type ErrInvalidSubjectUsersetsOnlyFilter struct {
+	error
+}
+
+// NewInvalidSubjectUsersetsOnlyFilterErr constructs a new invalid subject usersets-only filter error.
+func NewInvalidSubjectUsersetsOnlyFilterErr() ErrInvalidSubjectUsersetsOnlyFilter {
+	return ErrInvalidSubjectUsersetsOnlyFilter{
+		error: fmt.Errorf(
+			"reading only userset subjects requires a subject filter without a relation",
+		),
+	}
+}
+
+// GRPCStatus implements retrieving the gRPC status for the error.
+func (err ErrInvalidSubjectUsersetsOnlyFilter) GRPCStatus() *status.Status {
+	return spiceerrors.WithCodeAndDetails(
+		err,
+		codes.InvalidArgument,
+		spiceerrors.ForReason(
+			v1.ErrorReason_ERROR_REASON_UNSPECIFIED,
+			map[string]string{},
+		),
+	)
+}
+
+// ErrBulkImportDuplicateRelationship indicates that a relationship being imported with the `strict`
+// duplicate mode already exists.
+type ErrBulkImportDuplicateRelationship struct {
+	error
+	relationship string
+}
+
+// NewBulkImportDuplicateRelationshipErr constructs a new bulk import duplicate relationship error.
+func NewBulkImportDuplicateRelationshipErr(relationship string) ErrBulkImportDuplicateRelationship {
+	return ErrBulkImportDuplicateRelationship{
+		error: fmt.Errorf(
+			"found existing relationship `%s` in strict bulk import",
+			relationship,
+		),
+		relationship: relationship,
+	}
+}
+
+// GRPCStatus implements retrieving the gRPC status for the error.
+func (err ErrBulkImportDuplicateRelationship) GRPCStatus() *status.Status {
+	return spiceerrors.WithCodeAndDetails(
+		err,
+		codes.AlreadyExists,
+		spiceerrors.ForReason(
+			v1.ErrorReason_ERROR_REASON_UNSPECIFIED,
+			map[string]string{
+				"relationship": err.relationship,
+			},
+		),
+	)
+}
+
+// ErrNamespaceNameNotAllowed indicates that a schema defined a namespace whose name does not match
+// the configured namespace name pattern.
+type ErrNamespaceNameNotAllowed struct {
+	error
+	namespaceName string
+	pattern       string
+}
+
+// NewNamespaceNameNotAllowedErr constructs a new namespace name not allowed error.
+func NewNamespaceNameNotAllowedErr(namespaceName string, pattern string) ErrNamespaceNameNotAllowed {
+	return ErrNamespaceNameNotAllowed{
+		error: fmt.Errorf(
+			"the name of definition `%s` does not match the required pattern `%s`",
+			namespaceName,
+			pattern,
+		),
+		namespaceName: namespaceName,
+		pattern:       pattern,
+	}
+}
+
+// GRPCStatus implements retrieving the gRPC status for the error.
+func (err ErrNamespaceNameNotAllowed) GRPCStatus() *status.Status {
+	return spiceerrors.WithCodeAndDetails(
+		err,
+		codes.InvalidArgument,
+		spiceerrors.ForReason(
+			v1.ErrorReason_ERROR_REASON_UNSPECIFIED,
+			map[string]string{
+				"definition_name": err.namespaceName,
+				"pattern":         err.pattern,
+			},
+		),
+	)
+}
+
+// ErrSchemaTooLarge indicates that the schema text given to WriteSchema exceeds the configured
+// maximum size.
+type ErrSchemaTooLarge struct {
+	error
+	size    int
+	maximum uint32
+}
+
+// NewSchemaTooLargeErr constructs a new schema too large error.
+func NewSchemaTooLargeErr(size int, maximum uint32) ErrSchemaTooLarge {
+	return ErrSchemaTooLarge{
+		error: fmt.Errorf(
+			"the schema is %d bytes, which exceeds the maximum allowed size of %d bytes",
+			size,
+			maximum,
+		),
+		size:    size,
+		maximum: maximum,
+	}
+}
+
+// GRPCStatus implements retrieving the gRPC status for the error.
+func (err ErrSchemaTooLarge) GRPCStatus() *status.Status {
+	return spiceerrors.WithCodeAndDetails(
+		err,
+		codes.InvalidArgument,
+		spiceerrors.ForReason(
+			v1.ErrorReason_ERROR_REASON_UNSPECIFIED,
+			map[string]string{
+				"schema_size":         strconv.Itoa(err.size),
+				"maximum_schema_size": strconv.FormatUint(uint64(err.maximum), 10),
+			},
+		),
+	)
+}
+
 func defaultIfZero[T comparable](value T, defaultValue T) T {
 	var zero T
 	if value == zero {
@@ -308,3 +568,99 @@ func defaultIfZero[T comparable](value T, defaultValue T) T {
 	}
 	return value
 }
+
+// ErrInvalidSchemaFingerprint indicates that the expected schema fingerprint given in a request
+// could not be decoded.
+type ErrInvalidSchemaFingerprint struct {
+	error
+	fingerprint string
+}
+
+// NewInvalidSchemaFingerprintErr constructs a new invalid schema fingerprint error.
+func NewInvalidSchemaFingerprintErr(fingerprint string, err error) ErrInvalidSchemaFingerprint {
+	return ErrInvalidSchemaFingerprint{
+		error: fmt.Errorf(
+			"invalid expected schema fingerprint `%s`: %w",
+			fingerprint,
+			err,
+		),
+		fingerprint: fingerprint,
+	}
+}
+
+// GRPCStatus implements retrieving the gRPC status for the error.
+func (err ErrInvalidSchemaFingerprint) GRPCStatus() *status.Status {
+	return spiceerrors.WithCodeAndDetails(
+		err,
+		codes.InvalidArgument,
+		spiceerrors.ForReason(
+			v1.ErrorReason_ERROR_REASON_UNSPECIFIED,
+			map[string]string{
+				"fingerprint": err.fingerprint,
+			},
+		),
+	)
+}
+
+// ErrInvalidSchemaPageSize indicates that an invalid page size was requested for a schema read.
+type ErrInvalidSchemaPageSize struct {
+	error
+	value string
+}
+
+// NewInvalidSchemaPageSizeErr constructs a new invalid schema page size error.
+func NewInvalidSchemaPageSizeErr(value string) ErrInvalidSchemaPageSize {
+	return ErrInvalidSchemaPageSize{
+		error: fmt.Errorf(
+			"the schema page size must be a positive integer, found `%s`",
+			value,
+		),
+		value: value,
+	}
+}
+
+// GRPCStatus implements retrieving the gRPC status for the error.
+func (err ErrInvalidSchemaPageSize) GRPCStatus() *status.Status {
+	return spiceerrors.WithCodeAndDetails(
+		err,
+		codes.InvalidArgument,
+		spiceerrors.ForReason(
+			v1.ErrorReason_ERROR_REASON_UNSPECIFIED,
+			map[string]string{
+				"value": err.value,
+			},
+		),
+	)
+}
+
+// ErrMissingCaveatContext indicates that a caveat could not be evaluated because the request did not
+// provide all of the context it references.
+type ErrMissingCaveatContext struct {
+	error
+	missingFields []string
+}
+
+// NewMissingCaveatContextErr constructs a new missing caveat context error.
+func NewMissingCaveatContextErr(missingFields []string) ErrMissingCaveatContext {
+	return ErrMissingCaveatContext{
+		error: fmt.Errorf(
+			"the permission depends on a caveat whose context was not provided in the request: missing `%s`",
+			strings.Join(missingFields, "`, `"),
+		),
+		missingFields: missingFields,
+	}
+}
+
+// GRPCStatus implements retrieving the gRPC status for the error.
+func (err ErrMissingCaveatContext) GRPCStatus() *status.Status {
+	return spiceerrors.WithCodeAndDetails(
+		err,
+		codes.InvalidArgument,
+		spiceerrors.ForReason(
+			v1.ErrorReason_ERROR_REASON_UNSPECIFIED,
+			map[string]string{
+				"missing_context_fields": strings.Join(err.missingFields, ","),
+			},
+		),
+	)
+}
@@ -4,14 +4,17 @@ import (
 	"context"
 	"errors"
 	"io"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/authzed/authzed-go/pkg/responsemeta"
 	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
 	grpcvalidate "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/validator"
 	"github.com/rs/zerolog/log"
 	"github.com/samber/lo"
 	"golang.org/x/exp/slices"
+	"google.golang.org/grpc/metadata"
 
 	"github.com/authzed/spicedb/internal/middleware"
 	datastoremw "github.com/authzed/spicedb/internal/middleware/datastore"
@@ -36,8 +39,56 @@ const (
 	defaultExportBatchSizeFallback   = 1_000
 	maxExportBatchSizeFallback       = 1_000
 	streamReadTimeoutFallbackSeconds = 600
+	bulkImportDuplicateSampleSize    = 10
 )
 
+// RequestBulkImportDuplicateMode is the request header which selects how BulkImportRelationships
+// handles incoming relationships that already exist. A value of `report` skips them and reports
+// their count and a sample in the response trailers, while `strict` fails the import on the first
+// one found. In both modes, each incoming relationship is looked up among those already stored
+// before it is loaded, so the behavior is the same for every datastore. If absent, incoming
+// relationships are loaded as given, and the behavior depends on the datastore: memdb overwrites
+// the existing relationship, while the Postgres, CockroachDB, MySQL and Spanner datastores reject
+// the duplicate and fail the whole import.
+const RequestBulkImportDuplicateMode = "io.spicedb.requestbulkimportduplicatemode"
+
+// BulkImportDuplicateCount is the response trailer containing the number of imported relationships
+// that already existed, when the `report` duplicate mode is requested.
+const BulkImportDuplicateCount responsemeta.ResponseMetadataTrailerKey = "io.spicedb.respmeta.bulkimportduplicatecount"
+
+// BulkImportDuplicateSample is the response trailer containing a comma-separated sample of the
+// imported relationships that already existed, when the `report` duplicate mode is requested.
+const BulkImportDuplicateSample responsemeta.ResponseMetadataTrailerKey = "io.spicedb.respmeta.bulkimportduplicatesample"
+
+type bulkImportDuplicateMode int
+
+const (
+	bulkImportDuplicatesIgnore bulkImportDuplicateMode = iota
+	bulkImportDuplicatesReport
+	bulkImportDuplicatesStrict
+)
+
+func bulkImportDuplicateModeFromContext(ctx context.Context) (bulkImportDuplicateMode, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return bulkImportDuplicatesIgnore, nil
+	}
+
+	values := md.Get(RequestBulkImportDuplicateMode)
+	if len(values) == 0 {
+		return bulkImportDuplicatesIgnore, nil
+	}
+
+	switch values[0] {
+	case "report":
+		return bulkImportDuplicatesReport, nil
+	case "strict":
+		return bulkImportDuplicatesStrict, nil
+	default:
+		return bulkImportDuplicatesIgnore, NewInvalidBulkImportDuplicateModeErr(values[0])
+	}
+}
+
 // NewExperimentalServer creates a ExperimentalServiceServer instance.
 func NewExperimentalServer(opts ...options.ExperimentalServerOptionsOption) v1.ExperimentalServiceServer {
 	config := options.NewExperimentalServerOptionsWithOptionsAndDefaults(opts...)
@@ -107,9 +158,62 @@ type bulkLoadAdapter struct {
 	currentBatch []*v1.Relationship
 	numSent      int
 	err          error
+
+	duplicateMode   bulkImportDuplicateMode
+	reader          datastore.Reader
+	duplicateCount  uint64
+	duplicateSample []string
+}
+
+func (a *bulkLoadAdapter) Next(ctx context.Context) (*core.RelationTuple, error) {
+	for {
+		next, err := a.next()
+		if next == nil || err != nil || a.duplicateMode == bulkImportDuplicatesIgnore {
+			return next, err
+		}
+
+		exists, err := a.exists(ctx, next)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return next, nil
+		}
+
+		if a.duplicateMode == bulkImportDuplicatesStrict {
+			return nil, NewBulkImportDuplicateRelationshipErr(tuple.StringWithoutCaveat(next))
+		}
+
+		a.duplicateCount++
+		if len(a.duplicateSample) < bulkImportDuplicateSampleSize {
+			a.duplicateSample = append(a.duplicateSample, tuple.StringWithoutCaveat(next))
+		}
+	}
+}
+
+// exists returns whether a relationship with the same resource, relation and subject as the given
+// relationship is already stored.
+func (a *bulkLoadAdapter) exists(ctx context.Context, tpl *core.RelationTuple) (bool, error) {
+	it, err := a.reader.QueryRelationships(ctx, datastore.RelationshipsFilter{
+		ResourceType:             tpl.ResourceAndRelation.Namespace,
+		OptionalResourceIds:      []string{tpl.ResourceAndRelation.ObjectId},
+		OptionalResourceRelation: tpl.ResourceAndRelation.Relation,
+		OptionalSubjectsSelectors: []datastore.SubjectsSelector{{
+			OptionalSubjectType: tpl.Subject.Namespace,
+			OptionalSubjectIds:  []string{tpl.Subject.ObjectId},
+			RelationFilter:      datastore.SubjectRelationFilter{}.WithRelation(tpl.Subject.Relation),
+		}},
+	}, dsoptions.WithLimit(lo.ToPtr(uint64(1))))
+	if err != nil {
+		return false, err
+	}
+	defer it.Close()
+
+	found := it.Next() != nil
+	return found, it.Err()
 }
 
-func (a *bulkLoadAdapter) Next(_ context.Context) (*core.RelationTuple, error) {
+func (a *bulkLoadAdapter) next() (*core.RelationTuple, error) {
 	for a.err == nil && a.numSent == len(a.currentBatch) {
 		// Load a new batch
 		batch, err := a.stream.Recv()
@@ -187,7 +291,13 @@ func (es *experimentalServer) rewriteError(ctx context.Context, err error) error
 func (es *experimentalServer) BulkImportRelationships(stream v1.ExperimentalService_BulkImportRelationshipsServer) error {
 	ds := datastoremw.MustFromContext(stream.Context())
 
-	var numWritten uint64
+	duplicateMode, err := bulkImportDuplicateModeFromContext(stream.Context())
+	if err != nil {
+		return es.rewriteError(stream.Context(), err)
+	}
+
+	var numWritten, duplicateCount uint64
+	var duplicateSample []string
 	if _, err := ds.ReadWriteTx(stream.Context(), func(rwt datastore.ReadWriteTransaction) error {
 		loadedNamespaces := make(map[string]*namespace.TypeSystem)
 		loadedCaveats := make(map[string]*core.CaveatDefinition)
@@ -200,7 +310,9 @@ func (es *experimentalServer) BulkImportRelationships(stream v1.ExperimentalServ
 				ResourceAndRelation: &core.ObjectAndRelation{},
 				Subject:             &core.ObjectAndRelation{},
 			},
-			caveat: core.ContextualizedCaveat{},
+			caveat:        core.ContextualizedCaveat{},
+			duplicateMode: duplicateMode,
+			reader:        rwt,
 		}
 
 		var streamWritten uint64
@@ -239,12 +351,23 @@ func (es *experimentalServer) BulkImportRelationships(stream v1.ExperimentalServ
 			}
 		}
 		numWritten += streamWritten
+		duplicateCount = adapter.duplicateCount
+		duplicateSample = adapter.duplicateSample
 
 		return err
 	}, dsoptions.WithDisableRetries(true)); err != nil {
 		return es.rewriteError(stream.Context(), err)
 	}
 
+	if duplicateMode == bulkImportDuplicatesReport {
+		if err := responsemeta.SetResponseTrailerMetadata(stream.Context(), map[responsemeta.ResponseMetadataTrailerKey]string{
+			BulkImportDuplicateCount:  strconv.FormatUint(duplicateCount, 10),
+			BulkImportDuplicateSample: strings.Join(duplicateSample, ","),
+		}); err != nil {
+			return es.rewriteError(stream.Context(), err)
+		}
+	}
+
 	usagemetrics.SetInContext(stream.Context(), &dispatchv1.ResponseMeta{
 		// One request for the whole load
 		DispatchCount: 1,
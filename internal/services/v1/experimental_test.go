@@ -11,10 +11,14 @@ import (
 	"testing"
 
 	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
+	"github.com/authzed/grpcutil"
 	"github.com/scylladb/go-set"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 
 	"github.com/authzed/spicedb/internal/datastore/memdb"
+	v1svc "github.com/authzed/spicedb/internal/services/v1"
 	tf "github.com/authzed/spicedb/internal/testfixtures"
 	"github.com/authzed/spicedb/internal/testserver"
 	"github.com/authzed/spicedb/pkg/tuple"
@@ -108,6 +112,68 @@ func randomBatch(min, max int) func() int {
 	}
 }
 
+func TestBulkImportRelationshipsDuplicateModes(t *testing.T) {
+	testCases := []struct {
+		mode              string
+		expectedLoaded    uint64
+		expectedCode      codes.Code
+		expectedCount     string
+		expectedSample    string
+		expectedDuplicate bool
+	}{
+		{"", 2, codes.OK, "", "", false},
+		{"report", 1, codes.OK, "1", "folder:company#owner@user:owner", true},
+		{"strict", 0, codes.AlreadyExists, "", "", false},
+		{"unknown", 0, codes.InvalidArgument, "", "", false},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.mode, func(t *testing.T) {
+			require := require.New(t)
+
+			conn, cleanup, _, _ := testserver.NewTestServer(require, 0, memdb.DisableGC, true, tf.StandardDatastoreWithData)
+			client := v1.NewExperimentalServiceClient(conn)
+			t.Cleanup(cleanup)
+
+			ctx := context.Background()
+			if tc.mode != "" {
+				ctx = metadata.AppendToOutgoingContext(ctx, v1svc.RequestBulkImportDuplicateMode, tc.mode)
+			}
+
+			writer, err := client.BulkImportRelationships(ctx)
+			require.NoError(err)
+
+			err = writer.Send(&v1.BulkImportRelationshipsRequest{
+				Relationships: []*v1.Relationship{
+					rel("folder", "company", "owner", "user", "owner", ""),
+					rel("folder", "company", "viewer", "user", "newuser", ""),
+				},
+			})
+			if err != nil {
+				require.ErrorIs(err, io.EOF)
+			}
+
+			resp, err := writer.CloseAndRecv()
+			if tc.expectedCode != codes.OK {
+				grpcutil.RequireStatus(t, tc.expectedCode, err)
+				return
+			}
+
+			require.NoError(err)
+			require.Equal(tc.expectedLoaded, resp.NumLoaded)
+
+			trailer := writer.Trailer()
+			if tc.expectedDuplicate {
+				require.Equal([]string{tc.expectedCount}, trailer.Get(string(v1svc.BulkImportDuplicateCount)))
+				require.Equal([]string{tc.expectedSample}, trailer.Get(string(v1svc.BulkImportDuplicateSample)))
+			} else {
+				require.Empty(trailer.Get(string(v1svc.BulkImportDuplicateCount)))
+			}
+		})
+	}
+}
+
 func TestBulkExportRelationships(t *testing.T) {
 	conn, cleanup, _, _ := testserver.NewTestServer(require.New(t), 0, memdb.DisableGC, true, tf.StandardDatastoreWithSchema)
 	client := v1.NewExperimentalServiceClient(conn)
@@ -11,7 +11,7 @@ import (
 	"github.com/authzed/spicedb/pkg/tuple"
 )
 
-func computeReadRelationshipsRequestHash(req *v1.ReadRelationshipsRequest) (string, error) {
+func computeReadRelationshipsRequestHash(req *v1.ReadRelationshipsRequest, caveatName string, subjectUsersetsOnly bool) (string, error) {
 	osf := req.RelationshipFilter.OptionalSubjectFilter
 	if osf == nil {
 		osf = &v1.SubjectFilter{}
@@ -22,7 +22,7 @@ func computeReadRelationshipsRequestHash(req *v1.ReadRelationshipsRequest) (stri
 		srf = osf.OptionalRelation.Relation
 	}
 
-	return computeCallHash("v1.readrelationships", req.Consistency, map[string]any{
+	hashArgs := map[string]any{
 		"filter-resource-type": req.RelationshipFilter.ResourceType,
 		"filter-relation":      req.RelationshipFilter.OptionalRelation,
 		"filter-resource-id":   req.RelationshipFilter.OptionalResourceId,
@@ -30,7 +30,17 @@ func computeReadRelationshipsRequestHash(req *v1.ReadRelationshipsRequest) (stri
 		"subject-relation":     srf,
 		"subject-resource-id":  osf.OptionalSubjectId,
 		"limit":                req.OptionalLimit,
-	})
+	}
+
+	// Only included when set, to keep the hashes of existing cursors stable.
+	if caveatName != "" {
+		hashArgs["filter-caveat-name"] = caveatName
+	}
+	if subjectUsersetsOnly {
+		hashArgs["subject-usersets-only"] = true
+	}
+
+	return computeCallHash("v1.readrelationships", req.Consistency, hashArgs)
 }
 
 func computeLRRequestHash(req *v1.LookupResourcesRequest) (string, error) {
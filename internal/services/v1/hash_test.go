@@ -196,7 +196,7 @@ func TestReadRelationshipsHashStability(t *testing.T) {
 			verr := tc.request.Validate()
 			require.NoError(t, verr)
 
-			hash, err := computeReadRelationshipsRequestHash(tc.request)
+			hash, err := computeReadRelationshipsRequestHash(tc.request, "", false)
 			require.NoError(t, err)
 			require.Equal(t, tc.expectedHash, hash)
 		})
@@ -0,0 +1,54 @@
+package v1
+
+import (
+	"fmt"
+)
+
+// MissingCaveatContextPolicy defines how permission results which depend on a caveat whose
+// context was not provided in the request are returned.
+type MissingCaveatContextPolicy int
+
+const (
+	// MissingCaveatContextConditional returns the result as a conditional permission, along with the
+	// names of the missing context fields.
+	MissingCaveatContextConditional MissingCaveatContextPolicy = iota
+
+	// MissingCaveatContextDeny returns the result as no permission, as though the caveat had
+	// evaluated to false.
+	MissingCaveatContextDeny
+
+	// MissingCaveatContextError fails the request with an error naming the missing context fields.
+	MissingCaveatContextError
+)
+
+var missingCaveatContextPolicyNames = map[string]MissingCaveatContextPolicy{
+	"conditional": MissingCaveatContextConditional,
+	"deny":        MissingCaveatContextDeny,
+	"error":       MissingCaveatContextError,
+}
+
+// ParseMissingCaveatContextPolicy parses the name of a MissingCaveatContextPolicy: one of
+// `conditional`, `deny` or `error`.
+func ParseMissingCaveatContextPolicy(name string) (MissingCaveatContextPolicy, error) {
+	policy, ok := missingCaveatContextPolicyNames[name]
+	if !ok {
+		return MissingCaveatContextConditional, fmt.Errorf("unknown missing caveat context policy `%s`; must be one of `conditional`, `deny` or `error`", name)
+	}
+	return policy, nil
+}
+
+// resolveConditional applies the policy to a result which is conditional on the given missing
+// context fields, returning whether the result should be returned as conditional rather than
+// denied, or the error with which to fail the request.
+func (policy MissingCaveatContextPolicy) resolveConditional(missingFields []string) (bool, error) {
+	switch policy {
+	case MissingCaveatContextDeny:
+		return false, nil
+
+	case MissingCaveatContextError:
+		return false, NewMissingCaveatContextErr(missingFields)
+
+	default:
+		return true, nil
+	}
+}
@@ -2,7 +2,10 @@ package v1
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
 
 	"github.com/authzed/authzed-go/pkg/requestmeta"
 	"github.com/authzed/authzed-go/pkg/responsemeta"
@@ -19,6 +22,7 @@ import (
 	dispatchpkg "github.com/authzed/spicedb/internal/dispatch"
 	"github.com/authzed/spicedb/internal/graph"
 	"github.com/authzed/spicedb/internal/graph/computed"
+	log "github.com/authzed/spicedb/internal/logging"
 	datastoremw "github.com/authzed/spicedb/internal/middleware/datastore"
 	"github.com/authzed/spicedb/internal/middleware/usagemetrics"
 	"github.com/authzed/spicedb/internal/namespace"
@@ -37,6 +41,35 @@ func (ps *permissionServer) rewriteError(ctx context.Context, err error) error {
 	})
 }
 
+// MissingNamespacePolicy defines how a check handles a resource whose namespace is not defined
+// in the schema.
+type MissingNamespacePolicy int
+
+const (
+	// MissingNamespaceError fails the check with the same error returned for any other undefined
+	// object definition.
+	MissingNamespaceError MissingNamespacePolicy = iota
+
+	// MissingNamespaceDeny returns that the subject does not have the permission, allowing clients
+	// to degrade gracefully while a schema defining the namespace is being rolled out.
+	MissingNamespaceDeny
+)
+
+var missingNamespacePolicyNames = map[string]MissingNamespacePolicy{
+	"error": MissingNamespaceError,
+	"deny":  MissingNamespaceDeny,
+}
+
+// ParseMissingNamespacePolicy parses the name of a MissingNamespacePolicy: one of `error` or
+// `deny`.
+func ParseMissingNamespacePolicy(name string) (MissingNamespacePolicy, error) {
+	policy, ok := missingNamespacePolicyNames[name]
+	if !ok {
+		return MissingNamespaceError, fmt.Errorf("unknown missing namespace policy `%s`; must be one of `error` or `deny`", name)
+	}
+	return policy, nil
+}
+
 func (ps *permissionServer) CheckPermission(ctx context.Context, req *v1.CheckPermissionRequest) (*v1.CheckPermissionResponse, error) {
 	atRevision, checkedAt, err := consistency.RevisionFromContext(ctx)
 	if err != nil {
@@ -63,41 +96,59 @@ func (ps *permissionServer) CheckPermission(ctx context.Context, req *v1.CheckPe
 				AllowEllipsis: true,
 			},
 		}, ds); err != nil {
+		var nsNotFoundErr namespace.ErrNamespaceNotFound
+		if ps.config.CheckMissingNamespacePolicy == MissingNamespaceDeny &&
+			errors.As(err, &nsNotFoundErr) &&
+			nsNotFoundErr.NotFoundNamespaceName() == req.Resource.ObjectType {
+			log.Ctx(ctx).Debug().Str("namespace", req.Resource.ObjectType).Msg("denying check for resource of undefined namespace")
+			return &v1.CheckPermissionResponse{
+				CheckedAt:      checkedAt,
+				Permissionship: v1.CheckPermissionResponse_PERMISSIONSHIP_NO_PERMISSION,
+			}, nil
+		}
+
 		return nil, ps.rewriteError(ctx, err)
 	}
 
 	debugOption := computed.NoDebugging
+	var debugTraceMaxDepth uint32
 	if md, ok := metadata.FromIncomingContext(ctx); ok {
 		_, isDebuggingEnabled := md[string(requestmeta.RequestDebugInformation)]
 		if isDebuggingEnabled {
 			debugOption = computed.BasicDebuggingEnabled
 		}
+
+		if values := md.Get(RequestDebugTraceMaxDepth); len(values) > 0 {
+			parsed, perr := strconv.ParseUint(values[0], 10, 32)
+			if perr != nil || parsed == 0 {
+				return nil, ps.rewriteError(ctx, NewInvalidDebugTraceMaxDepthErr(values[0]))
+			}
+			debugTraceMaxDepth = uint32(parsed)
+		}
 	}
 
-	cr, metadata, err := computed.ComputeCheck(ctx, ps.dispatch,
-		computed.CheckParameters{
-			ResourceType: &core.RelationReference{
-				Namespace: req.Resource.ObjectType,
-				Relation:  req.Permission,
-			},
-			Subject: &core.ObjectAndRelation{
-				Namespace: req.Subject.Object.ObjectType,
-				ObjectId:  req.Subject.Object.ObjectId,
-				Relation:  normalizeSubjectRelation(req.Subject),
-			},
-			CaveatContext: caveatContext,
-			AtRevision:    atRevision,
-			MaximumDepth:  ps.config.MaximumAPIDepth,
-			DebugOption:   debugOption,
+	params := computed.CheckParameters{
+		ResourceType: &core.RelationReference{
+			Namespace: req.Resource.ObjectType,
+			Relation:  req.Permission,
+		},
+		Subject: &core.ObjectAndRelation{
+			Namespace: req.Subject.Object.ObjectType,
+			ObjectId:  req.Subject.Object.ObjectId,
+			Relation:  normalizeSubjectRelation(req.Subject),
 		},
-		req.Resource.ObjectId,
-	)
+		CaveatContext: caveatContext,
+		AtRevision:    atRevision,
+		MaximumDepth:  ps.config.MaximumAPIDepth,
+		DebugOption:   debugOption,
+	}
+	cr, metadata, err := computed.ComputeCheck(ctx, ps.dispatch, params, req.Resource.ObjectId)
 	usagemetrics.SetInContext(ctx, metadata)
 
 	if debugOption != computed.NoDebugging && metadata.DebugInfo != nil {
 		// Convert the dispatch debug information into API debug information and marshal into
 		// the footer.
-		converted, cerr := ConvertCheckDispatchDebugInformation(ctx, caveatContext, metadata, ds)
+		converted, omitted, cerr := ConvertCheckDispatchDebugInformationWithMaxDepth(ctx, caveatContext, metadata, ds, debugTraceMaxDepth)
 		if cerr != nil {
 			return nil, ps.rewriteError(ctx, cerr)
 		}
@@ -107,9 +158,32 @@ func (ps *permissionServer) CheckPermission(ctx context.Context, req *v1.CheckPe
 			return nil, ps.rewriteError(ctx, merr)
 		}
 
-		serr := responsemeta.SetResponseTrailerMetadata(ctx, map[responsemeta.ResponseMetadataTrailerKey]string{
+		loggable, merr := protojson.Marshal(loggableDebugInformation(converted))
+		if merr != nil {
+			return nil, ps.rewriteError(ctx, merr)
+		}
+
+		log.Ctx(ctx).Debug().
+			Str("revision", atRevision.String()).
+			RawJSON("debugInformation", loggable).
+			Msg("computed check debug information")
+
+		trailer := map[responsemeta.ResponseMetadataTrailerKey]string{
 			responsemeta.DebugInformation: string(marshaled),
-		})
+		}
+		if debugTraceMaxDepth > 0 {
+			if omitted == nil {
+				omitted = []OmittedCheckTraces{}
+			}
+
+			marshaledOmitted, merr := json.Marshal(omitted)
+			if merr != nil {
+				return nil, ps.rewriteError(ctx, merr)
+			}
+			trailer[DebugTraceOmittedSubtrees] = string(marshaledOmitted)
+		}
+
+		serr := responsemeta.SetResponseTrailerMetadata(ctx, trailer)
 		if serr != nil {
 			return nil, ps.rewriteError(ctx, serr)
 		}
@@ -119,14 +193,15 @@ func (ps *permissionServer) CheckPermission(ctx context.Context, req *v1.CheckPe
 		return nil, ps.rewriteError(ctx, err)
 	}
 
-	var partialCaveat *v1.PartialCaveatInfo
-	permissionship := v1.CheckPermissionResponse_PERMISSIONSHIP_NO_PERMISSION
-	if cr.Membership == dispatch.ResourceCheckResult_MEMBER {
-		permissionship = v1.CheckPermissionResponse_PERMISSIONSHIP_HAS_PERMISSION
-	} else if cr.Membership == dispatch.ResourceCheckResult_CAVEATED_MEMBER {
-		permissionship = v1.CheckPermissionResponse_PERMISSIONSHIP_CONDITIONAL_PERMISSION
-		partialCaveat = &v1.PartialCaveatInfo{
-			MissingRequiredContext: cr.MissingExprFields,
+	permissionship, partialCaveat, err := ps.permissionshipOf(cr)
+	if err != nil {
+		return nil, ps.rewriteError(ctx, err)
+	}
+
+	if resourceIDs, permissions, ok := checkMatrixFromContext(ctx, req); ok {
+		params.DebugOption = computed.NoDebugging
+		if err := ps.computeCheckMatrix(ctx, ds, params, resourceIDs, permissions); err != nil {
+			return nil, ps.rewriteError(ctx, err)
 		}
 	}
 
@@ -137,6 +212,35 @@ func (ps *permissionServer) CheckPermission(ctx context.Context, req *v1.CheckPe
 	}, nil
 }
 
+// permissionshipOf returns the permissionship of a check result, along with the context missing
+// to compute it if conditional, as directed by the missing caveat context policy.
+func (ps *permissionServer) permissionshipOf(cr *dispatch.ResourceCheckResult) (v1.CheckPermissionResponse_Permissionship, *v1.PartialCaveatInfo, error) {
+	switch cr.Membership {
+	case dispatch.ResourceCheckResult_MEMBER:
+		return v1.CheckPermissionResponse_PERMISSIONSHIP_HAS_PERMISSION, nil, nil
+
+	case dispatch.ResourceCheckResult_CAVEATED_MEMBER:
+		conditional, err := ps.config.MissingCaveatContextPolicy.resolveConditional(cr.MissingExprFields)
+		if err != nil {
+			return v1.CheckPermissionResponse_PERMISSIONSHIP_UNSPECIFIED, nil, err
+		}
+
+		if conditional {
+			return v1.CheckPermissionResponse_PERMISSIONSHIP_CONDITIONAL_PERMISSION, &v1.PartialCaveatInfo{
+				MissingRequiredContext: cr.MissingExprFields,
+			}, nil
+		}
+	}
+
+	return v1.CheckPermissionResponse_PERMISSIONSHIP_NO_PERMISSION, nil, nil
+}
+
+// RequestExpandAllowTruncation is the request header which, when present on an ExpandPermissionTree
+// request, returns the tree expanded up to the maximum depth rather than failing the request. Each
+// point at which the tree was truncated is returned as a node with neither an intermediate nor a
+// leaf set.
+const RequestExpandAllowTruncation = "io.spicedb.requestexpandallowtruncation"
+
 func (ps *permissionServer) ExpandPermissionTree(ctx context.Context, req *v1.ExpandPermissionTreeRequest) (*v1.ExpandPermissionTreeResponse, error) {
 	atRevision, expandedAt, err := consistency.RevisionFromContext(ctx)
 	if err != nil {
@@ -150,6 +254,12 @@ func (ps *permissionServer) ExpandPermissionTree(ctx context.Context, req *v1.Ex
 		return nil, ps.rewriteError(ctx, err)
 	}
 
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if _, allowTruncation := md[RequestExpandAllowTruncation]; allowTruncation {
+			ctx = graph.ContextWithExpandTruncation(ctx)
+		}
+	}
+
 	resp, err := ps.dispatch.DispatchExpand(ctx, &dispatch.DispatchExpandRequest{
 		Metadata: &dispatch.ResolverMeta{
 			AtRevision:     atRevision.String(),
@@ -234,6 +344,12 @@ func TranslateRelationshipTree(tree *v1.PermissionRelationshipTree) *core.Relati
 			Expanded: expanded,
 		}
 
+	case nil:
+		// A tree without a type marks a truncated expansion.
+		return &core.RelationTupleTreeNode{
+			Expanded: expanded,
+		}
+
 	default:
 		panic("unknown type of expansion tree node")
 	}
@@ -315,6 +431,18 @@ func TranslateExpansionTree(node *core.RelationTupleTreeNode) *v1.PermissionRela
 			ExpandedRelation: node.Expanded.Relation,
 		}
 
+	case nil:
+		// A node without a type marks a truncated expansion.
+		tree := &v1.PermissionRelationshipTree{}
+		if node.Expanded != nil {
+			tree.ExpandedObject = &v1.ObjectReference{
+				ObjectType: node.Expanded.Namespace,
+				ObjectId:   node.Expanded.ObjectId,
+			}
+			tree.ExpandedRelation = node.Expanded.Relation
+		}
+		return tree
+
 	default:
 		panic("unknown type of expansion tree node")
 	}
@@ -379,6 +507,16 @@ func (ps *permissionServer) LookupResources(req *v1.LookupResourcesRequest, resp
 		var partial *v1.PartialCaveatInfo
 		permissionship := v1.LookupPermissionship_LOOKUP_PERMISSIONSHIP_HAS_PERMISSION
 		if found.Permissionship == dispatch.ResolvedResource_CONDITIONALLY_HAS_PERMISSION {
+			conditional, err := ps.config.MissingCaveatContextPolicy.resolveConditional(found.MissingRequiredContext)
+			if err != nil {
+				return ps.rewriteError(ctx, err)
+			}
+
+			if !conditional {
+				// Skip the denied resource.
+				return nil
+			}
+
 			permissionship = v1.LookupPermissionship_LOOKUP_PERMISSIONSHIP_CONDITIONAL_PERMISSION
 			partial = &v1.PartialCaveatInfo{
 				MissingRequiredContext: found.MissingRequiredContext,
@@ -490,7 +628,7 @@ func (ps *permissionServer) LookupSubjects(req *v1.LookupSubjectsRequest, resp v
 
 			excludedSubjects := make([]*v1.ResolvedSubject, 0, len(foundSubject.ExcludedSubjects))
 			for _, excludedSubject := range foundSubject.ExcludedSubjects {
-				resolvedExcludedSubject, err := foundSubjectToResolvedSubject(ctx, excludedSubject, caveatContext, ds)
+				resolvedExcludedSubject, err := foundSubjectToResolvedSubject(ctx, excludedSubject, caveatContext, ds, ps.config.MissingCaveatContextPolicy, true)
 				if err != nil {
 					return err
 				}
@@ -502,7 +640,7 @@ func (ps *permissionServer) LookupSubjects(req *v1.LookupSubjectsRequest, resp v
 				excludedSubjects = append(excludedSubjects, resolvedExcludedSubject)
 			}
 
-			subject, err := foundSubjectToResolvedSubject(ctx, foundSubject, caveatContext, ds)
+			subject, err := foundSubjectToResolvedSubject(ctx, foundSubject, caveatContext, ds, ps.config.MissingCaveatContextPolicy, false)
 			if err != nil {
 				return err
 			}
@@ -552,7 +690,18 @@ func (ps *permissionServer) LookupSubjects(req *v1.LookupSubjectsRequest, resp v
 	return nil
 }
 
-func foundSubjectToResolvedSubject(ctx context.Context, foundSubject *dispatch.FoundSubject, caveatContext map[string]any, ds datastore.CaveatReader) (*v1.ResolvedSubject, error) {
+// foundSubjectToResolvedSubject converts the found subject into its API form, evaluating its caveat
+// expression, if any, over the caveat context. Returns nil if the subject is not found once its
+// caveat has been evaluated. If the caveat is missing context, the policy is applied; a denied
+// excluded subject is returned as unconditionally excluded.
+func foundSubjectToResolvedSubject(
+	ctx context.Context,
+	foundSubject *dispatch.FoundSubject,
+	caveatContext map[string]any,
+	ds datastore.CaveatReader,
+	policy MissingCaveatContextPolicy,
+	isExcluded bool,
+) (*v1.ResolvedSubject, error) {
 	var partialCaveat *v1.PartialCaveatInfo
 	permissionship := v1.LookupPermissionship_LOOKUP_PERMISSIONSHIP_HAS_PERMISSION
 	if foundSubject.GetCaveatExpression() != nil {
@@ -567,8 +716,21 @@ func foundSubjectToResolvedSubject(ctx context.Context, foundSubject *dispatch.F
 			permissionship = v1.LookupPermissionship_LOOKUP_PERMISSIONSHIP_HAS_PERMISSION
 		} else if cr.IsPartial() {
 			missingFields, _ := cr.MissingVarNames()
-			partialCaveat = &v1.PartialCaveatInfo{
-				MissingRequiredContext: missingFields,
+			conditional, err := policy.resolveConditional(missingFields)
+			if err != nil {
+				return nil, err
+			}
+
+			switch {
+			case conditional:
+				partialCaveat = &v1.PartialCaveatInfo{
+					MissingRequiredContext: missingFields,
+				}
+			case isExcluded:
+				permissionship = v1.LookupPermissionship_LOOKUP_PERMISSIONSHIP_HAS_PERMISSION
+			default:
+				// Skip this denied subject.
+				return nil, nil
 			}
 		} else {
 			// Skip this found subject.
@@ -2,6 +2,7 @@ package v1_test
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -306,6 +307,58 @@ func TestCheckPermissions(t *testing.T) {
 	}
 }
 
+func TestCheckPermissionMissingNamespacePolicy(t *testing.T) {
+	testCases := []struct {
+		policy       string
+		resourceType string
+		subjectType  string
+		expectedCode codes.Code
+	}{
+		{"error", "unknown", "user", codes.FailedPrecondition},
+		{"deny", "unknown", "user", codes.OK},
+		{"deny", "document", "unknown", codes.FailedPrecondition},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.policy+"-"+tc.resourceType+"-"+tc.subjectType, func(t *testing.T) {
+			require := require.New(t)
+			conn, cleanup, _, _ := testserver.NewTestServerWithConfig(
+				require,
+				testTimedeltas[0],
+				memdb.DisableGC,
+				true,
+				testserver.ServerConfig{
+					MaxUpdatesPerWrite:          1000,
+					MaxPreconditionsCount:       1000,
+					StreamingAPITimeout:         30 * time.Second,
+					CheckMissingNamespacePolicy: tc.policy,
+				},
+				tf.StandardDatastoreWithData,
+			)
+			client := v1.NewPermissionsServiceClient(conn)
+			t.Cleanup(cleanup)
+
+			resp, err := client.CheckPermission(context.Background(), &v1.CheckPermissionRequest{
+				Consistency: &v1.Consistency{
+					Requirement: &v1.Consistency_FullyConsistent{FullyConsistent: true},
+				},
+				Resource:   obj(tc.resourceType, "someresource"),
+				Permission: "view",
+				Subject:    sub(tc.subjectType, "someuser", ""),
+			})
+			if tc.expectedCode != codes.OK {
+				grpcutil.RequireStatus(t, tc.expectedCode, err)
+				return
+			}
+
+			require.NoError(err)
+			require.NotNil(resp.CheckedAt)
+			require.Equal(v1.CheckPermissionResponse_PERMISSIONSHIP_NO_PERMISSION, resp.Permissionship)
+		})
+	}
+}
+
 func TestCheckPermissionWithDebugInfo(t *testing.T) {
 	require := require.New(t)
 	conn, cleanup, _, revision := testserver.NewTestServer(require, testTimedeltas[0], memdb.DisableGC, true, tf.StandardDatastoreWithData)
@@ -352,6 +405,68 @@ func TestCheckPermissionWithDebugInfo(t *testing.T) {
 	require.Equal(4, len(compiled.OrderedDefinitions))
 }
 
+func TestCheckPermissionMatrix(t *testing.T) {
+	require := require.New(t)
+	conn, cleanup, _, revision := testserver.NewTestServer(require, testTimedeltas[0], memdb.DisableGC, true, tf.StandardDatastoreWithData)
+	client := v1.NewPermissionsServiceClient(conn)
+	t.Cleanup(cleanup)
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(),
+		RequestCheckMatrixResourceIDs, "companyplan, healthplan",
+		RequestCheckMatrixPermissions, "edit",
+	)
+
+	var trailer metadata.MD
+	checkResp, err := client.CheckPermission(ctx, &v1.CheckPermissionRequest{
+		Consistency: &v1.Consistency{
+			Requirement: &v1.Consistency_AtLeastAsFresh{
+				AtLeastAsFresh: zedtoken.MustNewFromRevision(revision),
+			},
+		},
+		Resource:   obj("document", "masterplan"),
+		Permission: "view",
+		Subject:    sub("user", "auditor", ""),
+	}, grpc.Trailer(&trailer))
+	require.NoError(err)
+	require.Equal(v1.CheckPermissionResponse_PERMISSIONSHIP_HAS_PERMISSION, checkResp.Permissionship)
+
+	encodedMatrix, err := responsemeta.GetResponseTrailerMetadataOrNil(trailer, CheckMatrix)
+	require.NoError(err)
+	require.NotNil(encodedMatrix)
+
+	var matrix map[string]map[string]string
+	require.NoError(json.Unmarshal([]byte(*encodedMatrix), &matrix))
+	require.Equal(map[string]map[string]string{
+		"view": {
+			"masterplan":  "HAS_PERMISSION",
+			"companyplan": "HAS_PERMISSION",
+			"healthplan":  "NO_PERMISSION",
+		},
+		"edit": {
+			"masterplan":  "NO_PERMISSION",
+			"companyplan": "NO_PERMISSION",
+			"healthplan":  "NO_PERMISSION",
+		},
+	}, matrix)
+
+	tooManyIDs := make([]string, 0, maxCheckMatrixCells)
+	for i := 0; i < maxCheckMatrixCells; i++ {
+		tooManyIDs = append(tooManyIDs, fmt.Sprintf("doc%d", i))
+	}
+
+	_, err = client.CheckPermission(
+		metadata.AppendToOutgoingContext(context.Background(), RequestCheckMatrixResourceIDs, strings.Join(tooManyIDs, ",")),
+		&v1.CheckPermissionRequest{
+			Consistency: &v1.Consistency{
+				Requirement: &v1.Consistency_FullyConsistent{FullyConsistent: true},
+			},
+			Resource:   obj("document", "masterplan"),
+			Permission: "view",
+			Subject:    sub("user", "auditor", ""),
+		})
+	grpcutil.RequireStatus(t, codes.InvalidArgument, err)
+}
+
 func TestLookupResources(t *testing.T) {
 	testCases := []struct {
 		objectType        string
@@ -882,6 +997,124 @@ func TestCheckWithCaveats(t *testing.T) {
 	grpcutil.RequireStatus(t, codes.InvalidArgument, err)
 }
 
+func TestMissingCaveatContextPolicy(t *testing.T) {
+	testCases := []struct {
+		policy                 string
+		expectedCode           codes.Code
+		expectedPermissionship v1.CheckPermissionResponse_Permissionship
+		expectedSubjects       []expectedSubject
+	}{
+		{
+			"conditional",
+			codes.OK,
+			v1.CheckPermissionResponse_PERMISSIONSHIP_CONDITIONAL_PERMISSION,
+			[]expectedSubject{{"sarah", true}, {"tom", false}},
+		},
+		{
+			"deny",
+			codes.OK,
+			v1.CheckPermissionResponse_PERMISSIONSHIP_NO_PERMISSION,
+			[]expectedSubject{{"tom", false}},
+		},
+		{
+			"error",
+			codes.InvalidArgument,
+			v1.CheckPermissionResponse_PERMISSIONSHIP_UNSPECIFIED,
+			nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.policy, func(t *testing.T) {
+			req := require.New(t)
+			conn, cleanup, _, revision := testserver.NewTestServerWithConfig(
+				req,
+				testTimedeltas[0],
+				memdb.DisableGC,
+				true,
+				testserver.ServerConfig{
+					MaxUpdatesPerWrite:         1000,
+					MaxPreconditionsCount:      1000,
+					StreamingAPITimeout:        30 * time.Second,
+					MissingCaveatContextPolicy: tc.policy,
+				},
+				func(ds datastore.Datastore, require *require.Assertions) (datastore.Datastore, datastore.Revision) {
+					return tf.DatastoreFromSchemaAndTestRelationships(ds, `
+						definition user {}
+
+						caveat testcaveat(somecondition int) {
+							somecondition == 42
+						}
+
+						definition document {
+							relation viewer: user | user with testcaveat
+							permission view = viewer
+						}
+					`, []*core.RelationTuple{
+						tuple.MustParse("document:first#viewer@user:tom"),
+						tuple.MustWithCaveat(tuple.MustParse("document:first#viewer@user:sarah"), "testcaveat"),
+					}, require)
+				},
+			)
+			client := v1.NewPermissionsServiceClient(conn)
+			t.Cleanup(cleanup)
+
+			ctx := context.Background()
+			consistency := &v1.Consistency{
+				Requirement: &v1.Consistency_AtLeastAsFresh{
+					AtLeastAsFresh: zedtoken.MustNewFromRevision(revision),
+				},
+			}
+
+			checkResp, err := client.CheckPermission(ctx, &v1.CheckPermissionRequest{
+				Consistency: consistency,
+				Resource:    obj("document", "first"),
+				Permission:  "view",
+				Subject:     sub("user", "sarah", ""),
+			})
+			if tc.expectedCode != codes.OK {
+				grpcutil.RequireStatus(t, tc.expectedCode, err)
+				req.ErrorContains(err, "somecondition")
+			} else {
+				req.NoError(err)
+				req.Equal(tc.expectedPermissionship, checkResp.Permissionship)
+			}
+
+			lookupClient, err := client.LookupSubjects(ctx, &v1.LookupSubjectsRequest{
+				Consistency:       consistency,
+				Resource:          obj("document", "first"),
+				Permission:        "view",
+				SubjectObjectType: "user",
+			})
+			req.NoError(err)
+
+			var resolvedSubjects []expectedSubject
+			for {
+				resp, err := lookupClient.Recv()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+
+				if tc.expectedCode != codes.OK {
+					grpcutil.RequireStatus(t, tc.expectedCode, err)
+					return
+				}
+
+				req.NoError(err)
+				resolvedSubjects = append(resolvedSubjects, expectedSubject{
+					resp.Subject.SubjectObjectId,
+					resp.Subject.Permissionship == v1.LookupPermissionship_LOOKUP_PERMISSIONSHIP_CONDITIONAL_PERMISSION,
+				})
+			}
+
+			req.Equal(codes.OK, tc.expectedCode, "expected the lookup to fail")
+			sort.Sort(sortByID(resolvedSubjects))
+			req.Equal(tc.expectedSubjects, resolvedSubjects)
+		})
+	}
+}
+
 func TestCheckWithCaveatErrors(t *testing.T) {
 	req := require.New(t)
 	conn, cleanup, _, revision := testserver.NewTestServer(
@@ -13,14 +13,14 @@ import (
 var limitOne uint64 = 1
 
 // checkPreconditions checks whether the preconditions are met in the context of a datastore
-// read-write transaction, and returns an error if they are not met.
+// reader or read-write transaction, and returns an error if they are not met.
 func checkPreconditions(
 	ctx context.Context,
-	rwt datastore.ReadWriteTransaction,
+	reader datastore.Reader,
 	preconditions []*v1.Precondition,
 ) error {
 	for _, precond := range preconditions {
-		iter, err := rwt.QueryRelationships(ctx, datastore.RelationshipsFilterFromPublicFilter(precond.Filter), options.WithLimit(&limitOne))
+		iter, err := reader.QueryRelationships(ctx, datastore.RelationshipsFilterFromPublicFilter(precond.Filter), options.WithLimit(&limitOne))
 		if err != nil {
 			return fmt.Errorf("error reading relationships: %w", err)
 		}
@@ -3,16 +3,20 @@ package v1
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"time"
 
+	"github.com/authzed/authzed-go/pkg/responsemeta"
 	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
 	grpcvalidate "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/validator"
 	"github.com/jzelinskie/stringz"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/protobuf/proto"
 
 	"github.com/authzed/spicedb/internal/dispatch"
+	log "github.com/authzed/spicedb/internal/logging"
 	"github.com/authzed/spicedb/internal/middleware"
 	datastoremw "github.com/authzed/spicedb/internal/middleware/datastore"
 	"github.com/authzed/spicedb/internal/middleware/handwrittenvalidation"
@@ -68,6 +72,22 @@ type PermissionsServerConfig struct {
 	// MaxDatastoreReadPageSize defines the maximum number of relationships loaded from the
 	// datastore in one query.
 	MaxDatastoreReadPageSize uint64
+
+	// CheckMissingNamespacePolicy defines how CheckPermission handles a resource whose
+	// namespace is not defined in the schema.
+	CheckMissingNamespacePolicy MissingNamespacePolicy
+
+	// RelationshipValidationStrictness defines how thoroughly the relationships written by
+	// WriteRelationships are validated against the schema.
+	RelationshipValidationStrictness relationships.ValidationStrictness
+
+	// WriteUnknownNamespacePolicy defines how WriteRelationships handles relationships which
+	// reference namespaces that are not defined in the schema.
+	WriteUnknownNamespacePolicy UnknownNamespacePolicy
+
+	// MissingCaveatContextPolicy defines how permission results which depend on a caveat whose
+	// context was not provided in the request are returned.
+	MissingCaveatContextPolicy MissingCaveatContextPolicy
 }
 
 // NewPermissionsServer creates a PermissionsServiceServer instance.
@@ -76,13 +96,17 @@ func NewPermissionsServer(
 	config PermissionsServerConfig,
 ) v1.PermissionsServiceServer {
 	configWithDefaults := PermissionsServerConfig{
-		MaxPreconditionsCount:      defaultIfZero(config.MaxPreconditionsCount, 1000),
-		MaxUpdatesPerWrite:         defaultIfZero(config.MaxUpdatesPerWrite, 1000),
-		MaximumAPIDepth:            defaultIfZero(config.MaximumAPIDepth, 50),
-		StreamingAPITimeout:        defaultIfZero(config.StreamingAPITimeout, 30*time.Second),
-		MaxCaveatContextSize:       defaultIfZero(config.MaxCaveatContextSize, 4096),
-		MaxRelationshipContextSize: defaultIfZero(config.MaxRelationshipContextSize, 25_000),
-		MaxDatastoreReadPageSize:   defaultIfZero(config.MaxDatastoreReadPageSize, 1_000),
+		MaxPreconditionsCount:            defaultIfZero(config.MaxPreconditionsCount, 1000),
+		MaxUpdatesPerWrite:               defaultIfZero(config.MaxUpdatesPerWrite, 1000),
+		MaximumAPIDepth:                  defaultIfZero(config.MaximumAPIDepth, 50),
+		StreamingAPITimeout:              defaultIfZero(config.StreamingAPITimeout, 30*time.Second),
+		MaxCaveatContextSize:             defaultIfZero(config.MaxCaveatContextSize, 4096),
+		MaxRelationshipContextSize:       defaultIfZero(config.MaxRelationshipContextSize, 25_000),
+		MaxDatastoreReadPageSize:         defaultIfZero(config.MaxDatastoreReadPageSize, 1_000),
+		CheckMissingNamespacePolicy:      config.CheckMissingNamespacePolicy,
+		RelationshipValidationStrictness: config.RelationshipValidationStrictness,
+		WriteUnknownNamespacePolicy:      config.WriteUnknownNamespacePolicy,
+		MissingCaveatContextPolicy:       config.MissingCaveatContextPolicy,
 	}
 
 	return &permissionServer{
@@ -136,6 +160,47 @@ func (ps *permissionServer) checkFilterNamespaces(ctx context.Context, filter *v
 	return nil
 }
 
+// RequestReadCaveatName is the request header which, when present on a ReadRelationships request,
+// restricts the relationships returned to those whose grant is conditioned on the named caveat.
+// The caveat must be defined in the schema at the revision being read.
+const RequestReadCaveatName = "io.spicedb.requestreadcaveatname"
+
+// RequestReadSubjectUsersetsOnly is the request header which, when present on a ReadRelationships
+// request, restricts the relationships returned to those whose subject is a userset, i.e. has any
+// relation other than the ellipsis, such as `group:eng#member` or `group:eng#admin` but not
+// `group:eng`. Requires a subject filter without a relation. Omitting the relation of a subject
+// filter without this header matches subjects of any relation, including the ellipsis.
+const RequestReadSubjectUsersetsOnly = "io.spicedb.requestreadsubjectusersetsonly"
+
+func readSubjectUsersetsOnlyFromContext(ctx context.Context, filter *v1.RelationshipFilter) (bool, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return false, nil
+	}
+
+	if _, ok := md[RequestReadSubjectUsersetsOnly]; !ok {
+		return false, nil
+	}
+
+	if filter.OptionalSubjectFilter == nil || filter.OptionalSubjectFilter.OptionalRelation != nil {
+		return false, NewInvalidSubjectUsersetsOnlyFilterErr()
+	}
+	return true, nil
+}
+
+func readCaveatNameFromContext(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	values := md.Get(RequestReadCaveatName)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
 func (ps *permissionServer) ReadRelationships(req *v1.ReadRelationshipsRequest, resp v1.PermissionsService_ReadRelationshipsServer) error {
 	ctx := resp.Context()
 	atRevision, revisionReadAt, err := consistency.RevisionFromContext(ctx)
@@ -149,6 +214,13 @@ func (ps *permissionServer) ReadRelationships(req *v1.ReadRelationshipsRequest,
 		return ps.rewriteError(ctx, err)
 	}
 
+	caveatName := readCaveatNameFromContext(ctx)
+	if caveatName != "" {
+		if _, _, err := ds.ReadCaveatByName(ctx, caveatName); err != nil {
+			return ps.rewriteError(ctx, err)
+		}
+	}
+
 	usagemetrics.SetInContext(ctx, &dispatchv1.ResponseMeta{
 		DispatchCount: 1,
 	})
@@ -156,7 +228,12 @@ func (ps *permissionServer) ReadRelationships(req *v1.ReadRelationshipsRequest,
 	limit := 0
 	var startCursor options.Cursor
 
-	rrRequestHash, err := computeReadRelationshipsRequestHash(req)
+	subjectUsersetsOnly, err := readSubjectUsersetsOnlyFromContext(ctx, req.RelationshipFilter)
+	if err != nil {
+		return ps.rewriteError(ctx, err)
+	}
+
+	rrRequestHash, err := computeReadRelationshipsRequestHash(req, caveatName, subjectUsersetsOnly)
 	if err != nil {
 		return ps.rewriteError(ctx, err)
 	}
@@ -187,10 +264,16 @@ func (ps *permissionServer) ReadRelationships(req *v1.ReadRelationshipsRequest,
 		}
 	}
 
+	filter := datastore.RelationshipsFilterFromPublicFilter(req.RelationshipFilter)
+	filter.OptionalCaveatName = caveatName
+	if subjectUsersetsOnly {
+		filter.OptionalSubjectsSelectors[0].RelationFilter = filter.OptionalSubjectsSelectors[0].RelationFilter.WithOnlyNonEllipsisRelations()
+	}
+
 	tupleIterator, err := pagination.NewPaginatedIterator(
 		ctx,
 		ds,
-		datastore.RelationshipsFilterFromPublicFilter(req.RelationshipFilter),
+		filter,
 		pageSize,
 		options.ByResource,
 		startCursor,
@@ -291,8 +374,14 @@ func (ps *permissionServer) WriteRelationships(ctx context.Context, req *v1.Writ
 			}
 		}
 
+		if ps.config.WriteUnknownNamespacePolicy == UnknownNamespaceAutoCreate {
+			if err := createUnknownNamespaces(ctx, rwt, tupleUpdates); err != nil {
+				return ps.rewriteError(ctx, err)
+			}
+		}
+
 		// Validate the updates.
-		err := relationships.ValidateRelationshipUpdates(ctx, rwt, tupleUpdates)
+		err := relationships.ValidateRelationshipUpdatesWithStrictness(ctx, rwt, tupleUpdates, ps.config.RelationshipValidationStrictness)
 		if err != nil {
 			return ps.rewriteError(ctx, err)
 		}
@@ -312,6 +401,8 @@ func (ps *permissionServer) WriteRelationships(ctx context.Context, req *v1.Writ
 		return nil, ps.rewriteError(ctx, err)
 	}
 
+	log.Ctx(ctx).Debug().Stringer("revision", revision).Msg("wrote relationships at revision")
+
 	// Log a metric of the counts of the different kinds of update operations.
 	updateCountByOperation := make(map[v1.RelationshipUpdate_Operation]int, 0)
 	for _, update := range req.Updates {
@@ -327,6 +418,57 @@ func (ps *permissionServer) WriteRelationships(ctx context.Context, req *v1.Writ
 	}, nil
 }
 
+// RequestDeleteDryRun is the request header which, when present on a DeleteRelationships request,
+// reports the relationships that would be deleted at the head revision without deleting anything.
+// A value of `count` reports only the number of matching relationships in the response trailers,
+// while `relationships` (or an empty value) also reports the matching relationships themselves.
+// Preconditions and the deletion limit are evaluated exactly as they would be for the deletion.
+const RequestDeleteDryRun = "io.spicedb.requestdeletedryrun"
+
+// DeleteDryRunCount is the response trailer containing the number of relationships that would have
+// been deleted by a dry-run DeleteRelationships request.
+const DeleteDryRunCount responsemeta.ResponseMetadataTrailerKey = "io.spicedb.respmeta.deletedryruncount"
+
+// DeleteDryRunRelationships is the response trailer containing a comma-separated list of the
+// relationships that would have been deleted by a dry-run DeleteRelationships request, truncated to
+// the first 100 found and to 8KiB. The DeleteDryRunCount trailer contains the full count.
+const DeleteDryRunRelationships responsemeta.ResponseMetadataTrailerKey = "io.spicedb.respmeta.deletedryrunrelationships"
+
+const deleteDryRunMaxListed = 100
+
+type deleteDryRunMode int
+
+const (
+	deleteDryRunDisabled deleteDryRunMode = iota
+	deleteDryRunCountOnly
+	deleteDryRunWithRelationships
+)
+
+func deleteDryRunModeFromContext(ctx context.Context) (deleteDryRunMode, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return deleteDryRunDisabled, nil
+	}
+
+	values, ok := md[RequestDeleteDryRun]
+	if !ok {
+		return deleteDryRunDisabled, nil
+	}
+
+	if len(values) == 0 {
+		return deleteDryRunWithRelationships, nil
+	}
+
+	switch values[0] {
+	case "count":
+		return deleteDryRunCountOnly, nil
+	case "", "relationships":
+		return deleteDryRunWithRelationships, nil
+	default:
+		return deleteDryRunDisabled, NewInvalidDeleteDryRunModeErr(values[0])
+	}
+}
+
 func (ps *permissionServer) DeleteRelationships(ctx context.Context, req *v1.DeleteRelationshipsRequest) (*v1.DeleteRelationshipsResponse, error) {
 	if len(req.OptionalPreconditions) > int(ps.config.MaxPreconditionsCount) {
 		return nil, ps.rewriteError(
@@ -335,6 +477,15 @@ func (ps *permissionServer) DeleteRelationships(ctx context.Context, req *v1.Del
 		)
 	}
 
+	dryRunMode, err := deleteDryRunModeFromContext(ctx)
+	if err != nil {
+		return nil, ps.rewriteError(ctx, err)
+	}
+
+	if dryRunMode != deleteDryRunDisabled {
+		return ps.dryRunDeleteRelationships(ctx, req, dryRunMode)
+	}
+
 	ds := datastoremw.MustFromContext(ctx)
 	deletionProgress := v1.DeleteRelationshipsResponse_DELETION_PROGRESS_COMPLETE
 
@@ -396,8 +547,85 @@ func (ps *permissionServer) DeleteRelationships(ctx context.Context, req *v1.Del
 		return nil, ps.rewriteError(ctx, err)
 	}
 
+	log.Ctx(ctx).Debug().Stringer("revision", revision).Msg("deleted relationships at revision")
+
 	return &v1.DeleteRelationshipsResponse{
 		DeletedAt:        zedtoken.MustNewFromRevision(revision),
 		DeletionProgress: deletionProgress,
 	}, nil
 }
+
+// dryRunDeleteRelationships evaluates a DeleteRelationships request at the head revision and reports
+// the relationships it would delete in the response trailers, without modifying the datastore.
+func (ps *permissionServer) dryRunDeleteRelationships(ctx context.Context, req *v1.DeleteRelationshipsRequest, mode deleteDryRunMode) (*v1.DeleteRelationshipsResponse, error) {
+	ds := datastoremw.MustFromContext(ctx)
+	headRevision, err := ds.HeadRevision(ctx)
+	if err != nil {
+		return nil, ps.rewriteError(ctx, err)
+	}
+
+	reader := ds.SnapshotReader(headRevision)
+	if err := ps.checkFilterNamespaces(ctx, req.RelationshipFilter, reader); err != nil {
+		return nil, ps.rewriteError(ctx, err)
+	}
+
+	usagemetrics.SetInContext(ctx, &dispatchv1.ResponseMeta{
+		DispatchCount: uint32(len(req.OptionalPreconditions)) + 1,
+	})
+
+	if err := checkPreconditions(ctx, reader, req.OptionalPreconditions); err != nil {
+		return nil, ps.rewriteError(ctx, err)
+	}
+
+	var queryOpts []options.QueryOptionsOption
+	if req.OptionalLimit > 0 {
+		limitPlusOne := uint64(req.OptionalLimit) + 1
+		queryOpts = append(queryOpts, options.WithLimit(&limitPlusOne))
+	}
+
+	iter, err := reader.QueryRelationships(ctx, datastore.RelationshipsFilterFromPublicFilter(req.RelationshipFilter), queryOpts...)
+	if err != nil {
+		return nil, ps.rewriteError(ctx, err)
+	}
+	defer iter.Close()
+
+	deletionProgress := v1.DeleteRelationshipsResponse_DELETION_PROGRESS_COMPLETE
+	var count uint64
+	listed := make([]string, 0, deleteDryRunMaxListed)
+	for tpl := iter.Next(); tpl != nil; tpl = iter.Next() {
+		if req.OptionalLimit > 0 && count == uint64(req.OptionalLimit) {
+			deletionProgress = v1.DeleteRelationshipsResponse_DELETION_PROGRESS_PARTIAL
+			if !req.OptionalAllowPartialDeletions {
+				return nil, ps.rewriteError(ctx, NewCouldNotTransactionallyDeleteErr(req.RelationshipFilter, req.OptionalLimit))
+			}
+			break
+		}
+
+		count++
+		if mode == deleteDryRunWithRelationships && len(listed) < deleteDryRunMaxListed {
+			listed = append(listed, tuple.StringWithoutCaveat(tpl))
+		}
+	}
+	if iter.Err() != nil {
+		return nil, ps.rewriteError(ctx, iter.Err())
+	}
+	iter.Close()
+
+	trailer := map[responsemeta.ResponseMetadataTrailerKey]string{
+		DeleteDryRunCount: strconv.FormatUint(count, 10),
+	}
+	if mode == deleteDryRunWithRelationships {
+		trailer[DeleteDryRunRelationships], _ = joinWithinSize(listed, ",", maxListTrailerSize)
+	}
+
+	if err := responsemeta.SetResponseTrailerMetadata(ctx, trailer); err != nil {
+		return nil, ps.rewriteError(ctx, err)
+	}
+
+	log.Ctx(ctx).Debug().Stringer("revision", headRevision).Uint64("count", count).Msg("evaluated dry-run deletion of relationships at revision")
+
+	return &v1.DeleteRelationshipsResponse{
+		DeletedAt:        zedtoken.MustNewFromRevision(headRevision),
+		DeletionProgress: deletionProgress,
+	}, nil
+}
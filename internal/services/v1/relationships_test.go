@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strings"
 	"testing"
 	"time"
 
@@ -13,12 +14,15 @@ import (
 	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
 	"github.com/authzed/grpcutil"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/structpb"
 
 	"github.com/authzed/spicedb/internal/datastore/memdb"
+	v1svc "github.com/authzed/spicedb/internal/services/v1"
 	tf "github.com/authzed/spicedb/internal/testfixtures"
 	"github.com/authzed/spicedb/internal/testserver"
 	core "github.com/authzed/spicedb/pkg/proto/core/v1"
@@ -1149,6 +1153,91 @@ func TestDeleteRelationshipsBeyondLimitPartial(t *testing.T) {
 	}
 }
 
+func TestDeleteRelationshipsDryRun(t *testing.T) {
+	testCases := []struct {
+		name                  string
+		mode                  string
+		limit                 uint32
+		allowPartial          bool
+		expectedCode          codes.Code
+		expectedCount         string
+		expectedRelationships []string
+	}{
+		{
+			"relationships",
+			"relationships",
+			0,
+			false,
+			codes.OK,
+			"2",
+			[]string{"folder:company#viewer@user:legal", "folder:company#viewer@folder:auditors#viewer"},
+		},
+		{"count only", "count", 0, false, codes.OK, "2", nil},
+		{"partial", "count", 1, true, codes.OK, "1", nil},
+		{"beyond limit", "count", 1, false, codes.InvalidArgument, "", nil},
+		{"unknown mode", "unknown", 0, false, codes.InvalidArgument, "", nil},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+			conn, cleanup, _, _ := testserver.NewTestServer(require, 0, memdb.DisableGC, true, tf.StandardDatastoreWithData)
+			client := v1.NewPermissionsServiceClient(conn)
+			t.Cleanup(cleanup)
+
+			filter := &v1.RelationshipFilter{
+				ResourceType:       "folder",
+				OptionalResourceId: "company",
+				OptionalRelation:   "viewer",
+			}
+
+			ctx := metadata.AppendToOutgoingContext(context.Background(), v1svc.RequestDeleteDryRun, tc.mode)
+
+			var trailer metadata.MD
+			resp, err := client.DeleteRelationships(ctx, &v1.DeleteRelationshipsRequest{
+				RelationshipFilter:            filter,
+				OptionalLimit:                 tc.limit,
+				OptionalAllowPartialDeletions: tc.allowPartial,
+			}, grpc.Trailer(&trailer))
+			if tc.expectedCode != codes.OK {
+				grpcutil.RequireStatus(t, tc.expectedCode, err)
+				return
+			}
+
+			require.NoError(err)
+			require.NotNil(resp.DeletedAt)
+			require.Equal([]string{tc.expectedCount}, trailer.Get(string(v1svc.DeleteDryRunCount)))
+
+			if tc.expectedRelationships != nil {
+				listed := trailer.Get(string(v1svc.DeleteDryRunRelationships))
+				require.Len(listed, 1)
+				require.ElementsMatch(tc.expectedRelationships, strings.Split(listed[0], ","))
+			} else {
+				require.Empty(trailer.Get(string(v1svc.DeleteDryRunRelationships)))
+			}
+
+			// Ensure nothing was deleted.
+			stream, err := client.ReadRelationships(context.Background(), &v1.ReadRelationshipsRequest{
+				Consistency:        &v1.Consistency{Requirement: &v1.Consistency_FullyConsistent{FullyConsistent: true}},
+				RelationshipFilter: filter,
+			})
+			require.NoError(err)
+
+			found := 0
+			for {
+				_, err := stream.Recv()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				require.NoError(err)
+				found++
+			}
+			require.Equal(2, found)
+		})
+	}
+}
+
 func TestDeleteRelationshipsPreconditionsOverLimit(t *testing.T) {
 	require := require.New(t)
 	conn, cleanup, _, _ := testserver.NewTestServerWithConfig(
@@ -1207,6 +1296,138 @@ func TestDeleteRelationshipsPreconditionsOverLimit(t *testing.T) {
 	require.Contains(err.Error(), "precondition count of 2 is greater than maximum allowed of 1")
 }
 
+func TestWriteRelationshipsValidationStrictness(t *testing.T) {
+	tcs := []struct {
+		validation           string
+		wrongSubjectCode     codes.Code
+		undefinedRelationErr bool
+	}{
+		{"full", codes.InvalidArgument, true},
+		{"types-only", codes.OK, true},
+		{"none", codes.OK, false},
+	}
+
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.validation, func(t *testing.T) {
+			require := require.New(t)
+			conn, cleanup, _, _ := testserver.NewTestServerWithConfig(
+				require,
+				testTimedeltas[0],
+				memdb.DisableGC,
+				true,
+				testserver.ServerConfig{
+					MaxPreconditionsCount:  1000,
+					MaxUpdatesPerWrite:     1000,
+					RelationshipValidation: tc.validation,
+				},
+				tf.StandardDatastoreWithData,
+			)
+			client := v1.NewPermissionsServiceClient(conn)
+			t.Cleanup(cleanup)
+
+			write := func(rel string) error {
+				_, err := client.WriteRelationships(context.Background(), &v1.WriteRelationshipsRequest{
+					Updates: []*v1.RelationshipUpdate{{
+						Operation:    v1.RelationshipUpdate_OPERATION_TOUCH,
+						Relationship: tuple.ParseRel(rel),
+					}},
+				})
+				return err
+			}
+
+			// The subject type is not allowed on the relation.
+			err := write("document:somedoc#viewer@folder:somefolder")
+			if tc.wrongSubjectCode == codes.OK {
+				require.NoError(err)
+			} else {
+				grpcutil.RequireStatus(t, tc.wrongSubjectCode, err)
+			}
+
+			// The relation is not defined.
+			err = write("document:somedoc#unknownrelation@user:someuser")
+			if tc.undefinedRelationErr {
+				require.Error(err)
+			} else {
+				require.NoError(err)
+			}
+		})
+	}
+}
+
+func TestWriteRelationshipsUnknownNamespacePolicy(t *testing.T) {
+	tcs := []struct {
+		policy       string
+		expectedCode codes.Code
+	}{
+		{"reject", codes.FailedPrecondition},
+		{"auto-create", codes.OK},
+	}
+
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.policy, func(t *testing.T) {
+			require := require.New(t)
+			conn, cleanup, _, _ := testserver.NewTestServerWithConfig(
+				require,
+				testTimedeltas[0],
+				memdb.DisableGC,
+				true,
+				testserver.ServerConfig{
+					MaxPreconditionsCount:       1000,
+					MaxUpdatesPerWrite:          1000,
+					WriteUnknownNamespacePolicy: tc.policy,
+				},
+				tf.StandardDatastoreWithData,
+			)
+			client := v1.NewPermissionsServiceClient(conn)
+			t.Cleanup(cleanup)
+
+			resp, err := client.WriteRelationships(context.Background(), &v1.WriteRelationshipsRequest{
+				Updates: []*v1.RelationshipUpdate{
+					{Operation: v1.RelationshipUpdate_OPERATION_TOUCH, Relationship: tuple.ParseRel("newtype:foo#viewer@user:tom")},
+					{Operation: v1.RelationshipUpdate_OPERATION_TOUCH, Relationship: tuple.ParseRel("newtype:foo#viewer@newsubject:bar")},
+					{Operation: v1.RelationshipUpdate_OPERATION_TOUCH, Relationship: tuple.ParseRel("newtype:foo#editor@user:*")},
+					{Operation: v1.RelationshipUpdate_OPERATION_TOUCH, Relationship: tuple.ParseRel("newtype:foo#grouped@newgroup:eng#member")},
+				},
+			})
+			if tc.expectedCode != codes.OK {
+				grpcutil.RequireStatus(t, tc.expectedCode, err)
+				return
+			}
+			require.NoError(err)
+
+			checkResp, err := client.CheckPermission(context.Background(), &v1.CheckPermissionRequest{
+				Consistency: &v1.Consistency{
+					Requirement: &v1.Consistency_AtLeastAsFresh{AtLeastAsFresh: resp.WrittenAt},
+				},
+				Resource:   &v1.ObjectReference{ObjectType: "newtype", ObjectId: "foo"},
+				Permission: "editor",
+				Subject:    &v1.SubjectReference{Object: &v1.ObjectReference{ObjectType: "user", ObjectId: "sarah"}},
+			})
+			require.NoError(err)
+			require.Equal(v1.CheckPermissionResponse_PERMISSIONSHIP_HAS_PERMISSION, checkResp.Permissionship)
+
+			schemaResp, err := v1.NewSchemaServiceClient(conn).ReadSchema(context.Background(), &v1.ReadSchemaRequest{})
+			require.NoError(err)
+			require.Contains(schemaResp.SchemaText, "definition newsubject {}")
+			require.Contains(schemaResp.SchemaText, "relation viewer: user | newsubject")
+			require.Contains(schemaResp.SchemaText, "relation grouped: newgroup#member")
+
+			// The subject relation is defined on the new subject definition, with a placeholder type.
+			require.Contains(schemaResp.SchemaText, "relation member: newgroup")
+
+			// Relations are not added to definitions which already exist.
+			_, err = client.WriteRelationships(context.Background(), &v1.WriteRelationshipsRequest{
+				Updates: []*v1.RelationshipUpdate{
+					{Operation: v1.RelationshipUpdate_OPERATION_TOUCH, Relationship: tuple.ParseRel("document:somedoc#unknownrelation@user:tom")},
+				},
+			})
+			require.Error(err)
+		})
+	}
+}
+
 func TestWriteRelationshipsPreconditionsOverLimit(t *testing.T) {
 	require := require.New(t)
 	conn, cleanup, _, _ := testserver.NewTestServerWithConfig(
@@ -1323,6 +1544,165 @@ func TestWriteRelationshipsCaveatExceedsMaxSize(t *testing.T) {
 	require.ErrorContains(err, "exceeded maximum allowed caveat size of 1")
 }
 
+func TestReadRelationshipsByCaveatName(t *testing.T) {
+	testCases := []struct {
+		name         string
+		caveatName   string
+		expectedCode codes.Code
+		expected     []string
+	}{
+		{"no caveat filter", "", codes.OK, nil},
+		{"known caveat", "test", codes.OK, []string{"document:companyplan#caveated_viewer@user:tom"}},
+		{"unknown caveat", "unknown", codes.FailedPrecondition, nil},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+			conn, cleanup, _, _ := testserver.NewTestServer(require, 0, memdb.DisableGC, true, tf.StandardDatastoreWithData)
+			client := v1.NewPermissionsServiceClient(conn)
+			t.Cleanup(cleanup)
+
+			_, err := client.WriteRelationships(context.Background(), &v1.WriteRelationshipsRequest{
+				Updates: []*v1.RelationshipUpdate{{
+					Operation:    v1.RelationshipUpdate_OPERATION_CREATE,
+					Relationship: relWithCaveat("document", "companyplan", "caveated_viewer", "user", "tom", "", "test"),
+				}},
+			})
+			require.NoError(err)
+
+			ctx := context.Background()
+			if tc.caveatName != "" {
+				ctx = metadata.AppendToOutgoingContext(ctx, v1svc.RequestReadCaveatName, tc.caveatName)
+			}
+
+			stream, err := client.ReadRelationships(ctx, &v1.ReadRelationshipsRequest{
+				Consistency:        &v1.Consistency{Requirement: &v1.Consistency_FullyConsistent{FullyConsistent: true}},
+				RelationshipFilter: &v1.RelationshipFilter{ResourceType: "document"},
+			})
+			require.NoError(err)
+
+			var found []string
+			for {
+				rel, err := stream.Recv()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+
+				if tc.expectedCode != codes.OK {
+					grpcutil.RequireStatus(t, tc.expectedCode, err)
+					return
+				}
+
+				require.NoError(err)
+				if tc.caveatName != "" {
+					require.Equal(tc.caveatName, rel.Relationship.OptionalCaveat.CaveatName)
+				}
+				found = append(found, tuple.StringRelationshipWithoutCaveat(rel.Relationship))
+			}
+			require.Equal(codes.OK, tc.expectedCode)
+
+			if tc.expected != nil {
+				require.Equal(tc.expected, found)
+			} else {
+				require.Greater(len(found), 1)
+			}
+		})
+	}
+}
+
+func TestReadRelationshipsSubjectUsersetsOnly(t *testing.T) {
+	testCases := []struct {
+		name          string
+		usersetsOnly  bool
+		subjectFilter *v1.SubjectFilter
+		expectedCode  codes.Code
+		expected      []string
+	}{
+		{
+			"any subject relation",
+			false,
+			&v1.SubjectFilter{SubjectType: "folder"},
+			codes.OK,
+			[]string{
+				"folder:company#viewer@folder:auditors#viewer",
+				"folder:strategy#parent@folder:company",
+			},
+		},
+		{
+			"usersets only",
+			true,
+			&v1.SubjectFilter{SubjectType: "folder"},
+			codes.OK,
+			[]string{"folder:company#viewer@folder:auditors#viewer"},
+		},
+		{
+			"usersets only with subject ID",
+			true,
+			&v1.SubjectFilter{SubjectType: "folder", OptionalSubjectId: "company"},
+			codes.OK,
+			nil,
+		},
+		{
+			"usersets only with subject relation",
+			true,
+			&v1.SubjectFilter{SubjectType: "folder", OptionalRelation: &v1.SubjectFilter_RelationFilter{Relation: "viewer"}},
+			codes.InvalidArgument,
+			nil,
+		},
+		{
+			"usersets only without subject filter",
+			true,
+			nil,
+			codes.InvalidArgument,
+			nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+			conn, cleanup, _, _ := testserver.NewTestServer(require, 0, memdb.DisableGC, true, tf.StandardDatastoreWithData)
+			client := v1.NewPermissionsServiceClient(conn)
+			t.Cleanup(cleanup)
+
+			ctx := context.Background()
+			if tc.usersetsOnly {
+				ctx = metadata.AppendToOutgoingContext(ctx, v1svc.RequestReadSubjectUsersetsOnly, "true")
+			}
+
+			stream, err := client.ReadRelationships(ctx, &v1.ReadRelationshipsRequest{
+				Consistency: &v1.Consistency{Requirement: &v1.Consistency_FullyConsistent{FullyConsistent: true}},
+				RelationshipFilter: &v1.RelationshipFilter{
+					ResourceType:          "folder",
+					OptionalSubjectFilter: tc.subjectFilter,
+				},
+			})
+			require.NoError(err)
+
+			var found []string
+			for {
+				rel, err := stream.Recv()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+
+				if tc.expectedCode != codes.OK {
+					grpcutil.RequireStatus(t, tc.expectedCode, err)
+					return
+				}
+
+				require.NoError(err)
+				found = append(found, tuple.StringRelationshipWithoutCaveat(rel.Relationship))
+			}
+			require.Equal(codes.OK, tc.expectedCode)
+			require.ElementsMatch(tc.expected, found)
+		})
+	}
+}
+
 func TestReadRelationshipsWithTimeout(t *testing.T) {
 	require := require.New(t)
 
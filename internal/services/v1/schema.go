@@ -2,10 +2,20 @@ package v1
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 
+	"github.com/authzed/authzed-go/pkg/responsemeta"
 	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
 	grpcvalidate "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/validator"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 
 	log "github.com/authzed/spicedb/internal/logging"
@@ -21,8 +31,23 @@ import (
 	"github.com/authzed/spicedb/pkg/zedtoken"
 )
 
+// SchemaServerConfig is configuration for the schema server.
+type SchemaServerConfig struct {
+	// OrphanPolicy determines how relationships left behind by relations and definitions removed
+	// from the schema are handled.
+	OrphanPolicy shared.OrphanedRelationshipsPolicy
+
+	// NamespaceNamePattern, if non-nil, must be matched by the name of every object definition
+	// written.
+	NamespaceNamePattern *regexp.Regexp
+
+	// MaxSchemaSize, if non-zero, is the size in bytes above which schemas are rejected before
+	// being parsed.
+	MaxSchemaSize uint32
+}
+
 // NewSchemaServer creates a SchemaServiceServer instance.
-func NewSchemaServer(additiveOnly bool) v1.SchemaServiceServer {
+func NewSchemaServer(additiveOnly bool, config SchemaServerConfig) v1.SchemaServiceServer {
 	return &schemaServer{
 		WithServiceSpecificInterceptors: shared.WithServiceSpecificInterceptors{
 			Unary: middleware.ChainUnaryServer(
@@ -34,7 +59,10 @@ func NewSchemaServer(additiveOnly bool) v1.SchemaServiceServer {
 				usagemetrics.StreamServerInterceptor(),
 			),
 		},
-		additiveOnly: additiveOnly,
+		additiveOnly:         additiveOnly,
+		orphanPolicy:         config.OrphanPolicy,
+		namespaceNamePattern: config.NamespaceNamePattern,
+		maxSchemaSize:        config.MaxSchemaSize,
 	}
 }
 
@@ -42,37 +70,217 @@ type schemaServer struct {
 	v1.UnimplementedSchemaServiceServer
 	shared.WithServiceSpecificInterceptors
 
-	additiveOnly bool
+	additiveOnly         bool
+	orphanPolicy         shared.OrphanedRelationshipsPolicy
+	namespaceNamePattern *regexp.Regexp
+	maxSchemaSize        uint32
 }
 
 func (ss *schemaServer) rewriteError(ctx context.Context, err error) error {
 	return shared.RewriteError(ctx, err, nil)
 }
 
+// RequestExpectedSchemaFingerprint is the request header which, when present on a ReadSchema
+// request, contains the fingerprint of the schema the client expects, as previously found in the
+// SchemaFingerprint response trailer. The compatibility of the current schema with the expected one
+// is reported in the SchemaCompatibility response trailer.
+const RequestExpectedSchemaFingerprint = "io.spicedb.requestexpectedschemafingerprint"
+
+// SchemaFingerprint is the response trailer containing the fingerprint of the schema read by a
+// ReadSchema request. The fingerprint grows with the schema, so it is omitted if larger than 8KiB,
+// in which case it can be computed from the returned schema text with schemautil.SchemaFingerprint.
+const SchemaFingerprint responsemeta.ResponseMetadataTrailerKey = "io.spicedb.respmeta.schemafingerprint"
+
+// SchemaCompatibility is the response trailer reporting whether the schema read is `identical` to,
+// a `superset` of, or `incompatible` with the schema whose fingerprint was given in the
+// RequestExpectedSchemaFingerprint request header.
+const SchemaCompatibility responsemeta.ResponseMetadataTrailerKey = "io.spicedb.respmeta.schemacompatibility"
+
+func expectedSchemaFingerprintFromContext(ctx context.Context) (string, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", false
+	}
+
+	values := md.Get(RequestExpectedSchemaFingerprint)
+	if len(values) == 0 {
+		return "", false
+	}
+	return values[0], true
+}
+
+// RequestSchemaIfNoneMatch is the request header which, when present on a ReadSchema request,
+// contains one or more comma-separated schema ETags, as previously found in the SchemaETag response
+// trailer, or `*`. If the current schema matches, the schema text is omitted from the response and
+// the SchemaNotModified response trailer is set. The HTTP gateway maps the standard If-None-Match
+// header to this header.
+const RequestSchemaIfNoneMatch = "io.spicedb.requestschemaifnonematch"
+
+// SchemaETag is the response trailer containing an opaque identifier of the exact schema text
+// returned by a ReadSchema request. The HTTP gateway returns it as the ETag header.
+const SchemaETag responsemeta.ResponseMetadataTrailerKey = "io.spicedb.respmeta.schemaetag"
+
+// SchemaNotModified is the response trailer set to `true` when the schema matches one of the
+// ETags given in the RequestSchemaIfNoneMatch request header, in which case the schema text is not
+// returned. The HTTP gateway returns a 304 Not Modified status for such responses.
+const SchemaNotModified responsemeta.ResponseMetadataTrailerKey = "io.spicedb.respmeta.schemanotmodified"
+
+func schemaIfNoneMatchFromContext(ctx context.Context) []string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil
+	}
+
+	var etags []string
+	for _, value := range md.Get(RequestSchemaIfNoneMatch) {
+		for _, etag := range strings.Split(value, ",") {
+			// Accept the quoted and weak forms used by HTTP clients.
+			etag = strings.TrimPrefix(strings.TrimSpace(etag), "W/")
+			etags = append(etags, strings.Trim(etag, `"`))
+		}
+	}
+	return etags
+}
+
+// RequestSchemaPageSize is the request header which, when present on a ReadSchema request, limits the
+// schema text returned to the given positive number of object definitions, in order of name. Caveat
+// definitions are returned with the first page. If further definitions remain, the cursor from
+// which to read them is returned in the SchemaNextPageCursor response trailer. The fingerprint of
+// a schema covers all of its definitions, so paged reads return no SchemaFingerprint trailer and
+// reject the RequestExpectedSchemaFingerprint header.
+const RequestSchemaPageSize = "io.spicedb.requestschemapagesize"
+
+// RequestSchemaPageCursor is the request header which, when present alongside RequestSchemaPageSize
+// on a ReadSchema request, contains the cursor found in the SchemaNextPageCursor response trailer of
+// the previous page. The page is read at the revision of the first page, so that all pages of a
+// schema are consistent with one another.
+const RequestSchemaPageCursor = "io.spicedb.requestschemapagecursor"
+
+// SchemaNextPageCursor is the response trailer containing the cursor from which to read the next
+// page of a paged ReadSchema request, if any definitions remain.
+const SchemaNextPageCursor responsemeta.ResponseMetadataTrailerKey = "io.spicedb.respmeta.schemanextpagecursor"
+
+// schemaPage is a page of the schema requested with the RequestSchemaPageSize header.
+type schemaPage struct {
+	size      uint32
+	revision  datastore.Revision
+	afterName string
+}
+
+func schemaPageFromContext(ctx context.Context, ds datastore.Datastore) (*schemaPage, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil, nil
+	}
+
+	sizes := md.Get(RequestSchemaPageSize)
+	if len(sizes) == 0 {
+		return nil, nil
+	}
+
+	size, err := strconv.ParseUint(sizes[0], 10, 32)
+	if err != nil || size == 0 {
+		return nil, NewInvalidSchemaPageSizeErr(sizes[0])
+	}
+
+	page := &schemaPage{size: uint32(size)}
+	if cursors := md.Get(RequestSchemaPageCursor); len(cursors) > 0 {
+		page.revision, page.afterName, err = decodeSchemaPageCursor(cursors[0], ds)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
+}
+
+// encodeSchemaPageCursor encodes the revision of a paged schema read along with the name of the
+// last definition returned.
+func encodeSchemaPageCursor(revision datastore.Revision, lastName string) string {
+	token := zedtoken.MustNewFromRevision(revision).Token
+	return base64.RawURLEncoding.EncodeToString([]byte(token + ":" + lastName))
+}
+
+func decodeSchemaPageCursor(cursor string, ds datastore.Datastore) (datastore.Revision, string, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, "", NewInvalidCursorErr("could not decode schema page cursor")
+	}
+
+	token, lastName, ok := strings.Cut(string(decoded), ":")
+	if !ok || lastName == "" {
+		return nil, "", NewInvalidCursorErr("malformed schema page cursor")
+	}
+
+	revision, err := zedtoken.DecodeRevision(&v1.ZedToken{Token: token}, ds)
+	if err != nil {
+		return nil, "", NewInvalidCursorErr("could not decode the revision of the schema page cursor")
+	}
+	return revision, lastName, nil
+}
+
+func computeSchemaETag(schemaText string) string {
+	sum := sha256.Sum256([]byte(schemaText))
+	return hex.EncodeToString(sum[:16])
+}
+
 func (ss *schemaServer) ReadSchema(ctx context.Context, _ *v1.ReadSchemaRequest) (*v1.ReadSchemaResponse, error) {
-	// Schema is always read from the head revision.
 	ds := datastoremw.MustFromContext(ctx)
-	headRevision, err := ds.HeadRevision(ctx)
+	page, err := schemaPageFromContext(ctx, ds)
 	if err != nil {
 		return nil, ss.rewriteError(ctx, err)
 	}
 
-	reader := ds.SnapshotReader(headRevision)
+	if _, ok := expectedSchemaFingerprintFromContext(ctx); ok && page != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "the expected schema fingerprint cannot be compared with a page of the schema")
+	}
 
-	nsDefs, err := reader.ListAllNamespaces(ctx)
-	if err != nil {
-		return nil, ss.rewriteError(ctx, err)
+	// Schema is read from the head revision, or from the revision of the first page when paging.
+	var readRevision datastore.Revision
+	if page != nil && page.revision != nil {
+		readRevision = page.revision
+		if err := ds.CheckRevision(ctx, readRevision); err != nil {
+			return nil, ss.rewriteError(ctx, err)
+		}
+	} else {
+		readRevision, err = ds.HeadRevision(ctx)
+		if err != nil {
+			return nil, ss.rewriteError(ctx, err)
+		}
+	}
+
+	log.Ctx(ctx).Debug().Str("consistency", "fully_consistent").Stringer("revision", readRevision).Msg("resolved revision for request")
+
+	reader := ds.SnapshotReader(readRevision)
+
+	// Datastores list definitions in no particular order, so they are read in order of name to
+	// ensure the schema text, and thus its ETag, is stable between reads of the same schema.
+	var pageSize uint32
+	var afterName string
+	if page != nil {
+		pageSize, afterName = page.size, page.afterName
 	}
 
-	caveatDefs, err := reader.ListAllCaveats(ctx)
+	nsDefs, nextPageAfter, err := datastore.ListNamespacesPage(ctx, reader, pageSize, afterName)
 	if err != nil {
 		return nil, ss.rewriteError(ctx, err)
 	}
 
-	if len(nsDefs) == 0 {
+	if len(nsDefs) == 0 && afterName == "" {
 		return nil, status.Errorf(codes.NotFound, "No schema has been defined; please call WriteSchema to start")
 	}
 
+	var caveatDefs []datastore.RevisionedCaveat
+	if afterName == "" {
+		caveatDefs, err = reader.ListAllCaveats(ctx)
+		if err != nil {
+			return nil, ss.rewriteError(ctx, err)
+		}
+
+		sort.Slice(caveatDefs, func(i, j int) bool {
+			return caveatDefs[i].Definition.Name < caveatDefs[j].Definition.Name
+		})
+	}
+
 	schemaDefinitions := make([]compiler.SchemaDefinition, 0, len(nsDefs)+len(caveatDefs))
 	for _, caveatDef := range caveatDefs {
 		schemaDefinitions = append(schemaDefinitions, caveatDef.Definition)
@@ -87,19 +295,103 @@ func (ss *schemaServer) ReadSchema(ctx context.Context, _ *v1.ReadSchemaRequest)
 		return nil, ss.rewriteError(ctx, err)
 	}
 
+	etag := computeSchemaETag(schemaText)
+	trailers := map[responsemeta.ResponseMetadataTrailerKey]string{
+		SchemaETag: etag,
+	}
+
+	if page != nil {
+		if nextPageAfter != "" {
+			trailers[SchemaNextPageCursor] = encodeSchemaPageCursor(readRevision, nextPageAfter)
+		}
+	} else {
+		fingerprint, err := shared.ComputeSchemaFingerprint(datastore.DefinitionsOf(nsDefs), datastore.DefinitionsOf(caveatDefs))
+		if err != nil {
+			return nil, ss.rewriteError(ctx, err)
+		}
+
+		if len(fingerprint) <= maxListTrailerSize {
+			trailers[SchemaFingerprint] = fingerprint
+		}
+		if expected, ok := expectedSchemaFingerprintFromContext(ctx); ok {
+			compatibility, err := shared.CompareSchemaFingerprints(expected, fingerprint)
+			if err != nil {
+				return nil, ss.rewriteError(ctx, NewInvalidSchemaFingerprintErr(expected, err))
+			}
+			trailers[SchemaCompatibility] = string(compatibility)
+		}
+	}
+
+	notModified := false
+	for _, candidate := range schemaIfNoneMatchFromContext(ctx) {
+		if candidate == etag || candidate == "*" {
+			notModified = true
+			trailers[SchemaNotModified] = "true"
+			break
+		}
+	}
+
+	if err := responsemeta.SetResponseTrailerMetadata(ctx, trailers); err != nil {
+		return nil, ss.rewriteError(ctx, err)
+	}
+
 	usagemetrics.SetInContext(ctx, &dispatchv1.ResponseMeta{
 		DispatchCount: uint32(len(nsDefs) + len(caveatDefs)),
 	})
 
+	if notModified {
+		return &v1.ReadSchemaResponse{
+			ReadAt: zedtoken.MustNewFromRevision(readRevision),
+		}, nil
+	}
+
 	return &v1.ReadSchemaResponse{
 		SchemaText: schemaText,
-		ReadAt:     zedtoken.MustNewFromRevision(headRevision),
+		ReadAt:     zedtoken.MustNewFromRevision(readRevision),
 	}, nil
 }
 
+// RequestSchemaDryRun is the request header which, when present on a WriteSchema request, validates
+// the schema exactly as it would be validated for the write, including against the relationships
+// stored, without writing anything. Errors are returned as they would be for the write, and the
+// WrittenAt of a successful response contains the head revision at which the schema was validated.
+const RequestSchemaDryRun = "io.spicedb.requestschemadryrun"
+
+// SchemaDiff is the response trailer of a dry-run WriteSchema request containing the JSON-encoded
+// changes the schema makes to the stored object and caveat definitions, with those that would leave
+// stored relationships orphaned or no longer matching their caveat marked as breaking. It is set even
+// if the schema is rejected by the validation of the stored relationships. Changes are omitted from
+// the end of the diff to keep it within 8KiB, with the number omitted recorded in the diff, whose
+// `breaking` field always reflects all the changes.
+const SchemaDiff responsemeta.ResponseMetadataTrailerKey = "io.spicedb.respmeta.schemadiff"
+
+// SchemaOrphanedRelations is the response trailer of a WriteSchema request applied under the
+// permissive orphaned relationships policy, containing the comma-separated relations, in
+// `definition#relation` form, and removed definitions for which stored relationships were left
+// orphaned, truncated to 8KiB. It is only set if any relationships were left orphaned.
+const SchemaOrphanedRelations responsemeta.ResponseMetadataTrailerKey = "io.spicedb.respmeta.schemaorphanedrelations"
+
+// errSchemaDryRun is returned from the transaction of a dry-run WriteSchema to roll it back.
+var errSchemaDryRun = errors.New("schema dry run")
+
+func schemaDryRunFromContext(ctx context.Context) bool {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return false
+	}
+
+	_, ok = md[RequestSchemaDryRun]
+	return ok
+}
+
 func (ss *schemaServer) WriteSchema(ctx context.Context, in *v1.WriteSchemaRequest) (*v1.WriteSchemaResponse, error) {
 	log.Ctx(ctx).Trace().Str("schema", in.GetSchema()).Msg("requested Schema to be written")
 
+	// Reject oversized schemas before spending any time parsing them.
+	if ss.maxSchemaSize > 0 && len(in.GetSchema()) > int(ss.maxSchemaSize) {
+		return nil, ss.rewriteError(ctx, NewSchemaTooLargeErr(len(in.GetSchema()), ss.maxSchemaSize))
+	}
+
 	ds := datastoremw.MustFromContext(ctx)
 
 	// Compile the schema into the namespace definitions.
@@ -113,14 +405,35 @@ func (ss *schemaServer) WriteSchema(ctx context.Context, in *v1.WriteSchemaReque
 	}
 	log.Ctx(ctx).Trace().Int("objectDefinitions", len(compiled.ObjectDefinitions)).Int("caveatDefinitions", len(compiled.CaveatDefinitions)).Msg("compiled namespace definitions")
 
+	// Ensure the names of all object definitions follow the configured convention, if any.
+	if ss.namespaceNamePattern != nil {
+		for _, nsDef := range compiled.ObjectDefinitions {
+			if !ss.namespaceNamePattern.MatchString(nsDef.Name) {
+				return nil, ss.rewriteError(ctx, NewNamespaceNameNotAllowedErr(nsDef.Name, ss.namespaceNamePattern.String()))
+			}
+		}
+	}
+
 	// Do as much validation as we can before talking to the datastore.
-	validated, err := shared.ValidateSchemaChanges(ctx, compiled, ss.additiveOnly)
+	validated, err := shared.ValidateSchemaChanges(ctx, compiled, ss.additiveOnly, ss.orphanPolicy)
 	if err != nil {
 		return nil, ss.rewriteError(ctx, err)
 	}
 
-	// Update the schema.
+	// Update the schema. A dry run applies the changes in the same way, so that they are validated
+	// against the stored relationships, and then rolls them back.
+	dryRun := schemaDryRunFromContext(ctx)
+	var diff *shared.SchemaDiff
+	var orphanedRelations []string
 	revision, err := ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		if dryRun {
+			var err error
+			diff, err = shared.DiffSchema(ctx, rwt, compiled)
+			if err != nil {
+				return err
+			}
+		}
+
 		applied, err := shared.ApplySchemaChanges(ctx, rwt, validated)
 		if err != nil {
 			return err
@@ -128,12 +441,56 @@ func (ss *schemaServer) WriteSchema(ctx context.Context, in *v1.WriteSchemaReque
 		usagemetrics.SetInContext(ctx, &dispatchv1.ResponseMeta{
 			DispatchCount: applied.TotalOperationCount,
 		})
+
+		// Set on every attempt, as the transaction may be retried.
+		orphanedRelations = applied.OrphanedRelations
+
+		if dryRun {
+			return errSchemaDryRun
+		}
+
+		if applied.DeletedOrphanedRelationshipCount > 0 {
+			log.Ctx(ctx).Info().Uint64("count", applied.DeletedOrphanedRelationshipCount).Msg("deleted relationships orphaned by schema change")
+		}
 		return nil
 	})
+	if diff != nil {
+		encoded, merr := diff.EncodeWithMaxSize(maxListTrailerSize)
+		if merr != nil {
+			return nil, ss.rewriteError(ctx, merr)
+		}
+
+		if serr := responsemeta.SetResponseTrailerMetadata(ctx, map[responsemeta.ResponseMetadataTrailerKey]string{
+			SchemaDiff: string(encoded),
+		}); serr != nil {
+			return nil, ss.rewriteError(ctx, serr)
+		}
+	}
+	if len(orphanedRelations) > 0 && (err == nil || errors.Is(err, errSchemaDryRun)) {
+		joined, _ := joinWithinSize(orphanedRelations, ",", maxListTrailerSize)
+		if serr := responsemeta.SetResponseTrailerMetadata(ctx, map[responsemeta.ResponseMetadataTrailerKey]string{
+			SchemaOrphanedRelations: joined,
+		}); serr != nil {
+			return nil, ss.rewriteError(ctx, serr)
+		}
+	}
+	if dryRun && errors.Is(err, errSchemaDryRun) {
+		headRevision, err := ds.HeadRevision(ctx)
+		if err != nil {
+			return nil, ss.rewriteError(ctx, err)
+		}
+
+		log.Ctx(ctx).Debug().Stringer("revision", headRevision).Msg("validated schema without writing it")
+		return &v1.WriteSchemaResponse{
+			WrittenAt: zedtoken.MustNewFromRevision(headRevision),
+		}, nil
+	}
 	if err != nil {
 		return nil, ss.rewriteError(ctx, err)
 	}
 
+	log.Ctx(ctx).Debug().Stringer("revision", revision).Msg("wrote schema at revision")
+
 	return &v1.WriteSchemaResponse{
 		WrittenAt: zedtoken.MustNewFromRevision(revision),
 	}, nil
@@ -3,15 +3,19 @@ package v1_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	"google.golang.org/protobuf/types/known/structpb"
 
 	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
 	"github.com/authzed/grpcutil"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 
 	"github.com/authzed/spicedb/internal/datastore/memdb"
+	v1svc "github.com/authzed/spicedb/internal/services/v1"
 	tf "github.com/authzed/spicedb/internal/testfixtures"
 	"github.com/authzed/spicedb/internal/testserver"
 	core "github.com/authzed/spicedb/pkg/proto/core/v1"
@@ -31,6 +35,71 @@ func TestSchemaWriteNoPrefix(t *testing.T) {
 	require.NotEmpty(t, resp.WrittenAt.Token)
 }
 
+func TestSchemaWriteNamespaceNamePattern(t *testing.T) {
+	conn, cleanup, _, _ := testserver.NewTestServerWithConfig(require.New(t), 0, memdb.DisableGC, true,
+		testserver.ServerConfig{
+			MaxUpdatesPerWrite:         1000,
+			MaxPreconditionsCount:      1000,
+			StreamingAPITimeout:        30 * time.Second,
+			MaxRelationshipContextSize: 25000,
+			SchemaNamespaceNamePattern: "^tenant1/",
+		},
+		tf.EmptyDatastore)
+	t.Cleanup(cleanup)
+	client := v1.NewSchemaServiceClient(conn)
+
+	_, err := client.WriteSchema(context.Background(), &v1.WriteSchemaRequest{
+		Schema: `definition tenant1/user {}
+
+		definition tenant2/document {
+			relation viewer: tenant1/user
+		}`,
+	})
+	grpcutil.RequireStatus(t, codes.InvalidArgument, err)
+	require.ErrorContains(t, err, "tenant2/document")
+
+	_, err = client.ReadSchema(context.Background(), &v1.ReadSchemaRequest{})
+	grpcutil.RequireStatus(t, codes.NotFound, err)
+
+	_, err = client.WriteSchema(context.Background(), &v1.WriteSchemaRequest{
+		Schema: `definition tenant1/user {}
+
+		definition tenant1/document {
+			relation viewer: tenant1/user
+		}`,
+	})
+	require.NoError(t, err)
+}
+
+func TestSchemaWriteMaxSize(t *testing.T) {
+	conn, cleanup, _, _ := testserver.NewTestServerWithConfig(require.New(t), 0, memdb.DisableGC, true,
+		testserver.ServerConfig{
+			MaxUpdatesPerWrite:         1000,
+			MaxPreconditionsCount:      1000,
+			StreamingAPITimeout:        30 * time.Second,
+			MaxRelationshipContextSize: 25000,
+			MaxSchemaSize:              64,
+		},
+		tf.EmptyDatastore)
+	t.Cleanup(cleanup)
+	client := v1.NewSchemaServiceClient(conn)
+
+	_, err := client.WriteSchema(context.Background(), &v1.WriteSchemaRequest{
+		Schema: `definition user {}
+
+		definition document {
+			relation viewer: user
+		}`,
+	})
+	grpcutil.RequireStatus(t, codes.InvalidArgument, err)
+	require.ErrorContains(t, err, "exceeds the maximum allowed size of 64 bytes")
+
+	_, err = client.WriteSchema(context.Background(), &v1.WriteSchemaRequest{
+		Schema: `definition user {}`,
+	})
+	require.NoError(t, err)
+}
+
 func TestSchemaWriteInvalidSchema(t *testing.T) {
 	conn, cleanup, _, _ := testserver.NewTestServer(require.New(t), 0, memdb.DisableGC, true, tf.EmptyDatastore)
 	t.Cleanup(cleanup)
@@ -85,6 +154,336 @@ func TestSchemaWriteAndReadBack(t *testing.T) {
 	require.NotEmpty(t, readback.ReadAt.Token)
 }
 
+func TestSchemaReadBackInStableOrder(t *testing.T) {
+	conn, cleanup, _, _ := testserver.NewTestServer(require.New(t), 0, memdb.DisableGC, true, tf.EmptyDatastore)
+	t.Cleanup(cleanup)
+	client := v1.NewSchemaServiceClient(conn)
+
+	_, err := client.WriteSchema(context.Background(), &v1.WriteSchemaRequest{
+		Schema: `definition example/user {}
+
+		caveat zCaveat(somecondition int) {
+			somecondition == 42
+		}
+
+		definition example/organization {
+			relation member: example/user
+		}
+
+		caveat aCaveat(somecondition int) {
+			somecondition == 42
+		}
+
+		definition example/document {
+			relation viewer: example/user | example/user with aCaveat | example/user with zCaveat
+		}`,
+	})
+	require.NoError(t, err)
+
+	expectedSchema := "caveat aCaveat(somecondition int) {\n\tsomecondition == 42\n}\n\n" +
+		"caveat zCaveat(somecondition int) {\n\tsomecondition == 42\n}\n\n" +
+		"definition example/document {\n\trelation viewer: example/user | example/user with aCaveat | example/user with zCaveat\n}\n\n" +
+		"definition example/organization {\n\trelation member: example/user\n}\n\n" +
+		"definition example/user {}"
+
+	for i := 0; i < 3; i++ {
+		readback, err := client.ReadSchema(context.Background(), &v1.ReadSchemaRequest{})
+		require.NoError(t, err)
+		require.Equal(t, expectedSchema, readback.SchemaText)
+	}
+}
+
+func TestSchemaReadCompatibility(t *testing.T) {
+	conn, cleanup, _, _ := testserver.NewTestServer(require.New(t), 0, memdb.DisableGC, true, tf.EmptyDatastore)
+	t.Cleanup(cleanup)
+	client := v1.NewSchemaServiceClient(conn)
+
+	_, err := client.WriteSchema(context.Background(), &v1.WriteSchemaRequest{
+		Schema: `definition user {}
+
+		definition document {
+			relation viewer: user
+			permission view = viewer
+		}`,
+	})
+	require.NoError(t, err)
+
+	var trailer metadata.MD
+	_, err = client.ReadSchema(context.Background(), &v1.ReadSchemaRequest{}, grpc.Trailer(&trailer))
+	require.NoError(t, err)
+
+	fingerprints := trailer.Get(string(v1svc.SchemaFingerprint))
+	require.Len(t, fingerprints, 1)
+	require.Empty(t, trailer.Get(string(v1svc.SchemaCompatibility)))
+	expected := fingerprints[0]
+
+	readCompatibility := func() string {
+		ctx := metadata.AppendToOutgoingContext(context.Background(), v1svc.RequestExpectedSchemaFingerprint, expected)
+
+		var trailer metadata.MD
+		_, err := client.ReadSchema(ctx, &v1.ReadSchemaRequest{}, grpc.Trailer(&trailer))
+		require.NoError(t, err)
+		return trailer.Get(string(v1svc.SchemaCompatibility))[0]
+	}
+
+	require.Equal(t, "identical", readCompatibility())
+
+	// Add a relation, without changing the existing ones.
+	_, err = client.WriteSchema(context.Background(), &v1.WriteSchemaRequest{
+		Schema: `definition user {}
+
+		definition document {
+			relation viewer: user
+			relation editor: user
+			permission view = viewer
+		}`,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "superset", readCompatibility())
+
+	// Change an existing permission.
+	_, err = client.WriteSchema(context.Background(), &v1.WriteSchemaRequest{
+		Schema: `definition user {}
+
+		definition document {
+			relation viewer: user
+			relation editor: user
+			permission view = viewer + editor
+		}`,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "incompatible", readCompatibility())
+
+	// An invalid fingerprint is rejected.
+	ctx := metadata.AppendToOutgoingContext(context.Background(), v1svc.RequestExpectedSchemaFingerprint, "notafingerprint")
+	_, err = client.ReadSchema(ctx, &v1.ReadSchemaRequest{})
+	grpcutil.RequireStatus(t, codes.InvalidArgument, err)
+}
+
+func TestSchemaReadNotModified(t *testing.T) {
+	conn, cleanup, _, _ := testserver.NewTestServer(require.New(t), 0, memdb.DisableGC, true, tf.EmptyDatastore)
+	t.Cleanup(cleanup)
+	client := v1.NewSchemaServiceClient(conn)
+
+	_, err := client.WriteSchema(context.Background(), &v1.WriteSchemaRequest{
+		Schema: `definition user {}`,
+	})
+	require.NoError(t, err)
+
+	var trailer metadata.MD
+	resp, err := client.ReadSchema(context.Background(), &v1.ReadSchemaRequest{}, grpc.Trailer(&trailer))
+	require.NoError(t, err)
+	require.NotEmpty(t, resp.SchemaText)
+
+	etags := trailer.Get(string(v1svc.SchemaETag))
+	require.Len(t, etags, 1)
+	require.Empty(t, trailer.Get(string(v1svc.SchemaNotModified)))
+
+	readWithETag := func(ifNoneMatch string) (*v1.ReadSchemaResponse, metadata.MD) {
+		ctx := metadata.AppendToOutgoingContext(context.Background(), v1svc.RequestSchemaIfNoneMatch, ifNoneMatch)
+
+		var trailer metadata.MD
+		resp, err := client.ReadSchema(ctx, &v1.ReadSchemaRequest{}, grpc.Trailer(&trailer))
+		require.NoError(t, err)
+		return resp, trailer
+	}
+
+	// A matching ETag, in either its bare or quoted form, omits the schema.
+	for _, ifNoneMatch := range []string{etags[0], `"otheretag", W/"` + etags[0] + `"`, "*"} {
+		resp, trailer := readWithETag(ifNoneMatch)
+		require.Empty(t, resp.SchemaText)
+		require.NotNil(t, resp.ReadAt)
+		require.Equal(t, []string{"true"}, trailer.Get(string(v1svc.SchemaNotModified)))
+		require.Equal(t, etags, trailer.Get(string(v1svc.SchemaETag)))
+	}
+
+	// Once the schema changes, the old ETag no longer matches.
+	_, err = client.WriteSchema(context.Background(), &v1.WriteSchemaRequest{
+		Schema: `definition user {}
+
+		definition document {
+			relation viewer: user
+		}`,
+	})
+	require.NoError(t, err)
+
+	resp, trailer = readWithETag(etags[0])
+	require.NotEmpty(t, resp.SchemaText)
+	require.Empty(t, trailer.Get(string(v1svc.SchemaNotModified)))
+	require.NotEqual(t, etags, trailer.Get(string(v1svc.SchemaETag)))
+}
+
+func TestSchemaReadPaged(t *testing.T) {
+	conn, cleanup, _, _ := testserver.NewTestServer(require.New(t), 0, memdb.DisableGC, true, tf.EmptyDatastore)
+	t.Cleanup(cleanup)
+	client := v1.NewSchemaServiceClient(conn)
+
+	_, err := client.WriteSchema(context.Background(), &v1.WriteSchemaRequest{
+		Schema: `caveat somecaveat(somecondition int) {
+			somecondition == 42
+		}
+
+		definition user {}
+
+		definition team {
+			relation member: user
+		}
+
+		definition folder {}
+
+		definition document {
+			relation viewer: user with somecaveat
+		}`,
+	})
+	require.NoError(t, err)
+
+	readPage := func(cursor string) (*v1.ReadSchemaResponse, metadata.MD) {
+		ctx := metadata.AppendToOutgoingContext(context.Background(), v1svc.RequestSchemaPageSize, "2")
+		if cursor != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, v1svc.RequestSchemaPageCursor, cursor)
+		}
+
+		var trailer metadata.MD
+		resp, err := client.ReadSchema(ctx, &v1.ReadSchemaRequest{}, grpc.Trailer(&trailer))
+		require.NoError(t, err)
+		require.Empty(t, trailer.Get(string(v1svc.SchemaFingerprint)))
+		return resp, trailer
+	}
+
+	first, trailer := readPage("")
+	require.Equal(t, "caveat somecaveat(somecondition int) {\n\tsomecondition == 42\n}\n\n"+
+		"definition document {\n\trelation viewer: user with somecaveat\n}\n\n"+
+		"definition folder {}", first.SchemaText)
+	cursors := trailer.Get(string(v1svc.SchemaNextPageCursor))
+	require.Len(t, cursors, 1)
+
+	// Definitions written after the first page are not found by later pages, which are read at
+	// the revision of the first.
+	_, err = client.WriteSchema(context.Background(), &v1.WriteSchemaRequest{
+		Schema: `caveat somecaveat(somecondition int) {
+			somecondition == 42
+		}
+
+		definition user {}
+
+		definition team {
+			relation member: user
+		}
+
+		definition folder {}
+
+		definition document {
+			relation viewer: user with somecaveat
+		}
+
+		definition organization {}`,
+	})
+	require.NoError(t, err)
+
+	second, trailer := readPage(cursors[0])
+	require.Equal(t, "definition team {\n\trelation member: user\n}\n\ndefinition user {}", second.SchemaText)
+	require.Equal(t, first.ReadAt.Token, second.ReadAt.Token)
+	require.Empty(t, trailer.Get(string(v1svc.SchemaNextPageCursor)))
+
+	// Invalid page sizes and cursors are rejected, as is comparing a page with a fingerprint.
+	for _, pageSize := range []string{"0", "-1", "notanumber"} {
+		ctx := metadata.AppendToOutgoingContext(context.Background(), v1svc.RequestSchemaPageSize, pageSize)
+		_, err := client.ReadSchema(ctx, &v1.ReadSchemaRequest{})
+		grpcutil.RequireStatus(t, codes.InvalidArgument, err)
+	}
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), v1svc.RequestSchemaPageSize, "2", v1svc.RequestSchemaPageCursor, "notacursor")
+	_, err = client.ReadSchema(ctx, &v1.ReadSchemaRequest{})
+	grpcutil.RequireStatus(t, codes.FailedPrecondition, err)
+
+	ctx = metadata.AppendToOutgoingContext(context.Background(), v1svc.RequestSchemaPageSize, "2", v1svc.RequestExpectedSchemaFingerprint, "somefingerprint")
+	_, err = client.ReadSchema(ctx, &v1.ReadSchemaRequest{})
+	grpcutil.RequireStatus(t, codes.InvalidArgument, err)
+}
+
+func TestSchemaWriteDryRun(t *testing.T) {
+	conn, cleanup, _, _ := testserver.NewTestServer(require.New(t), 0, memdb.DisableGC, true, tf.EmptyDatastore)
+	t.Cleanup(cleanup)
+	client := v1.NewSchemaServiceClient(conn)
+	v1client := v1.NewPermissionsServiceClient(conn)
+
+	originalSchema := `definition example/document {
+	relation viewer: example/user
+}
+
+definition example/user {}`
+
+	_, err := client.WriteSchema(context.Background(), &v1.WriteSchemaRequest{
+		Schema: originalSchema,
+	})
+	require.NoError(t, err)
+
+	_, err = v1client.WriteRelationships(context.Background(), &v1.WriteRelationshipsRequest{
+		Updates: []*v1.RelationshipUpdate{tuple.UpdateToRelationshipUpdate(tuple.Create(
+			tuple.MustParse("example/document:somedoc#viewer@example/user:someuser#..."),
+		))},
+	})
+	require.NoError(t, err)
+
+	original, err := client.ReadSchema(context.Background(), &v1.ReadSchemaRequest{})
+	require.NoError(t, err)
+
+	dryRunCtx := metadata.AppendToOutgoingContext(context.Background(), v1svc.RequestSchemaDryRun, "")
+
+	// A valid schema is accepted without being written, and its changes reported.
+	var trailer metadata.MD
+	resp, err := client.WriteSchema(dryRunCtx, &v1.WriteSchemaRequest{
+		Schema: `caveat only_on_tuesday(day string) {
+			day == 'tuesday'
+		}
+
+		definition example/user {}
+
+		definition example/document {
+			relation viewer: example/user
+			relation editor: example/user
+		}`,
+	}, grpc.Trailer(&trailer))
+	require.NoError(t, err)
+	require.NotEmpty(t, resp.WrittenAt.Token)
+	require.JSONEq(t,
+		`{
+			"changes": [{"definition": "example/document", "type": "added-relation", "name": "editor"}],
+			"caveatChanges": [{"caveat": "only_on_tuesday", "type": "caveat-added"}],
+			"breaking": false
+		}`,
+		trailer.Get(string(v1svc.SchemaDiff))[0],
+	)
+
+	readResp, err := client.ReadSchema(context.Background(), &v1.ReadSchemaRequest{})
+	require.NoError(t, err)
+	require.Equal(t, original.SchemaText, readResp.SchemaText)
+
+	// Removing a relation which still has relationships is rejected as it would be for the write,
+	// with the change reported as breaking.
+	_, err = client.WriteSchema(dryRunCtx, &v1.WriteSchemaRequest{
+		Schema: `definition example/user {}
+
+		definition example/document {}`,
+	}, grpc.Trailer(&trailer))
+	grpcutil.RequireStatus(t, codes.InvalidArgument, err)
+	require.JSONEq(t,
+		`{
+			"changes": [{"definition": "example/document", "type": "removed-relation", "name": "viewer", "breaking": true}],
+			"caveatChanges": [],
+			"breaking": true
+		}`,
+		trailer.Get(string(v1svc.SchemaDiff))[0],
+	)
+
+	// Schemas which fail to parse are rejected with the position of the error.
+	_, err = client.WriteSchema(dryRunCtx, &v1.WriteSchemaRequest{
+		Schema: `definition example/user {`,
+	})
+	grpcutil.RequireStatus(t, codes.InvalidArgument, err)
+	require.Contains(t, err.Error(), "parse error in `schema`, line 1")
+}
+
 func TestSchemaDeleteRelation(t *testing.T) {
 	conn, cleanup, _, _ := testserver.NewTestServer(require.New(t), 0, memdb.DisableGC, true, tf.EmptyDatastore)
 	t.Cleanup(cleanup)
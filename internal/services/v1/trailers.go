@@ -0,0 +1,26 @@
+package v1
+
+import "strings"
+
+// maxListTrailerSize is the maximum size, in bytes, of the value of a response trailer whose size
+// depends on the data read, such as a list of relationships or schema changes. Proxies commonly
+// limit the combined size of the headers and trailers of a response to a few tens of KiB, so such
+// trailers are truncated or omitted beyond this size, as documented on each.
+const maxListTrailerSize = 8 * 1024
+
+// joinWithinSize joins as many of the values, in order, as fit within maxSize bytes once separated
+// by sep, returning the joined values and the number that fit.
+func joinWithinSize(values []string, sep string, maxSize int) (string, int) {
+	size := 0
+	for index, value := range values {
+		added := len(value)
+		if index > 0 {
+			added += len(sep)
+		}
+		if size+added > maxSize {
+			return strings.Join(values[:index], sep), index
+		}
+		size += added
+	}
+	return strings.Join(values, sep), len(values)
+}
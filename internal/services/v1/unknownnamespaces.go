@@ -0,0 +1,164 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+
+	log "github.com/authzed/spicedb/internal/logging"
+	"github.com/authzed/spicedb/internal/namespace"
+	"github.com/authzed/spicedb/pkg/datastore"
+	"github.com/authzed/spicedb/pkg/genutil/mapz"
+	ns "github.com/authzed/spicedb/pkg/namespace"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+// UnknownNamespacePolicy defines how WriteRelationships handles relationships which reference
+// namespaces that are not defined in the schema.
+type UnknownNamespacePolicy int
+
+const (
+	// UnknownNamespaceReject fails the write, requiring the namespaces to be defined first.
+	UnknownNamespaceReject UnknownNamespacePolicy = iota
+
+	// UnknownNamespaceAutoCreate defines each unknown namespace before the write, with a relation
+	// for each relation written on it that allows the subject types written to it, and for each
+	// relation used as a subject relation on it. Intended for prototyping only.
+	UnknownNamespaceAutoCreate
+)
+
+var unknownNamespacePolicyNames = map[string]UnknownNamespacePolicy{
+	"reject":      UnknownNamespaceReject,
+	"auto-create": UnknownNamespaceAutoCreate,
+}
+
+// ParseUnknownNamespacePolicy parses the name of an UnknownNamespacePolicy: one of `reject` or
+// `auto-create`.
+func ParseUnknownNamespacePolicy(name string) (UnknownNamespacePolicy, error) {
+	policy, ok := unknownNamespacePolicyNames[name]
+	if !ok {
+		return UnknownNamespaceReject, fmt.Errorf("unknown write unknown namespace policy `%s`; must be one of `reject` or `auto-create`", name)
+	}
+	return policy, nil
+}
+
+// createUnknownNamespaces defines a minimal namespace for each namespace referenced by the created
+// or touched relationships which does not yet exist. Relations are only added to the namespaces
+// being created, so writes to an undefined relation of an existing namespace still fail validation.
+// A relation which is only used as a subject relation, whose subject types are therefore unknown,
+// allows its own namespace as a placeholder type, to be replaced when the schema is written.
+func createUnknownNamespaces(ctx context.Context, rwt datastore.ReadWriteTransaction, updates []*core.RelationTupleUpdate) error {
+	referencedNames := mapz.NewSet[string]()
+	for _, update := range updates {
+		if update.Operation == core.RelationTupleUpdate_DELETE {
+			continue
+		}
+		referencedNames.Add(update.Tuple.ResourceAndRelation.Namespace)
+		referencedNames.Add(update.Tuple.Subject.Namespace)
+	}
+
+	if referencedNames.IsEmpty() {
+		return nil
+	}
+
+	existing, err := rwt.LookupNamespacesWithNames(ctx, referencedNames.AsSlice())
+	if err != nil {
+		return err
+	}
+
+	for _, nsDef := range existing {
+		referencedNames.Remove(nsDef.Definition.Name)
+	}
+
+	if referencedNames.IsEmpty() {
+		return nil
+	}
+
+	// Collect the relations written on each unknown namespace, in the order first written, along
+	// with the subject types written to each.
+	created := make([]*core.NamespaceDefinition, 0, referencedNames.Len())
+	relationNames := make(map[string][]string, referencedNames.Len())
+	allowedByRelation := make(map[string][]*core.AllowedRelation)
+	allowedSeen := mapz.NewSet[string]()
+	subjectRelationSeen := mapz.NewSet[string]()
+	for _, update := range updates {
+		for _, name := range []string{update.Tuple.ResourceAndRelation.Namespace, update.Tuple.Subject.Namespace} {
+			if referencedNames.Remove(name) {
+				created = append(created, ns.Namespace(name))
+			}
+		}
+
+		if update.Operation == core.RelationTupleUpdate_DELETE {
+			continue
+		}
+
+		var caveat *core.AllowedCaveat
+		if update.Tuple.Caveat != nil {
+			caveat = ns.AllowedCaveat(update.Tuple.Caveat.CaveatName)
+		}
+
+		var allowed *core.AllowedRelation
+		if update.Tuple.Subject.ObjectId == tuple.PublicWildcard {
+			allowed = ns.AllowedPublicNamespaceWithCaveat(update.Tuple.Subject.Namespace, caveat)
+		} else {
+			allowed = ns.AllowedRelationWithCaveat(update.Tuple.Subject.Namespace, update.Tuple.Subject.Relation, caveat)
+		}
+
+		if update.Tuple.Subject.Relation != tuple.Ellipsis {
+			subjectKey := update.Tuple.Subject.Namespace + "#" + update.Tuple.Subject.Relation
+			if _, ok := allowedByRelation[subjectKey]; !ok && !subjectRelationSeen.Has(subjectKey) {
+				subjectRelationSeen.Add(subjectKey)
+				relationNames[update.Tuple.Subject.Namespace] = append(relationNames[update.Tuple.Subject.Namespace], update.Tuple.Subject.Relation)
+			}
+		}
+
+		namespaceName := update.Tuple.ResourceAndRelation.Namespace
+		relationKey := namespaceName + "#" + update.Tuple.ResourceAndRelation.Relation
+		if !allowedSeen.Add(relationKey + "@" + namespace.SourceForAllowedRelation(allowed)) {
+			continue
+		}
+
+		if _, ok := allowedByRelation[relationKey]; !ok && !subjectRelationSeen.Has(relationKey) {
+			relationNames[namespaceName] = append(relationNames[namespaceName], update.Tuple.ResourceAndRelation.Relation)
+		}
+		allowedByRelation[relationKey] = append(allowedByRelation[relationKey], allowed)
+	}
+
+	for _, nsDef := range created {
+		for _, relationName := range relationNames[nsDef.Name] {
+			allowed, ok := allowedByRelation[nsDef.Name+"#"+relationName]
+			if !ok {
+				allowed = []*core.AllowedRelation{ns.AllowedRelation(nsDef.Name, tuple.Ellipsis)}
+			}
+			nsDef.Relation = append(nsDef.Relation, ns.MustRelation(relationName, nil, allowed...))
+		}
+	}
+
+	resolver := namespace.ResolverForDatastoreReader(rwt).WithPredefinedElements(namespace.PredefinedElements{
+		Namespaces: created,
+	})
+	for _, nsDef := range created {
+		ts, err := namespace.NewNamespaceTypeSystem(nsDef, resolver)
+		if err != nil {
+			return err
+		}
+
+		vts, err := ts.Validate(ctx)
+		if err != nil {
+			return err
+		}
+
+		if err := namespace.AnnotateNamespace(vts); err != nil {
+			return err
+		}
+	}
+
+	if err := rwt.WriteNamespaces(ctx, created...); err != nil {
+		return err
+	}
+
+	for _, nsDef := range created {
+		log.Ctx(ctx).Info().Str("namespace", nsDef.Name).Int("relations", len(nsDef.Relation)).Msg("auto-created unknown namespace referenced by relationship write")
+	}
+	return nil
+}
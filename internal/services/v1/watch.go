@@ -90,21 +90,21 @@ func (ws *watchServer) Watch(req *v1.WatchRequest, stream v1.WatchService_WatchS
 	}
 }
 
+// filterUpdates returns the API form of the candidate updates whose resource is of one of the
+// given object types, or of all the candidate updates if no object types are given. Updates are
+// filtered before conversion, so that subscribers to a few object types do not pay for converting
+// changes to all others.
 func filterUpdates(objectTypes map[string]struct{}, candidates []*core.RelationTupleUpdate) []*v1.RelationshipUpdate {
-	updates := tuple.UpdatesToRelationshipUpdates(candidates)
-
 	if len(objectTypes) == 0 {
-		return updates
+		return tuple.UpdatesToRelationshipUpdates(candidates)
 	}
 
-	var filtered []*v1.RelationshipUpdate
-	for _, update := range updates {
-		objectType := update.GetRelationship().GetResource().GetObjectType()
-
-		if _, ok := objectTypes[objectType]; ok {
-			filtered = append(filtered, update)
+	var filtered []*core.RelationTupleUpdate
+	for _, candidate := range candidates {
+		if _, ok := objectTypes[candidate.Tuple.ResourceAndRelation.Namespace]; ok {
+			filtered = append(filtered, candidate)
 		}
 	}
 
-	return filtered
+	return tuple.UpdatesToRelationshipUpdates(filtered)
 }
@@ -21,10 +21,17 @@ import (
 
 // ServerConfig is configuration for the test server.
 type ServerConfig struct {
-	MaxUpdatesPerWrite         uint16
-	MaxPreconditionsCount      uint16
-	MaxRelationshipContextSize int
-	StreamingAPITimeout        time.Duration
+	MaxUpdatesPerWrite          uint16
+	MaxPreconditionsCount       uint16
+	MaxRelationshipContextSize  int
+	StreamingAPITimeout         time.Duration
+	SchemaNamespaceNamePattern  string
+	CheckMissingNamespacePolicy string
+	RelationshipValidation      string
+	WriteUnknownNamespacePolicy string
+	MissingCaveatContextPolicy  string
+	ErrorVerbosity              string
+	MaxSchemaSize               uint32
 }
 
 // NewTestServer creates a new test server, using defaults for the config.
@@ -70,6 +77,13 @@ func NewTestServerWithConfig(require *require.Assertions,
 			Enabled: true,
 		}),
 		server.WithSchemaPrefixesRequired(schemaPrefixRequired),
+		server.WithSchemaNamespaceNamePattern(config.SchemaNamespaceNamePattern),
+		server.WithCheckMissingNamespacePolicy(config.CheckMissingNamespacePolicy),
+		server.WithRelationshipValidation(config.RelationshipValidation),
+		server.WithWriteUnknownNamespacePolicy(config.WriteUnknownNamespacePolicy),
+		server.WithMissingCaveatContextPolicy(config.MissingCaveatContextPolicy),
+		server.WithErrorVerbosity(config.ErrorVerbosity),
+		server.WithMaxSchemaSize(config.MaxSchemaSize),
 		server.WithGRPCAuthFunc(func(ctx context.Context) (context.Context, error) {
 			return ctx, nil
 		}),
@@ -36,13 +36,20 @@ type Config struct {
 	// DefaultTTL configures a default deadline on the lifetime of any keys set
 	// to the cache.
 	DefaultTTL time.Duration
+
+	// TTLJitter is the maximum fraction, between 0 and 1, by which the TTL of
+	// each key is randomly shortened, spreading out the expiration of keys set
+	// at around the same time so that they are not all recomputed at once.
+	// Keys are never given a TTL longer than DefaultTTL.
+	TTLJitter float64
 }
 
 func (c *Config) MarshalZerologObject(e *zerolog.Event) {
 	e.
 		Str("maxCost", humanize.IBytes(uint64(c.MaxCost))).
 		Int64("numCounters", c.NumCounters).
-		Dur("defaultTTL", c.DefaultTTL)
+		Dur("defaultTTL", c.DefaultTTL).
+		Float64("ttlJitter", c.TTLJitter)
 }
 
 // Cache defines an interface for a generic cache.
@@ -4,6 +4,7 @@
 package cache
 
 import (
+	"math/rand"
 	"time"
 
 	"github.com/outcaste-io/ristretto"
@@ -61,7 +62,24 @@ func (w wrapped) Set(key, entry any, cost int64) bool {
 	if w.defaultTTL <= 0 {
 		return w.Cache.Set(key, entry, cost)
 	}
-	return w.Cache.SetWithTTL(key, entry, cost, w.defaultTTL)
+	return w.Cache.SetWithTTL(key, entry, cost, jitteredTTL(w.defaultTTL, w.config.TTLJitter))
+}
+
+// jitteredTTL returns the TTL shortened by a random amount of up to the given
+// fraction of it.
+func jitteredTTL(ttl time.Duration, jitter float64) time.Duration {
+	if jitter <= 0 {
+		return ttl
+	}
+	if jitter > 1 {
+		jitter = 1
+	}
+
+	jittered := ttl - time.Duration(rand.Float64()*jitter*float64(ttl)) //nolint:gosec
+	if jittered <= 0 {
+		return 1
+	}
+	return jittered
 }
 
 var _ Cache = (*wrapped)(nil)
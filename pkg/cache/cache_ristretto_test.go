@@ -0,0 +1,29 @@
+//go:build !wasm
+// +build !wasm
+
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJitteredTTL(t *testing.T) {
+	ttl := 10 * time.Second
+
+	require.Equal(t, ttl, jitteredTTL(ttl, 0))
+
+	for i := 0; i < 100; i++ {
+		jittered := jitteredTTL(ttl, 0.2)
+		require.LessOrEqual(t, jittered, ttl)
+		require.GreaterOrEqual(t, jittered, 8*time.Second)
+	}
+
+	for i := 0; i < 100; i++ {
+		jittered := jitteredTTL(ttl, 5)
+		require.LessOrEqual(t, jittered, ttl)
+		require.Positive(t, jittered)
+	}
+}
@@ -3,8 +3,13 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
 	"time"
 
+	"github.com/jzelinskie/cobrautil/v2"
 	"github.com/spf13/cobra"
 
 	"github.com/authzed/spicedb/internal/datastore/common"
@@ -13,6 +18,7 @@ import (
 	"github.com/authzed/spicedb/pkg/cmd/server"
 	"github.com/authzed/spicedb/pkg/cmd/termination"
 	dspkg "github.com/authzed/spicedb/pkg/datastore"
+	"github.com/authzed/spicedb/pkg/tuple"
 )
 
 func RegisterDatastoreRootFlags(_ *cobra.Command) {
@@ -37,6 +43,14 @@ func NewDatastoreCommand(_ string) (*cobra.Command, error) {
 	}
 	datastoreCmd.AddCommand(gcCmd)
 
+	deleteSubjectCfg := datastore.Config{}
+	deleteSubjectCmd := NewDeleteSubjectDatastoreCommand(datastoreCmd.Use, &deleteSubjectCfg)
+	deleteSubjectCmd.Flags().Bool("dry-run", false, "print the relationships of the subject that would be deleted, grouped by resource type and relation, without deleting them")
+	if err := datastore.RegisterDatastoreFlagsWithPrefix(deleteSubjectCmd.Flags(), "", &deleteSubjectCfg); err != nil {
+		return nil, err
+	}
+	datastoreCmd.AddCommand(deleteSubjectCmd)
+
 	return datastoreCmd, nil
 }
 
@@ -81,3 +95,102 @@ func NewGCDatastoreCommand(programName string, cfg *datastore.Config) *cobra.Com
 		}),
 	}
 }
+
+func NewDeleteSubjectDatastoreCommand(programName string, cfg *datastore.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:     "delete-subject <subject>",
+		Short:   "deletes every relationship of a subject",
+		Long:    "Deletes every relationship whose subject is the given subject, such as user:tom, across all resource types and relations, in a single transaction. A subject given with a relation, such as group:eng#member, only matches relationships with that subject relation; otherwise relationships with any subject relation match. With --dry-run, the relationships that would be deleted are summarized instead.",
+		PreRunE: server.DefaultPreRunE(programName),
+		RunE: termination.PublishError(func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+
+			subjectsFilter, err := subjectsFilterFromArg(args[0])
+			if err != nil {
+				return err
+			}
+
+			// Disable background GC and hedging.
+			cfg.GCInterval = -1 * time.Hour
+			cfg.RequestHedgingEnabled = false
+
+			ds, err := datastore.NewDatastore(ctx, cfg.ToOption())
+			if err != nil {
+				return fmt.Errorf("failed to create datastore: %w", err)
+			}
+			defer ds.Close()
+
+			if cobrautil.MustGetBool(cmd, "dry-run") {
+				return printSubjectImpact(ctx, cmd.OutOrStdout(), ds, subjectsFilter)
+			}
+
+			revision, deleted, err := common.DeleteBySubject(ctx, ds, subjectsFilter)
+			if err != nil {
+				return fmt.Errorf("failed to delete relationships of subject: %w", err)
+			}
+
+			log.Ctx(ctx).Info().Str("subject", args[0]).Uint64("deleted", deleted).Stringer("revision", revision).Msg("deleted relationships of subject")
+			fmt.Fprintf(cmd.OutOrStdout(), "deleted %d relationships\n", deleted)
+			return nil
+		}),
+		Args: cobra.ExactArgs(1),
+	}
+}
+
+// printSubjectImpact prints the resources on which the subjects matching the filter have
+// relationships at the head revision, grouped by resource type and relation.
+func printSubjectImpact(ctx context.Context, out io.Writer, ds dspkg.Datastore, subjectsFilter dspkg.SubjectsFilter) error {
+	headRevision, err := ds.HeadRevision(ctx)
+	if err != nil {
+		return err
+	}
+
+	impact, err := common.SummarizeSubjectImpact(ctx, ds.SnapshotReader(headRevision), subjectsFilter)
+	if err != nil {
+		return fmt.Errorf("failed to summarize relationships of subject: %w", err)
+	}
+
+	resourceTypes := make([]string, 0, len(impact.Resources))
+	for resourceType := range impact.Resources {
+		resourceTypes = append(resourceTypes, resourceType)
+	}
+	sort.Strings(resourceTypes)
+
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "RESOURCE TYPE\tRELATION\tRESOURCES")
+	for _, resourceType := range resourceTypes {
+		relations := make([]string, 0, len(impact.Resources[resourceType]))
+		for relation := range impact.Resources[resourceType] {
+			relations = append(relations, relation)
+		}
+		sort.Strings(relations)
+
+		for _, relation := range relations {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", resourceType, relation, strings.Join(impact.Resources[resourceType][relation], ", "))
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "would delete %d relationships\n", impact.RelationshipCount)
+	return nil
+}
+
+// subjectsFilterFromArg returns the filter matching the subject given as a command argument. A
+// subject without a relation matches every subject relation.
+func subjectsFilterFromArg(arg string) (dspkg.SubjectsFilter, error) {
+	subject := tuple.ParseSubjectONR(arg)
+	if subject == nil {
+		return dspkg.SubjectsFilter{}, fmt.Errorf("invalid subject %q; must be of the form type:id or type:id#relation", arg)
+	}
+
+	subjectsFilter := dspkg.SubjectsFilter{
+		SubjectType:        subject.Namespace,
+		OptionalSubjectIds: []string{subject.ObjectId},
+	}
+	if strings.Contains(arg, "#") {
+		subjectsFilter.RelationFilter = dspkg.SubjectRelationFilter{}.WithRelation(subject.Relation)
+	}
+	return subjectsFilter, nil
+}
@@ -138,6 +138,10 @@ type Config struct {
 	// MySQL
 	TablePrefix string `debugmap:"visible"`
 
+	// Memory
+	MemoryIndexedSubjectTypes    []string `debugmap:"visible"`
+	MemoryRevisionRetentionCount uint32   `debugmap:"visible"`
+
 	// Internal
 	WatchBufferLength uint16 `debugmap:"visible"`
 
@@ -207,6 +211,8 @@ func RegisterDatastoreFlagsWithPrefix(flagSet *pflag.FlagSet, prefix string, opt
 	flagSet.StringVar(&opts.SpannerCredentialsFile, flagName("datastore-spanner-credentials"), "", "path to service account key credentials file with access to the cloud spanner instance (omit to use application default credentials)")
 	flagSet.StringVar(&opts.SpannerEmulatorHost, flagName("datastore-spanner-emulator-host"), "", "URI of spanner emulator instance used for development and testing (e.g. localhost:9010)")
 	flagSet.StringVar(&opts.TablePrefix, flagName("datastore-mysql-table-prefix"), "", "prefix to add to the name of all SpiceDB database tables")
+	flagSet.StringSliceVar(&opts.MemoryIndexedSubjectTypes, flagName("datastore-memory-indexed-subject-types"), defaults.MemoryIndexedSubjectTypes, "subject types for which an additional index is maintained to speed up reverse lookups (memory driver only)")
+	flagSet.Uint32Var(&opts.MemoryRevisionRetentionCount, flagName("datastore-memory-revision-retention-count"), defaults.MemoryRevisionRetentionCount, "number of most recent revisions kept readable regardless of the GC window, discarding older revisions once outside both (memory driver only; 0 retains by GC window alone)")
 	flagSet.StringVar(&opts.MigrationPhase, flagName("datastore-migration-phase"), "", "datastore-specific flag that should be used to signal to a datastore which phase of a multi-step migration it is in")
 	flagSet.Uint16Var(&opts.WatchBufferLength, flagName("datastore-watch-buffer-length"), 1024, "how many events the watch buffer should queue before forcefully disconnecting reader")
 
@@ -260,6 +266,8 @@ func DefaultDatastoreConfig() *Config {
 		SpannerCredentialsFile:         "",
 		SpannerEmulatorHost:            "",
 		TablePrefix:                    "",
+		MemoryIndexedSubjectTypes:      []string{},
+		MemoryRevisionRetentionCount:   0,
 		MigrationPhase:                 "",
 		FollowerReadDelay:              4_800 * time.Millisecond,
 	}
@@ -448,5 +456,11 @@ func newMySQLDatastore(opts Config) (datastore.Datastore, error) {
 
 func newMemoryDatstore(opts Config) (datastore.Datastore, error) {
 	log.Warn().Msg("in-memory datastore is not persistent and not feasible to run in a high availability fashion")
-	return memdb.NewMemdbDatastore(opts.WatchBufferLength, opts.RevisionQuantization, opts.GCWindow)
+	return memdb.NewMemdbDatastore(
+		opts.WatchBufferLength,
+		opts.RevisionQuantization,
+		opts.GCWindow,
+		memdb.WithIndexedSubjectTypes(opts.MemoryIndexedSubjectTypes...),
+		memdb.WithRevisionRetentionCount(opts.MemoryRevisionRetentionCount),
+	)
 }
@@ -61,6 +61,8 @@ func (c *Config) ToOption() ConfigOption {
 		to.SpannerCredentialsFile = c.SpannerCredentialsFile
 		to.SpannerEmulatorHost = c.SpannerEmulatorHost
 		to.TablePrefix = c.TablePrefix
+		to.MemoryIndexedSubjectTypes = c.MemoryIndexedSubjectTypes
+		to.MemoryRevisionRetentionCount = c.MemoryRevisionRetentionCount
 		to.WatchBufferLength = c.WatchBufferLength
 		to.MigrationPhase = c.MigrationPhase
 	}
@@ -99,6 +101,8 @@ func (c Config) DebugMap() map[string]any {
 	debugMap["SpannerCredentialsFile"] = helpers.DebugValue(c.SpannerCredentialsFile, false)
 	debugMap["SpannerEmulatorHost"] = helpers.DebugValue(c.SpannerEmulatorHost, false)
 	debugMap["TablePrefix"] = helpers.DebugValue(c.TablePrefix, false)
+	debugMap["MemoryIndexedSubjectTypes"] = helpers.DebugValue(c.MemoryIndexedSubjectTypes, false)
+	debugMap["MemoryRevisionRetentionCount"] = helpers.DebugValue(c.MemoryRevisionRetentionCount, false)
 	debugMap["WatchBufferLength"] = helpers.DebugValue(c.WatchBufferLength, false)
 	debugMap["MigrationPhase"] = helpers.DebugValue(c.MigrationPhase, false)
 	return debugMap
@@ -344,6 +348,27 @@ func WithTablePrefix(tablePrefix string) ConfigOption {
 	}
 }
 
+// WithMemoryIndexedSubjectTypes returns an option that can append MemoryIndexedSubjectTypess to Config.MemoryIndexedSubjectTypes
+func WithMemoryIndexedSubjectTypes(memoryIndexedSubjectTypes string) ConfigOption {
+	return func(c *Config) {
+		c.MemoryIndexedSubjectTypes = append(c.MemoryIndexedSubjectTypes, memoryIndexedSubjectTypes)
+	}
+}
+
+// SetMemoryIndexedSubjectTypes returns an option that can set MemoryIndexedSubjectTypes on a Config
+func SetMemoryIndexedSubjectTypes(memoryIndexedSubjectTypes []string) ConfigOption {
+	return func(c *Config) {
+		c.MemoryIndexedSubjectTypes = memoryIndexedSubjectTypes
+	}
+}
+
+// WithMemoryRevisionRetentionCount returns an option that can set MemoryRevisionRetentionCount on a Config
+func WithMemoryRevisionRetentionCount(memoryRevisionRetentionCount uint32) ConfigOption {
+	return func(c *Config) {
+		c.MemoryRevisionRetentionCount = memoryRevisionRetentionCount
+	}
+}
+
 // WithWatchBufferLength returns an option that can set WatchBufferLength on a Config
 func WithWatchBufferLength(watchBufferLength uint16) ConfigOption {
 	return func(c *Config) {
@@ -40,6 +40,14 @@ var (
 		NumCounters: 100_000,
 		MaxCost:     "70%",
 	}
+
+	dispatchExpandCacheDefaults = &server.CacheConfig{
+		Name:        "dispatch_expand",
+		Enabled:     false,
+		Metrics:     true,
+		NumCounters: 10_000,
+		MaxCost:     "16MiB",
+	}
 )
 
 func RegisterServeFlags(cmd *cobra.Command, config *server.Config) error {
@@ -75,6 +83,7 @@ func RegisterServeFlags(cmd *cobra.Command, config *server.Config) error {
 
 	// Flags for HTTP gateway
 	util.RegisterHTTPServerFlags(cmd.Flags(), &config.HTTPGateway, "http", "gateway", ":8443", false)
+	util.RegisterHTTPServerTimeoutFlags(cmd.Flags(), &config.HTTPGateway, "http", "gateway")
 	cmd.Flags().StringVar(&config.HTTPGatewayUpstreamAddr, "http-upstream-override-addr", "", "Override the upstream to point to a different gRPC server")
 	if err := cmd.Flags().MarkHidden("http-upstream-override-addr"); err != nil {
 		return fmt.Errorf("failed to mark flag as hidden: %w", err)
@@ -83,6 +92,8 @@ func RegisterServeFlags(cmd *cobra.Command, config *server.Config) error {
 	if err := cmd.Flags().MarkHidden("http-upstream-override-tls-cert-path"); err != nil {
 		return fmt.Errorf("failed to mark flag as hidden: %w", err)
 	}
+	cmd.Flags().StringVar(&config.HTTPGatewayUpstreamTLSClientCertPath, "http-upstream-tls-client-cert-path", "", "local path to the TLS certificate presented by the http gateway to the upstream gRPC server, for upstreams requiring mutual TLS")
+	cmd.Flags().StringVar(&config.HTTPGatewayUpstreamTLSClientKeyPath, "http-upstream-tls-client-key-path", "", "local path to the TLS key of the certificate presented by the http gateway to the upstream gRPC server")
 	cmd.Flags().BoolVar(&config.HTTPGatewayCorsEnabled, "http-cors-enabled", false, "DANGEROUS: Enable CORS on the http gateway")
 	if err := cmd.Flags().MarkHidden("http-cors-enabled"); err != nil {
 		return fmt.Errorf("failed to mark flag as hidden: %w", err)
@@ -91,14 +102,22 @@ func RegisterServeFlags(cmd *cobra.Command, config *server.Config) error {
 	if err := cmd.Flags().MarkHidden("http-cors-allowed-origins"); err != nil {
 		return fmt.Errorf("failed to mark flag as hidden: %w", err)
 	}
+	cmd.Flags().StringSliceVar(&config.HTTPGatewayForwardedHeaders, "http-forwarded-headers", nil, "HTTP headers which the http gateway forwards to the gRPC server as request metadata")
+	cmd.Flags().Int64Var(&config.HTTPGatewayMaxRequestBodyBytes, "http-max-request-body-bytes", 0, "maximum size in bytes of a request body accepted by the http gateway; larger requests are rejected with a 413 status. A value of zero uses the default of 8MiB")
+	cmd.Flags().DurationVar(&config.HTTPGatewayRequestTimeout, "http-request-timeout", 0, "deadline applied to each upstream call made by the http gateway, including streaming calls; calls exceeding it are answered with a 504 status. A value of zero applies no deadline")
+	cmd.Flags().BoolVar(&config.HTTPGatewayCompressionEnabled, "http-compression-enabled", false, "gzip compress the responses of the http gateway for clients accepting it")
+	cmd.Flags().IntVar(&config.HTTPGatewayCompressionMinSize, "http-compression-min-size", 0, "minimum size in bytes of a response compressed by the http gateway. A value of zero uses the default of 1KiB")
 
 	// Flags for configuring the dispatch server
 	util.RegisterGRPCServerFlags(cmd.Flags(), &config.DispatchServer, "dispatch-cluster", "dispatch", ":50053", false)
 	server.RegisterCacheFlags(cmd.Flags(), "dispatch-cache", &config.DispatchCacheConfig, dispatchCacheDefaults)
 	server.RegisterCacheFlags(cmd.Flags(), "dispatch-cluster-cache", &config.ClusterDispatchCacheConfig, dispatchClusterCacheDefaults)
+	server.RegisterCacheFlags(cmd.Flags(), "dispatch-expand-cache", &config.DispatchExpandCacheConfig, dispatchExpandCacheDefaults)
 
 	// Flags for configuring dispatch requests
 	cmd.Flags().Uint32Var(&config.DispatchMaxDepth, "dispatch-max-depth", 50, "maximum recursion depth for nested calls")
+	cmd.Flags().StringVar(&config.DispatchEmptyRewritePolicy, "dispatch-empty-rewrite-policy", "deny", `how check treats a permission whose rewrite has no operands: "deny" denies every subject and "error" fails the request`)
+	cmd.Flags().Uint32Var(&config.DispatchMaxDirectRelationshipsPerNode, "dispatch-max-direct-relationships-per-node", 0, "maximum number of relationships read per resource for a single relation in check and expand requests, which fail if it is exceeded (0 for unlimited)")
 	cmd.Flags().StringVar(&config.DispatchUpstreamAddr, "dispatch-upstream-addr", "", "upstream grpc address to dispatch to")
 	cmd.Flags().StringVar(&config.DispatchUpstreamCAPath, "dispatch-upstream-ca-path", "", "local path to the TLS CA used when connecting to the dispatch cluster")
 	cmd.Flags().DurationVar(&config.DispatchUpstreamTimeout, "dispatch-upstream-timeout", 60*time.Second, "maximum duration of a dispatch call an upstream cluster before it times out")
@@ -113,15 +132,37 @@ func RegisterServeFlags(cmd *cobra.Command, config *server.Config) error {
 	cmd.Flags().Uint16Var(&config.DispatchHashringReplicationFactor, "dispatch-hashring-replication-factor", 100, "set the replication factor of the consistent hasher used for the dispatcher")
 	cmd.Flags().Uint8Var(&config.DispatchHashringSpread, "dispatch-hashring-spread", 1, "set the spread of the consistent hasher used for the dispatcher")
 
+	cmd.Flags().Int64Var(&config.DispatchBacklogThreshold, "dispatch-backlog-threshold", 0, "number of dispatch tasks waiting on the concurrency limits above which the health check reports NOT_SERVING. a value of zero disables the check")
+
 	// Flags for configuring API behavior
 	cmd.Flags().BoolVar(&config.DisableV1SchemaAPI, "disable-v1-schema-api", false, "disables the V1 schema API")
 	cmd.Flags().BoolVar(&config.DisableVersionResponse, "disable-version-response", false, "disables version response support in the API")
+	cmd.Flags().StringVar(&config.ErrorVerbosity, "error-verbosity", "verbose", `how much detail about failed requests is returned to clients: "verbose" returns the full error and "safe" returns only the error code and request ID, writing the full error to the server logs`)
+	cmd.Flags().StringVar(&config.LogIDRedaction, "log-id-redaction", "none", `how object and subject IDs are written to the logs: "none" writes them as-is, "hash" writes a hash that is consistent within the process so that lines about the same object can be correlated and "redact" omits them`)
 	cmd.Flags().Uint16Var(&config.MaximumUpdatesPerWrite, "write-relationships-max-updates-per-call", 1000, "maximum number of updates allowed for WriteRelationships calls")
 	cmd.Flags().Uint16Var(&config.MaximumPreconditionCount, "update-relationships-max-preconditions-per-call", 1000, "maximum number of preconditions allowed for WriteRelationships and DeleteRelationships calls")
 	cmd.Flags().IntVar(&config.MaxCaveatContextSize, "max-caveat-context-size", 4096, "maximum allowed size of request caveat context in bytes. A value of zero or less means no limit")
 	cmd.Flags().IntVar(&config.MaxRelationshipContextSize, "max-relationship-context-size", 25000, "maximum allowed size of the context to be stored in a relationship")
+	cmd.Flags().StringVar(&config.MissingCaveatContextPolicy, "missing-caveat-context-policy", "conditional", `how permission results depending on a caveat whose context was not provided are returned: "conditional" returns them as conditional along with the missing fields, "deny" returns no permission and "error" fails the request`)
 	cmd.Flags().DurationVar(&config.StreamingAPITimeout, "streaming-api-response-delay-timeout", 30*time.Second, "max duration time elapsed between messages sent by the server-side to the client (responses) before the stream times out")
 
+	cmd.Flags().StringToStringVar(&config.NamespaceDefaultConsistency, "namespace-default-consistency", map[string]string{}, `consistency applied to requests targeting a namespace that do not specify one, as namespace=consistency pairs; consistency must be "minimize_latency" or "fully_consistent". namespaces not listed use minimize_latency`)
+
+	cmd.Flags().StringVar(&config.CheckWarmupFile, "check-warmup-file", "", "path to a file of checks, one resource:id#permission@subject:id per line, to compute in the background at startup, and again each time the served revision moves, so that they are cached before requested")
+	cmd.Flags().DurationVar(&config.CheckWarmupTimeout, "check-warmup-timeout", 30*time.Second, "maximum duration of each check warmup; checks not started by then are skipped")
+
+	cmd.Flags().StringVar(&config.SchemaOrphanPolicy, "schema-orphaned-relationships-policy", "strict", `how WriteSchema handles relationships for removed relations: "strict" rejects the change, "cascade" deletes the relationships and "permissive" keeps them and logs a warning`)
+
+	cmd.Flags().StringVar(&config.CheckMissingNamespacePolicy, "check-missing-namespace-policy", "error", `how CheckPermission handles a resource whose definition does not exist: "error" fails the request and "deny" returns no permission`)
+
+	cmd.Flags().StringVar(&config.RelationshipValidation, "write-relationships-validation", "full", `how thoroughly WriteRelationships validates relationships against the schema: "full" checks allowed subject types and caveats, "types-only" checks only that the definitions and relations exist and "none" skips validation, for trusted writers only`)
+
+	cmd.Flags().StringVar(&config.WriteUnknownNamespacePolicy, "write-unknown-namespace-policy", "reject", `how WriteRelationships handles relationships on definitions that do not exist: "reject" fails the request and "auto-create" defines them with the relations and subject types written, for prototyping only`)
+
+	cmd.Flags().StringVar(&config.SchemaNamespaceNamePattern, "schema-namespace-name-pattern", "", "regular expression that the name of every definition written via WriteSchema must match, such as ^tenant1/. if empty, any valid name is accepted")
+
+	cmd.Flags().Uint32Var(&config.MaxSchemaSize, "schema-max-size-bytes", 1024*1024, "maximum size in bytes of the schema text accepted by WriteSchema, checked before the schema is parsed. A value of zero means no limit")
+
 	cmd.Flags().BoolVar(&config.V1SchemaAdditiveOnly, "testing-only-schema-additive-writes", false, "append new definitions to the existing schema, rather than overwriting it")
 	if err := cmd.Flags().MarkHidden("testing-only-schema-additive-writes"); err != nil {
 		return fmt.Errorf("failed to mark flag as required: %w", err)
@@ -38,6 +38,7 @@ type CacheConfig struct {
 	NumCounters int64         `debugmap:"visible"`
 	Metrics     bool          `debugmap:"visible"`
 	Enabled     bool          `debugmap:"visible"`
+	TTLJitter   float64       `debugmap:"visible"`
 	defaultTTL  time.Duration `debugmap:"visible"`
 }
 
@@ -73,11 +74,16 @@ func (cc *CacheConfig) Complete() (cache.Cache, error) {
 		return nil, fmt.Errorf("error parsing cache max memory: `%s`: %w", cc.MaxCost, err)
 	}
 
+	if cc.TTLJitter < 0 || cc.TTLJitter > 1 {
+		return nil, fmt.Errorf("cache TTL jitter must be between 0 and 1, found %v", cc.TTLJitter)
+	}
+
 	if cc.Metrics {
 		return cache.NewCacheWithMetrics(cc.Name, &cache.Config{
 			MaxCost:     int64(maxCost),
 			NumCounters: cc.NumCounters,
 			DefaultTTL:  cc.defaultTTL,
+			TTLJitter:   cc.TTLJitter,
 		})
 	}
 
@@ -85,6 +91,7 @@ func (cc *CacheConfig) Complete() (cache.Cache, error) {
 		MaxCost:     int64(maxCost),
 		NumCounters: cc.NumCounters,
 		DefaultTTL:  cc.defaultTTL,
+		TTLJitter:   cc.TTLJitter,
 	})
 }
 
@@ -111,4 +118,5 @@ func RegisterCacheFlags(flags *pflag.FlagSet, flagPrefix string, config, default
 	flags.Int64Var(&config.NumCounters, flagPrefix+"-num-counters", defaults.NumCounters, "number of TinyLFU samples to track")
 	flags.BoolVar(&config.Metrics, flagPrefix+"-metrics", defaults.Metrics, "enable cache metrics")
 	flags.BoolVar(&config.Enabled, flagPrefix+"-enabled", defaults.Enabled, "enable caching")
+	flags.Float64Var(&config.TTLJitter, flagPrefix+"-ttl-jitter", defaults.TTLJitter, "maximum fraction, between 0 and 1, by which the TTL of each cache entry is randomly shortened so that entries do not all expire at once")
 }
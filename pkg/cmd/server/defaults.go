@@ -27,6 +27,7 @@ import (
 	consistencymw "github.com/authzed/spicedb/internal/middleware/consistency"
 	datastoremw "github.com/authzed/spicedb/internal/middleware/datastore"
 	dispatchmw "github.com/authzed/spicedb/internal/middleware/dispatcher"
+	"github.com/authzed/spicedb/internal/middleware/revisiontimestamp"
 	"github.com/authzed/spicedb/internal/middleware/servicespecific"
 	"github.com/authzed/spicedb/pkg/datastore"
 	logmw "github.com/authzed/spicedb/pkg/middleware/logging"
@@ -128,14 +129,19 @@ const (
 	DefaultMiddlewareGRPCProm      = "grpcprom"
 	DefaultMiddlewareServerVersion = "serverversion"
 
-	DefaultInternalMiddlewareDispatch       = "dispatch"
-	DefaultInternalMiddlewareDatastore      = "datastore"
-	DefaultInternalMiddlewareConsistency    = "consistency"
-	DefaultInternalMiddlewareServerSpecific = "servicespecific"
+	// MiddlewareErrorVerbosity is added after DefaultMiddlewareLog when errors are configured to
+	// be returned to clients without their details.
+	MiddlewareErrorVerbosity = "errorverbosity"
+
+	DefaultInternalMiddlewareDispatch          = "dispatch"
+	DefaultInternalMiddlewareDatastore         = "datastore"
+	DefaultInternalMiddlewareConsistency       = "consistency"
+	DefaultInternalMiddlewareRevisionTimestamp = "revisiontimestamp"
+	DefaultInternalMiddlewareServerSpecific    = "servicespecific"
 )
 
 // DefaultUnaryMiddleware generates the default middleware chain used for the public SpiceDB Unary gRPC methods
-func DefaultUnaryMiddleware(logger zerolog.Logger, authFunc grpcauth.AuthFunc, enableVersionResponse bool, dispatcher dispatch.Dispatcher, ds datastore.Datastore) (*MiddlewareChain[grpc.UnaryServerInterceptor], error) {
+func DefaultUnaryMiddleware(logger zerolog.Logger, authFunc grpcauth.AuthFunc, enableVersionResponse bool, dispatcher dispatch.Dispatcher, ds datastore.Datastore, consistencyOpts ...consistencymw.Option) (*MiddlewareChain[grpc.UnaryServerInterceptor], error) {
 	chain, err := NewMiddlewareChain([]ReferenceableMiddleware[grpc.UnaryServerInterceptor]{
 		NewUnaryMiddleware().
 			WithName(DefaultMiddlewareRequestID).
@@ -188,7 +194,13 @@ func DefaultUnaryMiddleware(logger zerolog.Logger, authFunc grpcauth.AuthFunc, e
 		NewUnaryMiddleware().
 			WithName(DefaultInternalMiddlewareConsistency).
 			WithInternal(true).
-			WithInterceptor(consistencymw.UnaryServerInterceptor()).
+			WithInterceptor(consistencymw.UnaryServerInterceptor(consistencyOpts...)).
+			Done(),
+
+		NewUnaryMiddleware().
+			WithName(DefaultInternalMiddlewareRevisionTimestamp).
+			WithInternal(true).
+			WithInterceptor(revisiontimestamp.UnaryServerInterceptor()).
 			Done(),
 
 		NewUnaryMiddleware().
@@ -201,7 +213,7 @@ func DefaultUnaryMiddleware(logger zerolog.Logger, authFunc grpcauth.AuthFunc, e
 }
 
 // DefaultStreamingMiddleware generates the default middleware chain used for the public SpiceDB Streaming gRPC methods
-func DefaultStreamingMiddleware(logger zerolog.Logger, authFunc grpcauth.AuthFunc, enableVersionResponse bool, dispatcher dispatch.Dispatcher, ds datastore.Datastore) (*MiddlewareChain[grpc.StreamServerInterceptor], error) {
+func DefaultStreamingMiddleware(logger zerolog.Logger, authFunc grpcauth.AuthFunc, enableVersionResponse bool, dispatcher dispatch.Dispatcher, ds datastore.Datastore, consistencyOpts ...consistencymw.Option) (*MiddlewareChain[grpc.StreamServerInterceptor], error) {
 	chain, err := NewMiddlewareChain([]ReferenceableMiddleware[grpc.StreamServerInterceptor]{
 		NewStreamMiddleware().
 			WithName(DefaultMiddlewareRequestID).
@@ -254,7 +266,13 @@ func DefaultStreamingMiddleware(logger zerolog.Logger, authFunc grpcauth.AuthFun
 		NewStreamMiddleware().
 			WithName(DefaultInternalMiddlewareConsistency).
 			WithInternal(true).
-			WithInterceptor(consistencymw.StreamServerInterceptor()).
+			WithInterceptor(consistencymw.StreamServerInterceptor(consistencyOpts...)).
+			Done(),
+
+		NewStreamMiddleware().
+			WithName(DefaultInternalMiddlewareRevisionTimestamp).
+			WithInternal(true).
+			WithInterceptor(revisiontimestamp.StreamServerInterceptor()).
 			Done(),
 
 		NewStreamMiddleware().
@@ -291,6 +309,14 @@ func DefaultDispatchMiddleware(logger zerolog.Logger, authFunc grpcauth.AuthFunc
 
 func InterceptorLogger(l zerolog.Logger) grpclog.Logger {
 	return grpclog.LoggerFunc(func(ctx context.Context, lvl grpclog.Level, msg string, fields ...any) {
+		// Errors reach the logger as text, so the IDs they reference are redacted here.
+		for i := 1; i < len(fields); i += 2 {
+			if text, ok := fields[i].(string); ok && fields[i-1] == "grpc.error" {
+				fields[i] = logging.RedactText(text)
+			}
+		}
+		msg = logging.RedactText(msg)
+
 		l := l.With().Fields(fields).Logger()
 
 		switch lvl {
@@ -6,6 +6,7 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"regexp"
 	"strconv"
 	"sync"
 	"time"
@@ -32,10 +33,15 @@ import (
 	combineddispatch "github.com/authzed/spicedb/internal/dispatch/combined"
 	"github.com/authzed/spicedb/internal/dispatch/graph"
 	"github.com/authzed/spicedb/internal/gateway"
+	maingraph "github.com/authzed/spicedb/internal/graph"
 	log "github.com/authzed/spicedb/internal/logging"
+	consistencymw "github.com/authzed/spicedb/internal/middleware/consistency"
+	"github.com/authzed/spicedb/internal/middleware/errorverbosity"
+	"github.com/authzed/spicedb/internal/relationships"
 	"github.com/authzed/spicedb/internal/services"
 	dispatchSvc "github.com/authzed/spicedb/internal/services/dispatch"
 	"github.com/authzed/spicedb/internal/services/health"
+	"github.com/authzed/spicedb/internal/services/shared"
 	v1svc "github.com/authzed/spicedb/internal/services/v1"
 	"github.com/authzed/spicedb/internal/telemetry"
 	"github.com/authzed/spicedb/pkg/balancer"
@@ -59,13 +65,22 @@ type Config struct {
 	PresharedSecureKey     []string              `debugmap:"sensitive"`
 	ShutdownGracePeriod    time.Duration         `debugmap:"visible"`
 	DisableVersionResponse bool                  `debugmap:"visible"`
+	LogIDRedaction         string                `debugmap:"visible"`
+	ErrorVerbosity         string                `debugmap:"visible"`
 
 	// GRPC Gateway config
-	HTTPGateway                    util.HTTPServerConfig `debugmap:"visible"`
-	HTTPGatewayUpstreamAddr        string                `debugmap:"visible"`
-	HTTPGatewayUpstreamTLSCertPath string                `debugmap:"visible"`
-	HTTPGatewayCorsEnabled         bool                  `debugmap:"visible"`
-	HTTPGatewayCorsAllowedOrigins  []string              `debugmap:"visible-format"`
+	HTTPGateway                          util.HTTPServerConfig `debugmap:"visible"`
+	HTTPGatewayUpstreamAddr              string                `debugmap:"visible"`
+	HTTPGatewayUpstreamTLSCertPath       string                `debugmap:"visible"`
+	HTTPGatewayUpstreamTLSClientCertPath string                `debugmap:"visible"`
+	HTTPGatewayUpstreamTLSClientKeyPath  string                `debugmap:"visible"`
+	HTTPGatewayCorsEnabled               bool                  `debugmap:"visible"`
+	HTTPGatewayCorsAllowedOrigins        []string              `debugmap:"visible-format"`
+	HTTPGatewayForwardedHeaders          []string              `debugmap:"visible-format"`
+	HTTPGatewayMaxRequestBodyBytes       int64                 `debugmap:"visible"`
+	HTTPGatewayRequestTimeout            time.Duration         `debugmap:"visible"`
+	HTTPGatewayCompressionEnabled        bool                  `debugmap:"visible"`
+	HTTPGatewayCompressionMinSize        int                   `debugmap:"visible"`
 
 	// Datastore
 	DatastoreConfig datastorecfg.Config `debugmap:"visible"`
@@ -82,31 +97,45 @@ type Config struct {
 	SchemaPrefixesRequired bool `debugmap:"visible"`
 
 	// Dispatch options
-	DispatchServer                    util.GRPCServerConfig   `debugmap:"visible"`
-	DispatchMaxDepth                  uint32                  `debugmap:"visible"`
-	GlobalDispatchConcurrencyLimit    uint16                  `debugmap:"visible"`
-	DispatchConcurrencyLimits         graph.ConcurrencyLimits `debugmap:"visible"`
-	DispatchUpstreamAddr              string                  `debugmap:"visible"`
-	DispatchUpstreamCAPath            string                  `debugmap:"visible"`
-	DispatchUpstreamTimeout           time.Duration           `debugmap:"visible"`
-	DispatchClientMetricsEnabled      bool                    `debugmap:"visible"`
-	DispatchClientMetricsPrefix       string                  `debugmap:"visible"`
-	DispatchClusterMetricsEnabled     bool                    `debugmap:"visible"`
-	DispatchClusterMetricsPrefix      string                  `debugmap:"visible"`
-	Dispatcher                        dispatch.Dispatcher     `debugmap:"visible"`
-	DispatchHashringReplicationFactor uint16                  `debugmap:"visible"`
-	DispatchHashringSpread            uint8                   `debugmap:"visible"`
+	DispatchServer                        util.GRPCServerConfig   `debugmap:"visible"`
+	DispatchMaxDepth                      uint32                  `debugmap:"visible"`
+	DispatchMaxDirectRelationshipsPerNode uint32                  `debugmap:"visible"`
+	DispatchEmptyRewritePolicy            string                  `debugmap:"visible"`
+	GlobalDispatchConcurrencyLimit        uint16                  `debugmap:"visible"`
+	DispatchConcurrencyLimits             graph.ConcurrencyLimits `debugmap:"visible"`
+	DispatchUpstreamAddr                  string                  `debugmap:"visible"`
+	DispatchUpstreamCAPath                string                  `debugmap:"visible"`
+	DispatchUpstreamTimeout               time.Duration           `debugmap:"visible"`
+	DispatchClientMetricsEnabled          bool                    `debugmap:"visible"`
+	DispatchClientMetricsPrefix           string                  `debugmap:"visible"`
+	DispatchClusterMetricsEnabled         bool                    `debugmap:"visible"`
+	DispatchClusterMetricsPrefix          string                  `debugmap:"visible"`
+	Dispatcher                            dispatch.Dispatcher     `debugmap:"visible"`
+	DispatchHashringReplicationFactor     uint16                  `debugmap:"visible"`
+	DispatchHashringSpread                uint8                   `debugmap:"visible"`
+	DispatchBacklogThreshold              int64                   `debugmap:"visible"`
 
 	DispatchCacheConfig        CacheConfig `debugmap:"visible"`
 	ClusterDispatchCacheConfig CacheConfig `debugmap:"visible"`
+	DispatchExpandCacheConfig  CacheConfig `debugmap:"visible"`
 
 	// API Behavior
-	DisableV1SchemaAPI       bool          `debugmap:"visible"`
-	V1SchemaAdditiveOnly     bool          `debugmap:"visible"`
-	MaximumUpdatesPerWrite   uint16        `debugmap:"visible"`
-	MaximumPreconditionCount uint16        `debugmap:"visible"`
-	MaxDatastoreReadPageSize uint64        `debugmap:"visible"`
-	StreamingAPITimeout      time.Duration `debugmap:"visible"`
+	DisableV1SchemaAPI          bool              `debugmap:"visible"`
+	V1SchemaAdditiveOnly        bool              `debugmap:"visible"`
+	SchemaOrphanPolicy          string            `debugmap:"visible"`
+	SchemaNamespaceNamePattern  string            `debugmap:"visible"`
+	MaxSchemaSize               uint32            `debugmap:"visible"`
+	MaximumUpdatesPerWrite      uint16            `debugmap:"visible"`
+	MaximumPreconditionCount    uint16            `debugmap:"visible"`
+	MaxDatastoreReadPageSize    uint64            `debugmap:"visible"`
+	StreamingAPITimeout         time.Duration     `debugmap:"visible"`
+	CheckMissingNamespacePolicy string            `debugmap:"visible"`
+	RelationshipValidation      string            `debugmap:"visible"`
+	WriteUnknownNamespacePolicy string            `debugmap:"visible"`
+	MissingCaveatContextPolicy  string            `debugmap:"visible"`
+	NamespaceDefaultConsistency map[string]string `debugmap:"visible"`
+	CheckWarmupFile             string            `debugmap:"visible"`
+	CheckWarmupTimeout          time.Duration     `debugmap:"visible"`
 
 	// Additional Services
 	MetricsAPI util.HTTPServerConfig `debugmap:"visible"`
@@ -180,6 +209,14 @@ func (c *Config) Complete(ctx context.Context) (RunnableServer, error) {
 		}
 	}()
 
+	if c.LogIDRedaction != "" {
+		idRedaction, err := log.ParseIDRedaction(c.LogIDRedaction)
+		if err != nil {
+			return nil, err
+		}
+		log.SetIDRedaction(idRedaction)
+	}
+
 	if len(c.PresharedSecureKey) < 1 && c.GRPCAuthFunc == nil {
 		return nil, fmt.Errorf("a preshared key must be provided to authenticate API requests")
 	}
@@ -191,7 +228,7 @@ func (c *Config) Complete(ctx context.Context) (RunnableServer, error) {
 				return nil, fmt.Errorf("preshared key #%d is empty", index+1)
 			}
 
-			log.Ctx(ctx).Trace().Int("preshared-key-"+strconv.Itoa(index+1)+"-length", len(presharedKey)).Msg("preshared key configured")
+			log.Ctx(ctx).Trace().Int("preshared-key-"+strconv.Itoa(index+1)+"-length", len(presharedKey)).Str("preshared-key-"+strconv.Itoa(index+1)+"-id", auth.PresharedKeyID(index)).Msg("preshared key configured")
 		}
 
 		c.GRPCAuthFunc = auth.MustRequirePresharedKey(c.PresharedSecureKey)
@@ -222,7 +259,15 @@ func (c *Config) Complete(ctx context.Context) (RunnableServer, error) {
 	ds = proxy.NewObservableDatastoreProxy(ds)
 	closeables.AddWithError(ds.Close)
 
-	enableGRPCHistogram()
+	EnableGRPCHistogram()
+
+	emptyRewritePolicy := maingraph.EmptyRewriteDeny
+	if c.DispatchEmptyRewritePolicy != "" {
+		emptyRewritePolicy, err = maingraph.ParseEmptyRewritePolicy(c.DispatchEmptyRewritePolicy)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	dispatcher := c.Dispatcher
 	if dispatcher == nil {
@@ -237,6 +282,17 @@ func (c *Config) Complete(ctx context.Context) (RunnableServer, error) {
 		closeables.AddWithoutError(cc.Close)
 		log.Ctx(ctx).Info().EmbedObject(cc).Msg("configured dispatch cache")
 
+		ecc, err := c.DispatchExpandCacheConfig.WithRevisionParameters(
+			c.DatastoreConfig.RevisionQuantization,
+			c.DatastoreConfig.FollowerReadDelay,
+			c.DatastoreConfig.MaxRevisionStalenessPercent,
+		).Complete()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create dispatcher: %w", err)
+		}
+		closeables.AddWithoutError(ecc.Close)
+		log.Ctx(ctx).Info().EmbedObject(ecc).Msg("configured dispatch expand cache")
+
 		dispatchPresharedKey := ""
 		if len(c.PresharedSecureKey) > 0 {
 			dispatchPresharedKey = c.PresharedSecureKey[0]
@@ -264,7 +320,10 @@ func (c *Config) Complete(ctx context.Context) (RunnableServer, error) {
 			combineddispatch.MetricsEnabled(c.DispatchClientMetricsEnabled),
 			combineddispatch.PrometheusSubsystem(c.DispatchClientMetricsPrefix),
 			combineddispatch.Cache(cc),
+			combineddispatch.ExpandCache(ecc),
 			combineddispatch.ConcurrencyLimits(concurrencyLimits),
+			combineddispatch.MaxDirectRelationshipsPerNode(c.DispatchMaxDirectRelationshipsPerNode),
+			combineddispatch.EmptyRewritePolicy(emptyRewritePolicy),
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create dispatcher: %w", err)
@@ -301,6 +360,8 @@ func (c *Config) Complete(ctx context.Context) (RunnableServer, error) {
 			clusterdispatch.PrometheusSubsystem(c.DispatchClusterMetricsPrefix),
 			clusterdispatch.Cache(cdcc),
 			clusterdispatch.RemoteDispatchTimeout(c.DispatchUpstreamTimeout),
+			clusterdispatch.MaxDirectRelationshipsPerNode(c.DispatchMaxDirectRelationshipsPerNode),
+			clusterdispatch.EmptyRewritePolicy(emptyRewritePolicy),
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to configure cluster dispatch: %w", err)
@@ -320,6 +381,18 @@ func (c *Config) Complete(ctx context.Context) (RunnableServer, error) {
 	}
 	closeables.AddWithoutError(dispatchGrpcServer.GracefulStop)
 
+	var warmCheckCache func(ctx context.Context)
+	if c.CheckWarmupFile != "" {
+		checks, err := loadCheckWarmupFile(c.CheckWarmupFile)
+		if err != nil {
+			return nil, err
+		}
+
+		warmupConcurrencyLimit := c.DispatchConcurrencyLimits.WithOverallDefaultLimit(c.GlobalDispatchConcurrencyLimit).Check
+		warmCheckCache = newCheckWarmupFunc(ds, dispatcher, checks, c.CheckWarmupTimeout, c.DatastoreConfig.RevisionQuantization, c.DispatchMaxDepth, warmupConcurrencyLimit)
+		log.Ctx(ctx).Info().Int("checks", len(checks)).Str("path", c.CheckWarmupFile).Msg("configured check warmup")
+	}
+
 	datastoreFeatures, err := ds.Features(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("error determining datastore features: %w", err)
@@ -332,18 +405,39 @@ func (c *Config) Complete(ctx context.Context) (RunnableServer, error) {
 		v1SchemaServiceOption = services.V1SchemaServiceAdditiveOnly
 	}
 
+	schemaOrphanPolicy := shared.OrphanedRelationshipsStrict
+	if c.SchemaOrphanPolicy != "" {
+		schemaOrphanPolicy, err = shared.ParseOrphanedRelationshipsPolicy(c.SchemaOrphanPolicy)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var schemaNamespaceNamePattern *regexp.Regexp
+	if c.SchemaNamespaceNamePattern != "" {
+		schemaNamespaceNamePattern, err = regexp.Compile(c.SchemaNamespaceNamePattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schema namespace name pattern: %w", err)
+		}
+	}
+
 	watchServiceOption := services.WatchServiceEnabled
 	if !datastoreFeatures.Watch.Enabled {
 		log.Ctx(ctx).Warn().Str("reason", datastoreFeatures.Watch.Reason).Msg("watch api disabled; underlying datastore does not support it")
 		watchServiceOption = services.WatchServiceDisabled
 	}
 
-	defaultUnaryMiddlewareChain, err := DefaultUnaryMiddleware(log.Logger, c.GRPCAuthFunc, !c.DisableVersionResponse, dispatcher, ds)
+	namespaceDefaultConsistency, err := consistencymw.ParseNamespaceDefaults(c.NamespaceDefaultConsistency)
+	if err != nil {
+		return nil, err
+	}
+
+	defaultUnaryMiddlewareChain, err := DefaultUnaryMiddleware(log.Logger, c.GRPCAuthFunc, !c.DisableVersionResponse, dispatcher, ds, consistencymw.WithNamespaceDefaults(namespaceDefaultConsistency))
 	if err != nil {
 		return nil, fmt.Errorf("error building default middlewares: %w", err)
 	}
 
-	defaultStreamingMiddlewareChain, err := DefaultStreamingMiddleware(log.Logger, c.GRPCAuthFunc, !c.DisableVersionResponse, dispatcher, ds)
+	defaultStreamingMiddlewareChain, err := DefaultStreamingMiddleware(log.Logger, c.GRPCAuthFunc, !c.DisableVersionResponse, dispatcher, ds, consistencymw.WithNamespaceDefaults(namespaceDefaultConsistency))
 	if err != nil {
 		return nil, fmt.Errorf("error building default middlewares: %w", err)
 	}
@@ -356,6 +450,42 @@ func (c *Config) Complete(ctx context.Context) (RunnableServer, error) {
 		)
 	}
 
+	errorVerbosity := errorverbosity.Verbose
+	if c.ErrorVerbosity != "" {
+		errorVerbosity, err = errorverbosity.ParseVerbosity(c.ErrorVerbosity)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if errorVerbosity == errorverbosity.Safe {
+		if err := defaultUnaryMiddlewareChain.append(MiddlewareModification[grpc.UnaryServerInterceptor]{
+			DependencyMiddlewareName: DefaultMiddlewareLog,
+			Operation:                OperationAppend,
+			Middlewares: []ReferenceableMiddleware[grpc.UnaryServerInterceptor]{
+				NewUnaryMiddleware().
+					WithName(MiddlewareErrorVerbosity).
+					WithInterceptor(errorverbosity.UnaryServerInterceptor(errorVerbosity)).
+					Done(),
+			},
+		}); err != nil {
+			return nil, fmt.Errorf("error building error verbosity middleware: %w", err)
+		}
+
+		if err := defaultStreamingMiddlewareChain.append(MiddlewareModification[grpc.StreamServerInterceptor]{
+			DependencyMiddlewareName: DefaultMiddlewareLog,
+			Operation:                OperationAppend,
+			Middlewares: []ReferenceableMiddleware[grpc.StreamServerInterceptor]{
+				NewStreamMiddleware().
+					WithName(MiddlewareErrorVerbosity).
+					WithInterceptor(errorverbosity.StreamServerInterceptor(errorVerbosity)).
+					Done(),
+			},
+		}); err != nil {
+			return nil, fmt.Errorf("error building error verbosity middleware: %w", err)
+		}
+	}
+
 	unaryMiddleware, err := c.buildUnaryMiddleware(defaultUnaryMiddlewareChain)
 	if err != nil {
 		return nil, fmt.Errorf("error building unary middlewares: %w", err)
@@ -366,17 +496,53 @@ func (c *Config) Complete(ctx context.Context) (RunnableServer, error) {
 		return nil, fmt.Errorf("error building streaming middlewares: %w", err)
 	}
 
-	permSysConfig := v1svc.PermissionsServerConfig{
-		MaxPreconditionsCount:      c.MaximumPreconditionCount,
-		MaxUpdatesPerWrite:         c.MaximumUpdatesPerWrite,
-		MaximumAPIDepth:            c.DispatchMaxDepth,
-		MaxCaveatContextSize:       c.MaxCaveatContextSize,
-		MaxRelationshipContextSize: c.MaxRelationshipContextSize,
-		MaxDatastoreReadPageSize:   c.MaxDatastoreReadPageSize,
-		StreamingAPITimeout:        c.StreamingAPITimeout,
+	checkMissingNamespacePolicy := v1svc.MissingNamespaceError
+	if c.CheckMissingNamespacePolicy != "" {
+		checkMissingNamespacePolicy, err = v1svc.ParseMissingNamespacePolicy(c.CheckMissingNamespacePolicy)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	relationshipValidation := relationships.ValidationFull
+	if c.RelationshipValidation != "" {
+		relationshipValidation, err = relationships.ParseValidationStrictness(c.RelationshipValidation)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	healthManager := health.NewHealthManager(dispatcher, ds)
+	writeUnknownNamespacePolicy := v1svc.UnknownNamespaceReject
+	if c.WriteUnknownNamespacePolicy != "" {
+		writeUnknownNamespacePolicy, err = v1svc.ParseUnknownNamespacePolicy(c.WriteUnknownNamespacePolicy)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	missingCaveatContextPolicy := v1svc.MissingCaveatContextConditional
+	if c.MissingCaveatContextPolicy != "" {
+		missingCaveatContextPolicy, err = v1svc.ParseMissingCaveatContextPolicy(c.MissingCaveatContextPolicy)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	permSysConfig := v1svc.PermissionsServerConfig{
+		MaxPreconditionsCount:            c.MaximumPreconditionCount,
+		MaxUpdatesPerWrite:               c.MaximumUpdatesPerWrite,
+		MaximumAPIDepth:                  c.DispatchMaxDepth,
+		MaxCaveatContextSize:             c.MaxCaveatContextSize,
+		MaxRelationshipContextSize:       c.MaxRelationshipContextSize,
+		MaxDatastoreReadPageSize:         c.MaxDatastoreReadPageSize,
+		StreamingAPITimeout:              c.StreamingAPITimeout,
+		CheckMissingNamespacePolicy:      checkMissingNamespacePolicy,
+		RelationshipValidationStrictness: relationshipValidation,
+		WriteUnknownNamespacePolicy:      writeUnknownNamespacePolicy,
+		MissingCaveatContextPolicy:       missingCaveatContextPolicy,
+	}
+
+	healthManager := health.NewHealthManager(dispatcher, ds, health.WithDispatchBacklogThreshold(c.DispatchBacklogThreshold))
 	grpcServer, err := c.GRPCServer.Complete(zerolog.InfoLevel,
 		func(server *grpc.Server) {
 			services.RegisterGrpcServices(
@@ -386,6 +552,11 @@ func (c *Config) Complete(ctx context.Context) (RunnableServer, error) {
 				v1SchemaServiceOption,
 				watchServiceOption,
 				permSysConfig,
+				v1svc.SchemaServerConfig{
+					OrphanPolicy:         schemaOrphanPolicy,
+					NamespaceNamePattern: schemaNamespaceNamePattern,
+					MaxSchemaSize:        c.MaxSchemaSize,
+				},
 			)
 		},
 	)
@@ -440,6 +611,7 @@ func (c *Config) Complete(ctx context.Context) (RunnableServer, error) {
 		presharedKeys:       c.PresharedSecureKey,
 		telemetryReporter:   reporter,
 		healthManager:       healthManager,
+		warmCheckCache:      warmCheckCache,
 		closeFunc:           closeables.Close,
 	}, nil
 }
@@ -491,18 +663,36 @@ func (c *Config) initializeGateway(ctx context.Context) (util.RunnableHTTPServer
 	}
 
 	var gatewayHandler http.Handler
-	closeableGatewayHandler, err := gateway.NewHandler(ctx, c.HTTPGatewayUpstreamAddr, c.HTTPGatewayUpstreamTLSCertPath)
+	closeableGatewayHandler, err := gateway.NewHandler(ctx, gateway.HandlerConfig{
+		UpstreamAddr: c.HTTPGatewayUpstreamAddr,
+		UpstreamTLS: gateway.UpstreamTLSConfig{
+			CAPath:         c.HTTPGatewayUpstreamTLSCertPath,
+			ClientCertPath: c.HTTPGatewayUpstreamTLSClientCertPath,
+			ClientKeyPath:  c.HTTPGatewayUpstreamTLSClientKeyPath,
+		},
+		ForwardedHeaders:    c.HTTPGatewayForwardedHeaders,
+		MaxRequestBodyBytes: c.HTTPGatewayMaxRequestBodyBytes,
+		RequestTimeout:      c.HTTPGatewayRequestTimeout,
+	})
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to initialize rest gateway: %w", err)
 	}
 	gatewayHandler = closeableGatewayHandler
 
+	if c.HTTPGatewayCompressionEnabled {
+		gatewayHandler, err = gateway.CompressionHandler(gatewayHandler, c.HTTPGatewayCompressionMinSize)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to initialize rest gateway: %w", err)
+		}
+	}
+
 	if c.HTTPGatewayCorsEnabled {
 		log.Ctx(ctx).Info().Strs("origins", c.HTTPGatewayCorsAllowedOrigins).Msg("Setting REST gateway CORS policy")
 		gatewayHandler = cors.New(cors.Options{
 			AllowedOrigins:   c.HTTPGatewayCorsAllowedOrigins,
 			AllowCredentials: true,
-			AllowedHeaders:   []string{"Authorization", "Content-Type"},
+			AllowedHeaders:   append(append([]string{}, gateway.CorsAllowedHeaders...), c.HTTPGatewayForwardedHeaders...),
+			ExposedHeaders:   gateway.CorsExposedHeaders(),
 			Debug:            log.Debug().Enabled(),
 		}).Handler(gatewayHandler)
 	}
@@ -539,6 +729,7 @@ type completedServerConfig struct {
 	unaryMiddleware     []grpc.UnaryServerInterceptor
 	streamingMiddleware []grpc.StreamServerInterceptor
 	presharedKeys       []string
+	warmCheckCache      func(ctx context.Context)
 	closeFunc           func() error
 }
 
@@ -576,6 +767,13 @@ func (c *completedServerConfig) Run(ctx context.Context) error {
 	g.Go(c.metricsServer.ListenAndServe)
 	g.Go(func() error { return c.telemetryReporter(ctx) })
 
+	if c.warmCheckCache != nil {
+		g.Go(func() error {
+			c.warmCheckCache(ctx)
+			return nil
+		})
+	}
+
 	g.Go(stopOnCancelWithErr(c.closeFunc))
 
 	if err := g.Wait(); err != nil {
@@ -588,9 +786,10 @@ func (c *completedServerConfig) Run(ctx context.Context) error {
 
 var promOnce sync.Once
 
-// enableGRPCHistogram enables the standard time history for gRPC requests,
-// ensuring that it is only enabled once
-func enableGRPCHistogram() {
+// EnableGRPCHistogram enables the standard time history for gRPC requests,
+// ensuring that it is only enabled once per process, even if it is called by
+// several servers.
+func EnableGRPCHistogram() {
 	// EnableHandlingTimeHistogram is not thread safe and only needs to happen
 	// once
 	promOnce.Do(func() {
@@ -0,0 +1,133 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/authzed/spicedb/internal/dispatch"
+	"github.com/authzed/spicedb/internal/graph/computed"
+	log "github.com/authzed/spicedb/internal/logging"
+	datastoremw "github.com/authzed/spicedb/internal/middleware/datastore"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+// loadCheckWarmupFile reads the checks found in the given file, one per line, in the form
+// `resource:id#permission@subject:id`. Empty lines and lines starting with `//` are ignored.
+func loadCheckWarmupFile(path string) ([]*core.RelationTuple, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open check warmup file: %w", err)
+	}
+	defer file.Close()
+
+	var checks []*core.RelationTuple
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "//") {
+			continue
+		}
+
+		check := tuple.Parse(line)
+		if check == nil {
+			return nil, fmt.Errorf("invalid check on line %d of check warmup file: %q", lineNumber, line)
+		}
+		checks = append(checks, check)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read check warmup file: %w", err)
+	}
+
+	return checks, nil
+}
+
+// newCheckWarmupFunc returns a function which computes the given checks at the datastore's
+// optimized revision, which is the revision served to requests without a consistency
+// requirement, and computes them again each time that revision moves, checking every poll
+// interval, so that requests are served from the dispatch cache. The warm-up is best-effort:
+// failures are logged and never stop the server.
+func newCheckWarmupFunc(
+	ds datastore.Datastore,
+	dispatcher dispatch.Check,
+	checks []*core.RelationTuple,
+	timeout time.Duration,
+	pollInterval time.Duration,
+	maximumDepth uint32,
+	concurrencyLimit uint16,
+) func(ctx context.Context) {
+	return func(ctx context.Context) {
+		ctx = datastoremw.ContextWithDatastore(ctx, ds)
+		if pollInterval <= 0 {
+			pollInterval = time.Second
+		}
+
+		var warmed datastore.Revision
+		for {
+			revision, err := ds.OptimizedRevision(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				log.Ctx(ctx).Warn().Err(err).Msg("failed to determine revision for check warmup")
+			} else if warmed == nil || !revision.Equal(warmed) {
+				warmChecksAt(ctx, dispatcher, checks, revision, timeout, maximumDepth, concurrencyLimit, warmed == nil)
+				warmed = revision
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(pollInterval):
+			}
+		}
+	}
+}
+
+// warmChecksAt computes the given checks at the given revision, within the timeout, if any.
+// The outcome of the first warm-up is logged at info level, and that of later ones at debug.
+func warmChecksAt(
+	ctx context.Context,
+	dispatcher dispatch.Check,
+	checks []*core.RelationTuple,
+	revision datastore.Revision,
+	timeout time.Duration,
+	maximumDepth uint32,
+	concurrencyLimit uint16,
+	first bool,
+) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	result, err := computed.WarmCheckCache(ctx, dispatcher, computed.WarmupParameters{
+		AtRevision:       revision,
+		MaximumDepth:     maximumDepth,
+		ConcurrencyLimit: concurrencyLimit,
+	}, checks)
+
+	event := log.Ctx(ctx).Debug()
+	if first {
+		event = log.Ctx(ctx).Info()
+	}
+	if err != nil {
+		event = log.Ctx(ctx).Warn().Err(err)
+	}
+	event.
+		Str("revision", revision.String()).
+		Uint64("completed", result.Completed).
+		Uint64("failed", result.Failed).
+		Uint64("skipped", result.Skipped).
+		Dur("duration", time.Since(start)).
+		Msg("finished check warmup")
+}
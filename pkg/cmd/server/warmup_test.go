@@ -0,0 +1,78 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/internal/datastore/memdb"
+	"github.com/authzed/spicedb/internal/dispatch"
+	"github.com/authzed/spicedb/internal/dispatch/graph"
+	tf "github.com/authzed/spicedb/internal/testfixtures"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	dispatchv1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+type revisionRecordingDispatcher struct {
+	dispatch.Check
+
+	sync.Mutex
+	revisions map[string]struct{}
+}
+
+func (d *revisionRecordingDispatcher) DispatchCheck(ctx context.Context, req *dispatchv1.DispatchCheckRequest) (*dispatchv1.DispatchCheckResponse, error) {
+	d.Lock()
+	d.revisions[req.Metadata.AtRevision] = struct{}{}
+	d.Unlock()
+	return d.Check.DispatchCheck(ctx, req)
+}
+
+func (d *revisionRecordingDispatcher) warmedRevisions() int {
+	d.Lock()
+	defer d.Unlock()
+	return len(d.revisions)
+}
+
+func TestCheckWarmupFollowsServedRevision(t *testing.T) {
+	require := require.New(t)
+
+	rawDS, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(err)
+	ds, _ := tf.StandardDatastoreWithData(rawDS, require)
+
+	dispatcher := &revisionRecordingDispatcher{
+		Check:     graph.NewLocalOnlyDispatcher(10),
+		revisions: map[string]struct{}{},
+	}
+
+	checks := []*core.RelationTuple{tuple.MustParse("document:masterplan#view@user:eng_lead")}
+	warmup := newCheckWarmupFunc(ds, dispatcher, checks, time.Second, 10*time.Millisecond, 50, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		warmup(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	require.Eventually(func() bool { return dispatcher.warmedRevisions() == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	_, err = ds.ReadWriteTx(context.Background(), func(rwt datastore.ReadWriteTransaction) error {
+		return rwt.WriteRelationships(context.Background(), []*core.RelationTupleUpdate{
+			tuple.Touch(tuple.MustParse("document:masterplan#viewer@user:newviewer")),
+		})
+	})
+	require.NoError(err)
+
+	// The checks are warmed again once the served revision moves.
+	require.Eventually(func() bool { return dispatcher.warmedRevisions() == 2 }, 5*time.Second, 10*time.Millisecond)
+}
@@ -35,6 +35,7 @@ func (c *CacheConfig) ToOption() CacheConfigOption {
 		to.NumCounters = c.NumCounters
 		to.Metrics = c.Metrics
 		to.Enabled = c.Enabled
+		to.TTLJitter = c.TTLJitter
 		to.defaultTTL = c.defaultTTL
 	}
 }
@@ -47,6 +48,7 @@ func (c CacheConfig) DebugMap() map[string]any {
 	debugMap["NumCounters"] = helpers.DebugValue(c.NumCounters, false)
 	debugMap["Metrics"] = helpers.DebugValue(c.Metrics, false)
 	debugMap["Enabled"] = helpers.DebugValue(c.Enabled, false)
+	debugMap["TTLJitter"] = helpers.DebugValue(c.TTLJitter, false)
 	return debugMap
 }
 
@@ -100,3 +102,10 @@ func WithEnabled(enabled bool) CacheConfigOption {
 		c.Enabled = enabled
 	}
 }
+
+// WithTTLJitter returns an option that can set TTLJitter on a CacheConfig
+func WithTTLJitter(tTLJitter float64) CacheConfigOption {
+	return func(c *CacheConfig) {
+		c.TTLJitter = tTLJitter
+	}
+}
@@ -43,11 +43,20 @@ func (c *Config) ToOption() ConfigOption {
 		to.PresharedSecureKey = c.PresharedSecureKey
 		to.ShutdownGracePeriod = c.ShutdownGracePeriod
 		to.DisableVersionResponse = c.DisableVersionResponse
+		to.LogIDRedaction = c.LogIDRedaction
+		to.ErrorVerbosity = c.ErrorVerbosity
 		to.HTTPGateway = c.HTTPGateway
 		to.HTTPGatewayUpstreamAddr = c.HTTPGatewayUpstreamAddr
 		to.HTTPGatewayUpstreamTLSCertPath = c.HTTPGatewayUpstreamTLSCertPath
+		to.HTTPGatewayUpstreamTLSClientCertPath = c.HTTPGatewayUpstreamTLSClientCertPath
+		to.HTTPGatewayUpstreamTLSClientKeyPath = c.HTTPGatewayUpstreamTLSClientKeyPath
 		to.HTTPGatewayCorsEnabled = c.HTTPGatewayCorsEnabled
 		to.HTTPGatewayCorsAllowedOrigins = c.HTTPGatewayCorsAllowedOrigins
+		to.HTTPGatewayForwardedHeaders = c.HTTPGatewayForwardedHeaders
+		to.HTTPGatewayMaxRequestBodyBytes = c.HTTPGatewayMaxRequestBodyBytes
+		to.HTTPGatewayRequestTimeout = c.HTTPGatewayRequestTimeout
+		to.HTTPGatewayCompressionEnabled = c.HTTPGatewayCompressionEnabled
+		to.HTTPGatewayCompressionMinSize = c.HTTPGatewayCompressionMinSize
 		to.DatastoreConfig = c.DatastoreConfig
 		to.Datastore = c.Datastore
 		to.MaxCaveatContextSize = c.MaxCaveatContextSize
@@ -56,6 +65,8 @@ func (c *Config) ToOption() ConfigOption {
 		to.SchemaPrefixesRequired = c.SchemaPrefixesRequired
 		to.DispatchServer = c.DispatchServer
 		to.DispatchMaxDepth = c.DispatchMaxDepth
+		to.DispatchMaxDirectRelationshipsPerNode = c.DispatchMaxDirectRelationshipsPerNode
+		to.DispatchEmptyRewritePolicy = c.DispatchEmptyRewritePolicy
 		to.GlobalDispatchConcurrencyLimit = c.GlobalDispatchConcurrencyLimit
 		to.DispatchConcurrencyLimits = c.DispatchConcurrencyLimits
 		to.DispatchUpstreamAddr = c.DispatchUpstreamAddr
@@ -68,14 +79,26 @@ func (c *Config) ToOption() ConfigOption {
 		to.Dispatcher = c.Dispatcher
 		to.DispatchHashringReplicationFactor = c.DispatchHashringReplicationFactor
 		to.DispatchHashringSpread = c.DispatchHashringSpread
+		to.DispatchBacklogThreshold = c.DispatchBacklogThreshold
 		to.DispatchCacheConfig = c.DispatchCacheConfig
 		to.ClusterDispatchCacheConfig = c.ClusterDispatchCacheConfig
+		to.DispatchExpandCacheConfig = c.DispatchExpandCacheConfig
 		to.DisableV1SchemaAPI = c.DisableV1SchemaAPI
 		to.V1SchemaAdditiveOnly = c.V1SchemaAdditiveOnly
+		to.SchemaOrphanPolicy = c.SchemaOrphanPolicy
+		to.SchemaNamespaceNamePattern = c.SchemaNamespaceNamePattern
+		to.MaxSchemaSize = c.MaxSchemaSize
 		to.MaximumUpdatesPerWrite = c.MaximumUpdatesPerWrite
 		to.MaximumPreconditionCount = c.MaximumPreconditionCount
 		to.MaxDatastoreReadPageSize = c.MaxDatastoreReadPageSize
 		to.StreamingAPITimeout = c.StreamingAPITimeout
+		to.CheckMissingNamespacePolicy = c.CheckMissingNamespacePolicy
+		to.RelationshipValidation = c.RelationshipValidation
+		to.WriteUnknownNamespacePolicy = c.WriteUnknownNamespacePolicy
+		to.MissingCaveatContextPolicy = c.MissingCaveatContextPolicy
+		to.NamespaceDefaultConsistency = c.NamespaceDefaultConsistency
+		to.CheckWarmupFile = c.CheckWarmupFile
+		to.CheckWarmupTimeout = c.CheckWarmupTimeout
 		to.MetricsAPI = c.MetricsAPI
 		to.UnaryMiddlewareModification = c.UnaryMiddlewareModification
 		to.StreamingMiddlewareModification = c.StreamingMiddlewareModification
@@ -96,11 +119,20 @@ func (c Config) DebugMap() map[string]any {
 	debugMap["PresharedSecureKey"] = helpers.SensitiveDebugValue(c.PresharedSecureKey)
 	debugMap["ShutdownGracePeriod"] = helpers.DebugValue(c.ShutdownGracePeriod, false)
 	debugMap["DisableVersionResponse"] = helpers.DebugValue(c.DisableVersionResponse, false)
+	debugMap["LogIDRedaction"] = helpers.DebugValue(c.LogIDRedaction, false)
+	debugMap["ErrorVerbosity"] = helpers.DebugValue(c.ErrorVerbosity, false)
 	debugMap["HTTPGateway"] = helpers.DebugValue(c.HTTPGateway, false)
 	debugMap["HTTPGatewayUpstreamAddr"] = helpers.DebugValue(c.HTTPGatewayUpstreamAddr, false)
 	debugMap["HTTPGatewayUpstreamTLSCertPath"] = helpers.DebugValue(c.HTTPGatewayUpstreamTLSCertPath, false)
+	debugMap["HTTPGatewayUpstreamTLSClientCertPath"] = helpers.DebugValue(c.HTTPGatewayUpstreamTLSClientCertPath, false)
+	debugMap["HTTPGatewayUpstreamTLSClientKeyPath"] = helpers.DebugValue(c.HTTPGatewayUpstreamTLSClientKeyPath, false)
 	debugMap["HTTPGatewayCorsEnabled"] = helpers.DebugValue(c.HTTPGatewayCorsEnabled, false)
 	debugMap["HTTPGatewayCorsAllowedOrigins"] = helpers.DebugValue(c.HTTPGatewayCorsAllowedOrigins, true)
+	debugMap["HTTPGatewayForwardedHeaders"] = helpers.DebugValue(c.HTTPGatewayForwardedHeaders, true)
+	debugMap["HTTPGatewayMaxRequestBodyBytes"] = helpers.DebugValue(c.HTTPGatewayMaxRequestBodyBytes, false)
+	debugMap["HTTPGatewayRequestTimeout"] = helpers.DebugValue(c.HTTPGatewayRequestTimeout, false)
+	debugMap["HTTPGatewayCompressionEnabled"] = helpers.DebugValue(c.HTTPGatewayCompressionEnabled, false)
+	debugMap["HTTPGatewayCompressionMinSize"] = helpers.DebugValue(c.HTTPGatewayCompressionMinSize, false)
 	debugMap["DatastoreConfig"] = helpers.DebugValue(c.DatastoreConfig, false)
 	debugMap["Datastore"] = helpers.DebugValue(c.Datastore, false)
 	debugMap["MaxCaveatContextSize"] = helpers.DebugValue(c.MaxCaveatContextSize, false)
@@ -109,6 +141,8 @@ func (c Config) DebugMap() map[string]any {
 	debugMap["SchemaPrefixesRequired"] = helpers.DebugValue(c.SchemaPrefixesRequired, false)
 	debugMap["DispatchServer"] = helpers.DebugValue(c.DispatchServer, false)
 	debugMap["DispatchMaxDepth"] = helpers.DebugValue(c.DispatchMaxDepth, false)
+	debugMap["DispatchMaxDirectRelationshipsPerNode"] = helpers.DebugValue(c.DispatchMaxDirectRelationshipsPerNode, false)
+	debugMap["DispatchEmptyRewritePolicy"] = helpers.DebugValue(c.DispatchEmptyRewritePolicy, false)
 	debugMap["GlobalDispatchConcurrencyLimit"] = helpers.DebugValue(c.GlobalDispatchConcurrencyLimit, false)
 	debugMap["DispatchConcurrencyLimits"] = helpers.DebugValue(c.DispatchConcurrencyLimits, false)
 	debugMap["DispatchUpstreamAddr"] = helpers.DebugValue(c.DispatchUpstreamAddr, false)
@@ -121,14 +155,26 @@ func (c Config) DebugMap() map[string]any {
 	debugMap["Dispatcher"] = helpers.DebugValue(c.Dispatcher, false)
 	debugMap["DispatchHashringReplicationFactor"] = helpers.DebugValue(c.DispatchHashringReplicationFactor, false)
 	debugMap["DispatchHashringSpread"] = helpers.DebugValue(c.DispatchHashringSpread, false)
+	debugMap["DispatchBacklogThreshold"] = helpers.DebugValue(c.DispatchBacklogThreshold, false)
 	debugMap["DispatchCacheConfig"] = helpers.DebugValue(c.DispatchCacheConfig, false)
 	debugMap["ClusterDispatchCacheConfig"] = helpers.DebugValue(c.ClusterDispatchCacheConfig, false)
+	debugMap["DispatchExpandCacheConfig"] = helpers.DebugValue(c.DispatchExpandCacheConfig, false)
 	debugMap["DisableV1SchemaAPI"] = helpers.DebugValue(c.DisableV1SchemaAPI, false)
 	debugMap["V1SchemaAdditiveOnly"] = helpers.DebugValue(c.V1SchemaAdditiveOnly, false)
+	debugMap["SchemaOrphanPolicy"] = helpers.DebugValue(c.SchemaOrphanPolicy, false)
+	debugMap["SchemaNamespaceNamePattern"] = helpers.DebugValue(c.SchemaNamespaceNamePattern, false)
+	debugMap["MaxSchemaSize"] = helpers.DebugValue(c.MaxSchemaSize, false)
 	debugMap["MaximumUpdatesPerWrite"] = helpers.DebugValue(c.MaximumUpdatesPerWrite, false)
 	debugMap["MaximumPreconditionCount"] = helpers.DebugValue(c.MaximumPreconditionCount, false)
 	debugMap["MaxDatastoreReadPageSize"] = helpers.DebugValue(c.MaxDatastoreReadPageSize, false)
 	debugMap["StreamingAPITimeout"] = helpers.DebugValue(c.StreamingAPITimeout, false)
+	debugMap["CheckMissingNamespacePolicy"] = helpers.DebugValue(c.CheckMissingNamespacePolicy, false)
+	debugMap["RelationshipValidation"] = helpers.DebugValue(c.RelationshipValidation, false)
+	debugMap["WriteUnknownNamespacePolicy"] = helpers.DebugValue(c.WriteUnknownNamespacePolicy, false)
+	debugMap["MissingCaveatContextPolicy"] = helpers.DebugValue(c.MissingCaveatContextPolicy, false)
+	debugMap["NamespaceDefaultConsistency"] = helpers.DebugValue(c.NamespaceDefaultConsistency, false)
+	debugMap["CheckWarmupFile"] = helpers.DebugValue(c.CheckWarmupFile, false)
+	debugMap["CheckWarmupTimeout"] = helpers.DebugValue(c.CheckWarmupTimeout, false)
 	debugMap["MetricsAPI"] = helpers.DebugValue(c.MetricsAPI, false)
 	debugMap["SilentlyDisableTelemetry"] = helpers.DebugValue(c.SilentlyDisableTelemetry, false)
 	debugMap["TelemetryCAOverridePath"] = helpers.DebugValue(c.TelemetryCAOverridePath, false)
@@ -195,6 +241,20 @@ func WithDisableVersionResponse(disableVersionResponse bool) ConfigOption {
 	}
 }
 
+// WithLogIDRedaction returns an option that can set LogIDRedaction on a Config
+func WithLogIDRedaction(logIDRedaction string) ConfigOption {
+	return func(c *Config) {
+		c.LogIDRedaction = logIDRedaction
+	}
+}
+
+// WithErrorVerbosity returns an option that can set ErrorVerbosity on a Config
+func WithErrorVerbosity(errorVerbosity string) ConfigOption {
+	return func(c *Config) {
+		c.ErrorVerbosity = errorVerbosity
+	}
+}
+
 // WithHTTPGateway returns an option that can set HTTPGateway on a Config
 func WithHTTPGateway(hTTPGateway util.HTTPServerConfig) ConfigOption {
 	return func(c *Config) {
@@ -216,6 +276,20 @@ func WithHTTPGatewayUpstreamTLSCertPath(hTTPGatewayUpstreamTLSCertPath string) C
 	}
 }
 
+// WithHTTPGatewayUpstreamTLSClientCertPath returns an option that can set HTTPGatewayUpstreamTLSClientCertPath on a Config
+func WithHTTPGatewayUpstreamTLSClientCertPath(hTTPGatewayUpstreamTLSClientCertPath string) ConfigOption {
+	return func(c *Config) {
+		c.HTTPGatewayUpstreamTLSClientCertPath = hTTPGatewayUpstreamTLSClientCertPath
+	}
+}
+
+// WithHTTPGatewayUpstreamTLSClientKeyPath returns an option that can set HTTPGatewayUpstreamTLSClientKeyPath on a Config
+func WithHTTPGatewayUpstreamTLSClientKeyPath(hTTPGatewayUpstreamTLSClientKeyPath string) ConfigOption {
+	return func(c *Config) {
+		c.HTTPGatewayUpstreamTLSClientKeyPath = hTTPGatewayUpstreamTLSClientKeyPath
+	}
+}
+
 // WithHTTPGatewayCorsEnabled returns an option that can set HTTPGatewayCorsEnabled on a Config
 func WithHTTPGatewayCorsEnabled(hTTPGatewayCorsEnabled bool) ConfigOption {
 	return func(c *Config) {
@@ -237,6 +311,48 @@ func SetHTTPGatewayCorsAllowedOrigins(hTTPGatewayCorsAllowedOrigins []string) Co
 	}
 }
 
+// WithHTTPGatewayForwardedHeaders returns an option that can append HTTPGatewayForwardedHeaderss to Config.HTTPGatewayForwardedHeaders
+func WithHTTPGatewayForwardedHeaders(hTTPGatewayForwardedHeaders string) ConfigOption {
+	return func(c *Config) {
+		c.HTTPGatewayForwardedHeaders = append(c.HTTPGatewayForwardedHeaders, hTTPGatewayForwardedHeaders)
+	}
+}
+
+// SetHTTPGatewayForwardedHeaders returns an option that can set HTTPGatewayForwardedHeaders on a Config
+func SetHTTPGatewayForwardedHeaders(hTTPGatewayForwardedHeaders []string) ConfigOption {
+	return func(c *Config) {
+		c.HTTPGatewayForwardedHeaders = hTTPGatewayForwardedHeaders
+	}
+}
+
+// WithHTTPGatewayMaxRequestBodyBytes returns an option that can set HTTPGatewayMaxRequestBodyBytes on a Config
+func WithHTTPGatewayMaxRequestBodyBytes(hTTPGatewayMaxRequestBodyBytes int64) ConfigOption {
+	return func(c *Config) {
+		c.HTTPGatewayMaxRequestBodyBytes = hTTPGatewayMaxRequestBodyBytes
+	}
+}
+
+// WithHTTPGatewayRequestTimeout returns an option that can set HTTPGatewayRequestTimeout on a Config
+func WithHTTPGatewayRequestTimeout(hTTPGatewayRequestTimeout time.Duration) ConfigOption {
+	return func(c *Config) {
+		c.HTTPGatewayRequestTimeout = hTTPGatewayRequestTimeout
+	}
+}
+
+// WithHTTPGatewayCompressionEnabled returns an option that can set HTTPGatewayCompressionEnabled on a Config
+func WithHTTPGatewayCompressionEnabled(hTTPGatewayCompressionEnabled bool) ConfigOption {
+	return func(c *Config) {
+		c.HTTPGatewayCompressionEnabled = hTTPGatewayCompressionEnabled
+	}
+}
+
+// WithHTTPGatewayCompressionMinSize returns an option that can set HTTPGatewayCompressionMinSize on a Config
+func WithHTTPGatewayCompressionMinSize(hTTPGatewayCompressionMinSize int) ConfigOption {
+	return func(c *Config) {
+		c.HTTPGatewayCompressionMinSize = hTTPGatewayCompressionMinSize
+	}
+}
+
 // WithDatastoreConfig returns an option that can set DatastoreConfig on a Config
 func WithDatastoreConfig(datastoreConfig datastore.Config) ConfigOption {
 	return func(c *Config) {
@@ -293,6 +409,20 @@ func WithDispatchMaxDepth(dispatchMaxDepth uint32) ConfigOption {
 	}
 }
 
+// WithDispatchMaxDirectRelationshipsPerNode returns an option that can set DispatchMaxDirectRelationshipsPerNode on a Config
+func WithDispatchMaxDirectRelationshipsPerNode(dispatchMaxDirectRelationshipsPerNode uint32) ConfigOption {
+	return func(c *Config) {
+		c.DispatchMaxDirectRelationshipsPerNode = dispatchMaxDirectRelationshipsPerNode
+	}
+}
+
+// WithDispatchEmptyRewritePolicy returns an option that can set DispatchEmptyRewritePolicy on a Config
+func WithDispatchEmptyRewritePolicy(dispatchEmptyRewritePolicy string) ConfigOption {
+	return func(c *Config) {
+		c.DispatchEmptyRewritePolicy = dispatchEmptyRewritePolicy
+	}
+}
+
 // WithGlobalDispatchConcurrencyLimit returns an option that can set GlobalDispatchConcurrencyLimit on a Config
 func WithGlobalDispatchConcurrencyLimit(globalDispatchConcurrencyLimit uint16) ConfigOption {
 	return func(c *Config) {
@@ -377,6 +507,13 @@ func WithDispatchHashringSpread(dispatchHashringSpread uint8) ConfigOption {
 	}
 }
 
+// WithDispatchBacklogThreshold returns an option that can set DispatchBacklogThreshold on a Config
+func WithDispatchBacklogThreshold(dispatchBacklogThreshold int64) ConfigOption {
+	return func(c *Config) {
+		c.DispatchBacklogThreshold = dispatchBacklogThreshold
+	}
+}
+
 // WithDispatchCacheConfig returns an option that can set DispatchCacheConfig on a Config
 func WithDispatchCacheConfig(dispatchCacheConfig CacheConfig) ConfigOption {
 	return func(c *Config) {
@@ -391,6 +528,13 @@ func WithClusterDispatchCacheConfig(clusterDispatchCacheConfig CacheConfig) Conf
 	}
 }
 
+// WithDispatchExpandCacheConfig returns an option that can set DispatchExpandCacheConfig on a Config
+func WithDispatchExpandCacheConfig(dispatchExpandCacheConfig CacheConfig) ConfigOption {
+	return func(c *Config) {
+		c.DispatchExpandCacheConfig = dispatchExpandCacheConfig
+	}
+}
+
 // WithDisableV1SchemaAPI returns an option that can set DisableV1SchemaAPI on a Config
 func WithDisableV1SchemaAPI(disableV1SchemaAPI bool) ConfigOption {
 	return func(c *Config) {
@@ -405,6 +549,27 @@ func WithV1SchemaAdditiveOnly(v1SchemaAdditiveOnly bool) ConfigOption {
 	}
 }
 
+// WithSchemaOrphanPolicy returns an option that can set SchemaOrphanPolicy on a Config
+func WithSchemaOrphanPolicy(schemaOrphanPolicy string) ConfigOption {
+	return func(c *Config) {
+		c.SchemaOrphanPolicy = schemaOrphanPolicy
+	}
+}
+
+// WithSchemaNamespaceNamePattern returns an option that can set SchemaNamespaceNamePattern on a Config
+func WithSchemaNamespaceNamePattern(schemaNamespaceNamePattern string) ConfigOption {
+	return func(c *Config) {
+		c.SchemaNamespaceNamePattern = schemaNamespaceNamePattern
+	}
+}
+
+// WithMaxSchemaSize returns an option that can set MaxSchemaSize on a Config
+func WithMaxSchemaSize(maxSchemaSize uint32) ConfigOption {
+	return func(c *Config) {
+		c.MaxSchemaSize = maxSchemaSize
+	}
+}
+
 // WithMaximumUpdatesPerWrite returns an option that can set MaximumUpdatesPerWrite on a Config
 func WithMaximumUpdatesPerWrite(maximumUpdatesPerWrite uint16) ConfigOption {
 	return func(c *Config) {
@@ -433,6 +598,62 @@ func WithStreamingAPITimeout(streamingAPITimeout time.Duration) ConfigOption {
 	}
 }
 
+// WithCheckMissingNamespacePolicy returns an option that can set CheckMissingNamespacePolicy on a Config
+func WithCheckMissingNamespacePolicy(checkMissingNamespacePolicy string) ConfigOption {
+	return func(c *Config) {
+		c.CheckMissingNamespacePolicy = checkMissingNamespacePolicy
+	}
+}
+
+// WithRelationshipValidation returns an option that can set RelationshipValidation on a Config
+func WithRelationshipValidation(relationshipValidation string) ConfigOption {
+	return func(c *Config) {
+		c.RelationshipValidation = relationshipValidation
+	}
+}
+
+// WithWriteUnknownNamespacePolicy returns an option that can set WriteUnknownNamespacePolicy on a Config
+func WithWriteUnknownNamespacePolicy(writeUnknownNamespacePolicy string) ConfigOption {
+	return func(c *Config) {
+		c.WriteUnknownNamespacePolicy = writeUnknownNamespacePolicy
+	}
+}
+
+// WithMissingCaveatContextPolicy returns an option that can set MissingCaveatContextPolicy on a Config
+func WithMissingCaveatContextPolicy(missingCaveatContextPolicy string) ConfigOption {
+	return func(c *Config) {
+		c.MissingCaveatContextPolicy = missingCaveatContextPolicy
+	}
+}
+
+// WithNamespaceDefaultConsistency returns an option that can append NamespaceDefaultConsistencys to Config.NamespaceDefaultConsistency
+func WithNamespaceDefaultConsistency(key string, value string) ConfigOption {
+	return func(c *Config) {
+		c.NamespaceDefaultConsistency[key] = value
+	}
+}
+
+// SetNamespaceDefaultConsistency returns an option that can set NamespaceDefaultConsistency on a Config
+func SetNamespaceDefaultConsistency(namespaceDefaultConsistency map[string]string) ConfigOption {
+	return func(c *Config) {
+		c.NamespaceDefaultConsistency = namespaceDefaultConsistency
+	}
+}
+
+// WithCheckWarmupFile returns an option that can set CheckWarmupFile on a Config
+func WithCheckWarmupFile(checkWarmupFile string) ConfigOption {
+	return func(c *Config) {
+		c.CheckWarmupFile = checkWarmupFile
+	}
+}
+
+// WithCheckWarmupTimeout returns an option that can set CheckWarmupTimeout on a Config
+func WithCheckWarmupTimeout(checkWarmupTimeout time.Duration) ConfigOption {
+	return func(c *Config) {
+		c.CheckWarmupTimeout = checkWarmupTimeout
+	}
+}
+
 // WithMetricsAPI returns an option that can set MetricsAPI on a Config
 func WithMetricsAPI(metricsAPI util.HTTPServerConfig) ConfigOption {
 	return func(c *Config) {
@@ -2,9 +2,19 @@ package cmd
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
 
+	"github.com/authzed/grpcutil"
+	"github.com/jzelinskie/cobrautil/v2"
 	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
 
+	"github.com/authzed/spicedb/internal/auth"
 	"github.com/authzed/spicedb/pkg/cmd/server"
 	"github.com/authzed/spicedb/pkg/cmd/termination"
 	"github.com/authzed/spicedb/pkg/cmd/testserver"
@@ -17,21 +27,45 @@ func RegisterTestingFlags(cmd *cobra.Command, config *testserver.Config) {
 
 	util.RegisterHTTPServerFlags(cmd.Flags(), &config.HTTPGateway, "http", "http", ":8081", false)
 	util.RegisterHTTPServerFlags(cmd.Flags(), &config.ReadOnlyHTTPGateway, "readonly-http", "read-only HTTP", ":8082", false)
+	util.RegisterHTTPServerFlags(cmd.Flags(), &config.MetricsAPI, "metrics", "metrics", ":9090", false)
 
-	cmd.Flags().StringSliceVar(&config.LoadConfigs, "load-configs", []string{}, "configuration yaml files to load")
+	cmd.Flags().StringSliceVar(&config.LoadConfigs, "load-configs", []string{}, "configuration yaml files to load; directories load the .yaml and .yml files within them and glob patterns the files they match, in sorted order; http(s) URLs are fetched with a timeout, and may be suffixed with #sha256=<hex> to verify their contents, in which case they are fetched once per process")
+	cmd.Flags().BoolVar(&config.WatchConfigs, "watch-configs", false, "watch the --load-configs files for changes, replacing the datastore of every token with one loaded from the changed files")
+	cmd.Flags().DurationVar(&config.ShutdownTimeout, "shutdown-timeout", 0, "maximum amount of time after receiving sigint to wait for the RPCs in flight to finish before closing their connections. A value of zero means no limit")
 
 	// Flags for API behavior
 	cmd.Flags().Uint16Var(&config.MaximumUpdatesPerWrite, "write-relationships-max-updates-per-call", 1000, "maximum number of updates allowed for WriteRelationships calls")
 	cmd.Flags().Uint16Var(&config.MaximumPreconditionCount, "update-relationships-max-preconditions-per-call", 1000, "maximum number of preconditions allowed for WriteRelationships and DeleteRelationships calls")
 	cmd.Flags().IntVar(&config.MaxCaveatContextSize, "max-caveat-context-size", 4096, "maximum allowed size of request caveat context in bytes. A value of zero or less means no limit")
 	cmd.Flags().IntVar(&config.MaxRelationshipContextSize, "max-relationship-context-size", 25000, "maximum allowed size of the context to be stored in a relationship")
+	cmd.Flags().Uint16Var(&config.MaxConcurrentWritesPerToken, "max-concurrent-writes-per-token", 0, "maximum number of writes allowed to execute concurrently for a single token; 1 serializes writes in submission order. A value of zero means no limit")
+	cmd.Flags().DurationVar(&config.TokenDatastoreTTL, "token-datastore-ttl", 0, "duration after its last request at which the datastore of a token is discarded, to be rebuilt from the config files on its next request. A value of zero means datastores are never discarded")
+	cmd.Flags().BoolVar(&config.AllowResetAllDatastores, "allow-reset-all-datastores", false, "allow any request to discard the datastores of all tokens with the io.spicedb.requestresetdatastore header set to \"all\"")
+	cmd.Flags().Uint32Var(&config.MaxDepth, "max-depth", 50, "maximum recursion depth for nested calls")
+	cmd.Flags().StringVar(&config.SchemaOrphanPolicy, "schema-orphaned-relationships-policy", "strict", `how WriteSchema handles relationships for removed relations: "strict" rejects the change, "cascade" deletes the relationships and "permissive" keeps them and logs a warning`)
+	cmd.Flags().StringVar(&config.WriteUnknownNamespacePolicy, "write-unknown-namespace-policy", "reject", `how WriteRelationships handles relationships on definitions that do not exist: "reject" fails the request and "auto-create" defines them with the relations and subject types written`)
+
+	// Flags for dispatch
+	cmd.Flags().BoolVar(&config.DispatchCache, "dispatch-cache", false, "cache the results of dispatched subproblems, keyed by the revision and datastore of each request")
+	cmd.Flags().DurationVar(&config.DispatchCacheTTL, "dispatch-cache-ttl", 1*time.Minute, "duration after which cached dispatch results expire")
+	cmd.Flags().Uint16Var(&config.DispatchConcurrencyLimit, "dispatch-concurrency-limit", 0, "maximum number of subproblems run in parallel by each request or subrequest. A value of zero means GOMAXPROCS")
+
+	// Flags for authentication
+	cmd.Flags().StringVar(&config.JWTJWKSURL, "jwt-jwks-url", "", "URL of the JWKS of an OIDC issuer; if set, bearer tokens must be JWTs signed by one of its keys, and requests share a datastore per tenant rather than per token")
+	cmd.Flags().StringVar(&config.JWTIssuer, "jwt-issuer", "", "issuer required in the iss claim of JWTs, if any")
+	cmd.Flags().StringVar(&config.JWTAudience, "jwt-audience", "", "audience required in the aud claim of JWTs, if any")
+	cmd.Flags().StringVar(&config.JWTTenantClaim, "jwt-tenant-claim", auth.DefaultTenantClaim, "claim of JWTs containing the tenant of the request")
+
+	// Flags for the datastore of each token
+	cmd.Flags().DurationVar(&config.GCWindow, "gc-window", 1*time.Hour, "amount of time before revisions are garbage collected in the datastore of each token")
+	cmd.Flags().DurationVar(&config.RevisionQuantization, "revision-quantization-interval", 10*time.Millisecond, "boundary interval to which to round the revision used by requests that do not require full consistency; must not exceed the gc window")
 }
 
 func NewTestingCommand(programName string, config *testserver.Config) *cobra.Command {
 	return &cobra.Command{
 		Use:     "serve-testing",
 		Short:   "test server with an in-memory datastore",
-		Long:    "An in-memory spicedb server which serves completely isolated datastores per client-supplied auth token used.",
+		Long:    "An in-memory spicedb server which serves completely isolated datastores per client-supplied auth token used.\nTraces can be exported to a collector using the --otel-* flags; exporting is disabled by default.",
 		PreRunE: server.DefaultPreRunE(programName),
 		RunE: termination.PublishError(func(cmd *cobra.Command, args []string) error {
 			signalctx := SignalContextWithGracePeriod(
@@ -46,3 +80,157 @@ func NewTestingCommand(programName string, config *testserver.Config) *cobra.Com
 		}),
 	}
 }
+
+func RegisterTestingArchiveFlags(cmd *cobra.Command) error {
+	cmd.Flags().String("endpoint", "localhost:50051", "address of the gRPC API of the running test server")
+	cmd.Flags().String("token", "", "token whose isolated datastore should be used")
+	cmd.Flags().String("file", "", "path of the archive file")
+	if err := cmd.MarkFlagRequired("file"); err != nil {
+		return fmt.Errorf("failed to mark flag as required: %w", err)
+	}
+	return nil
+}
+
+func NewTestingExportCommand(programName string) *cobra.Command {
+	return &cobra.Command{
+		Use:     "export",
+		Short:   "export the state of a running test server to an archive",
+		Long:    "Exports the schema and all relationships stored for a token of a running test server into a single, versioned and checksummed archive file.",
+		PreRunE: server.DefaultPreRunE(programName),
+		RunE: termination.PublishError(func(cmd *cobra.Command, args []string) error {
+			conn, err := dialTestServer(cmd)
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+
+			archive, err := testserver.ExportArchive(cmd.Context(), conn)
+			if err != nil {
+				return err
+			}
+
+			f, err := os.Create(cobrautil.MustGetStringExpanded(cmd, "file"))
+			if err != nil {
+				return fmt.Errorf("could not create archive file: %w", err)
+			}
+			defer f.Close()
+
+			return testserver.WriteArchive(f, archive)
+		}),
+		Args: cobra.ExactArgs(0),
+	}
+}
+
+func NewTestingImportCommand(programName string) *cobra.Command {
+	return &cobra.Command{
+		Use:     "import",
+		Short:   "import an archive into a running test server",
+		Long:    "Validates an archive file created by the export command and writes its schema and relationships for a token of a running test server.",
+		PreRunE: server.DefaultPreRunE(programName),
+		RunE: termination.PublishError(func(cmd *cobra.Command, args []string) error {
+			f, err := os.Open(cobrautil.MustGetStringExpanded(cmd, "file"))
+			if err != nil {
+				return fmt.Errorf("could not open archive file: %w", err)
+			}
+			defer f.Close()
+
+			archive, err := testserver.ReadArchive(f)
+			if err != nil {
+				return err
+			}
+
+			conn, err := dialTestServer(cmd)
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+
+			return testserver.ImportArchive(cmd.Context(), conn, archive)
+		}),
+		Args: cobra.ExactArgs(0),
+	}
+}
+
+func NewTestingValidateCommand(programName string) *cobra.Command {
+	return &cobra.Command{
+		Use:     "validate <file>...",
+		Short:   "run the assertions and expected relations of validation files",
+		Long:    "Loads the given validation files into an in-memory datastore, as --load-configs does, runs each of their assertions, and verifies that the subjects computed for each entry of their validation block match those expected exactly. Exits with an error if any of them fails.",
+		PreRunE: server.DefaultPreRunE(programName),
+		RunE: termination.PublishError(func(cmd *cobra.Command, args []string) error {
+			report, err := testserver.ValidateFiles(cmd.Context(), args)
+			if err != nil {
+				return err
+			}
+
+			for _, failure := range report.Failures {
+				fmt.Fprintf(cmd.OutOrStdout(), "FAIL %s\n", failure)
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "%d assertions, %d expected relations; %d failures\n", report.Assertions, report.ExpectedRelations, len(report.Failures))
+
+			if len(report.Failures) > 0 {
+				return fmt.Errorf("%d validation failures", len(report.Failures))
+			}
+			return nil
+		}),
+		Args: cobra.MinimumNArgs(1),
+	}
+}
+
+func RegisterTestingStatsFlags(cmd *cobra.Command) {
+	cmd.Flags().String("endpoint", "localhost:50051", "address of the gRPC API of the running test server")
+	cmd.Flags().String("token", "", "token whose isolated datastore should be described")
+}
+
+func NewTestingStatsCommand(programName string) *cobra.Command {
+	return &cobra.Command{
+		Use:     "stats",
+		Short:   "print the statistics of the datastore of a token of a running test server",
+		Long:    "Prints the number of relationships of each namespace defined in the datastore of a token of a running test server, along with the number of namespaces and relationships in total, such as to check what --load-configs loaded.",
+		PreRunE: server.DefaultPreRunE(programName),
+		RunE: termination.PublishError(func(cmd *cobra.Command, args []string) error {
+			conn, err := dialTestServer(cmd)
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+
+			stats, err := testserver.FetchStatistics(cmd.Context(), conn)
+			if err != nil {
+				return err
+			}
+
+			names := make([]string, 0, len(stats.RelationshipsByNamespace))
+			for name := range stats.RelationshipsByNamespace {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "NAMESPACE\tRELATIONSHIPS")
+			for _, name := range names {
+				fmt.Fprintf(w, "%s\t%d\n", name, stats.RelationshipsByNamespace[name])
+			}
+			if err := w.Flush(); err != nil {
+				return err
+			}
+
+			if stats.OmittedNamespaces > 0 {
+				fmt.Fprintf(cmd.OutOrStdout(), "%d namespaces with the fewest relationships omitted\n", stats.OmittedNamespaces)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "%d namespaces, %d relationships\n", stats.Namespaces, stats.Relationships)
+			return nil
+		}),
+		Args: cobra.ExactArgs(0),
+	}
+}
+
+func dialTestServer(cmd *cobra.Command) (*grpc.ClientConn, error) {
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	if token := cobrautil.MustGetString(cmd, "token"); token != "" {
+		opts = append(opts, grpcutil.WithInsecureBearerToken(token))
+	}
+
+	return grpc.DialContext(cmd.Context(), cobrautil.MustGetString(cmd, "endpoint"), opts...)
+}
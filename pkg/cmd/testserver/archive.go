@@ -0,0 +1,190 @@
+package testserver
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
+	"google.golang.org/grpc"
+
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+// ArchiveVersion is the version of the archive format produced by ExportArchive.
+const ArchiveVersion = 1
+
+const archiveImportBatchSize = 1000
+
+// Archive is a portable, self-describing snapshot of the schema and relationships
+// stored for a single testserver token.
+type Archive struct {
+	// Version is the version of the archive format.
+	Version int `json:"version"`
+
+	// Revision is the ZedToken of the revision at which the archive was exported.
+	Revision string `json:"revision"`
+
+	// Schema is the schema text at the exported revision.
+	Schema string `json:"schema"`
+
+	// Relationships are all relationships at the exported revision, in their
+	// string form.
+	Relationships []string `json:"relationships"`
+
+	// Checksum is the hex encoded SHA-256 checksum over all other fields of the
+	// archive.
+	Checksum string `json:"checksum"`
+}
+
+func (a *Archive) computeChecksum() string {
+	hasher := sha256.New()
+	writeField := func(value string) {
+		fmt.Fprintf(hasher, "%d:%s", len(value), value)
+	}
+
+	writeField(fmt.Sprintf("%d", a.Version))
+	writeField(a.Revision)
+	writeField(a.Schema)
+	for _, rel := range a.Relationships {
+		writeField(rel)
+	}
+	return hex.EncodeToString(hasher.Sum(nil))
+}
+
+// Validate ensures that the archive is of a supported version and that its
+// contents match its checksum.
+func (a *Archive) Validate() error {
+	if a.Version != ArchiveVersion {
+		return fmt.Errorf("unsupported archive version %d; expected %d", a.Version, ArchiveVersion)
+	}
+
+	if a.Checksum != a.computeChecksum() {
+		return errors.New("archive checksum does not match its contents")
+	}
+
+	return nil
+}
+
+// WriteArchive seals the archive with its checksum and writes it to the given writer.
+func WriteArchive(w io.Writer, archive *Archive) error {
+	archive.Checksum = archive.computeChecksum()
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(archive)
+}
+
+// ReadArchive reads an archive from the given reader and validates it.
+func ReadArchive(r io.Reader) (*Archive, error) {
+	var archive Archive
+	if err := json.NewDecoder(r).Decode(&archive); err != nil {
+		return nil, fmt.Errorf("could not decode archive: %w", err)
+	}
+
+	if err := archive.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &archive, nil
+}
+
+// ExportArchive exports the schema and all relationships visible over the given
+// connection into an archive. All data is read at the same revision.
+func ExportArchive(ctx context.Context, conn grpc.ClientConnInterface) (*Archive, error) {
+	schemaResp, err := v1.NewSchemaServiceClient(conn).ReadSchema(ctx, &v1.ReadSchemaRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("could not read schema: %w", err)
+	}
+
+	archive := &Archive{
+		Version:       ArchiveVersion,
+		Revision:      schemaResp.ReadAt.GetToken(),
+		Schema:        schemaResp.SchemaText,
+		Relationships: []string{},
+	}
+
+	stream, err := v1.NewExperimentalServiceClient(conn).BulkExportRelationships(ctx, &v1.BulkExportRelationshipsRequest{
+		Consistency: &v1.Consistency{
+			Requirement: &v1.Consistency_AtExactSnapshot{AtExactSnapshot: schemaResp.ReadAt},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not export relationships: %w", err)
+	}
+
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not export relationships: %w", err)
+		}
+
+		for _, rel := range resp.Relationships {
+			relString, err := tuple.StringRelationship(rel)
+			if err != nil {
+				return nil, err
+			}
+			archive.Relationships = append(archive.Relationships, relString)
+		}
+	}
+
+	return archive, nil
+}
+
+// ImportArchive writes the schema and relationships found in the archive over
+// the given connection. The archive is validated before any data is written.
+func ImportArchive(ctx context.Context, conn grpc.ClientConnInterface, archive *Archive) error {
+	if err := archive.Validate(); err != nil {
+		return err
+	}
+
+	rels := make([]*v1.Relationship, 0, len(archive.Relationships))
+	for _, relString := range archive.Relationships {
+		rel := tuple.ParseRel(relString)
+		if rel == nil {
+			return fmt.Errorf("invalid relationship in archive: %q", relString)
+		}
+		rels = append(rels, rel)
+	}
+
+	if _, err := v1.NewSchemaServiceClient(conn).WriteSchema(ctx, &v1.WriteSchemaRequest{
+		Schema: archive.Schema,
+	}); err != nil {
+		return fmt.Errorf("could not write schema: %w", err)
+	}
+
+	if len(rels) == 0 {
+		return nil
+	}
+
+	stream, err := v1.NewExperimentalServiceClient(conn).BulkImportRelationships(ctx)
+	if err != nil {
+		return fmt.Errorf("could not import relationships: %w", err)
+	}
+
+	for start := 0; start < len(rels); start += archiveImportBatchSize {
+		end := start + archiveImportBatchSize
+		if end > len(rels) {
+			end = len(rels)
+		}
+
+		if err := stream.Send(&v1.BulkImportRelationshipsRequest{
+			Relationships: rels[start:end],
+		}); err != nil {
+			return fmt.Errorf("could not import relationships: %w", err)
+		}
+	}
+
+	if _, err := stream.CloseAndRecv(); err != nil {
+		return fmt.Errorf("could not import relationships: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package testserver
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchiveRoundTrip(t *testing.T) {
+	require := require.New(t)
+
+	archive := &Archive{
+		Version:  ArchiveVersion,
+		Revision: "sometoken",
+		Schema:   "definition user {}\n\ndefinition document {\n\trelation viewer: user\n}",
+		Relationships: []string{
+			"document:first#viewer@user:tom",
+			"document:second#viewer@user:fred",
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(WriteArchive(&buf, archive))
+
+	read, err := ReadArchive(bytes.NewReader(buf.Bytes()))
+	require.NoError(err)
+	require.Equal(archive, read)
+}
+
+func TestArchiveValidation(t *testing.T) {
+	require := require.New(t)
+
+	archive := &Archive{
+		Version:       ArchiveVersion,
+		Revision:      "sometoken",
+		Schema:        "definition user {}",
+		Relationships: []string{},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(WriteArchive(&buf, archive))
+
+	tampered := *archive
+	tampered.Relationships = []string{"document:first#viewer@user:tom"}
+	require.ErrorContains(tampered.Validate(), "checksum")
+
+	unsupported := *archive
+	unsupported.Version = ArchiveVersion + 1
+	require.ErrorContains(unsupported.Validate(), "unsupported archive version")
+
+	_, err := ReadArchive(bytes.NewReader([]byte("not an archive")))
+	require.Error(err)
+}
@@ -0,0 +1,58 @@
+package testserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/authzed/authzed-go/pkg/responsemeta"
+	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/authzed/spicedb/internal/middleware/pertoken"
+)
+
+// DatastoreStatistics describes the size of the datastore of a token of a running test server at
+// its head revision.
+type DatastoreStatistics struct {
+	// Relationships is the total number of relationships.
+	Relationships uint64 `json:"relationships"`
+
+	// Namespaces is the number of namespaces defined.
+	Namespaces int `json:"namespaces"`
+
+	// RelationshipsByNamespace is the number of relationships whose resource is of each defined
+	// namespace, keyed by namespace name. To bound the size of the response, the namespaces with
+	// the fewest relationships may be omitted.
+	RelationshipsByNamespace map[string]uint64 `json:"relationshipsByNamespace"`
+
+	// OmittedNamespaces is the number of namespaces omitted from RelationshipsByNamespace.
+	OmittedNamespaces int `json:"omittedNamespaces,omitempty"`
+}
+
+// FetchStatistics returns the statistics of the datastore visible over the given connection,
+// which are requested alongside a read of the schema.
+func FetchStatistics(ctx context.Context, conn grpc.ClientConnInterface) (*DatastoreStatistics, error) {
+	ctx = metadata.AppendToOutgoingContext(ctx, pertoken.RequestStatistics, "true")
+
+	// A datastore without any schema fails the read, but still reports its statistics.
+	var trailer metadata.MD
+	_, err := v1.NewSchemaServiceClient(conn).ReadSchema(ctx, &v1.ReadSchemaRequest{}, grpc.Trailer(&trailer))
+	if err != nil && status.Code(err) != codes.NotFound {
+		return nil, fmt.Errorf("could not read statistics: %w", err)
+	}
+
+	encoded, err := responsemeta.GetResponseTrailerMetadata(trailer, pertoken.DatastoreStatisticsTrailer)
+	if err != nil {
+		return nil, fmt.Errorf("could not read statistics: %w", err)
+	}
+
+	var stats DatastoreStatistics
+	if err := json.Unmarshal([]byte(encoded), &stats); err != nil {
+		return nil, fmt.Errorf("could not decode statistics: %w", err)
+	}
+	return &stats, nil
+}
@@ -3,12 +3,24 @@ package testserver
 import (
 	"context"
 	"fmt"
-
+	"math"
+	"runtime"
+	"sync/atomic"
+	"time"
+
+	grpcauth "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/auth"
+	grpcprom "github.com/grpc-ecosystem/go-grpc-prometheus"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rs/zerolog"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc"
 
+	"github.com/authzed/spicedb/internal/auth"
+	"github.com/authzed/spicedb/internal/dispatch"
+	"github.com/authzed/spicedb/internal/dispatch/caching"
 	"github.com/authzed/spicedb/internal/dispatch/graph"
+	"github.com/authzed/spicedb/internal/dispatch/keys"
 	"github.com/authzed/spicedb/internal/gateway"
 	log "github.com/authzed/spicedb/internal/logging"
 	consistencymw "github.com/authzed/spicedb/internal/middleware/consistency"
@@ -18,24 +30,51 @@ import (
 	"github.com/authzed/spicedb/internal/middleware/servicespecific"
 	"github.com/authzed/spicedb/internal/services"
 	"github.com/authzed/spicedb/internal/services/health"
+	"github.com/authzed/spicedb/internal/services/shared"
 	v1svc "github.com/authzed/spicedb/internal/services/v1"
+	"github.com/authzed/spicedb/pkg/cache"
+	"github.com/authzed/spicedb/pkg/cmd/server"
 	"github.com/authzed/spicedb/pkg/cmd/util"
-	"github.com/authzed/spicedb/pkg/datastore"
 )
 
-const maxDepth = 50
+const (
+	defaultMaxDepth = 50
+
+	defaultDispatchCacheTTL          = 1 * time.Minute
+	dispatchCacheMaxCost             = 64 << 20
+	dispatchCacheNumCounters         = 10_000
+	dispatchCachePrometheusSubsystem = "dispatch"
+)
 
 //go:generate go run github.com/ecordell/optgen -output zz_generated.options.go . Config
 type Config struct {
-	GRPCServer                 util.GRPCServerConfig `debugmap:"visible"`
-	ReadOnlyGRPCServer         util.GRPCServerConfig `debugmap:"visible"`
-	HTTPGateway                util.HTTPServerConfig `debugmap:"visible"`
-	ReadOnlyHTTPGateway        util.HTTPServerConfig `debugmap:"visible"`
-	LoadConfigs                []string              `debugmap:"visible"`
-	MaximumUpdatesPerWrite     uint16                `debugmap:"visible"`
-	MaximumPreconditionCount   uint16                `debugmap:"visible"`
-	MaxCaveatContextSize       int                   `debugmap:"visible"`
-	MaxRelationshipContextSize int                   `debugmap:"visible"`
+	GRPCServer                  util.GRPCServerConfig `debugmap:"visible"`
+	ReadOnlyGRPCServer          util.GRPCServerConfig `debugmap:"visible"`
+	HTTPGateway                 util.HTTPServerConfig `debugmap:"visible"`
+	ReadOnlyHTTPGateway         util.HTTPServerConfig `debugmap:"visible"`
+	LoadConfigs                 []string              `debugmap:"visible"`
+	WatchConfigs                bool                  `debugmap:"visible"`
+	MaximumUpdatesPerWrite      uint16                `debugmap:"visible"`
+	MaximumPreconditionCount    uint16                `debugmap:"visible"`
+	MaxCaveatContextSize        int                   `debugmap:"visible"`
+	MaxRelationshipContextSize  int                   `debugmap:"visible"`
+	MaxConcurrentWritesPerToken uint16                `debugmap:"visible"`
+	WriteUnknownNamespacePolicy string                `debugmap:"visible"`
+	SchemaOrphanPolicy          string                `debugmap:"visible"`
+	TokenDatastoreTTL           time.Duration         `debugmap:"visible"`
+	AllowResetAllDatastores     bool                  `debugmap:"visible"`
+	ShutdownTimeout             time.Duration         `debugmap:"visible"`
+	GCWindow                    time.Duration         `debugmap:"visible"`
+	RevisionQuantization        time.Duration         `debugmap:"visible"`
+	MaxDepth                    uint32                `debugmap:"visible"`
+	DispatchCache               bool                  `debugmap:"visible"`
+	DispatchCacheTTL            time.Duration         `debugmap:"visible"`
+	DispatchConcurrencyLimit    uint16                `debugmap:"visible"`
+	MetricsAPI                  util.HTTPServerConfig `debugmap:"visible"`
+	JWTJWKSURL                  string                `debugmap:"visible"`
+	JWTIssuer                   string                `debugmap:"visible"`
+	JWTAudience                 string                `debugmap:"visible"`
+	JWTTenantClaim              string                `debugmap:"visible"`
 }
 
 type RunnableTestServer interface {
@@ -44,18 +83,97 @@ type RunnableTestServer interface {
 	ReadOnlyGRPCDialContext(ctx context.Context, opts ...grpc.DialOption) (*grpc.ClientConn, error)
 }
 
-type datastoreReady struct{}
-
-func (dr datastoreReady) ReadyState(_ context.Context) (datastore.ReadyState, error) {
-	return datastore.ReadyState{IsReady: true}, nil
+// jwtValidator returns the validator of the JWTs authenticating requests, whose tenant determines
+// the datastore of each request.
+func (c *Config) jwtValidator() (*auth.JWTValidator, error) {
+	opts := []auth.JWTOption{
+		auth.WithIssuer(c.JWTIssuer),
+		auth.WithAudience(c.JWTAudience),
+	}
+	if c.JWTTenantClaim != "" {
+		opts = append(opts, auth.WithTenantClaim(c.JWTTenantClaim))
+	}
+	return auth.NewJWTValidator(c.JWTJWKSURL, opts...)
 }
 
 func (c *Config) Complete() (RunnableTestServer, error) {
-	dispatcher := graph.NewLocalOnlyDispatcher(10)
+	gcWindow := pertoken.DefaultGCWindow
+	if c.GCWindow != 0 {
+		gcWindow = c.GCWindow
+	}
+
+	revisionQuantization := pertoken.DefaultRevisionQuantization
+	if c.RevisionQuantization != 0 {
+		revisionQuantization = c.RevisionQuantization
+	}
+
+	if gcWindow < 0 || revisionQuantization < 0 {
+		return nil, fmt.Errorf("gc window and revision quantization interval must not be negative, found %v and %v", gcWindow, revisionQuantization)
+	}
+
+	if revisionQuantization > gcWindow {
+		return nil, fmt.Errorf("gc window %v must be at least the revision quantization interval %v", gcWindow, revisionQuantization)
+	}
+
+	maxDepth := uint32(defaultMaxDepth)
+	if c.MaxDepth != 0 {
+		maxDepth = c.MaxDepth
+	}
+
+	dispatcher, err := c.completeDispatcher()
+	if err != nil {
+		return nil, err
+	}
 
-	datastoreMiddleware := pertoken.NewMiddleware(c.LoadConfigs)
+	datastoreOpts := []pertoken.Option{
+		pertoken.WithGCWindow(gcWindow),
+		pertoken.WithRevisionQuantization(revisionQuantization),
+		pertoken.WithResetAllDatastores(c.AllowResetAllDatastores),
+		pertoken.WithMaxConcurrentWritesPerToken(c.MaxConcurrentWritesPerToken),
+		pertoken.WithTokenTTL(c.TokenDatastoreTTL),
+	}
+
+	// Without JWT validation, any bearer token is accepted and given its own datastore.
+	authFunc := func(ctx context.Context) (context.Context, error) {
+		return ctx, nil
+	}
+	if c.JWTJWKSURL != "" {
+		validator, err := c.jwtValidator()
+		if err != nil {
+			return nil, err
+		}
+
+		authFunc = validator.AuthFunc()
+		datastoreOpts = append(datastoreOpts, pertoken.WithDatastoreKey(func(ctx context.Context) string {
+			tenant, _ := auth.TenantFromContext(ctx)
+			return tenant
+		}))
+	}
+
+	datastoreMiddleware := pertoken.NewMiddleware(c.LoadConfigs, datastoreOpts...)
 
-	healthManager := health.NewHealthManager(dispatcher, &datastoreReady{})
+	if c.MetricsAPI.HTTPEnabled {
+		server.EnableGRPCHistogram()
+	}
+
+	// The services are reported as serving once the datastore of a token can be created.
+	healthManager := health.NewHealthManager(dispatcher, datastoreMiddleware)
+
+	writeUnknownNamespacePolicy := v1svc.UnknownNamespaceReject
+	if c.WriteUnknownNamespacePolicy != "" {
+		writeUnknownNamespacePolicy, err = v1svc.ParseUnknownNamespacePolicy(c.WriteUnknownNamespacePolicy)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	schemaOrphanPolicy := shared.OrphanedRelationshipsStrict
+	if c.SchemaOrphanPolicy != "" {
+		schemaOrphanPolicy, err = shared.ParseOrphanedRelationshipsPolicy(c.SchemaOrphanPolicy)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	registerServices := func(srv *grpc.Server) {
 		services.RegisterGrpcServices(
@@ -65,21 +183,32 @@ func (c *Config) Complete() (RunnableTestServer, error) {
 			services.V1SchemaServiceEnabled,
 			services.WatchServiceEnabled,
 			v1svc.PermissionsServerConfig{
-				MaxPreconditionsCount: c.MaximumPreconditionCount,
-				MaxUpdatesPerWrite:    c.MaximumUpdatesPerWrite,
-				MaximumAPIDepth:       maxDepth,
-				MaxCaveatContextSize:  c.MaxCaveatContextSize,
+				MaxPreconditionsCount:       c.MaximumPreconditionCount,
+				MaxUpdatesPerWrite:          c.MaximumUpdatesPerWrite,
+				MaximumAPIDepth:             maxDepth,
+				MaxCaveatContextSize:        c.MaxCaveatContextSize,
+				WriteUnknownNamespacePolicy: writeUnknownNamespacePolicy,
 			},
+			v1svc.SchemaServerConfig{OrphanPolicy: schemaOrphanPolicy},
 		)
 	}
+	var gRPCInFlight, readOnlyGRPCInFlight inFlightRPCs
 	gRPCSrv, err := c.GRPCServer.Complete(zerolog.InfoLevel, registerServices,
 		grpc.ChainUnaryInterceptor(
+			gRPCInFlight.unaryServerInterceptor,
+			otelgrpc.UnaryServerInterceptor(),
+			grpcprom.UnaryServerInterceptor,
+			grpcauth.UnaryServerInterceptor(authFunc),
 			datastoreMiddleware.UnaryServerInterceptor(),
 			dispatchmw.UnaryServerInterceptor(dispatcher),
 			consistencymw.UnaryServerInterceptor(),
 			servicespecific.UnaryServerInterceptor,
 		),
 		grpc.ChainStreamInterceptor(
+			gRPCInFlight.streamServerInterceptor,
+			otelgrpc.StreamServerInterceptor(),
+			grpcprom.StreamServerInterceptor,
+			grpcauth.StreamServerInterceptor(authFunc),
 			datastoreMiddleware.StreamServerInterceptor(),
 			dispatchmw.StreamServerInterceptor(dispatcher),
 			consistencymw.StreamServerInterceptor(),
@@ -90,16 +219,26 @@ func (c *Config) Complete() (RunnableTestServer, error) {
 		return nil, err
 	}
 
+	// Requests with headers that would change the datastores are rejected before the datastore of
+	// the token is set, which the read-only interceptors then wrap.
 	readOnlyGRPCSrv, err := c.ReadOnlyGRPCServer.Complete(zerolog.InfoLevel, registerServices,
 		grpc.ChainUnaryInterceptor(
-			datastoreMiddleware.UnaryServerInterceptor(),
+			readOnlyGRPCInFlight.unaryServerInterceptor,
+			otelgrpc.UnaryServerInterceptor(),
+			grpcprom.UnaryServerInterceptor,
+			grpcauth.UnaryServerInterceptor(authFunc),
+			datastoreMiddleware.ReadOnlyUnaryServerInterceptor(),
 			readonly.UnaryServerInterceptor(),
 			dispatchmw.UnaryServerInterceptor(dispatcher),
 			consistencymw.UnaryServerInterceptor(),
 			servicespecific.UnaryServerInterceptor,
 		),
 		grpc.ChainStreamInterceptor(
-			datastoreMiddleware.StreamServerInterceptor(),
+			readOnlyGRPCInFlight.streamServerInterceptor,
+			otelgrpc.StreamServerInterceptor(),
+			grpcprom.StreamServerInterceptor,
+			grpcauth.StreamServerInterceptor(authFunc),
+			datastoreMiddleware.ReadOnlyStreamServerInterceptor(),
 			readonly.StreamServerInterceptor(),
 			dispatchmw.StreamServerInterceptor(dispatcher),
 			consistencymw.StreamServerInterceptor(),
@@ -110,7 +249,10 @@ func (c *Config) Complete() (RunnableTestServer, error) {
 		return nil, err
 	}
 
-	gatewayHandler, err := gateway.NewHandler(context.TODO(), c.GRPCServer.Address, c.GRPCServer.TLSCertPath)
+	gatewayHandler, err := gateway.NewHandler(context.TODO(), gateway.HandlerConfig{
+		UpstreamAddr: c.GRPCServer.Address,
+		UpstreamTLS:  gateway.UpstreamTLSConfig{CAPath: c.GRPCServer.TLSCertPath},
+	})
 	if err != nil {
 		log.Fatal().Err(err).Msg("failed to initialize rest gateway")
 	}
@@ -124,7 +266,10 @@ func (c *Config) Complete() (RunnableTestServer, error) {
 		return nil, fmt.Errorf("failed to initialize rest gateway: %w", err)
 	}
 
-	readOnlyGatewayHandler, err := gateway.NewHandler(context.TODO(), c.ReadOnlyGRPCServer.Address, c.ReadOnlyGRPCServer.TLSCertPath)
+	readOnlyGatewayHandler, err := gateway.NewHandler(context.TODO(), gateway.HandlerConfig{
+		UpstreamAddr: c.ReadOnlyGRPCServer.Address,
+		UpstreamTLS:  gateway.UpstreamTLSConfig{CAPath: c.ReadOnlyGRPCServer.TLSCertPath},
+	})
 	if err != nil {
 		log.Fatal().Err(err).Msg("failed to initialize rest gateway")
 	}
@@ -138,26 +283,125 @@ func (c *Config) Complete() (RunnableTestServer, error) {
 		return nil, fmt.Errorf("failed to initialize rest gateway: %w", err)
 	}
 
+	metricsServer, err := c.MetricsAPI.Complete(zerolog.InfoLevel, server.MetricsHandler(nil, nil))
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize metrics server: %w", err)
+	}
+
 	return &completedTestServer{
 		gRPCServer:            gRPCSrv,
 		readOnlyGRPCServer:    readOnlyGRPCSrv,
 		gatewayServer:         gatewayServer,
 		readOnlyGatewayServer: readOnlyGatewayServer,
+		metricsServer:         metricsServer,
 		healthManager:         healthManager,
+		datastoreMiddleware:   datastoreMiddleware,
+		watchConfigs:          c.WatchConfigs,
+		metricsEnabled:        c.MetricsAPI.HTTPEnabled,
+		shutdownTimeout:       c.ShutdownTimeout,
+		gRPCInFlight:          &gRPCInFlight,
+		readOnlyGRPCInFlight:  &readOnlyGRPCInFlight,
 	}, nil
 }
 
+// dispatchConcurrencyLimit returns the maximum number of subproblems run in parallel by each
+// dispatched operation, which defaults to GOMAXPROCS. Each operation limits its own subproblems,
+// so nested dispatches never wait on the limit of the operation which dispatched them.
+func (c *Config) dispatchConcurrencyLimit() uint16 {
+	if c.DispatchConcurrencyLimit != 0 {
+		return c.DispatchConcurrencyLimit
+	}
+
+	gomaxprocs := runtime.GOMAXPROCS(0)
+	if gomaxprocs > math.MaxUint16 {
+		return math.MaxUint16
+	}
+	return uint16(gomaxprocs)
+}
+
+// completeDispatcher returns the dispatcher used to compute permissions. If the dispatch cache is
+// enabled, the results of dispatches are cached until they expire after the dispatch cache TTL.
+// Cache keys include the revision of the request, so that writes are never hidden by cached
+// results, and the scope of the datastore of the request, so that datastores with equal revisions
+// never share cached results.
+func (c *Config) completeDispatcher() (dispatch.Dispatcher, error) {
+	if !c.DispatchCache {
+		return graph.NewLocalOnlyDispatcher(c.dispatchConcurrencyLimit()), nil
+	}
+
+	ttl := defaultDispatchCacheTTL
+	if c.DispatchCacheTTL != 0 {
+		ttl = c.DispatchCacheTTL
+	}
+
+	if ttl < 0 {
+		return nil, fmt.Errorf("dispatch cache TTL must not be negative, found %v", ttl)
+	}
+
+	dispatchCache, err := cache.NewCache(&cache.Config{
+		MaxCost:     dispatchCacheMaxCost,
+		NumCounters: dispatchCacheNumCounters,
+		DefaultTTL:  ttl,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dispatch cache: %w", err)
+	}
+
+	keyHandler := keys.NewScopedKeyHandler(&keys.DirectKeyHandler{}, pertoken.DatastoreScope)
+	cachingDispatcher, err := caching.NewCachingDispatcher(dispatchCache, true, dispatchCachePrometheusSubsystem, keyHandler)
+	if err != nil {
+		return nil, err
+	}
+
+	cachingDispatcher.SetDelegate(graph.NewDispatcher(cachingDispatcher, graph.SharedConcurrencyLimits(c.dispatchConcurrencyLimit())))
+	return cachingDispatcher, nil
+}
+
 type completedTestServer struct {
 	gRPCServer         util.RunnableGRPCServer
 	readOnlyGRPCServer util.RunnableGRPCServer
 
 	gatewayServer         util.RunnableHTTPServer
 	readOnlyGatewayServer util.RunnableHTTPServer
+	metricsServer         util.RunnableHTTPServer
 
 	healthManager health.Manager
+
+	datastoreMiddleware *pertoken.MiddlewareForTesting
+	watchConfigs        bool
+	metricsEnabled      bool
+
+	shutdownTimeout      time.Duration
+	gRPCInFlight         *inFlightRPCs
+	readOnlyGRPCInFlight *inFlightRPCs
+}
+
+// inFlightRPCs counts the RPCs being handled by a server.
+type inFlightRPCs struct {
+	count atomic.Int64
+}
+
+func (f *inFlightRPCs) unaryServerInterceptor(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	f.count.Add(1)
+	defer f.count.Add(-1)
+	return handler(ctx, req)
+}
+
+func (f *inFlightRPCs) streamServerInterceptor(srv any, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	f.count.Add(1)
+	defer f.count.Add(-1)
+	return handler(srv, stream)
 }
 
 func (c *completedTestServer) Run(ctx context.Context) error {
+	// The token datastores are reported for as long as the server runs.
+	if c.metricsEnabled {
+		if err := prometheus.Register(c.datastoreMiddleware); err != nil {
+			return fmt.Errorf("failed to register token datastore metrics: %w", err)
+		}
+		defer prometheus.Unregister(c.datastoreMiddleware)
+	}
+
 	g, ctx := errgroup.WithContext(ctx)
 
 	stopOnCancel := func(stopFn func()) func() error {
@@ -170,11 +414,33 @@ func (c *completedTestServer) Run(ctx context.Context) error {
 
 	g.Go(c.healthManager.Checker(ctx))
 
+	// Report the services as no longer serving while the servers stop gracefully, and if they
+	// have not stopped by the shutdown timeout, stop them immediately.
+	gracefulStop := func(srv util.RunnableGRPCServer, inFlight *inFlightRPCs) func() {
+		return func() {
+			c.healthManager.HealthSvc().Server.Shutdown()
+			if c.shutdownTimeout <= 0 {
+				srv.GracefulStop()
+				return
+			}
+
+			forceStop := time.AfterFunc(c.shutdownTimeout, func() {
+				log.Ctx(ctx).Warn().
+					Int64("inFlightRPCs", inFlight.count.Load()).
+					Stringer("timeout", c.shutdownTimeout).
+					Msg("gRPC server did not stop gracefully within the shutdown timeout; forcing it to stop")
+				srv.Stop()
+			})
+			defer forceStop.Stop()
+			srv.GracefulStop()
+		}
+	}
+
 	g.Go(c.gRPCServer.Listen(ctx))
-	g.Go(stopOnCancel(c.gRPCServer.GracefulStop))
+	g.Go(stopOnCancel(gracefulStop(c.gRPCServer, c.gRPCInFlight)))
 
 	g.Go(c.readOnlyGRPCServer.Listen(ctx))
-	g.Go(stopOnCancel(c.readOnlyGRPCServer.GracefulStop))
+	g.Go(stopOnCancel(gracefulStop(c.readOnlyGRPCServer, c.readOnlyGRPCInFlight)))
 
 	g.Go(c.gatewayServer.ListenAndServe)
 	g.Go(stopOnCancel(c.gatewayServer.Close))
@@ -182,6 +448,15 @@ func (c *completedTestServer) Run(ctx context.Context) error {
 	g.Go(c.readOnlyGatewayServer.ListenAndServe)
 	g.Go(stopOnCancel(c.readOnlyGatewayServer.Close))
 
+	g.Go(c.metricsServer.ListenAndServe)
+	g.Go(stopOnCancel(c.metricsServer.Close))
+
+	if c.watchConfigs {
+		g.Go(func() error {
+			return c.datastoreMiddleware.WatchConfigs(ctx)
+		})
+	}
+
 	if err := g.Wait(); err != nil {
 		log.Ctx(ctx).Warn().Err(err).Msg("error shutting down servers")
 	}
@@ -0,0 +1,153 @@
+package testserver
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
+	"github.com/authzed/grpcutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/authzed/spicedb/internal/middleware/pertoken"
+	"github.com/authzed/spicedb/pkg/cmd/util"
+)
+
+func TestCompleteValidatesDatastoreTiming(t *testing.T) {
+	for _, tc := range []struct {
+		name                 string
+		gcWindow             time.Duration
+		revisionQuantization time.Duration
+		expectedError        string
+	}{
+		{
+			name:                 "quantization exceeds gc window",
+			gcWindow:             time.Second,
+			revisionQuantization: time.Minute,
+			expectedError:        "must be at least the revision quantization interval",
+		},
+		{
+			name:                 "quantization exceeds default gc window",
+			revisionQuantization: 2 * time.Hour,
+			expectedError:        "must be at least the revision quantization interval",
+		},
+		{
+			name:          "negative gc window",
+			gcWindow:      -time.Second,
+			expectedError: "must not be negative",
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			config := NewConfigWithOptions(
+				WithGCWindow(tc.gcWindow),
+				WithRevisionQuantization(tc.revisionQuantization),
+			)
+
+			_, err := config.Complete()
+			require.ErrorContains(t, err, tc.expectedError)
+		})
+	}
+}
+
+func TestCompleteValidatesDispatchCacheTTL(t *testing.T) {
+	config := NewConfigWithOptions(
+		WithDispatchCache(true),
+		WithDispatchCacheTTL(-time.Second),
+	)
+
+	_, err := config.Complete()
+	require.ErrorContains(t, err, "dispatch cache TTL must not be negative")
+}
+
+func TestCompleteValidatesSchemaOrphanPolicy(t *testing.T) {
+	config := NewConfigWithOptions(WithSchemaOrphanPolicy("unknown"))
+
+	_, err := config.Complete()
+	require.ErrorContains(t, err, "unknown orphaned relationships policy `unknown`")
+}
+
+func TestDispatchConcurrencyLimit(t *testing.T) {
+	require.Equal(t, uint16(runtime.GOMAXPROCS(0)), NewConfigWithOptions().dispatchConcurrencyLimit())
+	require.Equal(t, uint16(3), NewConfigWithOptions(WithDispatchConcurrencyLimit(3)).dispatchConcurrencyLimit())
+}
+
+func TestInFlightRPCs(t *testing.T) {
+	var inFlight inFlightRPCs
+
+	_, err := inFlight.unaryServerInterceptor(context.Background(), nil, nil, func(ctx context.Context, req any) (any, error) {
+		require.Equal(t, int64(1), inFlight.count.Load())
+		return nil, nil
+	})
+	require.NoError(t, err)
+
+	err = inFlight.streamServerInterceptor(nil, nil, nil, func(srv any, stream grpc.ServerStream) error {
+		require.Equal(t, int64(1), inFlight.count.Load())
+		return errors.New("some error")
+	})
+	require.Error(t, err)
+	require.Equal(t, int64(0), inFlight.count.Load())
+}
+
+func TestReadOnlyServerRejectsMutatingHeaders(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte(`---
+schema: >-
+  definition user {}
+`), 0o600))
+
+	srv, err := NewConfigWithOptions(
+		WithGRPCServer(util.GRPCServerConfig{Network: util.BufferedNetwork, Enabled: true}),
+		WithReadOnlyGRPCServer(util.GRPCServerConfig{Network: util.BufferedNetwork, Enabled: true}),
+		WithLoadConfigs(configFile),
+	).Complete()
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		require.NoError(t, <-done)
+	})
+
+	readOnlyConn, err := srv.ReadOnlyGRPCDialContext(ctx, grpcutil.WithInsecureBearerToken("sometoken"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = readOnlyConn.Close() })
+	readOnlyClient := v1.NewSchemaServiceClient(readOnlyConn)
+
+	conn, err := srv.GRPCDialContext(ctx, grpcutil.WithInsecureBearerToken("sometoken"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	client := v1.NewSchemaServiceClient(conn)
+
+	// Reads without any such header are served by the read-only server.
+	_, err = readOnlyClient.ReadSchema(ctx, &v1.ReadSchemaRequest{})
+	require.NoError(t, err)
+
+	for _, headers := range [][]string{
+		{pertoken.RequestResetDatastore, "token"},
+		{pertoken.RequestCreateSnapshot, "somesnapshot"},
+		{pertoken.RequestDeleteSnapshot, "somesnapshot"},
+		{pertoken.RequestReloadConfigs, "add"},
+	} {
+		headerCtx := metadata.AppendToOutgoingContext(ctx, headers...)
+
+		_, err := readOnlyClient.ReadSchema(headerCtx, &v1.ReadSchemaRequest{})
+		require.Equal(t, codes.PermissionDenied, status.Code(err), headers[0])
+
+		// The same header is accepted by the read-write server.
+		_, err = client.ReadSchema(headerCtx, &v1.ReadSchemaRequest{})
+		require.NoError(t, err, headers[0])
+	}
+}
@@ -0,0 +1,99 @@
+package testserver
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/authzed/spicedb/internal/datastore/memdb"
+	"github.com/authzed/spicedb/internal/dispatch/graph"
+	datastoremw "github.com/authzed/spicedb/internal/middleware/datastore"
+	"github.com/authzed/spicedb/pkg/development"
+	devinterface "github.com/authzed/spicedb/pkg/proto/developer/v1"
+	"github.com/authzed/spicedb/pkg/validationfile"
+)
+
+// ValidationFailure is a failure found when validating a validation file.
+type ValidationFailure struct {
+	// FilePath is the path of the file in which the failing assertion or expected relation was
+	// defined.
+	FilePath string
+
+	// Error describes the failure, along with its position in the file.
+	Error *devinterface.DeveloperError
+}
+
+// String returns a human-readable description of the failure.
+func (vf ValidationFailure) String() string {
+	return fmt.Sprintf("%s:%d: %s", vf.FilePath, vf.Error.Line, vf.Error.Message)
+}
+
+// ValidationReport is the result of validating a set of validation files.
+type ValidationReport struct {
+	// Assertions is the number of assertions run.
+	Assertions int
+
+	// ExpectedRelations is the number of resources and permissions whose expected subjects were
+	// compared with those computed.
+	ExpectedRelations int
+
+	// Failures are the failures found, sorted by file and then by line.
+	Failures []ValidationFailure
+}
+
+// ValidateFiles loads the given validation files into an in-memory datastore, as --load-configs
+// does, and runs the assertions and verifies the expected relations of each file against it. The
+// returned error is only set if the files could not be loaded or an assertion or expected relation
+// could not be run for a reason other than the data in the files.
+func ValidateFiles(ctx context.Context, filePaths []string) (*ValidationReport, error) {
+	ds, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	if err != nil {
+		return nil, err
+	}
+	defer ds.Close()
+
+	populated, revision, err := validationfile.PopulateFromFiles(ctx, ds, filePaths)
+	if err != nil {
+		return nil, err
+	}
+
+	devContext := &development.DevContext{
+		Ctx:        datastoremw.ContextWithDatastore(ctx, ds),
+		Datastore:  ds,
+		Revision:   revision,
+		Dispatcher: graph.NewLocalOnlyDispatcher(10),
+	}
+
+	report := &ValidationReport{}
+	for i, parsed := range populated.ParsedFiles {
+		filePath := populated.FilePaths[i]
+
+		assertions := parsed.Assertions
+		report.Assertions += len(assertions.AssertTrue) + len(assertions.AssertCaveated) + len(assertions.AssertFalse) + len(assertions.AssertSubjects)
+
+		devErrs, err := development.RunAllAssertions(devContext, &assertions)
+		if err != nil {
+			return nil, fmt.Errorf("could not run the assertions of %s: %w", filePath, err)
+		}
+
+		expectedRelations := parsed.ExpectedRelations
+		report.ExpectedRelations += len(expectedRelations.ValidationMap)
+
+		_, validationErrs, err := development.RunValidation(devContext, &expectedRelations)
+		if err != nil {
+			return nil, fmt.Errorf("could not verify the expected relations of %s: %w", filePath, err)
+		}
+		devErrs = append(devErrs, validationErrs...)
+
+		failures := make([]ValidationFailure, 0, len(devErrs))
+		for _, devErr := range devErrs {
+			failures = append(failures, ValidationFailure{FilePath: filePath, Error: devErr})
+		}
+		sort.SliceStable(failures, func(i, j int) bool {
+			return failures[i].Error.Line < failures[j].Error.Line
+		})
+		report.Failures = append(report.Failures, failures...)
+	}
+
+	return report, nil
+}
@@ -0,0 +1,63 @@
+package testserver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	devinterface "github.com/authzed/spicedb/pkg/proto/developer/v1"
+)
+
+func TestValidateFilesAssertions(t *testing.T) {
+	report, err := ValidateFiles(context.Background(), []string{"testdata/assertions.yaml"})
+	require.NoError(t, err)
+	require.Equal(t, 6, report.Assertions)
+
+	type summary struct {
+		filePath string
+		context  string
+		kind     devinterface.DeveloperError_ErrorKind
+	}
+
+	summaries := make([]summary, 0, len(report.Failures))
+	for _, failure := range report.Failures {
+		summaries = append(summaries, summary{failure.FilePath, failure.Error.Context, failure.Error.Kind})
+	}
+
+	require.Equal(t, []summary{
+		{"testdata/assertions.yaml", "resource:first#view@user:fred", devinterface.DeveloperError_ASSERTION_FAILED},
+		{"testdata/assertions.yaml", "resource:first#view@user:tom", devinterface.DeveloperError_ASSERTION_FAILED},
+		{"testdata/assertions.yaml", "resource:first#unknown@user:tom", devinterface.DeveloperError_UNKNOWN_RELATION},
+	}, summaries)
+}
+
+func TestValidateFilesInvalidFile(t *testing.T) {
+	_, err := ValidateFiles(context.Background(), []string{"../../validationfile/testdata/unknown_namespace_rel.yaml"})
+	require.Error(t, err)
+}
+
+func TestValidateFilesExpectedRelations(t *testing.T) {
+	report, err := ValidateFiles(context.Background(), []string{"testdata/expected_relations.yaml"})
+	require.NoError(t, err)
+	require.Equal(t, 4, report.ExpectedRelations)
+
+	type summary struct {
+		line    uint32
+		context string
+		kind    devinterface.DeveloperError_ErrorKind
+	}
+
+	summaries := make([]summary, 0, len(report.Failures))
+	for _, failure := range report.Failures {
+		require.Equal(t, "testdata/expected_relations.yaml", failure.FilePath)
+		summaries = append(summaries, summary{failure.Error.Line, failure.Error.Context, failure.Error.Kind})
+	}
+
+	// The subjects of resource:first#view and resource:second#view match those expected.
+	require.Equal(t, []summary{
+		{0, "resource:first#unknown", devinterface.DeveloperError_UNKNOWN_RELATION},
+		{21, "resource:first#reader", devinterface.DeveloperError_EXTRA_RELATIONSHIP_FOUND},
+		{22, "[user:sarah] is <resource:first#reader>", devinterface.DeveloperError_MISSING_EXPECTED_RELATIONSHIP},
+	}, summaries)
+}
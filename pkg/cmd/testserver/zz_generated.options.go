@@ -5,6 +5,7 @@ import (
 	util "github.com/authzed/spicedb/pkg/cmd/util"
 	defaults "github.com/creasty/defaults"
 	helpers "github.com/ecordell/optgen/helpers"
+	"time"
 )
 
 type ConfigOption func(c *Config)
@@ -36,10 +37,28 @@ func (c *Config) ToOption() ConfigOption {
 		to.HTTPGateway = c.HTTPGateway
 		to.ReadOnlyHTTPGateway = c.ReadOnlyHTTPGateway
 		to.LoadConfigs = c.LoadConfigs
+		to.WatchConfigs = c.WatchConfigs
 		to.MaximumUpdatesPerWrite = c.MaximumUpdatesPerWrite
 		to.MaximumPreconditionCount = c.MaximumPreconditionCount
 		to.MaxCaveatContextSize = c.MaxCaveatContextSize
 		to.MaxRelationshipContextSize = c.MaxRelationshipContextSize
+		to.MaxConcurrentWritesPerToken = c.MaxConcurrentWritesPerToken
+		to.WriteUnknownNamespacePolicy = c.WriteUnknownNamespacePolicy
+		to.SchemaOrphanPolicy = c.SchemaOrphanPolicy
+		to.TokenDatastoreTTL = c.TokenDatastoreTTL
+		to.AllowResetAllDatastores = c.AllowResetAllDatastores
+		to.ShutdownTimeout = c.ShutdownTimeout
+		to.GCWindow = c.GCWindow
+		to.RevisionQuantization = c.RevisionQuantization
+		to.MaxDepth = c.MaxDepth
+		to.DispatchCache = c.DispatchCache
+		to.DispatchCacheTTL = c.DispatchCacheTTL
+		to.DispatchConcurrencyLimit = c.DispatchConcurrencyLimit
+		to.MetricsAPI = c.MetricsAPI
+		to.JWTJWKSURL = c.JWTJWKSURL
+		to.JWTIssuer = c.JWTIssuer
+		to.JWTAudience = c.JWTAudience
+		to.JWTTenantClaim = c.JWTTenantClaim
 	}
 }
 
@@ -51,10 +70,28 @@ func (c Config) DebugMap() map[string]any {
 	debugMap["HTTPGateway"] = helpers.DebugValue(c.HTTPGateway, false)
 	debugMap["ReadOnlyHTTPGateway"] = helpers.DebugValue(c.ReadOnlyHTTPGateway, false)
 	debugMap["LoadConfigs"] = helpers.DebugValue(c.LoadConfigs, false)
+	debugMap["WatchConfigs"] = helpers.DebugValue(c.WatchConfigs, false)
 	debugMap["MaximumUpdatesPerWrite"] = helpers.DebugValue(c.MaximumUpdatesPerWrite, false)
 	debugMap["MaximumPreconditionCount"] = helpers.DebugValue(c.MaximumPreconditionCount, false)
 	debugMap["MaxCaveatContextSize"] = helpers.DebugValue(c.MaxCaveatContextSize, false)
 	debugMap["MaxRelationshipContextSize"] = helpers.DebugValue(c.MaxRelationshipContextSize, false)
+	debugMap["MaxConcurrentWritesPerToken"] = helpers.DebugValue(c.MaxConcurrentWritesPerToken, false)
+	debugMap["WriteUnknownNamespacePolicy"] = helpers.DebugValue(c.WriteUnknownNamespacePolicy, false)
+	debugMap["SchemaOrphanPolicy"] = helpers.DebugValue(c.SchemaOrphanPolicy, false)
+	debugMap["TokenDatastoreTTL"] = helpers.DebugValue(c.TokenDatastoreTTL, false)
+	debugMap["AllowResetAllDatastores"] = helpers.DebugValue(c.AllowResetAllDatastores, false)
+	debugMap["ShutdownTimeout"] = helpers.DebugValue(c.ShutdownTimeout, false)
+	debugMap["GCWindow"] = helpers.DebugValue(c.GCWindow, false)
+	debugMap["RevisionQuantization"] = helpers.DebugValue(c.RevisionQuantization, false)
+	debugMap["MaxDepth"] = helpers.DebugValue(c.MaxDepth, false)
+	debugMap["DispatchCache"] = helpers.DebugValue(c.DispatchCache, false)
+	debugMap["DispatchCacheTTL"] = helpers.DebugValue(c.DispatchCacheTTL, false)
+	debugMap["DispatchConcurrencyLimit"] = helpers.DebugValue(c.DispatchConcurrencyLimit, false)
+	debugMap["MetricsAPI"] = helpers.DebugValue(c.MetricsAPI, false)
+	debugMap["JWTJWKSURL"] = helpers.DebugValue(c.JWTJWKSURL, false)
+	debugMap["JWTIssuer"] = helpers.DebugValue(c.JWTIssuer, false)
+	debugMap["JWTAudience"] = helpers.DebugValue(c.JWTAudience, false)
+	debugMap["JWTTenantClaim"] = helpers.DebugValue(c.JWTTenantClaim, false)
 	return debugMap
 }
 
@@ -116,6 +153,13 @@ func SetLoadConfigs(loadConfigs []string) ConfigOption {
 	}
 }
 
+// WithWatchConfigs returns an option that can set WatchConfigs on a Config
+func WithWatchConfigs(watchConfigs bool) ConfigOption {
+	return func(c *Config) {
+		c.WatchConfigs = watchConfigs
+	}
+}
+
 // WithMaximumUpdatesPerWrite returns an option that can set MaximumUpdatesPerWrite on a Config
 func WithMaximumUpdatesPerWrite(maximumUpdatesPerWrite uint16) ConfigOption {
 	return func(c *Config) {
@@ -143,3 +187,122 @@ func WithMaxRelationshipContextSize(maxRelationshipContextSize int) ConfigOption
 		c.MaxRelationshipContextSize = maxRelationshipContextSize
 	}
 }
+
+// WithMaxConcurrentWritesPerToken returns an option that can set MaxConcurrentWritesPerToken on a Config
+func WithMaxConcurrentWritesPerToken(maxConcurrentWritesPerToken uint16) ConfigOption {
+	return func(c *Config) {
+		c.MaxConcurrentWritesPerToken = maxConcurrentWritesPerToken
+	}
+}
+
+// WithWriteUnknownNamespacePolicy returns an option that can set WriteUnknownNamespacePolicy on a Config
+func WithWriteUnknownNamespacePolicy(writeUnknownNamespacePolicy string) ConfigOption {
+	return func(c *Config) {
+		c.WriteUnknownNamespacePolicy = writeUnknownNamespacePolicy
+	}
+}
+
+// WithSchemaOrphanPolicy returns an option that can set SchemaOrphanPolicy on a Config
+func WithSchemaOrphanPolicy(schemaOrphanPolicy string) ConfigOption {
+	return func(c *Config) {
+		c.SchemaOrphanPolicy = schemaOrphanPolicy
+	}
+}
+
+// WithTokenDatastoreTTL returns an option that can set TokenDatastoreTTL on a Config
+func WithTokenDatastoreTTL(tokenDatastoreTTL time.Duration) ConfigOption {
+	return func(c *Config) {
+		c.TokenDatastoreTTL = tokenDatastoreTTL
+	}
+}
+
+// WithAllowResetAllDatastores returns an option that can set AllowResetAllDatastores on a Config
+func WithAllowResetAllDatastores(allowResetAllDatastores bool) ConfigOption {
+	return func(c *Config) {
+		c.AllowResetAllDatastores = allowResetAllDatastores
+	}
+}
+
+// WithShutdownTimeout returns an option that can set ShutdownTimeout on a Config
+func WithShutdownTimeout(shutdownTimeout time.Duration) ConfigOption {
+	return func(c *Config) {
+		c.ShutdownTimeout = shutdownTimeout
+	}
+}
+
+// WithGCWindow returns an option that can set GCWindow on a Config
+func WithGCWindow(gCWindow time.Duration) ConfigOption {
+	return func(c *Config) {
+		c.GCWindow = gCWindow
+	}
+}
+
+// WithRevisionQuantization returns an option that can set RevisionQuantization on a Config
+func WithRevisionQuantization(revisionQuantization time.Duration) ConfigOption {
+	return func(c *Config) {
+		c.RevisionQuantization = revisionQuantization
+	}
+}
+
+// WithMaxDepth returns an option that can set MaxDepth on a Config
+func WithMaxDepth(maxDepth uint32) ConfigOption {
+	return func(c *Config) {
+		c.MaxDepth = maxDepth
+	}
+}
+
+// WithDispatchCache returns an option that can set DispatchCache on a Config
+func WithDispatchCache(dispatchCache bool) ConfigOption {
+	return func(c *Config) {
+		c.DispatchCache = dispatchCache
+	}
+}
+
+// WithDispatchCacheTTL returns an option that can set DispatchCacheTTL on a Config
+func WithDispatchCacheTTL(dispatchCacheTTL time.Duration) ConfigOption {
+	return func(c *Config) {
+		c.DispatchCacheTTL = dispatchCacheTTL
+	}
+}
+
+// WithDispatchConcurrencyLimit returns an option that can set DispatchConcurrencyLimit on a Config
+func WithDispatchConcurrencyLimit(dispatchConcurrencyLimit uint16) ConfigOption {
+	return func(c *Config) {
+		c.DispatchConcurrencyLimit = dispatchConcurrencyLimit
+	}
+}
+
+// WithMetricsAPI returns an option that can set MetricsAPI on a Config
+func WithMetricsAPI(metricsAPI util.HTTPServerConfig) ConfigOption {
+	return func(c *Config) {
+		c.MetricsAPI = metricsAPI
+	}
+}
+
+// WithJWTJWKSURL returns an option that can set JWTJWKSURL on a Config
+func WithJWTJWKSURL(jWTJWKSURL string) ConfigOption {
+	return func(c *Config) {
+		c.JWTJWKSURL = jWTJWKSURL
+	}
+}
+
+// WithJWTIssuer returns an option that can set JWTIssuer on a Config
+func WithJWTIssuer(jWTIssuer string) ConfigOption {
+	return func(c *Config) {
+		c.JWTIssuer = jWTIssuer
+	}
+}
+
+// WithJWTAudience returns an option that can set JWTAudience on a Config
+func WithJWTAudience(jWTAudience string) ConfigOption {
+	return func(c *Config) {
+		c.JWTAudience = jWTAudience
+	}
+}
+
+// WithJWTTenantClaim returns an option that can set JWTTenantClaim on a Config
+func WithJWTTenantClaim(jWTTenantClaim string) ConfigOption {
+	return func(c *Config) {
+		c.JWTTenantClaim = jWTTenantClaim
+	}
+}
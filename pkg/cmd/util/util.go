@@ -41,6 +41,7 @@ type GRPCServerConfig struct {
 	TLSCertPath  string        `debugmap:"visible"`
 	TLSKeyPath   string        `debugmap:"visible"`
 	MaxConnAge   time.Duration `debugmap:"visible"`
+	MaxConnIdle  time.Duration `debugmap:"visible"`
 	Enabled      bool          `debugmap:"visible"`
 	BufferSize   int           `debugmap:"visible"`
 	ClientCAPath string        `debugmap:"visible"`
@@ -55,6 +56,7 @@ type GRPCServerConfig struct {
 // - "$PREFIX-tls-cert-path"
 // - "$PREFIX-tls-key-path"
 // - "$PREFIX-max-conn-age"
+// - "$PREFIX-max-conn-idle"
 func RegisterGRPCServerFlags(flags *pflag.FlagSet, config *GRPCServerConfig, flagPrefix, serviceName, defaultAddr string, defaultEnabled bool) {
 	flagPrefix = stringz.DefaultEmpty(flagPrefix, "grpc")
 	serviceName = stringz.DefaultEmpty(serviceName, "grpc")
@@ -66,6 +68,7 @@ func RegisterGRPCServerFlags(flags *pflag.FlagSet, config *GRPCServerConfig, fla
 	flags.StringVar(&config.TLSCertPath, flagPrefix+"-tls-cert-path", "", "local path to the TLS certificate used to serve "+serviceName)
 	flags.StringVar(&config.TLSKeyPath, flagPrefix+"-tls-key-path", "", "local path to the TLS key used to serve "+serviceName)
 	flags.DurationVar(&config.MaxConnAge, flagPrefix+"-max-conn-age", 30*time.Second, "how long a connection serving "+serviceName+" should be able to live")
+	flags.DurationVar(&config.MaxConnIdle, flagPrefix+"-max-conn-idle", 0, "how long a connection serving "+serviceName+" may be idle before it is closed (0 means connections are never closed for being idle)")
 	flags.BoolVar(&config.Enabled, flagPrefix+"-enabled", defaultEnabled, "enable "+serviceName+" gRPC server")
 	flags.Uint32Var(&config.MaxWorkers, flagPrefix+"-max-workers", 0, "set the number of workers for this server (0 value means 1 worker per request)")
 }
@@ -84,7 +87,8 @@ func (c *GRPCServerConfig) Complete(level zerolog.Level, svcRegistrationFn func(
 		c.BufferSize = 1024 * 1024
 	}
 	opts = append(opts, grpc.KeepaliveParams(keepalive.ServerParameters{
-		MaxConnectionAge: c.MaxConnAge,
+		MaxConnectionAge:  c.MaxConnAge,
+		MaxConnectionIdle: c.MaxConnIdle,
 	}), grpc.NumStreamWorkers(c.MaxWorkers))
 
 	tlsOpts, certWatcher, err := c.tlsOpts()
@@ -128,9 +132,10 @@ func (c *GRPCServerConfig) Complete(level zerolog.Level, svcRegistrationFn func(
 				Str("service", c.flagPrefix).
 				Msg("grpc server stopped serving")
 		},
-		stopFunc:    srv.GracefulStop,
-		creds:       clientCreds,
-		certWatcher: certWatcher,
+		stopFunc:      srv.GracefulStop,
+		forceStopFunc: srv.Stop,
+		creds:         clientCreds,
+		certWatcher:   certWatcher,
 	}, nil
 }
 
@@ -171,7 +176,10 @@ func (c *GRPCServerConfig) tlsOpts() ([]grpc.ServerOption, *certwatcher.CertWatc
 		})
 		return []grpc.ServerOption{grpc.Creds(creds)}, watcher, nil
 	default:
-		return nil, nil, nil
+		return nil, nil, fmt.Errorf("failed to start gRPC server: must provide both --%s-tls-cert-path and --%s-tls-key-path",
+			c.flagPrefix,
+			c.flagPrefix,
+		)
 	}
 }
 
@@ -204,6 +212,7 @@ type RunnableGRPCServer interface {
 	NetDialContext(ctx context.Context, s string) (net.Conn, error)
 	Insecure() bool
 	GracefulStop()
+	Stop()
 }
 
 type completedGRPCServer struct {
@@ -213,6 +222,7 @@ type completedGRPCServer struct {
 	listenFunc        func() error
 	prestopFunc       func()
 	stopFunc          func()
+	forceStopFunc     func()
 	dial              func(context.Context, ...grpc.DialOption) (*grpc.ClientConn, error)
 	netDial           func(ctx context.Context, s string) (net.Conn, error)
 	creds             credentials.TransportCredentials
@@ -228,6 +238,7 @@ func (c *completedGRPCServer) WithOpts(opts ...grpc.ServerOption) RunnableGRPCSe
 		return srv.Serve(c.listener)
 	}
 	c.stopFunc = srv.GracefulStop
+	c.forceStopFunc = srv.Stop
 	return c
 }
 
@@ -265,6 +276,12 @@ func (c *completedGRPCServer) GracefulStop() {
 	c.stopFunc()
 }
 
+// Stop stops a running server immediately, closing all connections and canceling all RPCs in
+// flight, including those waited on by a concurrent GracefulStop
+func (c *completedGRPCServer) Stop() {
+	c.forceStopFunc()
+}
+
 type disabledGrpcServer struct{}
 
 // WithOpts adds to the options for running the server
@@ -297,12 +314,23 @@ func (d *disabledGrpcServer) NetDialContext(_ context.Context, _ string) (net.Co
 // GracefulStop stops a running server
 func (d *disabledGrpcServer) GracefulStop() {}
 
+// Stop stops a running server immediately
+func (d *disabledGrpcServer) Stop() {}
+
 type HTTPServerConfig struct {
 	HTTPAddress     string `debugmap:"visible"`
 	HTTPTLSCertPath string `debugmap:"visible"`
 	HTTPTLSKeyPath  string `debugmap:"visible"`
 	HTTPEnabled     bool   `debugmap:"visible"`
 
+	// HTTPReadTimeout is the maximum duration for reading an entire request, including its body.
+	// Zero means no timeout.
+	HTTPReadTimeout time.Duration `debugmap:"visible"`
+
+	// HTTPWriteTimeout is the maximum duration before timing out the writing of a response,
+	// including streamed responses. Zero means no timeout.
+	HTTPWriteTimeout time.Duration `debugmap:"visible"`
+
 	flagPrefix string
 }
 
@@ -314,6 +342,8 @@ func (c *HTTPServerConfig) Complete(level zerolog.Level, handler http.Handler) (
 		Addr:              c.HTTPAddress,
 		Handler:           handler,
 		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       c.HTTPReadTimeout,
+		WriteTimeout:      c.HTTPWriteTimeout,
 	}
 	var serveFunc func() error
 	switch {
@@ -411,6 +441,19 @@ func RegisterHTTPServerFlags(flags *pflag.FlagSet, config *HTTPServerConfig, fla
 	flags.BoolVar(&config.HTTPEnabled, flagPrefix+"-enabled", defaultEnabled, "enable http "+serviceName+" server")
 }
 
+// RegisterHTTPServerTimeoutFlags adds the following flags, bounding the reading of requests and
+// writing of responses of an HTTP server, for use with HTTPServerConfig:
+// - "$PREFIX-read-timeout"
+// - "$PREFIX-write-timeout"
+// Both default to no timeout.
+func RegisterHTTPServerTimeoutFlags(flags *pflag.FlagSet, config *HTTPServerConfig, flagPrefix, serviceName string) {
+	flagPrefix = stringz.DefaultEmpty(flagPrefix, "http")
+	serviceName = stringz.DefaultEmpty(serviceName, "http")
+
+	flags.DurationVar(&config.HTTPReadTimeout, flagPrefix+"-read-timeout", 0, "maximum duration for reading an entire request to the "+serviceName+", including its body (0 for no timeout)")
+	flags.DurationVar(&config.HTTPWriteTimeout, flagPrefix+"-write-timeout", 0, "maximum duration for writing a response of the "+serviceName+", including streamed responses (0 for no timeout)")
+}
+
 // RegisterDeprecatedHTTPServerFlags registers a set of HTTP server flags as fully deprecated, for a removed HTTP service.
 func RegisterDeprecatedHTTPServerFlags(cmd *cobra.Command, flagPrefix, serviceName string) error {
 	ignored1 := ""
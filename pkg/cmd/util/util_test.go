@@ -16,6 +16,14 @@ func TestDisabledGRPC(t *testing.T) {
 	s.GracefulStop()
 }
 
+func TestGRPCRequiresTLSKeyPair(t *testing.T) {
+	_, err := (&GRPCServerConfig{Enabled: true, Network: "tcp", Address: "localhost:0", TLSCertPath: "cert.pem", flagPrefix: "grpc"}).Complete(zerolog.InfoLevel, nil)
+	require.EqualError(t, err, "failed to start gRPC server: must provide both --grpc-tls-cert-path and --grpc-tls-key-path")
+
+	_, err = (&GRPCServerConfig{Enabled: true, Network: "tcp", Address: "localhost:0", TLSKeyPath: "key.pem", flagPrefix: "grpc"}).Complete(zerolog.InfoLevel, nil)
+	require.EqualError(t, err, "failed to start gRPC server: must provide both --grpc-tls-cert-path and --grpc-tls-key-path")
+}
+
 func TestDisabledHTTP(t *testing.T) {
 	s, err := (&HTTPServerConfig{HTTPEnabled: false}).Complete(zerolog.InfoLevel, nil)
 	require.NoError(t, err)
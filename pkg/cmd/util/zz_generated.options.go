@@ -36,6 +36,7 @@ func (g *GRPCServerConfig) ToOption() GRPCServerConfigOption {
 		to.TLSCertPath = g.TLSCertPath
 		to.TLSKeyPath = g.TLSKeyPath
 		to.MaxConnAge = g.MaxConnAge
+		to.MaxConnIdle = g.MaxConnIdle
 		to.Enabled = g.Enabled
 		to.BufferSize = g.BufferSize
 		to.ClientCAPath = g.ClientCAPath
@@ -52,6 +53,7 @@ func (g GRPCServerConfig) DebugMap() map[string]any {
 	debugMap["TLSCertPath"] = helpers.DebugValue(g.TLSCertPath, false)
 	debugMap["TLSKeyPath"] = helpers.DebugValue(g.TLSKeyPath, false)
 	debugMap["MaxConnAge"] = helpers.DebugValue(g.MaxConnAge, false)
+	debugMap["MaxConnIdle"] = helpers.DebugValue(g.MaxConnIdle, false)
 	debugMap["Enabled"] = helpers.DebugValue(g.Enabled, false)
 	debugMap["BufferSize"] = helpers.DebugValue(g.BufferSize, false)
 	debugMap["ClientCAPath"] = helpers.DebugValue(g.ClientCAPath, false)
@@ -110,6 +112,13 @@ func WithMaxConnAge(maxConnAge time.Duration) GRPCServerConfigOption {
 	}
 }
 
+// WithMaxConnIdle returns an option that can set MaxConnIdle on a GRPCServerConfig
+func WithMaxConnIdle(maxConnIdle time.Duration) GRPCServerConfigOption {
+	return func(g *GRPCServerConfig) {
+		g.MaxConnIdle = maxConnIdle
+	}
+}
+
 // WithEnabled returns an option that can set Enabled on a GRPCServerConfig
 func WithEnabled(enabled bool) GRPCServerConfigOption {
 	return func(g *GRPCServerConfig) {
@@ -166,6 +175,8 @@ func (h *HTTPServerConfig) ToOption() HTTPServerConfigOption {
 		to.HTTPTLSCertPath = h.HTTPTLSCertPath
 		to.HTTPTLSKeyPath = h.HTTPTLSKeyPath
 		to.HTTPEnabled = h.HTTPEnabled
+		to.HTTPReadTimeout = h.HTTPReadTimeout
+		to.HTTPWriteTimeout = h.HTTPWriteTimeout
 		to.flagPrefix = h.flagPrefix
 	}
 }
@@ -177,6 +188,8 @@ func (h HTTPServerConfig) DebugMap() map[string]any {
 	debugMap["HTTPTLSCertPath"] = helpers.DebugValue(h.HTTPTLSCertPath, false)
 	debugMap["HTTPTLSKeyPath"] = helpers.DebugValue(h.HTTPTLSKeyPath, false)
 	debugMap["HTTPEnabled"] = helpers.DebugValue(h.HTTPEnabled, false)
+	debugMap["HTTPReadTimeout"] = helpers.DebugValue(h.HTTPReadTimeout, false)
+	debugMap["HTTPWriteTimeout"] = helpers.DebugValue(h.HTTPWriteTimeout, false)
 	return debugMap
 }
 
@@ -223,3 +236,17 @@ func WithHTTPEnabled(hTTPEnabled bool) HTTPServerConfigOption {
 		h.HTTPEnabled = hTTPEnabled
 	}
 }
+
+// WithHTTPReadTimeout returns an option that can set HTTPReadTimeout on a HTTPServerConfig
+func WithHTTPReadTimeout(hTTPReadTimeout time.Duration) HTTPServerConfigOption {
+	return func(h *HTTPServerConfig) {
+		h.HTTPReadTimeout = hTTPReadTimeout
+	}
+}
+
+// WithHTTPWriteTimeout returns an option that can set HTTPWriteTimeout on a HTTPServerConfig
+func WithHTTPWriteTimeout(hTTPWriteTimeout time.Duration) HTTPServerConfigOption {
+	return func(h *HTTPServerConfig) {
+		h.HTTPWriteTimeout = hTTPWriteTimeout
+	}
+}
@@ -102,7 +102,7 @@ func DecodeToDispatchCursor(encoded *v1.Cursor, callAndParameterHash string) (*d
 
 // DecodeToDispatchRevision decodes an encoded API cursor into an internal dispatch revision.
 // NOTE: this method does *not* verify the caller's method signature.
-func DecodeToDispatchRevision(encoded *v1.Cursor, ds revisionDecoder) (datastore.Revision, error) {
+func DecodeToDispatchRevision(encoded *v1.Cursor, ds datastore.RevisionDecoder) (datastore.Revision, error) {
 	decoded, err := Decode(encoded)
 	if err != nil {
 		return nil, err
@@ -120,7 +120,3 @@ func DecodeToDispatchRevision(encoded *v1.Cursor, ds revisionDecoder) (datastore
 
 	return parsed, nil
 }
-
-type revisionDecoder interface {
-	RevisionFromString(string) (datastore.Revision, error)
-}
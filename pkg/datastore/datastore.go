@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/authzed/spicedb/pkg/tuple"
 
@@ -289,6 +290,15 @@ type BulkWriteRelationshipSource interface {
 	Next(ctx context.Context) (*core.RelationTuple, error)
 }
 
+// RevisionDecoder is implemented by each datastore to parse the serialized form of its own
+// revisions. Together with Revision.String, it allows revisions to be carried opaquely in
+// ZedTokens and cursors without the service layer knowing their representation.
+type RevisionDecoder interface {
+	// RevisionFromString will parse the revision text and return the specific type of Revision
+	// used by the specific datastore implementation.
+	RevisionFromString(serialized string) (Revision, error)
+}
+
 // Datastore represents tuple access for a single namespace.
 type Datastore interface {
 	// SnapshotReader creates a read-only handle that reads the datastore at the specified revision.
@@ -311,9 +321,7 @@ type Datastore interface {
 	// hasn't been garbage collected.
 	CheckRevision(ctx context.Context, revision Revision) error
 
-	// RevisionFromString will parse the revision text and return the specific type of Revision
-	// used by the specific datastore implementation.
-	RevisionFromString(serialized string) (Revision, error)
+	RevisionDecoder
 
 	// Watch notifies the caller about all changes to tuples.
 	//
@@ -343,6 +351,24 @@ type UnwrappableDatastore interface {
 	Unwrap() Datastore
 }
 
+// RevisionTimestamper is an optional interface implemented by datastores whose revisions
+// can be mapped to the wall-clock time at which they were created.
+type RevisionTimestamper interface {
+	// RevisionTimestamp returns the wall-clock time at which the revision was created.
+	RevisionTimestamp(revision Revision) (time.Time, error)
+}
+
+// NamespaceWatermarker is an optional interface implemented by datastores which track,
+// for each namespace, the latest revision at which its definition or any of its
+// relationships changed.
+type NamespaceWatermarker interface {
+	// NamespaceWatermark returns the latest revision at which the definition of the
+	// namespace with the given name, or any relationship whose resource is of that
+	// namespace, changed. If no change has been observed for the namespace, the
+	// revision at which the datastore began tracking changes is returned.
+	NamespaceWatermark(ctx context.Context, name string) (Revision, error)
+}
+
 // Feature represents a capability that a datastore can support, plus an
 // optional message explaining the feature is available (or not).
 type Feature struct {
@@ -378,6 +404,12 @@ type Stats struct {
 	// ObjectTypeStatistics returns a slice element for each object type (namespace)
 	// stored in the datastore.
 	ObjectTypeStatistics []ObjectTypeStat
+
+	// RelationshipCountByObjectType is the exact number of relationships of each object type
+	// (namespace) defined in the datastore, keyed by its name. Counting them may require scanning
+	// all relationships, so it is only computed by datastores small enough for that to be cheap,
+	// such as memdb, and is nil otherwise.
+	RelationshipCountByObjectType map[string]uint64
 }
 
 // RelationshipIterator is an iterator over matched tuples.
@@ -1,5 +1,12 @@
 package datastore
 
+import (
+	"context"
+	"sort"
+
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+)
+
 // DefinitionsOf returns just the schema definitions found in the list of revisioned
 // definitions.
 func DefinitionsOf[T SchemaDefinition](revisionedDefinitions []RevisionedDefinition[T]) []T {
@@ -9,3 +16,123 @@ func DefinitionsOf[T SchemaDefinition](revisionedDefinitions []RevisionedDefinit
 	}
 	return definitions
 }
+
+// HeadState is the schema found in a datastore at its head revision, along with the means to
+// walk the relationships found at that same revision.
+type HeadState struct {
+	// Revision is the head revision at which the state was read.
+	Revision Revision
+
+	// Caveats are the caveats found at the revision, sorted by name.
+	Caveats []RevisionedCaveat
+
+	// Namespaces are the namespaces found at the revision, sorted by name.
+	Namespaces []RevisionedNamespace
+
+	reader Reader
+}
+
+// ReadHeadState reads the caveats and namespaces found in the given datastore at its current
+// head revision.
+func ReadHeadState(ctx context.Context, ds Datastore) (*HeadState, error) {
+	headRevision, err := ds.HeadRevision(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := ds.SnapshotReader(headRevision)
+	caveats, err := reader.ListAllCaveats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(caveats, func(i, j int) bool {
+		return caveats[i].Definition.Name < caveats[j].Definition.Name
+	})
+
+	namespaces, err := reader.ListAllNamespaces(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(namespaces, func(i, j int) bool {
+		return namespaces[i].Definition.Name < namespaces[j].Definition.Name
+	})
+
+	return &HeadState{
+		Revision:   headRevision,
+		Caveats:    caveats,
+		Namespaces: namespaces,
+		reader:     reader,
+	}, nil
+}
+
+// ForEachRelationship invokes fn with each relationship found at the head revision, namespace
+// by namespace, stopping at and returning the first error. The relationship is only valid for
+// the duration of the call, so callers that keep it must clone it.
+func (hs *HeadState) ForEachRelationship(ctx context.Context, fn func(tpl *core.RelationTuple) error) error {
+	for _, nsDef := range hs.Namespaces {
+		iter, err := hs.reader.QueryRelationships(ctx, RelationshipsFilter{
+			ResourceType: nsDef.Definition.Name,
+		})
+		if err != nil {
+			return err
+		}
+
+		for tpl := iter.Next(); tpl != nil; tpl = iter.Next() {
+			if err := fn(tpl); err != nil {
+				iter.Close()
+				return err
+			}
+		}
+		if err := iter.Err(); err != nil {
+			iter.Close()
+			return err
+		}
+		iter.Close()
+	}
+	return nil
+}
+
+// UnwrapAs recursively unwraps the datastore until it finds a datastore implementing
+// the requested type, returning that datastore and true if found.
+func UnwrapAs[T any](ds Datastore) (T, bool) {
+	for {
+		if found, ok := ds.(T); ok {
+			return found, true
+		}
+
+		wrapped, ok := ds.(UnwrappableDatastore)
+		if !ok {
+			var empty T
+			return empty, false
+		}
+		ds = wrapped.Unwrap()
+	}
+}
+
+// ListNamespacesPage returns up to pageSize of the namespaces found in the reader, ordered by name
+// and starting after the namespace named afterName, if given. The returned cursor is the name of
+// the last namespace returned, to be passed as afterName for the next page, and is empty once
+// all namespaces have been returned. Since the reader is fixed at a single revision, all pages
+// read from the same reader are consistent with one another. A pageSize of zero returns all
+// remaining namespaces.
+func ListNamespacesPage(ctx context.Context, reader Reader, pageSize uint32, afterName string) ([]RevisionedNamespace, string, error) {
+	namespaces, err := reader.ListAllNamespaces(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	sort.Slice(namespaces, func(i, j int) bool {
+		return namespaces[i].Definition.Name < namespaces[j].Definition.Name
+	})
+
+	start := sort.Search(len(namespaces), func(i int) bool {
+		return namespaces[i].Definition.Name > afterName
+	})
+	remaining := namespaces[start:]
+	if pageSize == 0 || uint32(len(remaining)) <= pageSize {
+		return remaining, "", nil
+	}
+
+	page := remaining[:pageSize]
+	return page, page[len(page)-1].Definition.Name, nil
+}
@@ -0,0 +1,66 @@
+package datastore_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/internal/datastore/memdb"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+)
+
+func TestListNamespacesPage(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	ds, err := memdb.NewMemdbDatastore(0, 0, 0)
+	require.NoError(err)
+	defer ds.Close()
+
+	revision, err := ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		return rwt.WriteNamespaces(ctx,
+			&core.NamespaceDefinition{Name: "user"},
+			&core.NamespaceDefinition{Name: "document"},
+			&core.NamespaceDefinition{Name: "folder"},
+			&core.NamespaceDefinition{Name: "organization"},
+			&core.NamespaceDefinition{Name: "team"},
+		)
+	})
+	require.NoError(err)
+
+	reader := ds.SnapshotReader(revision)
+
+	var names []string
+	var pages int
+	cursor := ""
+	for {
+		page, nextCursor, err := datastore.ListNamespacesPage(ctx, reader, 2, cursor)
+		require.NoError(err)
+		require.LessOrEqual(len(page), 2)
+		pages++
+
+		for _, ns := range page {
+			names = append(names, ns.Definition.Name)
+		}
+
+		if nextCursor == "" {
+			break
+		}
+		cursor = nextCursor
+	}
+
+	require.Equal([]string{"document", "folder", "organization", "team", "user"}, names)
+	require.Equal(3, pages)
+
+	all, cursor, err := datastore.ListNamespacesPage(ctx, reader, 0, "")
+	require.NoError(err)
+	require.Len(all, 5)
+	require.Empty(cursor)
+
+	exact, cursor, err := datastore.ListNamespacesPage(ctx, reader, 5, "")
+	require.NoError(err)
+	require.Len(exact, 5)
+	require.Empty(cursor)
+}
@@ -2,9 +2,12 @@ package development
 
 import (
 	"fmt"
+	"sort"
 
 	v1t "github.com/authzed/authzed-go/proto/authzed/api/v1"
 
+	"github.com/authzed/spicedb/internal/developmentmembership"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
 	devinterface "github.com/authzed/spicedb/pkg/proto/developer/v1"
 	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
 	"github.com/authzed/spicedb/pkg/tuple"
@@ -31,11 +34,126 @@ func RunAllAssertions(devContext *DevContext, assertions *blocks.Assertions) ([]
 		return nil, err
 	}
 
+	subjectsFailures, err := runSubjectsAssertions(devContext, assertions.AssertSubjects)
+	if err != nil {
+		return nil, err
+	}
+
 	failures := append(trueFailures, caveatedFailures...)
 	failures = append(failures, falseFailures...)
+	failures = append(failures, subjectsFailures...)
 	return failures, nil
 }
 
+// runSubjectsAssertions expands each of the resources and permissions found in the subjects
+// assertions, and reports any subjects expected but not found, as well as any found but not
+// expected. Exclusions from wildcards are not compared.
+func runSubjectsAssertions(devContext *DevContext, assertions blocks.SubjectsAssertions) ([]*devinterface.DeveloperError, error) {
+	var failures []*devinterface.DeveloperError
+
+	for _, assertion := range assertions {
+		line := uint32(assertion.SourcePosition.LineNumber)
+		column := uint32(assertion.SourcePosition.ColumnPosition)
+
+		foundSubjects, err := expandSubjects(devContext, assertion.ResourceAndRelation)
+		if err != nil {
+			devErr, wireErr := DistinguishGraphError(
+				devContext,
+				err,
+				devinterface.DeveloperError_ASSERTION,
+				line,
+				column,
+				assertion.ResourceAndRelationString,
+			)
+			if wireErr != nil {
+				return nil, wireErr
+			}
+			if devErr != nil {
+				failures = append(failures, devErr)
+			}
+			continue
+		}
+
+		failures = append(failures, compareAssertedSubjects(assertion, foundSubjects, line, column)...)
+	}
+
+	return failures, nil
+}
+
+// expandSubjects runs a full recursive expansion over the ONR and returns the terminal subjects found.
+func expandSubjects(devContext *DevContext, onr *core.ObjectAndRelation) (developmentmembership.FoundSubjects, error) {
+	er, err := devContext.Dispatcher.DispatchExpand(devContext.Ctx, &v1.DispatchExpandRequest{
+		ResourceAndRelation: onr,
+		Metadata: &v1.ResolverMeta{
+			AtRevision:     devContext.Revision.String(),
+			DepthRemaining: maxDispatchDepth,
+		},
+		ExpansionMode: v1.DispatchExpandRequest_RECURSIVE,
+	})
+	if err != nil {
+		return developmentmembership.FoundSubjects{}, err
+	}
+
+	foundSubjects, _, err := developmentmembership.NewMembershipSet().AddExpansion(onr, er.TreeNode)
+	return foundSubjects, err
+}
+
+func compareAssertedSubjects(assertion blocks.SubjectsAssertion, foundSubjects developmentmembership.FoundSubjects, line uint32, column uint32) []*devinterface.DeveloperError {
+	found := make(map[string]struct{}, len(foundSubjects.ListFound()))
+	for _, foundSubject := range foundSubjects.ListFound() {
+		found[subjectAssertionString(foundSubject.Subject(), foundSubject.GetCaveatExpression() != nil)] = struct{}{}
+	}
+
+	expected := make(map[string]struct{}, len(assertion.ExpectedSubjects))
+	for _, expectedSubject := range assertion.ExpectedSubjects {
+		expected[subjectAssertionString(expectedSubject.Subject, expectedSubject.IsCaveated)] = struct{}{}
+	}
+
+	var failures []*devinterface.DeveloperError
+	for _, missing := range sortedDifference(expected, found) {
+		failures = append(failures, &devinterface.DeveloperError{
+			Message: fmt.Sprintf("Expected subject `%s` for %s, but it was not found", missing, assertion.ResourceAndRelationString),
+			Source:  devinterface.DeveloperError_ASSERTION,
+			Kind:    devinterface.DeveloperError_ASSERTION_FAILED,
+			Context: assertion.ResourceAndRelationString,
+			Line:    line,
+			Column:  column,
+		})
+	}
+
+	for _, unexpected := range sortedDifference(found, expected) {
+		failures = append(failures, &devinterface.DeveloperError{
+			Message: fmt.Sprintf("Found unexpected subject `%s` for %s", unexpected, assertion.ResourceAndRelationString),
+			Source:  devinterface.DeveloperError_ASSERTION,
+			Kind:    devinterface.DeveloperError_ASSERTION_FAILED,
+			Context: assertion.ResourceAndRelationString,
+			Line:    line,
+			Column:  column,
+		})
+	}
+
+	return failures
+}
+
+func subjectAssertionString(subject *core.ObjectAndRelation, isCaveated bool) string {
+	if isCaveated {
+		return tuple.StringONR(subject) + "[...]"
+	}
+	return tuple.StringONR(subject)
+}
+
+// sortedDifference returns the sorted keys found in the first set but not the second.
+func sortedDifference(first map[string]struct{}, second map[string]struct{}) []string {
+	var difference []string
+	for key := range first {
+		if _, ok := second[key]; !ok {
+			difference = append(difference, key)
+		}
+	}
+	sort.Strings(difference)
+	return difference
+}
+
 func runAssertions(devContext *DevContext, assertions []blocks.Assertion, expected v1.ResourceCheckResult_Membership, fmtString string) ([]*devinterface.DeveloperError, error) {
 	var failures []*devinterface.DeveloperError
 
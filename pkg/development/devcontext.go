@@ -23,6 +23,7 @@ import (
 	datastoremw "github.com/authzed/spicedb/internal/middleware/datastore"
 	"github.com/authzed/spicedb/internal/namespace"
 	"github.com/authzed/spicedb/internal/relationships"
+	"github.com/authzed/spicedb/internal/services/shared"
 	v1svc "github.com/authzed/spicedb/internal/services/v1"
 	"github.com/authzed/spicedb/internal/sharederrors"
 	"github.com/authzed/spicedb/pkg/datastore"
@@ -136,7 +137,7 @@ func (dc *DevContext) RunV1InMemoryService() (*grpc.ClientConn, func(), error) {
 		MaximumAPIDepth:       50,
 		MaxCaveatContextSize:  0,
 	})
-	ss := v1svc.NewSchemaServer(false)
+	ss := v1svc.NewSchemaServer(false, v1svc.SchemaServerConfig{OrphanPolicy: shared.OrphanedRelationshipsStrict})
 
 	v1.RegisterPermissionsServiceServer(s, ps)
 	v1.RegisterSchemaServiceServer(s, ss)
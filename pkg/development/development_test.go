@@ -46,6 +46,47 @@ definition document {
 	require.Nil(t, adErrs)
 }
 
+func TestDevelopmentSubjectsAssertions(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreTopFunction("github.com/golang/glog.(*loggingT).flushDaemon"), goleak.IgnoreCurrent())
+
+	devCtx, devErrs, err := NewDevContext(context.Background(), &devinterface.RequestContext{
+		Schema: `definition user {}
+
+definition document {
+	relation viewer: user
+	relation editor: user
+	permission view = viewer + editor
+}
+`,
+		Relationships: []*core.RelationTuple{
+			tuple.MustParse("document:somedoc#viewer@user:someuser"),
+			tuple.MustParse("document:somedoc#editor@user:anotheruser"),
+		},
+	})
+
+	require.Nil(t, err)
+	require.Nil(t, devErrs)
+
+	assertions, devErr := ParseAssertionsYAML(`assertSubjects:
+  document:somedoc#view:
+  - user:someuser
+  - user:missinguser
+  document:somedoc#editor:
+  - user:anotheruser`)
+	require.Nil(t, devErr)
+
+	adErrs, err := RunAllAssertions(devCtx, assertions)
+	require.NoError(t, err)
+	require.Len(t, adErrs, 2)
+
+	require.Equal(t, "Expected subject `user:missinguser` for document:somedoc#view, but it was not found", adErrs[0].Message)
+	require.Equal(t, devinterface.DeveloperError_ASSERTION_FAILED, adErrs[0].Kind)
+	require.Equal(t, uint32(2), adErrs[0].Line)
+
+	require.Equal(t, "Found unexpected subject `user:anotheruser` for document:somedoc#view", adErrs[1].Message)
+	require.Equal(t, devinterface.DeveloperError_ASSERTION_FAILED, adErrs[1].Kind)
+}
+
 func TestDevelopmentInvalidRelationship(t *testing.T) {
 	defer goleak.VerifyNone(t, goleak.IgnoreTopFunction("github.com/golang/glog.(*loggingT).flushDaemon"), goleak.IgnoreCurrent())
 
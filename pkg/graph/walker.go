@@ -43,6 +43,44 @@ func HasThis(rewrite *core.UsersetRewrite) (bool, error) {
 	return result != nil && result.(bool), err
 }
 
+// HasEmptySetOperation returns true if the given rewrite, or any rewrite nested within it, is a
+// set operation without any children. If the rewrite is nil, returns false.
+func HasEmptySetOperation(rewrite *core.UsersetRewrite) (bool, error) {
+	if rewrite == nil {
+		return false, nil
+	}
+
+	if isEmptySetOperation(rewrite) {
+		return true, nil
+	}
+
+	result, err := WalkRewrite(rewrite, func(childOneof *core.SetOperation_Child) interface{} {
+		switch child := childOneof.ChildType.(type) {
+		case *core.SetOperation_Child_UsersetRewrite:
+			if isEmptySetOperation(child.UsersetRewrite) {
+				return true
+			}
+			return nil
+		default:
+			return nil
+		}
+	})
+	return result != nil && result.(bool), err
+}
+
+func isEmptySetOperation(rewrite *core.UsersetRewrite) bool {
+	switch rw := rewrite.RewriteOperation.(type) {
+	case *core.UsersetRewrite_Union:
+		return len(rw.Union.GetChild()) == 0
+	case *core.UsersetRewrite_Intersection:
+		return len(rw.Intersection.GetChild()) == 0
+	case *core.UsersetRewrite_Exclusion:
+		return len(rw.Exclusion.GetChild()) == 0
+	default:
+		return false
+	}
+}
+
 func walkRewriteChildren(so *core.SetOperation, handler WalkHandler) (interface{}, error) {
 	for _, childOneof := range so.Child {
 		vle := handler(childOneof)
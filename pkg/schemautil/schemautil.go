@@ -5,6 +5,7 @@ import (
 
 	"github.com/authzed/spicedb/pkg/datastore"
 
+	"github.com/authzed/spicedb/internal/namespace"
 	"github.com/authzed/spicedb/internal/services/shared"
 	core "github.com/authzed/spicedb/pkg/proto/core/v1"
 	"github.com/authzed/spicedb/pkg/schemadsl/compiler"
@@ -13,7 +14,7 @@ import (
 // ValidateSchemaChanges validates the schema found in the compiled schema and returns a
 // ValidatedSchemaChanges, if fully validated.
 func ValidateSchemaChanges(ctx context.Context, compiled *compiler.CompiledSchema, isAdditiveOnly bool) (*shared.ValidatedSchemaChanges, error) {
-	return shared.ValidateSchemaChanges(ctx, compiled, isAdditiveOnly)
+	return shared.ValidateSchemaChanges(ctx, compiled, isAdditiveOnly, shared.OrphanedRelationshipsStrict)
 }
 
 // ApplySchemaChanges applies schema changes found in the validated changes struct, via the specified
@@ -32,3 +33,53 @@ func ApplySchemaChanges(
 	}
 	return result, nil, nil
 }
+
+// WriteSchemaAndRelationships writes the schema found in the compiled schema, replacing the
+// existing schema, and creates the given relationships in a single transaction, returning the
+// revision at which both were written. The relationships are validated against the compiled
+// schema, so a definition and its initial relationships can be written without a revision at
+// which the definition exists but has no relationships.
+func WriteSchemaAndRelationships(
+	ctx context.Context,
+	ds datastore.Datastore,
+	compiled *compiler.CompiledSchema,
+	rels []*core.RelationTuple,
+) (datastore.Revision, error) {
+	validated, err := shared.ValidateSchemaChanges(ctx, compiled, false, shared.OrphanedRelationshipsStrict)
+	if err != nil {
+		return datastore.NoRevision, err
+	}
+
+	return ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		_, err := shared.ApplySchemaChangesWithRelationships(ctx, rwt, validated, rels)
+		return err
+	})
+}
+
+// DependentPermissions returns the permissions in the compiled schema whose computation
+// transitively includes the given relation or permission, i.e. those affected by granting it.
+func DependentPermissions(compiled *compiler.CompiledSchema, namespaceName string, relationName string) ([]*core.RelationReference, error) {
+	return namespace.DependentPermissions(compiled.ObjectDefinitions, &core.RelationReference{
+		Namespace: namespaceName,
+		Relation:  relationName,
+	})
+}
+
+// SchemaFingerprint returns the fingerprint of the compiled schema, as reported by ReadSchema.
+// Clients can send it back to the server to determine whether the server's current schema is
+// compatible with the one they were built against.
+func SchemaFingerprint(compiled *compiler.CompiledSchema) (string, error) {
+	return shared.ComputeSchemaFingerprint(compiled.ObjectDefinitions, compiled.CaveatDefinitions)
+}
+
+// ReflectPermissions returns each permission of the named definition in the compiled schema, along
+// with its rewrite expression as a tree of unions, intersections, exclusions, arrows and relation
+// references, for rendering or analyzing the permission logic.
+func ReflectPermissions(compiled *compiler.CompiledSchema, namespaceName string) ([]namespace.ReflectedPermission, error) {
+	for _, def := range compiled.ObjectDefinitions {
+		if def.Name == namespaceName {
+			return namespace.ReflectPermissions(def)
+		}
+	}
+	return nil, namespace.NewNamespaceNotFoundErr(namespaceName)
+}
@@ -8,6 +8,7 @@ import (
 	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
 	yamlv3 "gopkg.in/yaml.v3"
 
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
 	"github.com/authzed/spicedb/pkg/spiceerrors"
 	"github.com/authzed/spicedb/pkg/tuple"
 )
@@ -23,6 +24,10 @@ type Assertions struct {
 	// AssertFalse is the set of relationships to assert false.
 	AssertFalse []Assertion `yaml:"assertFalse"`
 
+	// AssertSubjects is the set of resources and permissions for which the exact set of
+	// subjects found is asserted.
+	AssertSubjects SubjectsAssertions `yaml:"assertSubjects"`
+
 	// SourcePosition is the position of the assertions in the file.
 	SourcePosition spiceerrors.SourcePosition
 }
@@ -55,6 +60,10 @@ type internalAssertions struct {
 
 	// AssertFalse is the set of relationships to assert false.
 	AssertFalse []Assertion `yaml:"assertFalse"`
+
+	// AssertSubjects is the set of resources and permissions for which the exact set of
+	// subjects found is asserted.
+	AssertSubjects SubjectsAssertions `yaml:"assertSubjects"`
 }
 
 // UnmarshalYAML is a custom unmarshaller.
@@ -67,6 +76,7 @@ func (a *Assertions) UnmarshalYAML(node *yamlv3.Node) error {
 	a.AssertTrue = ia.AssertTrue
 	a.AssertFalse = ia.AssertFalse
 	a.AssertCaveated = ia.AssertCaveated
+	a.AssertSubjects = ia.AssertSubjects
 	a.SourcePosition = spiceerrors.SourcePosition{LineNumber: node.Line, ColumnPosition: node.Column}
 	return nil
 }
@@ -124,6 +134,100 @@ func (a *Assertion) UnmarshalYAML(node *yamlv3.Node) error {
 	return nil
 }
 
+// SubjectsAssertions is the set of subjects assertions defined in the validation file, in the
+// order in which they were defined.
+type SubjectsAssertions []SubjectsAssertion
+
+// SubjectsAssertion is a parsed assertion of the exact set of subjects found for a resource and
+// permission or relation.
+type SubjectsAssertion struct {
+	// ResourceAndRelationString is the string form of the resource and permission or relation.
+	// Form: `document:firstdoc#view`
+	ResourceAndRelationString string
+
+	// ResourceAndRelation is the parsed resource and permission or relation.
+	ResourceAndRelation *core.ObjectAndRelation
+
+	// ExpectedSubjects are the subjects expected to be found. Caveated subjects are
+	// specified with a `[...]` suffix, such as `user:tom[...]`.
+	ExpectedSubjects []SubjectAndCaveat
+
+	// SourcePosition is the position of the assertion in the file.
+	SourcePosition spiceerrors.SourcePosition
+}
+
+// UnmarshalYAML is a custom unmarshaller.
+func (sa *SubjectsAssertions) UnmarshalYAML(node *yamlv3.Node) error {
+	if node.Kind != yamlv3.MappingNode {
+		return spiceerrors.NewErrorWithSource(
+			fmt.Errorf("expected a map of resources to subjects for subjects assertions"),
+			node.Value,
+			uint64(node.Line),
+			uint64(node.Column),
+		)
+	}
+
+	assertions := make(SubjectsAssertions, 0, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, valueNode := node.Content[i], node.Content[i+1]
+
+		resourceAndRelationString := ""
+		if err := keyNode.Decode(&resourceAndRelationString); err != nil {
+			return convertYamlError(err)
+		}
+
+		trimmed := strings.TrimSpace(resourceAndRelationString)
+		onr := tuple.ParseONR(trimmed)
+		if onr == nil {
+			return spiceerrors.NewErrorWithSource(
+				fmt.Errorf("error parsing resource in subjects assertion `%s`", trimmed),
+				trimmed,
+				uint64(keyNode.Line),
+				uint64(keyNode.Column),
+			)
+		}
+
+		var subjectStrings []string
+		if err := valueNode.Decode(&subjectStrings); err != nil {
+			return convertYamlError(err)
+		}
+
+		expectedSubjects := make([]SubjectAndCaveat, 0, len(subjectStrings))
+		for index, subjectString := range subjectStrings {
+			subjectString = strings.TrimSpace(subjectString)
+
+			isCaveated := false
+			if strings.HasSuffix(subjectString, "[...]") {
+				subjectString = strings.TrimSuffix(subjectString, "[...]")
+				isCaveated = true
+			}
+
+			subjectONR := tuple.ParseSubjectONR(subjectString)
+			if subjectONR == nil {
+				subjectNode := valueNode.Content[index]
+				return spiceerrors.NewErrorWithSource(
+					fmt.Errorf("error parsing subject `%s` in subjects assertion `%s`", subjectString, trimmed),
+					subjectString,
+					uint64(subjectNode.Line),
+					uint64(subjectNode.Column),
+				)
+			}
+
+			expectedSubjects = append(expectedSubjects, SubjectAndCaveat{subjectONR, isCaveated})
+		}
+
+		assertions = append(assertions, SubjectsAssertion{
+			ResourceAndRelationString: resourceAndRelationString,
+			ResourceAndRelation:       onr,
+			ExpectedSubjects:          expectedSubjects,
+			SourcePosition:            spiceerrors.SourcePosition{LineNumber: keyNode.Line, ColumnPosition: keyNode.Column},
+		})
+	}
+
+	*sa = assertions
+	return nil
+}
+
 // ParseAssertionsBlock parses the given contents as an assertions block.
 func ParseAssertionsBlock(contents []byte) (*Assertions, error) {
 	a := internalAssertions{}
@@ -134,5 +238,6 @@ func ParseAssertionsBlock(contents []byte) (*Assertions, error) {
 		AssertTrue:     a.AssertTrue,
 		AssertCaveated: a.AssertCaveated,
 		AssertFalse:    a.AssertFalse,
+		AssertSubjects: a.AssertSubjects,
 	}, nil
 }
@@ -139,6 +139,52 @@ assertFalse: garbage
 				SourcePosition: spiceerrors.SourcePosition{LineNumber: 1, ColumnPosition: 1},
 			},
 		},
+		{
+			"with subjects assertion",
+			`assertSubjects:
+  document:foo#view:
+  - user:someone
+  - user:sometwo[...]
+  - team:first#member
+  document:bar#view: []`,
+			"",
+			Assertions{
+				AssertSubjects: SubjectsAssertions{
+					{
+						"document:foo#view",
+						tuple.ParseONR("document:foo#view"),
+						[]SubjectAndCaveat{
+							{tuple.ParseSubjectONR("user:someone"), false},
+							{tuple.ParseSubjectONR("user:sometwo"), true},
+							{tuple.ParseSubjectONR("team:first#member"), false},
+						},
+						spiceerrors.SourcePosition{LineNumber: 2, ColumnPosition: 3},
+					},
+					{
+						"document:bar#view",
+						tuple.ParseONR("document:bar#view"),
+						[]SubjectAndCaveat{},
+						spiceerrors.SourcePosition{LineNumber: 6, ColumnPosition: 3},
+					},
+				},
+				SourcePosition: spiceerrors.SourcePosition{LineNumber: 1, ColumnPosition: 1},
+			},
+		},
+		{
+			"with invalid subjects assertion resource",
+			`assertSubjects:
+  document:foo: []`,
+			"error parsing resource in subjects assertion",
+			Assertions{},
+		},
+		{
+			"with invalid subjects assertion subject",
+			`assertSubjects:
+  document:foo#view:
+  - not a subject`,
+			"error parsing subject `not a subject`",
+			Assertions{},
+		},
 	}
 
 	for _, tc := range tests {
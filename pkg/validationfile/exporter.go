@@ -0,0 +1,70 @@
+package validationfile
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/schemadsl/compiler"
+	"github.com/authzed/spicedb/pkg/schemadsl/generator"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+// exportedFile is the subset of the validation file format written by ExportToWriter.
+type exportedFile struct {
+	Schema        string `yaml:"schema"`
+	Relationships string `yaml:"relationships"`
+}
+
+// ExportToWriter writes the schema and relationships found in the given datastore at its head
+// revision to the writer, as a validation file which can be loaded with PopulateFromFiles.
+// Definitions and relationships are written sorted by name, so that the same state always
+// produces the same file.
+func ExportToWriter(ctx context.Context, ds datastore.Datastore, w io.Writer) error {
+	state, err := datastore.ReadHeadState(ctx, ds)
+	if err != nil {
+		return err
+	}
+
+	definitions := make([]compiler.SchemaDefinition, 0, len(state.Caveats)+len(state.Namespaces))
+	for _, caveatDef := range state.Caveats {
+		definitions = append(definitions, caveatDef.Definition)
+	}
+	for _, nsDef := range state.Namespaces {
+		definitions = append(definitions, nsDef.Definition)
+	}
+
+	schema, _, err := generator.GenerateSchema(definitions)
+	if err != nil {
+		return fmt.Errorf("error generating schema: %w", err)
+	}
+
+	var relationships []string
+	if err := state.ForEachRelationship(ctx, func(tpl *core.RelationTuple) error {
+		tplString, err := tuple.String(tpl)
+		if err != nil {
+			return err
+		}
+		relationships = append(relationships, tplString)
+		return nil
+	}); err != nil {
+		return err
+	}
+	sort.Strings(relationships)
+
+	encoder := yamlv3.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(exportedFile{
+		Schema:        schema,
+		Relationships: strings.Join(relationships, "\n"),
+	}); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
@@ -4,6 +4,9 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 
 	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
 
@@ -36,15 +39,43 @@ type PopulatedValidationFile struct {
 
 	// ParsedFiles are the underlying parsed validation files.
 	ParsedFiles []ValidationFile
+
+	// FilePaths are the paths of the ParsedFiles, in the same order.
+	FilePaths []string
+
+	// NamespaceSources maps the name of each namespace to the sorted paths of the files
+	// in which it was defined. A namespace with more than one source was defined in
+	// multiple files.
+	NamespaceSources map[string][]string
+}
+
+// ConflictingNamespaceSources returns the subset of NamespaceSources for namespaces
+// which were defined in more than one file.
+func (pvf *PopulatedValidationFile) ConflictingNamespaceSources() map[string][]string {
+	conflicts := make(map[string][]string)
+	for name, sources := range pvf.NamespaceSources {
+		if len(sources) > 1 {
+			conflicts[name] = sources
+		}
+	}
+	return conflicts
 }
 
 // PopulateFromFiles populates the given datastore with the namespaces and tuples found in
-// the validation file(s) specified.
+// the validation file(s) specified. Each file path may also be an http(s) URL, in which case
+// the file is fetched; a URL fragment of the form `sha256=<hex>` verifies the fetched contents
+// against the given checksum. Local paths may also be directories or glob patterns, which are
+// expanded as described in ExpandFilePaths.
 func PopulateFromFiles(ctx context.Context, ds datastore.Datastore, filePaths []string) (*PopulatedValidationFile, datastore.Revision, error) {
+	filePaths, err := ExpandFilePaths(filePaths)
+	if err != nil {
+		return nil, datastore.NoRevision, err
+	}
+
 	contents := map[string][]byte{}
 
 	for _, filePath := range filePaths {
-		fileContents, err := os.ReadFile(filePath)
+		fileContents, err := readFileContents(ctx, filePath)
 		if err != nil {
 			return nil, datastore.NoRevision, err
 		}
@@ -55,21 +86,127 @@ func PopulateFromFiles(ctx context.Context, ds datastore.Datastore, filePaths []
 	return PopulateFromFilesContents(ctx, ds, contents)
 }
 
+// PathKind is the kind of a validation file path, which determines how the files it refers to are
+// found.
+type PathKind int
+
+const (
+	// LocalPath is the path of a local file or directory.
+	LocalPath PathKind = iota
+
+	// RemotePath is an http(s) URL.
+	RemotePath
+
+	// PatternPath is a local glob pattern, as supported by filepath.Glob.
+	PatternPath
+)
+
+// KindOfPath returns the kind of the given validation file path.
+func KindOfPath(filePath string) PathKind {
+	switch {
+	case strings.HasPrefix(filePath, "http://") || strings.HasPrefix(filePath, "https://"):
+		return RemotePath
+	case strings.ContainsAny(filePath, "*?["):
+		return PatternPath
+	default:
+		return LocalPath
+	}
+}
+
+// ExpandFilePaths expands the directories and glob patterns found in the given validation file
+// paths into the files they refer to. A directory expands to the `.yaml` and `.yml` files found
+// directly within it, and a pattern, as supported by filepath.Glob, to the files it matches; in
+// both cases, the files are sorted by path. It is an error for either to match no file. Other
+// paths, including http(s) URLs, are returned as given.
+func ExpandFilePaths(filePaths []string) ([]string, error) {
+	expanded := make([]string, 0, len(filePaths))
+	for _, filePath := range filePaths {
+		switch KindOfPath(filePath) {
+		case RemotePath:
+			expanded = append(expanded, filePath)
+			continue
+
+		case PatternPath:
+			matches, err := filepath.Glob(filePath)
+			if err != nil {
+				return nil, fmt.Errorf("invalid config file pattern %s: %w", filePath, err)
+			}
+
+			files := make([]string, 0, len(matches))
+			for _, match := range matches {
+				if info, err := os.Stat(match); err == nil && info.IsDir() {
+					continue
+				}
+				files = append(files, match)
+			}
+			if len(files) == 0 {
+				return nil, fmt.Errorf("config file pattern %s matches no files", filePath)
+			}
+
+			sort.Strings(files)
+			expanded = append(expanded, files...)
+			continue
+		}
+
+		info, err := os.Stat(filePath)
+		if err != nil || !info.IsDir() {
+			// Missing files are reported when they are read.
+			expanded = append(expanded, filePath)
+			continue
+		}
+
+		entries, err := os.ReadDir(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("could not read config directory %s: %w", filePath, err)
+		}
+
+		found := 0
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+
+			if ext := filepath.Ext(entry.Name()); ext != ".yaml" && ext != ".yml" {
+				continue
+			}
+
+			// Entries are returned sorted by name.
+			expanded = append(expanded, filepath.Join(filePath, entry.Name()))
+			found++
+		}
+		if found == 0 {
+			return nil, fmt.Errorf("config directory %s contains no .yaml or .yml files", filePath)
+		}
+	}
+	return expanded, nil
+}
+
+// relationshipsChunkSize is the number of relationships written at a time when populating a
+// datastore.
+const relationshipsChunkSize = 500
+
 // PopulateFromFilesContents populates the given datastore with the namespaces and tuples found in
-// the validation file(s) contents specified.
+// the validation file(s) contents specified. The files are processed in the order of their paths.
 func PopulateFromFilesContents(ctx context.Context, ds datastore.Datastore, filesContents map[string][]byte) (*PopulatedValidationFile, datastore.Revision, error) {
 	var schema string
 	var objectDefs []*core.NamespaceDefinition
 	var caveatDefs []*core.CaveatDefinition
 	var tuples []*core.RelationTuple
-	var updates []*core.RelationTupleUpdate
+	namespaceSources := make(map[string][]string)
 
 	var revision datastore.Revision
 
 	files := make([]ValidationFile, 0, len(filesContents))
 
-	// Parse each file into definitions and relationship updates.
-	for filePath, fileContents := range filesContents {
+	filePaths := make([]string, 0, len(filesContents))
+	for filePath := range filesContents {
+		filePaths = append(filePaths, filePath)
+	}
+	sort.Strings(filePaths)
+
+	// Parse each file into definitions and relationships.
+	for _, filePath := range filePaths {
+		fileContents := filesContents[filePath]
 		// Decode the validation file.
 		parsed, err := DecodeValidationFile(fileContents)
 		if err != nil {
@@ -96,18 +233,25 @@ func PopulateFromFilesContents(ctx context.Context, ds datastore.Datastore, file
 
 			log.Ctx(ctx).Info().Str("filePath", filePath).Int("schemaDefinitionCount", len(parsed.Schema.CompiledSchema.OrderedDefinitions)).Msg("adding schema definitions")
 			objectDefs = append(objectDefs, defs...)
+			for _, def := range defs {
+				namespaceSources[def.Name] = append(namespaceSources[def.Name], filePath)
+			}
 			caveatDefs = append(caveatDefs, parsed.Schema.CompiledSchema.CaveatDefinitions...)
 		}
 
-		// Parse relationships for updates.
+		// Parse relationships.
 		for _, rel := range parsed.Relationships.Relationships {
 			tpl := tuple.MustFromRelationship[*v1.ObjectReference, *v1.SubjectReference, *v1.ContextualizedCaveat](rel)
-			updates = append(updates, tuple.Touch(tpl))
 			tuples = append(tuples, tpl)
 		}
 	}
 
-	// Load the definitions and relationships into the datastore.
+	for _, sources := range namespaceSources {
+		sort.Strings(sources)
+	}
+
+	// Write the definitions and relationships in a single transaction, so that if any definition or
+	// relationship is invalid or cannot be written, nothing is written.
 	revision, err := ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
 		// Write the caveat definitions.
 		err := rwt.WriteCaveats(ctx, caveatDefs)
@@ -141,31 +285,39 @@ func PopulateFromFilesContents(ctx context.Context, ds datastore.Datastore, file
 			}
 		}
 
-		return err
-	})
-
-	slicez.ForEachChunk(updates, 500, func(chunked []*core.RelationTupleUpdate) {
-		if err != nil {
-			return
+		if len(tuples) == 0 {
+			return nil
 		}
 
-		chunkedTuples := make([]*core.RelationTuple, 0, len(chunked))
-		for _, update := range chunked {
-			chunkedTuples = append(chunkedTuples, update.Tuple)
+		if err := relationships.ValidateRelationshipsForCreateOrTouch(ctx, rwt, tuples); err != nil {
+			return err
 		}
-		revision, err = ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
-			err = relationships.ValidateRelationshipsForCreateOrTouch(ctx, rwt, chunkedTuples)
-			if err != nil {
-				return err
+
+		// Write the relationships in chunks of bounded size. Relationships are touched rather than
+		// bulk loaded, so that bootstrapping over an existing datastore overwrites any relationships
+		// already present.
+		var werr error
+		slicez.ForEachChunk(tuples, relationshipsChunkSize, func(chunked []*core.RelationTuple) {
+			if werr != nil {
+				return
 			}
 
-			return rwt.WriteRelationships(ctx, chunked)
+			updates := make([]*core.RelationTupleUpdate, 0, len(chunked))
+			for _, tpl := range chunked {
+				updates = append(updates, tuple.Touch(tpl))
+			}
+			werr = rwt.WriteRelationships(ctx, updates)
 		})
+		if werr != nil {
+			return fmt.Errorf("error when loading relationships: %w", werr)
+		}
+		return nil
 	})
-
 	if err != nil {
 		return nil, nil, err
 	}
 
-	return &PopulatedValidationFile{schema, objectDefs, caveatDefs, tuples, files}, revision, err
+	log.Ctx(ctx).Debug().Int("relationshipCount", len(tuples)).Msg("loaded relationships")
+
+	return &PopulatedValidationFile{schema, objectDefs, caveatDefs, tuples, files, filePaths, namespaceSources}, revision, err
 }
@@ -2,6 +2,9 @@ package validationfile
 
 import (
 	"context"
+	"errors"
+	"os"
+	"path/filepath"
 	"sort"
 	"testing"
 
@@ -9,6 +12,7 @@ import (
 	"github.com/authzed/spicedb/internal/datastore/proxy/proxy_test"
 	"github.com/authzed/spicedb/pkg/datastore"
 	"github.com/authzed/spicedb/pkg/datastore/options"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
 	"github.com/authzed/spicedb/pkg/tuple"
 
 	"github.com/stretchr/testify/require"
@@ -141,6 +145,28 @@ func TestPopulateFromFiles(t *testing.T) {
 	}
 }
 
+func TestPopulateFromFilesNamespaceSources(t *testing.T) {
+	require := require.New(t)
+
+	ds, err := memdb.NewMemdbDatastore(0, 0, 0)
+	require.NoError(err)
+
+	parsed, _, err := PopulateFromFiles(context.Background(), ds, []string{
+		"testdata/initial_schema_and_rels.yaml",
+		"testdata/conflicting_schema.yaml",
+	})
+	require.NoError(err)
+
+	require.Equal(map[string][]string{
+		"example/user":    {"testdata/conflicting_schema.yaml", "testdata/initial_schema_and_rels.yaml"},
+		"example/project": {"testdata/initial_schema_and_rels.yaml"},
+	}, parsed.NamespaceSources)
+
+	require.Equal(map[string][]string{
+		"example/user": {"testdata/conflicting_schema.yaml", "testdata/initial_schema_and_rels.yaml"},
+	}, parsed.ConflictingNamespaceSources())
+}
+
 func TestPopulationChunking(t *testing.T) {
 	require := require.New(t)
 
@@ -148,18 +174,185 @@ func TestPopulationChunking(t *testing.T) {
 	require.NoError(err)
 
 	cs := txCountingDatastore{delegate: ds}
-	_, _, err = PopulateFromFiles(context.Background(), &cs, []string{"testdata/requires_chunking.yaml"})
+	parsed, revision, err := PopulateFromFiles(context.Background(), &cs, []string{"testdata/requires_chunking.yaml"})
+	require.NoError(err)
+	require.Equal(1, cs.count)
+	require.Equal(2, cs.writes)
+
+	iter, err := ds.SnapshotReader(revision).QueryRelationships(context.Background(), datastore.RelationshipsFilter{
+		ResourceType: parsed.Tuples[0].ResourceAndRelation.Namespace,
+	})
+	require.NoError(err)
+	defer iter.Close()
+
+	count := 0
+	for tpl := iter.Next(); tpl != nil; tpl = iter.Next() {
+		count++
+	}
+	require.NoError(iter.Err())
+	require.Equal(len(parsed.Tuples), count)
+}
+
+func TestPopulationIsAtomic(t *testing.T) {
+	require := require.New(t)
+
+	ds, err := memdb.NewMemdbDatastore(0, 0, 0)
+	require.NoError(err)
+
+	_, _, err = PopulateFromFiles(context.Background(), ds, []string{"testdata/unknown_namespace_rel.yaml"})
+	require.ErrorContains(err, "object definition `example/document` not found")
+
+	headRevision, err := ds.HeadRevision(context.Background())
+	require.NoError(err)
+
+	nsDefs, err := ds.SnapshotReader(headRevision).ListAllNamespaces(context.Background())
 	require.NoError(err)
-	require.Equal(3, cs.count)
+	require.Empty(nsDefs)
+}
+
+func TestPopulationIsAtomicAcrossChunks(t *testing.T) {
+	require := require.New(t)
+
+	ds, err := memdb.NewMemdbDatastore(0, 0, 0)
+	require.NoError(err)
+
+	cs := txCountingDatastore{delegate: ds, failOnWrite: 2}
+	parsed, _, err := PopulateFromFiles(context.Background(), &cs, []string{"testdata/requires_chunking.yaml"})
+	require.ErrorIs(err, errInjectedWrite)
+	require.Nil(parsed)
+
+	// Neither the definitions nor the relationships of the first chunk are visible.
+	headRevision, err := ds.HeadRevision(context.Background())
+	require.NoError(err)
+
+	reader := ds.SnapshotReader(headRevision)
+	nsDefs, err := reader.ListAllNamespaces(context.Background())
+	require.NoError(err)
+	require.Empty(nsDefs)
+
+	iter, err := reader.QueryRelationships(context.Background(), datastore.RelationshipsFilter{ResourceType: "example/project"})
+	require.NoError(err)
+	defer iter.Close()
+	require.Nil(iter.Next())
+	require.NoError(iter.Err())
+}
+
+func TestExpandFilePaths(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"b.yaml", "a.yml", "notes.txt"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("---"), 0o600))
+	}
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "nested.yaml"), 0o700))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "empty"), 0o700))
+
+	tests := []struct {
+		name          string
+		filePaths     []string
+		want          []string
+		expectedError string
+	}{
+		{
+			name:      "files and URLs",
+			filePaths: []string{"testdata/loader_no_comment.yaml", "https://example.com/config.yaml#sha256=abc"},
+			want:      []string{"testdata/loader_no_comment.yaml", "https://example.com/config.yaml#sha256=abc"},
+		},
+		{
+			name:      "directory",
+			filePaths: []string{dir},
+			want:      []string{filepath.Join(dir, "a.yml"), filepath.Join(dir, "b.yaml")},
+		},
+		{
+			name:      "glob",
+			filePaths: []string{filepath.Join(dir, "*"), "testdata/loader_*.yaml"},
+			want: []string{
+				filepath.Join(dir, "a.yml"),
+				filepath.Join(dir, "b.yaml"),
+				filepath.Join(dir, "notes.txt"),
+				"testdata/loader_no_comment.yaml",
+				"testdata/loader_with_comment.yaml",
+			},
+		},
+		{
+			name:          "glob matching nothing",
+			filePaths:     []string{"testdata/*.json"},
+			expectedError: "config file pattern testdata/*.json matches no files",
+		},
+		{
+			name:          "directory without config files",
+			filePaths:     []string{filepath.Join(dir, "empty")},
+			expectedError: "contains no .yaml or .yml files",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			expanded, err := ExpandFilePaths(tt.filePaths)
+			if tt.expectedError != "" {
+				require.ErrorContains(t, err, tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.want, expanded)
+		})
+	}
+}
+
+func TestKindOfPath(t *testing.T) {
+	require.Equal(t, LocalPath, KindOfPath("testdata/loader_no_comment.yaml"))
+	require.Equal(t, LocalPath, KindOfPath("testdata"))
+	require.Equal(t, PatternPath, KindOfPath("testdata/loader_*.yaml"))
+	require.Equal(t, PatternPath, KindOfPath("testdata/loader_[nw]*.yaml"))
+	require.Equal(t, RemotePath, KindOfPath("https://example.com/config.yaml#sha256=abc"))
+
+	// Query strings of URLs are not patterns.
+	require.Equal(t, RemotePath, KindOfPath("http://example.com/config.yaml?ref=main"))
+}
+
+func TestPopulateFromDirectoryWithMalformedFile(t *testing.T) {
+	dir := t.TempDir()
+	contents, err := os.ReadFile("testdata/loader_no_comment.yaml")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.yaml"), contents, 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.yaml"), []byte("schema: [unclosed"), 0o600))
+
+	ds, err := memdb.NewMemdbDatastore(0, 0, 0)
+	require.NoError(t, err)
+
+	_, _, err = PopulateFromFiles(context.Background(), ds, []string{dir})
+	require.ErrorContains(t, err, "error when parsing config file "+filepath.Join(dir, "b.yaml"))
 }
 
 type txCountingDatastore struct {
 	proxy_test.MockDatastore
 	count    int
 	delegate datastore.Datastore
+
+	// writes is the number of calls to WriteRelationships, and failOnWrite, if non-zero, the call
+	// which fails.
+	writes      int
+	failOnWrite int
 }
 
 func (c *txCountingDatastore) ReadWriteTx(ctx context.Context, userFunc datastore.TxUserFunc, option ...options.RWTOptionsOption) (datastore.Revision, error) {
 	c.count++
-	return c.delegate.ReadWriteTx(ctx, userFunc, option...)
+	return c.delegate.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		return userFunc(&writeCountingTransaction{rwt, c})
+	}, option...)
+}
+
+var errInjectedWrite = errors.New("injected write failure")
+
+type writeCountingTransaction struct {
+	datastore.ReadWriteTransaction
+	ds *txCountingDatastore
+}
+
+func (t *writeCountingTransaction) WriteRelationships(ctx context.Context, mutations []*core.RelationTupleUpdate) error {
+	t.ds.writes++
+	if t.ds.writes == t.ds.failOnWrite {
+		return errInjectedWrite
+	}
+	return t.ReadWriteTransaction.WriteRelationships(ctx, mutations)
 }
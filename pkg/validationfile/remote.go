@@ -0,0 +1,147 @@
+package validationfile
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// checksumFragmentPrefix is the prefix of the URL fragment which, when present on a remote
+// validation file URL, holds the expected hex encoded SHA-256 checksum of the file's contents.
+const checksumFragmentPrefix = "sha256="
+
+// remoteFileTimeout is the maximum time allowed to fetch a remote validation file.
+const remoteFileTimeout = 30 * time.Second
+
+// maxRemoteFileSize is the maximum size of a remote validation file, in bytes.
+const maxRemoteFileSize = 16 << 20
+
+var (
+	remoteFileClient = &http.Client{Timeout: remoteFileTimeout}
+
+	// remoteFileFetches shares a single fetch of a remote validation file between the concurrent
+	// loads of the same URL.
+	remoteFileFetches singleflight.Group
+
+	// verifiedRemoteFiles holds the contents of the remote validation files fetched by this
+	// process whose checksums were verified, keyed by URL including checksum. As their contents
+	// are pinned by the checksum, each is fetched only once. Files without a checksum are fetched
+	// on every load, so that reloads see their changes.
+	verifiedRemoteFiles   = map[string][]byte{}
+	verifiedRemoteFilesMu sync.RWMutex
+)
+
+// readFileContents reads the contents of the validation file at the given path, which may be
+// a local path or an http(s) URL.
+func readFileContents(ctx context.Context, filePath string) ([]byte, error) {
+	if KindOfPath(filePath) != RemotePath {
+		return os.ReadFile(filePath)
+	}
+
+	return fetchRemoteFile(ctx, filePath)
+}
+
+// fetchRemoteFile fetches the validation file found at the given URL. If the URL has a fragment
+// of the form `sha256=<hex>`, the contents are verified against that checksum, and are only
+// fetched if not already fetched and verified.
+func fetchRemoteFile(ctx context.Context, fileURL string) ([]byte, error) {
+	parsed, err := url.Parse(fileURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid validation file URL %q: %w", fileURL, err)
+	}
+
+	var expectedChecksum string
+	if parsed.Fragment != "" {
+		if !strings.HasPrefix(parsed.Fragment, checksumFragmentPrefix) {
+			return nil, fmt.Errorf("unsupported fragment in validation file URL %q: expected `%s<hex>`", fileURL, checksumFragmentPrefix)
+		}
+		expectedChecksum = strings.ToLower(strings.TrimPrefix(parsed.Fragment, checksumFragmentPrefix))
+		if expectedChecksum == "" {
+			return nil, fmt.Errorf("missing checksum in validation file URL %q: expected `%s<hex>`", fileURL, checksumFragmentPrefix)
+		}
+		parsed.Fragment = ""
+	}
+
+	fetchURL := parsed.String()
+	if expectedChecksum == "" {
+		contents, err := sharedRemoteFile(ctx, fetchURL)
+		if err != nil {
+			return nil, fmt.Errorf("could not fetch validation file %q: %w", fileURL, err)
+		}
+		return contents, nil
+	}
+
+	cacheKey := fetchURL + "#" + checksumFragmentPrefix + expectedChecksum
+	verifiedRemoteFilesMu.RLock()
+	contents, ok := verifiedRemoteFiles[cacheKey]
+	verifiedRemoteFilesMu.RUnlock()
+	if ok {
+		return contents, nil
+	}
+
+	contents, err = sharedRemoteFile(ctx, fetchURL)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch validation file %q: %w", fileURL, err)
+	}
+
+	checksum := sha256.Sum256(contents)
+	if found := hex.EncodeToString(checksum[:]); found != expectedChecksum {
+		return nil, fmt.Errorf("checksum mismatch for validation file %q: expected %s, found %s", fileURL, expectedChecksum, found)
+	}
+
+	verifiedRemoteFilesMu.Lock()
+	verifiedRemoteFiles[cacheKey] = contents
+	verifiedRemoteFilesMu.Unlock()
+	return contents, nil
+}
+
+// sharedRemoteFile fetches the file at the given URL, sharing the fetch with any concurrent
+// callers for the same URL.
+func sharedRemoteFile(ctx context.Context, fetchURL string) ([]byte, error) {
+	contents, err, _ := remoteFileFetches.Do(fetchURL, func() (any, error) {
+		return getRemoteFile(ctx, fetchURL)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return contents.([]byte), nil
+}
+
+// getRemoteFile fetches the file at the given URL, failing if it is larger than
+// maxRemoteFileSize.
+func getRemoteFile(ctx context.Context, fetchURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fetchURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := remoteFileClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	contents, err := io.ReadAll(io.LimitReader(resp.Body, maxRemoteFileSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(contents) > maxRemoteFileSize {
+		return nil, fmt.Errorf("file exceeds the maximum size of %d bytes", maxRemoteFileSize)
+	}
+	return contents, nil
+}
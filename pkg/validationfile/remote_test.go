@@ -0,0 +1,142 @@
+package validationfile
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/internal/datastore/memdb"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+)
+
+func TestPopulateFromRemoteFiles(t *testing.T) {
+	contents, err := os.ReadFile("testdata/initial_schema_and_rels.yaml")
+	require.NoError(t, err)
+
+	checksum := sha256.Sum256(contents)
+	hexChecksum := hex.EncodeToString(checksum[:])
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/schema.yaml" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(contents)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name          string
+		fileURL       string
+		expectedError string
+	}{
+		{"no checksum", server.URL + "/schema.yaml", ""},
+		{"valid checksum", server.URL + "/schema.yaml#sha256=" + hexChecksum, ""},
+		{"invalid checksum", server.URL + "/schema.yaml#sha256=deadbeef", "checksum mismatch"},
+		{"unsupported fragment", server.URL + "/schema.yaml#md5=deadbeef", "unsupported fragment"},
+		{"empty checksum", server.URL + "/schema.yaml#sha256=", "missing checksum"},
+		{"missing file", server.URL + "/missing.yaml", "unexpected status 404"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+
+			ds, err := memdb.NewMemdbDatastore(0, 0, 0)
+			require.NoError(err)
+
+			parsed, _, err := PopulateFromFiles(context.Background(), ds, []string{tt.fileURL})
+			if tt.expectedError != "" {
+				require.ErrorContains(err, tt.expectedError)
+				return
+			}
+
+			require.NoError(err)
+			require.Len(parsed.NamespaceDefinitions, 2)
+			require.Equal([]string{tt.fileURL}, parsed.NamespaceSources["example/user"])
+		})
+	}
+
+}
+
+func TestRemoteFilesCachedOnlyWhenVerified(t *testing.T) {
+	var contents atomic.Pointer[[]byte]
+	var fetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		_, _ = w.Write(*contents.Load())
+	}))
+	defer server.Close()
+
+	load := func(fileURL string) ([]*core.NamespaceDefinition, error) {
+		ds, err := memdb.NewMemdbDatastore(0, 0, 0)
+		require.NoError(t, err)
+		defer ds.Close()
+
+		parsed, _, err := PopulateFromFiles(context.Background(), ds, []string{fileURL})
+		if err != nil {
+			return nil, err
+		}
+		return parsed.NamespaceDefinitions, nil
+	}
+
+	original := []byte("schema: definition user {}")
+	contents.Store(&original)
+	checksum := sha256.Sum256(original)
+	pinnedURL := server.URL + "/schema.yaml#sha256=" + hex.EncodeToString(checksum[:])
+
+	// A file with a verified checksum is fetched only once.
+	for i := 0; i < 2; i++ {
+		definitions, err := load(pinnedURL)
+		require.NoError(t, err)
+		require.Len(t, definitions, 1)
+	}
+	require.Equal(t, int32(1), fetches.Load())
+
+	// A file without a checksum is fetched on every load, so that changes are seen.
+	definitions, err := load(server.URL + "/schema.yaml")
+	require.NoError(t, err)
+	require.Len(t, definitions, 1)
+
+	changed := []byte("schema: |-\n  definition user {}\n  definition group {}")
+	contents.Store(&changed)
+	definitions, err = load(server.URL + "/schema.yaml")
+	require.NoError(t, err)
+	require.Len(t, definitions, 2)
+	require.Equal(t, int32(3), fetches.Load())
+
+	// Contents failing verification are not cached.
+	checksum = sha256.Sum256(changed)
+	changedURL := server.URL + "/schema.yaml#sha256=" + hex.EncodeToString(checksum[:])
+	contents.Store(&original)
+	_, err = load(changedURL)
+	require.ErrorContains(t, err, "checksum mismatch")
+
+	contents.Store(&changed)
+	definitions, err = load(changedURL)
+	require.NoError(t, err)
+	require.Len(t, definitions, 2)
+	require.Equal(t, int32(5), fetches.Load())
+}
+
+func TestRemoteFileMaxSize(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(make([]byte, maxRemoteFileSize+1))
+	}))
+	defer server.Close()
+
+	ds, err := memdb.NewMemdbDatastore(0, 0, 0)
+	require.NoError(t, err)
+	defer ds.Close()
+
+	_, _, err = PopulateFromFiles(context.Background(), ds, []string{server.URL + "/schema.yaml"})
+	require.ErrorContains(t, err, "exceeds the maximum size")
+}
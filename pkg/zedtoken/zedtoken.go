@@ -1,4 +1,6 @@
-// Package zedtoken converts decimal.Decimal to zedtoken and vice versa
+// Package zedtoken converts datastore revisions to zedtokens and vice versa. Revisions are
+// serialized using their String form and parsed by the datastore that produced them, keeping
+// zedtokens opaque to clients while allowing each datastore its own revision representation.
 package zedtoken
 
 import (
@@ -24,7 +26,7 @@ const (
 // zedtoken argument to Decode
 var ErrNilZedToken = errors.New("zedtoken pointer was nil")
 
-// MustNewFromRevision generates an encoded zedtoken from an integral revision.
+// MustNewFromRevision generates an encoded zedtoken from a revision.
 func MustNewFromRevision(revision datastore.Revision) *v1.ZedToken {
 	encoded, err := NewFromRevision(revision)
 	if err != nil {
@@ -33,7 +35,7 @@ func MustNewFromRevision(revision datastore.Revision) *v1.ZedToken {
 	return encoded
 }
 
-// NewFromRevision generates an encoded zedtoken from an integral revision.
+// NewFromRevision generates an encoded zedtoken from a revision.
 func NewFromRevision(revision datastore.Revision) (*v1.ZedToken, error) {
 	toEncode := &zedtoken.DecodedZedToken{
 		VersionOneof: &zedtoken.DecodedZedToken_V1{
@@ -79,7 +81,7 @@ func Decode(encoded *v1.ZedToken) (*zedtoken.DecodedZedToken, error) {
 }
 
 // DecodeRevision converts and extracts the revision from a zedtoken or legacy zookie.
-func DecodeRevision(encoded *v1.ZedToken, ds revisionDecoder) (datastore.Revision, error) {
+func DecodeRevision(encoded *v1.ZedToken, ds datastore.RevisionDecoder) (datastore.Revision, error) {
 	decoded, err := Decode(encoded)
 	if err != nil {
 		return datastore.NoRevision, err
@@ -98,7 +100,3 @@ func DecodeRevision(encoded *v1.ZedToken, ds revisionDecoder) (datastore.Revisio
 		return datastore.NoRevision, fmt.Errorf(errDecodeError, fmt.Errorf("unknown zookie version: %T", decoded.VersionOneof))
 	}
 }
-
-type revisionDecoder interface {
-	RevisionFromString(string) (datastore.Revision, error)
-}